@@ -0,0 +1,46 @@
+package blake2b
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Hashing a single block message with F must match the BLAKE2b
+// hash function. Initial state is the IV mixed with the parameter
+// block of an unkeyed 64 bytes digest.
+var fTests = []genericTest{
+	{s: "empty message", in: []byte{}, exp: blake2b.Sum512([]byte{})},
+	{s: "abc", in: []byte("abc"), exp: blake2b.Sum512([]byte("abc"))},
+	{s: "128 bytes message", in: make([]byte, 128), exp: blake2b.Sum512(make([]byte, 128))},
+}
+
+func Test_Blake2b_F(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range fTests {
+		input := test.in.([]byte)
+		h := iv
+		h[0] ^= 0x01010040
+		var block [128]byte
+		copy(block[:], input)
+		var m [16]uint64
+		for i := range m {
+			m[i] = binary.LittleEndian.Uint64(block[i*8:])
+		}
+		F(&h, &m, [2]uint64{uint64(len(input)), 0}, true, 12)
+
+		var digest [64]byte
+		for i := range h {
+			binary.LittleEndian.PutUint64(digest[i*8:], h[i])
+		}
+		test.act = digest
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
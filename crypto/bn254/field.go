@@ -0,0 +1,287 @@
+// Package bn254 implements the BN254 (alt_bn128) elliptic curve groups
+// and the optimal ate pairing, as specified for Ethereum in EIP-196
+// and EIP-197. It is written in pure Go on top of math/big, and
+// it favours readability over performance. Arithmetic is not constant
+// time, hence it must not be used with secret inputs.
+package bn254
+
+import (
+	"math/big"
+)
+
+// P is the prime modulus of the base field
+var P, _ = new(big.Int).SetString("30644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd47", 16)
+
+// Order is the prime order of the G1 and G2 groups
+var Order, _ = new(big.Int).SetString("30644e72e131a029b85045b68181585d2833e84879b9709143e1f593f0000001", 16)
+
+func fpMul(a, b *big.Int) *big.Int {
+	r := new(big.Int).Mul(a, b)
+	return r.Mod(r, P)
+}
+
+func fpAdd(a, b *big.Int) *big.Int {
+	r := new(big.Int).Add(a, b)
+	return r.Mod(r, P)
+}
+
+func fpSub(a, b *big.Int) *big.Int {
+	r := new(big.Int).Sub(a, b)
+	return r.Mod(r, P)
+}
+
+func fpNeg(a *big.Int) *big.Int {
+	r := new(big.Int).Neg(a)
+	return r.Mod(r, P)
+}
+
+func fpInv(a *big.Int) *big.Int {
+	return new(big.Int).ModInverse(a, P)
+}
+
+// fp2 is the quadratic extension field element a + b*i, where i^2 = -1
+type fp2 struct {
+	a, b *big.Int
+}
+
+func newFp2(a, b int64) *fp2 {
+	return &fp2{big.NewInt(a), big.NewInt(b)}
+}
+
+func (x *fp2) add(y *fp2) *fp2 {
+	return &fp2{fpAdd(x.a, y.a), fpAdd(x.b, y.b)}
+}
+
+func (x *fp2) sub(y *fp2) *fp2 {
+	return &fp2{fpSub(x.a, y.a), fpSub(x.b, y.b)}
+}
+
+func (x *fp2) neg() *fp2 {
+	return &fp2{fpNeg(x.a), fpNeg(x.b)}
+}
+
+func (x *fp2) mul(y *fp2) *fp2 {
+	a := new(big.Int).Sub(new(big.Int).Mul(x.a, y.a), new(big.Int).Mul(x.b, y.b))
+	b := new(big.Int).Add(new(big.Int).Mul(x.a, y.b), new(big.Int).Mul(x.b, y.a))
+	return &fp2{a.Mod(a, P), b.Mod(b, P)}
+}
+
+func (x *fp2) mulScalar(k *big.Int) *fp2 {
+	return &fp2{fpMul(x.a, k), fpMul(x.b, k)}
+}
+
+// (a + bi)^-1 = (a - bi) / (a^2 + b^2)
+func (x *fp2) inv() *fp2 {
+	norm := fpInv(fpAdd(fpMul(x.a, x.a), fpMul(x.b, x.b)))
+	return &fp2{fpMul(x.a, norm), fpMul(fpNeg(x.b), norm)}
+}
+
+func (x *fp2) isZero() bool {
+	return x.a.Sign() == 0 && x.b.Sign() == 0
+}
+
+func (x *fp2) equal(y *fp2) bool {
+	return x.a.Cmp(y.a) == 0 && x.b.Cmp(y.b) == 0
+}
+
+// fp12 is the degree 12 extension field element represented as
+// a polynomial of w with coefficients in the base field, where
+// w^12 = 18w^6 - 82. The element at index i is the coefficient of w^i.
+type fp12 [12]*big.Int
+
+func fp12Zero() *fp12 {
+	var x fp12
+	for i := range x {
+		x[i] = new(big.Int)
+	}
+	return &x
+}
+
+func fp12One() *fp12 {
+	x := fp12Zero()
+	x[0].SetInt64(1)
+	return x
+}
+
+func fp12FromFp(a *big.Int) *fp12 {
+	x := fp12Zero()
+	x[0].Set(a)
+	return x
+}
+
+// Embeds a + bi into fp12, using the isomorphism which maps i to w^6 - 9
+func fp12FromFp2(y *fp2) *fp12 {
+	x := fp12Zero()
+	x[0] = fpSub(y.a, fpMul(y.b, big.NewInt(9)))
+	x[6].Set(y.b)
+	return x
+}
+
+func (x *fp12) add(y *fp12) *fp12 {
+	var r fp12
+	for i := range r {
+		r[i] = fpAdd(x[i], y[i])
+	}
+	return &r
+}
+
+func (x *fp12) sub(y *fp12) *fp12 {
+	var r fp12
+	for i := range r {
+		r[i] = fpSub(x[i], y[i])
+	}
+	return &r
+}
+
+func (x *fp12) neg() *fp12 {
+	return fp12Zero().sub(x)
+}
+
+func (x *fp12) mul(y *fp12) *fp12 {
+	var prod [23]*big.Int
+	for i := range prod {
+		prod[i] = new(big.Int)
+	}
+	t := new(big.Int)
+	for i := 0; i < 12; i++ {
+		if x[i].Sign() == 0 {
+			continue
+		}
+		for j := 0; j < 12; j++ {
+			prod[i+j].Add(prod[i+j], t.Mul(x[i], y[j]))
+		}
+	}
+	// reduce with w^12 = 18w^6 - 82, starting from the highest degree
+	for i := 22; i >= 12; i-- {
+		prod[i].Mod(prod[i], P)
+		prod[i-6].Add(prod[i-6], t.Mul(prod[i], big.NewInt(18)))
+		prod[i-12].Sub(prod[i-12], t.Mul(prod[i], big.NewInt(82)))
+	}
+	var r fp12
+	for i := range r {
+		r[i] = prod[i].Mod(prod[i], P)
+	}
+	return &r
+}
+
+func (x *fp12) exp(k *big.Int) *fp12 {
+	r := fp12One()
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		r = r.mul(r)
+		if k.Bit(i) == 1 {
+			r = r.mul(x)
+		}
+	}
+	return r
+}
+
+func (x *fp12) isZero() bool {
+	for _, c := range x {
+		if c.Sign() != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (x *fp12) equal(y *fp12) bool {
+	for i := range x {
+		if x[i].Cmp(y[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Inverse is found by the extended euclidean algorithm on
+// the polynomial and the modulus w^12 - 18w^6 + 82
+func (x *fp12) inv() *fp12 {
+	modulus := make([]*big.Int, 13)
+	for i := range modulus {
+		modulus[i] = new(big.Int)
+	}
+	modulus[0].SetInt64(82)
+	modulus[6].Sub(P, big.NewInt(18))
+	modulus[12].SetInt64(1)
+
+	r0, r1 := modulus, polyTrim(x[:])
+	s0, s1 := []*big.Int{}, []*big.Int{big.NewInt(1)}
+	for len(r1) > 1 {
+		q, rem := polyDivMod(r0, r1)
+		r0, r1 = r1, rem
+		s0, s1 = s1, polySub(s0, polyMul(q, s1))
+	}
+	// r1 is the constant gcd, which is non-zero for non-zero x
+	cInv := fpInv(r1[0])
+	r := fp12Zero()
+	for i, c := range s1 {
+		r[i] = fpMul(c, cInv)
+	}
+	return r
+}
+
+// Polynomials are coefficient slices without trailing zeroes
+func polyTrim(a []*big.Int) []*big.Int {
+	n := len(a)
+	for n > 0 && a[n-1].Sign() == 0 {
+		n--
+	}
+	return a[:n]
+}
+
+func polySub(a, b []*big.Int) []*big.Int {
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+	r := make([]*big.Int, n)
+	for i := range r {
+		r[i] = new(big.Int)
+		if i < len(a) {
+			r[i].Add(r[i], a[i])
+		}
+		if i < len(b) {
+			r[i].Sub(r[i], b[i])
+		}
+		r[i].Mod(r[i], P)
+	}
+	return polyTrim(r)
+}
+
+func polyMul(a, b []*big.Int) []*big.Int {
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+	r := make([]*big.Int, len(a)+len(b)-1)
+	for i := range r {
+		r[i] = new(big.Int)
+	}
+	for i := range a {
+		for j := range b {
+			r[i+j].Add(r[i+j], new(big.Int).Mul(a[i], b[j]))
+		}
+	}
+	for i := range r {
+		r[i].Mod(r[i], P)
+	}
+	return polyTrim(r)
+}
+
+func polyDivMod(a, b []*big.Int) ([]*big.Int, []*big.Int) {
+	rem := make([]*big.Int, len(a))
+	for i := range a {
+		rem[i] = new(big.Int).Set(a[i])
+	}
+	if len(a) < len(b) {
+		return nil, polyTrim(rem)
+	}
+	q := make([]*big.Int, len(a)-len(b)+1)
+	leadInv := fpInv(b[len(b)-1])
+	for i := len(q) - 1; i >= 0; i-- {
+		q[i] = fpMul(rem[i+len(b)-1], leadInv)
+		for j := range b {
+			rem[i+j] = fpSub(rem[i+j], fpMul(q[i], b[j]))
+		}
+	}
+	return polyTrim(q), polyTrim(rem[:len(b)-1])
+}
@@ -0,0 +1,107 @@
+package bn254
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	ErrInvalidPointLen = errors.New("invalid point length")
+	ErrCoordinateRange = errors.New("point coordinate not in field")
+	ErrPointNotOnCurve = errors.New("point not on curve")
+	ErrNotInSubgroup   = errors.New("point not in subgroup")
+)
+
+// G1 is a point on the curve y^2 = x^3 + 3 over the base field
+type G1 struct {
+	x, y *big.Int
+	inf  bool
+}
+
+var g1B = big.NewInt(3)
+
+// Returns the generator of G1, which is (1, 2)
+func G1Generator() *G1 {
+	return &G1{x: big.NewInt(1), y: big.NewInt(2)}
+}
+
+func g1Infinity() *G1 {
+	return &G1{x: new(big.Int), y: new(big.Int), inf: true}
+}
+
+// Unmarshal the 64 bytes [x || y] encoding of the point, and
+// validate it. Point at infinity is encoded as all zeroes.
+func (p *G1) Unmarshal(buff []byte) error {
+	if len(buff) != 64 {
+		return ErrInvalidPointLen
+	}
+	x := new(big.Int).SetBytes(buff[:32])
+	y := new(big.Int).SetBytes(buff[32:])
+	if x.Cmp(P) >= 0 || y.Cmp(P) >= 0 {
+		return ErrCoordinateRange
+	}
+	if x.Sign() == 0 && y.Sign() == 0 {
+		*p = *g1Infinity()
+		return nil
+	}
+	// G1 has prime order, hence any point on the curve is in the group
+	if fpMul(y, y).Cmp(fpAdd(fpMul(fpMul(x, x), x), g1B)) != 0 {
+		return ErrPointNotOnCurve
+	}
+	*p = G1{x: x, y: y}
+	return nil
+}
+
+// Returns the 64 bytes [x || y] encoding of the point
+func (p *G1) Marshal() []byte {
+	buff := make([]byte, 64)
+	if !p.inf {
+		p.x.FillBytes(buff[:32])
+		p.y.FillBytes(buff[32:])
+	}
+	return buff
+}
+
+func (p *G1) IsInfinity() bool {
+	return p.inf
+}
+
+func (p *G1) Neg() *G1 {
+	if p.inf {
+		return p
+	}
+	return &G1{x: p.x, y: fpNeg(p.y)}
+}
+
+func (p *G1) Add(q *G1) *G1 {
+	if p.inf {
+		return q
+	}
+	if q.inf {
+		return p
+	}
+	var m *big.Int
+	if p.x.Cmp(q.x) == 0 {
+		if p.y.Cmp(q.y) != 0 || p.y.Sign() == 0 {
+			return g1Infinity()
+		}
+		// m = 3x^2 / 2y
+		m = fpMul(fpMul(big.NewInt(3), fpMul(p.x, p.x)), fpInv(fpAdd(p.y, p.y)))
+	} else {
+		m = fpMul(fpSub(q.y, p.y), fpInv(fpSub(q.x, p.x)))
+	}
+	x := fpSub(fpSub(fpMul(m, m), p.x), q.x)
+	y := fpSub(fpMul(m, fpSub(p.x, x)), p.y)
+	return &G1{x: x, y: y}
+}
+
+func (p *G1) ScalarMult(k *big.Int) *G1 {
+	r := g1Infinity()
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		r = r.Add(r)
+		if k.Bit(i) == 1 {
+			r = r.Add(p)
+		}
+	}
+	return r
+}
@@ -0,0 +1,102 @@
+package bn254
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+var g1UnmarshalTests = []genericTest{
+	{
+		s:   "generator",
+		in:  hexToBytes("00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000002"),
+		exp: G1Generator(),
+	},
+	{
+		s:   "infinity",
+		in:  make([]byte, 64),
+		exp: g1Infinity(),
+	},
+	{
+		s:          "invalid length",
+		in:         make([]byte, 63),
+		exp:        ErrInvalidPointLen,
+		shouldFail: true,
+	},
+	{
+		s:          "x not in field",
+		in:         append(P.Bytes(), hexToBytes("0000000000000000000000000000000000000000000000000000000000000002")...),
+		exp:        ErrCoordinateRange,
+		shouldFail: true,
+	},
+	{
+		s:          "not on curve",
+		in:         hexToBytes("00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000003"),
+		exp:        ErrPointNotOnCurve,
+		shouldFail: true,
+	},
+}
+
+func Test_G1_Unmarshal(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range g1UnmarshalTests {
+		p := &G1{}
+		err := p.Unmarshal(test.in.([]byte))
+		if !test.shouldFail {
+			test.act = p
+		} else {
+			test.act = err
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+var twoG1 = hexToBytes("030644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd315ed738c0e0a7c92e7845f96b2ae9c0a68a6a449e3538fc7ff3ebf7a5a18a2c4")
+
+// in is the scalar to multiply the generator with
+var g1ScalarMultTests = []genericTest{
+	{s: "G * 0 is infinity", in: big.NewInt(0), exp: make([]byte, 64)},
+	{s: "G * 1 is G", in: big.NewInt(1), exp: G1Generator().Marshal()},
+	{s: "G * 2", in: big.NewInt(2), exp: twoG1},
+	{s: "G * order is infinity", in: Order, exp: make([]byte, 64)},
+	{s: "G * (order + 2) is G * 2", in: new(big.Int).Add(Order, big.NewInt(2)), exp: twoG1},
+}
+
+func Test_G1_ScalarMult(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range g1ScalarMultTests {
+		test.act = G1Generator().ScalarMult(test.in.(*big.Int)).Marshal()
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+var g1AddTests = []genericTest{
+	{s: "G + G", in: []*G1{G1Generator(), G1Generator()}, exp: twoG1},
+	{s: "G + 0", in: []*G1{G1Generator(), g1Infinity()}, exp: G1Generator().Marshal()},
+	{s: "0 + G", in: []*G1{g1Infinity(), G1Generator()}, exp: G1Generator().Marshal()},
+	{s: "G + -G", in: []*G1{G1Generator(), G1Generator().Neg()}, exp: make([]byte, 64)},
+}
+
+func Test_G1_Add(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range g1AddTests {
+		testIn := test.in.([]*G1)
+		test.act = testIn[0].Add(testIn[1]).Marshal()
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
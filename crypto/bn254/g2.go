@@ -0,0 +1,119 @@
+package bn254
+
+import (
+	"math/big"
+)
+
+// G2 is a point on the twisted curve y^2 = x^3 + 3 / (9 + i)
+// over the quadratic extension field, which is in the subgroup
+// of the prime order
+type G2 struct {
+	x, y *fp2
+	inf  bool
+}
+
+var g2B = newFp2(3, 0).mul(newFp2(9, 1).inv())
+
+// Returns the generator of G2
+func G2Generator() *G2 {
+	x := &fp2{}
+	x.a, _ = new(big.Int).SetString("1800deef121f1e76426a00665e5c4479674322d4f75edadd46debd5cd992f6ed", 16)
+	x.b, _ = new(big.Int).SetString("198e9393920d483a7260bfb731fb5d25f1aa493335a9e71297e485b7aef312c2", 16)
+	y := &fp2{}
+	y.a, _ = new(big.Int).SetString("12c85ea5db8c6deb4aab71808dcb408fe3d1e7690c43d37b4ce6cc0166fa7daa", 16)
+	y.b, _ = new(big.Int).SetString("090689d0585ff075ec9e99ad690c3395bc4b313370b38ef355acdadcd122975b", 16)
+	return &G2{x: x, y: y}
+}
+
+func g2Infinity() *G2 {
+	return &G2{x: newFp2(0, 0), y: newFp2(0, 0), inf: true}
+}
+
+// Unmarshal the 128 bytes [x.b || x.a || y.b || y.a] encoding of the
+// point, where each coordinate is a + b*i, and validate that it is
+// in the subgroup. Point at infinity is encoded as all zeroes.
+func (p *G2) Unmarshal(buff []byte) error {
+	if len(buff) != 128 {
+		return ErrInvalidPointLen
+	}
+	var coords [4]*big.Int
+	for i := range coords {
+		coords[i] = new(big.Int).SetBytes(buff[i*32 : (i+1)*32])
+		if coords[i].Cmp(P) >= 0 {
+			return ErrCoordinateRange
+		}
+	}
+	x := &fp2{a: coords[1], b: coords[0]}
+	y := &fp2{a: coords[3], b: coords[2]}
+	if x.isZero() && y.isZero() {
+		*p = *g2Infinity()
+		return nil
+	}
+	if !y.mul(y).equal(x.mul(x).mul(x).add(g2B)) {
+		return ErrPointNotOnCurve
+	}
+	q := &G2{x: x, y: y}
+	// twisted curve has points outside of the prime order
+	// subgroup, which must be rejected
+	if !q.ScalarMult(Order).inf {
+		return ErrNotInSubgroup
+	}
+	*p = *q
+	return nil
+}
+
+// Returns the 128 bytes [x.b || x.a || y.b || y.a] encoding of the point
+func (p *G2) Marshal() []byte {
+	buff := make([]byte, 128)
+	if !p.inf {
+		p.x.b.FillBytes(buff[:32])
+		p.x.a.FillBytes(buff[32:64])
+		p.y.b.FillBytes(buff[64:96])
+		p.y.a.FillBytes(buff[96:])
+	}
+	return buff
+}
+
+func (p *G2) IsInfinity() bool {
+	return p.inf
+}
+
+func (p *G2) Neg() *G2 {
+	if p.inf {
+		return p
+	}
+	return &G2{x: p.x, y: p.y.neg()}
+}
+
+func (p *G2) Add(q *G2) *G2 {
+	if p.inf {
+		return q
+	}
+	if q.inf {
+		return p
+	}
+	var m *fp2
+	if p.x.equal(q.x) {
+		if !p.y.equal(q.y) || p.y.isZero() {
+			return g2Infinity()
+		}
+		// m = 3x^2 / 2y
+		m = p.x.mul(p.x).mulScalar(big.NewInt(3)).mul(p.y.add(p.y).inv())
+	} else {
+		m = q.y.sub(p.y).mul(q.x.sub(p.x).inv())
+	}
+	x := m.mul(m).sub(p.x).sub(q.x)
+	y := m.mul(p.x.sub(x)).sub(p.y)
+	return &G2{x: x, y: y}
+}
+
+func (p *G2) ScalarMult(k *big.Int) *G2 {
+	r := g2Infinity()
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		r = r.Add(r)
+		if k.Bit(i) == 1 {
+			r = r.Add(p)
+		}
+	}
+	return r
+}
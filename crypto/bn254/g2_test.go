@@ -0,0 +1,87 @@
+package bn254
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+var g2UnmarshalTests = []genericTest{
+	{
+		s:   "generator",
+		in:  G2Generator().Marshal(),
+		exp: G2Generator(),
+	},
+	{
+		s:   "infinity",
+		in:  make([]byte, 128),
+		exp: g2Infinity(),
+	},
+	{
+		s:          "invalid length",
+		in:         make([]byte, 127),
+		exp:        ErrInvalidPointLen,
+		shouldFail: true,
+	},
+	{
+		s:          "coordinate not in field",
+		in:         append(G2Generator().Marshal()[:96], P.Bytes()...),
+		exp:        ErrCoordinateRange,
+		shouldFail: true,
+	},
+	{
+		s:          "swapped x coordinate parts is not on curve",
+		in:         swapG2XParts(G2Generator().Marshal()),
+		exp:        ErrPointNotOnCurve,
+		shouldFail: true,
+	},
+	{
+		s:          "not in subgroup",
+		in:         genG2NonSubgroupPoint().Marshal(),
+		exp:        ErrNotInSubgroup,
+		shouldFail: true,
+	},
+}
+
+func Test_G2_Unmarshal(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range g2UnmarshalTests {
+		p := &G2{}
+		err := p.Unmarshal(test.in.([]byte))
+		if !test.shouldFail {
+			test.act = p
+		} else {
+			test.act = err
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+var g2ScalarMultTests = []genericTest{
+	{s: "G * order is infinity", in: Order, exp: true},
+	{s: "G * 2 is G + G", in: big.NewInt(2), exp: true},
+}
+
+func Test_G2_ScalarMult(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range g2ScalarMultTests {
+		k := test.in.(*big.Int)
+		res := G2Generator().ScalarMult(k)
+		if k.Cmp(Order) == 0 {
+			test.act = res.IsInfinity()
+		} else {
+			test.act = res.Add(G2Generator().Neg()).Add(G2Generator().Neg()).IsInfinity()
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,99 @@
+package bn254
+
+import (
+	"math/big"
+)
+
+// Loop count of the optimal ate pairing, which is 6u + 2
+var ateLoopCount, _ = new(big.Int).SetString("29793968203157093288", 10)
+
+// Final exponent of the pairing, which is (p^12 - 1) / order
+var finalExp = func() *big.Int {
+	e := new(big.Int).Exp(P, big.NewInt(12), nil)
+	e.Sub(e, big.NewInt(1))
+	return e.Div(e, Order)
+}()
+
+// fp12Point is a point on the curve y^2 = x^3 + 3 over fp12,
+// which both G1 and G2 points are mapped to for the pairing
+type fp12Point struct {
+	x, y *fp12
+}
+
+func g1ToFp12(p *G1) *fp12Point {
+	return &fp12Point{x: fp12FromFp(p.x), y: fp12FromFp(p.y)}
+}
+
+// Maps the point on the twisted curve to the curve over fp12
+// with the untwisting isomorphism (x, y) -> (x * w^2, y * w^3)
+func g2ToFp12(p *G2) *fp12Point {
+	w2, w3 := fp12Zero(), fp12Zero()
+	w2[2].SetInt64(1)
+	w3[3].SetInt64(1)
+	return &fp12Point{x: fp12FromFp2(p.x).mul(w2), y: fp12FromFp2(p.y).mul(w3)}
+}
+
+func (p *fp12Point) add(q *fp12Point) *fp12Point {
+	var m *fp12
+	if p.x.equal(q.x) {
+		if !p.y.equal(q.y) {
+			return nil
+		}
+		three := fp12FromFp(big.NewInt(3))
+		m = three.mul(p.x).mul(p.x).mul(p.y.add(p.y).inv())
+	} else {
+		m = q.y.sub(p.y).mul(q.x.sub(p.x).inv())
+	}
+	x := m.mul(m).sub(p.x).sub(q.x)
+	y := m.mul(p.x.sub(x)).sub(p.y)
+	return &fp12Point{x: x, y: y}
+}
+
+// Evaluates the line through p and q (the tangent if they are
+// equal) at the point t
+func lineFunc(p, q, t *fp12Point) *fp12 {
+	if !p.x.equal(q.x) {
+		m := q.y.sub(p.y).mul(q.x.sub(p.x).inv())
+		return m.mul(t.x.sub(p.x)).sub(t.y.sub(p.y))
+	}
+	if p.y.equal(q.y) {
+		three := fp12FromFp(big.NewInt(3))
+		m := three.mul(p.x).mul(p.x).mul(p.y.add(p.y).inv())
+		return m.mul(t.x.sub(p.x)).sub(t.y.sub(p.y))
+	}
+	return t.x.sub(p.x)
+}
+
+// Miller loop of the optimal ate pairing, without the final exponentiation
+func millerLoop(q *G2, p *G1) *fp12 {
+	if q.inf || p.inf {
+		return fp12One()
+	}
+	Q, T := g2ToFp12(q), g1ToFp12(p)
+	R := Q
+	f := fp12One()
+	for i := ateLoopCount.BitLen() - 2; i >= 0; i-- {
+		f = f.mul(f).mul(lineFunc(R, R, T))
+		R = R.add(R)
+		if ateLoopCount.Bit(i) == 1 {
+			f = f.mul(lineFunc(R, Q, T))
+			R = R.add(Q)
+		}
+	}
+	// additional steps with the frobenius images of Q
+	Q1 := &fp12Point{x: Q.x.exp(P), y: Q.y.exp(P)}
+	nQ2 := &fp12Point{x: Q1.x.exp(P), y: Q1.y.exp(P).neg()}
+	f = f.mul(lineFunc(R, Q1, T))
+	R = R.add(Q1)
+	return f.mul(lineFunc(R, nQ2, T))
+}
+
+// PairingCheck reports whether the product of the pairings of the
+// given G1 and G2 point pairs is equal to one
+func PairingCheck(g1s []*G1, g2s []*G2) bool {
+	f := fp12One()
+	for i := range g1s {
+		f = f.mul(millerLoop(g2s[i], g1s[i]))
+	}
+	return f.exp(finalExp).equal(fp12One())
+}
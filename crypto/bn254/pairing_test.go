@@ -0,0 +1,40 @@
+package bn254
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+// in is the [g1 scalars, g2 scalars] of the pairs, where each
+// point is the generator of its group multiplied by the scalar
+var pairingCheckTests = []genericTest{
+	{s: "empty input", in: [][]int64{{}, {}}, exp: true},
+	{s: "e(G1, G2)", in: [][]int64{{1}, {1}}, exp: false},
+	{s: "e(G1, G2) * e(-G1, G2)", in: [][]int64{{1, -1}, {1, 1}}, exp: true},
+	{s: "e(G1, G2) * e(G1, -G2)", in: [][]int64{{1, 1}, {1, -1}}, exp: true},
+	{s: "e(G1, G2) * e(G1, G2)", in: [][]int64{{1, 1}, {1, 1}}, exp: false},
+	{s: "e(2G1, 3G2) * e(-6G1, G2)", in: [][]int64{{2, -6}, {3, 1}}, exp: true},
+	{s: "e(2G1, 3G2) * e(-5G1, G2)", in: [][]int64{{2, -5}, {3, 1}}, exp: false},
+	{s: "e(0, G2)", in: [][]int64{{0}, {1}}, exp: true},
+	{s: "e(G1, 0)", in: [][]int64{{1}, {0}}, exp: true},
+}
+
+func Test_Pairing_PairingCheck(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range pairingCheckTests {
+		testIn := test.in.([][]int64)
+		g1s, g2s := []*G1{}, []*G2{}
+		for i := range testIn[0] {
+			g1s = append(g1s, G1Generator().ScalarMult(new(big.Int).Mod(big.NewInt(testIn[0][i]), Order)))
+			g2s = append(g2s, G2Generator().ScalarMult(new(big.Int).Mod(big.NewInt(testIn[1][i]), Order)))
+		}
+		test.act = PairingCheck(g1s, g2s)
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
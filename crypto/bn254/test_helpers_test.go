@@ -0,0 +1,86 @@
+// This file contains helper functions and data for testing
+package bn254
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"reflect"
+)
+
+// genericTest is the struct used to hold the test data
+// in an organized format. It also helps generate messages
+// based on the expected and actual result comparison.
+type genericTest struct {
+	s          string
+	in         interface{}
+	exp        interface{}
+	act        interface{}
+	shouldFail bool
+}
+
+func (t genericTest) Check() (string, bool) {
+	if reflect.DeepEqual(t.exp, t.act) {
+		return fmt.Sprintf("\t✔ %s\n", t.s), false
+	} else {
+		return fmt.Sprintf("\033[31m\t✖ %s\n\t\texp: %#v\n\t\tgot: %#v\n\033[39m", t.s, t.exp, t.act), true
+	}
+}
+
+func hexToBytes(str string) []byte {
+	buff, _ := hex.DecodeString(str)
+	return buff
+}
+
+func hexToBig(str string) *big.Int {
+	n, _ := new(big.Int).SetString(str, 16)
+	return n
+}
+
+func (x *fp2) exp(k *big.Int) *fp2 {
+	r := newFp2(1, 0)
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		r = r.mul(r)
+		if k.Bit(i) == 1 {
+			r = r.mul(x)
+		}
+	}
+	return r
+}
+
+// Returns a point on the twisted curve, which is not in G2. It is
+// found by trying x coordinates until x^3 + b is a square in fp2.
+func genG2NonSubgroupPoint() *G2 {
+	pMinus3Over4 := new(big.Int).Rsh(new(big.Int).Sub(P, big.NewInt(3)), 2)
+	pMinus1Over2 := new(big.Int).Rsh(new(big.Int).Sub(P, big.NewInt(1)), 1)
+	minusOne := &fp2{fpNeg(big.NewInt(1)), new(big.Int)}
+	for i := int64(1); ; i++ {
+		x := newFp2(i, 1)
+		rhs := x.mul(x).mul(x).add(g2B)
+		// square root for p = 3 mod 4
+		a1 := rhs.exp(pMinus3Over4)
+		alpha := a1.mul(a1).mul(rhs)
+		x0 := a1.mul(rhs)
+		var y *fp2
+		if alpha.equal(minusOne) {
+			y = newFp2(0, 1).mul(x0)
+		} else {
+			y = alpha.add(newFp2(1, 0)).exp(pMinus1Over2).mul(x0)
+		}
+		if !y.mul(y).equal(rhs) {
+			continue
+		}
+		p := &G2{x: x, y: y}
+		if !p.ScalarMult(Order).inf {
+			return p
+		}
+	}
+}
+
+// Swaps the real and imaginary parts of the x coordinate
+// in the 128 bytes encoding of a G2 point
+func swapG2XParts(buff []byte) []byte {
+	swapped := append([]byte{}, buff[32:64]...)
+	swapped = append(swapped, buff[:32]...)
+	return append(swapped, buff[64:]...)
+}
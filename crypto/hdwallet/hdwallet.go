@@ -0,0 +1,131 @@
+// Package hdwallet derives the secp256k1 keys of the hierarchical
+// deterministic wallets from their mnemonics, as in BIP-39 and BIP-32.
+// It only derives the secret keys, and the mnemonics are not checked
+// against the BIP-39 word lists.
+package hdwallet
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"space/crypto/secp256k1"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// HardenedOffset is added to the indexes of the hardened children
+const HardenedOffset uint32 = 0x80000000
+
+var (
+	ErrInvalidPath     = errors.New("invalid derivation path")
+	ErrInvalidChildKey = errors.New("invalid child key")
+)
+
+// Returns the 64 bytes seed of the mnemonic with the password. The
+// mnemonic must already be in its NFKD form, which is the case for
+// the mnemonics of the English word list.
+func SeedFromMnemonic(mnemonic string, password string) []byte {
+	return pbkdf2.Key([]byte(mnemonic), []byte("mnemonic"+password), 2048, 64, sha512.New)
+}
+
+// Parses the derivation path such as m/44'/60'/0'/0/0,
+// where the hardened indexes are marked with '
+func ParsePath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("%w: %v must start with m", ErrInvalidPath, path)
+	}
+	indexes := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		offset := uint32(0)
+		if strings.HasSuffix(part, "'") {
+			part, offset = strings.TrimSuffix(part, "'"), HardenedOffset
+		}
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || uint32(index) >= HardenedOffset {
+			return nil, fmt.Errorf("%w: %v has invalid index %v", ErrInvalidPath, path, part)
+		}
+		indexes = append(indexes, uint32(index)+offset)
+	}
+	return indexes, nil
+}
+
+// Returns the secret key of the path, which is derived from the master
+// key of the seed by deriving the child of each index in the path
+func DeriveKey(seed []byte, path string) ([]byte, error) {
+	indexes, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	key, chainCode := hmacSHA512([]byte("Bitcoin seed"), seed)
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	for _, index := range indexes {
+		if key, chainCode, err = deriveChild(key, chainCode, index); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// Returns the key and the chain code of the child at the index. Hardened
+// children are derived from the secret key, and the normal children are
+// derived from the compressed public key.
+func deriveChild(key, chainCode []byte, index uint32) ([]byte, []byte, error) {
+	var data []byte
+	if index >= HardenedOffset {
+		data = append([]byte{0}, key...)
+	} else {
+		pubkey, err := secp256k1.PubkeyFromSeckey(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		data = compressPubkey(pubkey)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+	tweak, childChainCode := hmacSHA512(chainCode, data)
+	if err := checkKey(tweak); err != nil {
+		return nil, nil, err
+	}
+
+	// child key is the sum of the tweak and the parent key
+	child := new(big.Int).SetBytes(tweak)
+	child.Add(child, new(big.Int).SetBytes(key))
+	child.Mod(child, secp256k1.N)
+	childKey := child.FillBytes(make([]byte, secp256k1.SeckeyLength))
+	if err := checkKey(childKey); err != nil {
+		return nil, nil, err
+	}
+	return childKey, childChainCode, nil
+}
+
+// Keys must be in [1, N), which fails with negligible probability,
+// and BIP-32 skips the index of such keys
+func checkKey(key []byte) error {
+	k := new(big.Int).SetBytes(key)
+	if k.Sign() == 0 || k.Cmp(secp256k1.N) >= 0 {
+		return ErrInvalidChildKey
+	}
+	return nil
+}
+
+// Returns the left and the right halves of the HMAC-SHA512
+func hmacSHA512(key, data []byte) ([]byte, []byte) {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	return sum[:32], sum[32:]
+}
+
+// Returns the 33 bytes compressed form of the uncompressed public key,
+// which is the parity of y followed by x
+func compressPubkey(pubkey []byte) []byte {
+	return append([]byte{2 + pubkey[64]&1}, pubkey[1:33]...)
+}
@@ -0,0 +1,83 @@
+package hdwallet
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// in is the [seed, path], and exp is the derived secret key. Vectors
+// are the test vector 1 of BIP-32, and the first dev accounts of the
+// mnemonic "test test test test test test test test test test test junk"
+var deriveKeyTests = []genericTest{
+	{
+		s:   "bip32 master key",
+		in:  []interface{}{hexToBytes("000102030405060708090a0b0c0d0e0f"), "m"},
+		exp: hexToBytes("e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35"),
+	},
+	{
+		s:   "bip32 hardened child",
+		in:  []interface{}{hexToBytes("000102030405060708090a0b0c0d0e0f"), "m/0'"},
+		exp: hexToBytes("edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"),
+	},
+	{
+		s:   "bip32 normal child",
+		in:  []interface{}{hexToBytes("000102030405060708090a0b0c0d0e0f"), "m/0'/1"},
+		exp: hexToBytes("3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"),
+	},
+	{
+		s:   "first dev account",
+		in:  []interface{}{SeedFromMnemonic("test test test test test test test test test test test junk", ""), "m/44'/60'/0'/0/0"},
+		exp: hexToBytes("ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"),
+	},
+	{
+		s:   "second dev account",
+		in:  []interface{}{SeedFromMnemonic("test test test test test test test test test test test junk", ""), "m/44'/60'/0'/0/1"},
+		exp: hexToBytes("59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d"),
+	},
+	{
+		s:   "path without master",
+		in:  []interface{}{hexToBytes("000102030405060708090a0b0c0d0e0f"), "44'/60'"},
+		exp: ErrInvalidPath,
+	},
+	{
+		s:   "index out of range",
+		in:  []interface{}{hexToBytes("000102030405060708090a0b0c0d0e0f"), "m/2147483648"},
+		exp: ErrInvalidPath,
+	},
+}
+
+func Test_HDWallet_DeriveKey(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range deriveKeyTests {
+		testIn := test.in.([]interface{})
+		key, err := DeriveKey(testIn[0].([]byte), testIn[1].(string))
+		test.act = key
+		if err != nil {
+			test.act = err
+			if expErr, ok := test.exp.(error); ok && errors.Is(err, expErr) {
+				test.act = expErr
+			}
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+func Test_HDWallet_ParsePath(t *testing.T) {
+	indexes, err := ParsePath("m/44'/60'/0'/0/7")
+	test := genericTest{
+		s:   "hardened and normal indexes",
+		exp: []interface{}{[]uint32{44 + HardenedOffset, 60 + HardenedOffset, HardenedOffset, 0, 7}, nil},
+		act: []interface{}{indexes, err},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,32 @@
+// This file contains helper functions and data for testing
+package hdwallet
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+)
+
+// genericTest is the struct used to hold the test data
+// in an organized format. It also helps generate messages
+// based on the expected and actual result comparison.
+type genericTest struct {
+	s          string
+	in         interface{}
+	exp        interface{}
+	act        interface{}
+	shouldFail bool
+}
+
+func (t genericTest) Check() (string, bool) {
+	if reflect.DeepEqual(t.exp, t.act) {
+		return fmt.Sprintf("\t✔ %s\n", t.s), false
+	} else {
+		return fmt.Sprintf("\033[31m\t✖ %s\n\t\texp: %#v\n\t\tgot: %#v\n\033[39m", t.s, t.exp, t.act), true
+	}
+}
+
+func hexToBytes(str string) []byte {
+	buff, _ := hex.DecodeString(str)
+	return buff
+}
@@ -0,0 +1,138 @@
+// Package kzg4844 implements the verification of the KZG proofs of blobs
+// as specified in EIP-4844, using the trusted setup of the Ethereum KZG
+// ceremony. Proofs show that the polynomial committed to evaluates to the
+// claimed value at the given point.
+package kzg4844
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+
+	bls12381 "github.com/kilic/bls12-381"
+)
+
+const (
+	CommitmentLength = 48
+	ProofLength      = 48
+	ScalarLength     = 32
+
+	// Number of field elements in a blob
+	FieldElementsPerBlob = 4096
+	// Version byte of the versioned hashes of the KZG commitments
+	VersionedHashVersionKZG = 0x01
+)
+
+var (
+	ErrInvalidScalar     = errors.New("scalar is not a canonical field element")
+	ErrInvalidCommitment = errors.New("invalid kzg commitment")
+	ErrInvalidProof      = errors.New("invalid kzg proof")
+	ErrProofVerification = errors.New("kzg proof verification failed")
+)
+
+// BLS12-381 subgroup order, which is the modulus of the scalar field
+var BLSModulus, _ = new(big.Int).SetString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001", 16)
+
+type (
+	Commitment [CommitmentLength]byte
+	Proof      [ProofLength]byte
+	Scalar     [ScalarLength]byte
+)
+
+// Only the first two monomial G2 points of the trusted setup, the
+// generator and [tau]G2, are needed to verify proofs. Lagrange G1
+// points are only needed to compute commitments and proofs.
+//
+//go:embed trusted_setup.json
+var trustedSetupJSON []byte
+
+type trustedSetup struct {
+	G2Monomial []string `json:"g2_monomial"`
+}
+
+var (
+	g1 = bls12381.NewG1()
+	g2 = bls12381.NewG2()
+	// [tau]G2 of the trusted setup
+	tauG2 *bls12381.PointG2
+)
+
+func init() {
+	var setup trustedSetup
+	if err := json.Unmarshal(trustedSetupJSON, &setup); err != nil {
+		panic("kzg4844: invalid trusted setup: " + err.Error())
+	}
+	if len(setup.G2Monomial) < 2 {
+		panic("kzg4844: trusted setup is missing g2 points")
+	}
+	points := make([]*bls12381.PointG2, 2)
+	for i := range points {
+		buff, err := hex.DecodeString(strings.TrimPrefix(setup.G2Monomial[i], "0x"))
+		if err != nil {
+			panic("kzg4844: invalid trusted setup: " + err.Error())
+		}
+		if points[i], err = g2.FromCompressed(buff); err != nil {
+			panic("kzg4844: invalid trusted setup: " + err.Error())
+		}
+	}
+	if !g2.Equal(points[0], g2.One()) {
+		panic("kzg4844: trusted setup does not start with the generator")
+	}
+	tauG2 = points[1]
+}
+
+// Returns the versioned hash of the commitment, which is its
+// SHA256 hash with the first byte replaced by the version
+func CalcBlobHashV1(commitment Commitment) [32]byte {
+	hash := sha256.Sum256(commitment[:])
+	hash[0] = VersionedHashVersionKZG
+	return hash
+}
+
+// Verifies the proof that the polynomial committed to evaluates to
+// y at z. Commitment and proof are compressed G1 points, and they
+// must be in the subgroup. z and y must be less than the modulus.
+func VerifyProof(commitment Commitment, z Scalar, y Scalar, proof Proof) error {
+	zInt, err := scalarToBig(z)
+	if err != nil {
+		return err
+	}
+	yInt, err := scalarToBig(y)
+	if err != nil {
+		return err
+	}
+	c, err := g1.FromCompressed(commitment[:])
+	if err != nil {
+		return ErrInvalidCommitment
+	}
+	pi, err := g1.FromCompressed(proof[:])
+	if err != nil {
+		return ErrInvalidProof
+	}
+
+	// e(C - [y]G1, -G2) * e(proof, [tau]G2 - [z]G2) == 1
+	pMinusY := g1.New()
+	g1.Sub(pMinusY, c, g1.MulScalarBig(g1.New(), g1.One(), yInt))
+	xMinusZ := g2.New()
+	g2.Sub(xMinusZ, tauG2, g2.MulScalarBig(g2.New(), g2.One(), zInt))
+
+	engine := bls12381.NewEngine()
+	engine.AddPairInv(pMinusY, g2.One())
+	engine.AddPair(pi, xMinusZ)
+	if !engine.Check() {
+		return ErrProofVerification
+	}
+	return nil
+}
+
+func scalarToBig(s Scalar) (*big.Int, error) {
+	k := new(big.Int).SetBytes(s[:])
+	if k.Cmp(BLSModulus) >= 0 {
+		return nil, ErrInvalidScalar
+	}
+	return k, nil
+}
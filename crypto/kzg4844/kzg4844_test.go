@@ -0,0 +1,112 @@
+package kzg4844
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Point evaluation of a mainnet blob
+var (
+	testVersionedHash = hexToBytes("01e798154708fe7789429634053cbf9f99b619f9f084048927333fce637f549b")
+	testZ             = hexToBytes("564c0a11a0f704f4fc3e8acfe0f8245f0ad1347b378fbf96e206da11a5d36306")
+	testY             = hexToBytes("24d25032e67a7e6a4910df5834b8fe70e6bcfeeac0352434196bdf4b2485d5a1")
+	testCommitment    = hexToBytes("8f59a8d2a1a625a17f3fea0fe5eb8c896db3764f3185481bc22f91b4aaffcca25f26936857bc3a7c2539ea8ec3a952b7")
+	testProof         = hexToBytes("873033e038326e87ed3e1276fd140253fa08e9fc25fb2d9a98527fc22a2c9612fbeafdad446cbc7bcdbdcd780af2c16a")
+	// compressed point at infinity, which is the commitment of the zero polynomial
+	testInfinity = hexToBytes("c00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000")
+	testModulus  = hexToBytes("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001")
+)
+
+func withByte(buff []byte, i int, b byte) []byte {
+	res := append([]byte{}, buff...)
+	res[i] = b
+	return res
+}
+
+// in is the [commitment, z, y, proof]
+var verifyProofTests = []genericTest{
+	{
+		s:   "valid proof",
+		in:  [][]byte{testCommitment, testZ, testY, testProof},
+		exp: nil,
+	},
+	{
+		s:   "zero polynomial evaluates to zero",
+		in:  [][]byte{testInfinity, testZ, make([]byte, 32), testInfinity},
+		exp: nil,
+	},
+	{
+		s:   "wrong claimed value",
+		in:  [][]byte{testCommitment, testZ, withByte(testY, 31, 0xa0), testProof},
+		exp: ErrProofVerification,
+	},
+	{
+		s:   "wrong point",
+		in:  [][]byte{testCommitment, withByte(testZ, 31, 0x07), testY, testProof},
+		exp: ErrProofVerification,
+	},
+	{
+		s:   "zero polynomial evaluates to non-zero",
+		in:  [][]byte{testInfinity, testZ, withByte(make([]byte, 32), 31, 1), testInfinity},
+		exp: ErrProofVerification,
+	},
+	{
+		s:   "point not less than modulus",
+		in:  [][]byte{testCommitment, testModulus, testY, testProof},
+		exp: ErrInvalidScalar,
+	},
+	{
+		s:   "claimed value not less than modulus",
+		in:  [][]byte{testCommitment, testZ, testModulus, testProof},
+		exp: ErrInvalidScalar,
+	},
+	{
+		s:   "commitment without compression flag",
+		in:  [][]byte{withByte(testCommitment, 0, 0x0f), testZ, testY, testProof},
+		exp: ErrInvalidCommitment,
+	},
+	{
+		s:   "infinity proof with extra bits",
+		in:  [][]byte{testCommitment, testZ, testY, withByte(testInfinity, 47, 1)},
+		exp: ErrInvalidProof,
+	},
+}
+
+func Test_KZG_VerifyProof(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range verifyProofTests {
+		in := test.in.([][]byte)
+		var (
+			commitment Commitment
+			z, y       Scalar
+			proof      Proof
+		)
+		copy(commitment[:], in[0])
+		copy(z[:], in[1])
+		copy(y[:], in[2])
+		copy(proof[:], in[3])
+		test.act = VerifyProof(commitment, z, y, proof)
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+func Test_KZG_CalcBlobHashV1(t *testing.T) {
+	var commitment Commitment
+	copy(commitment[:], testCommitment)
+	hash := CalcBlobHashV1(commitment)
+	test := genericTest{
+		s:   "versioned hash of commitment",
+		exp: testVersionedHash,
+		act: hash[:],
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,143 @@
+// Package secp256k1 implements the secp256k1 elliptic curve operations
+// required by Space EVM. It is written in pure Go on top of math/big,
+// hence it does not require cgo. Note that the arithmetic is not
+// constant time, and it should not be used for signing with keys
+// which must be kept secret against side-channel attacks.
+package secp256k1
+
+import (
+	"math/big"
+)
+
+// Curve parameters of y^2 = x^3 + 7 over the prime field P
+var (
+	P, _  = new(big.Int).SetString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", 16)
+	N, _  = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+	Gx, _ = new(big.Int).SetString("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", 16)
+	Gy, _ = new(big.Int).SetString("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8", 16)
+	B     = big.NewInt(7)
+
+	halfN = new(big.Int).Rsh(N, 1)
+	// (P + 1) / 4 is used for square roots, since P = 3 mod 4
+	sqrtExp = new(big.Int).Rsh(new(big.Int).Add(P, big.NewInt(1)), 2)
+)
+
+// jacobianPoint represents the affine point (X / Z^2, Y / Z^3),
+// and Z being zero represents the point at infinity
+type jacobianPoint struct {
+	x, y, z *big.Int
+}
+
+func newJacobianPoint(x, y *big.Int) *jacobianPoint {
+	return &jacobianPoint{
+		x: new(big.Int).Set(x),
+		y: new(big.Int).Set(y),
+		z: big.NewInt(1),
+	}
+}
+
+func infinity() *jacobianPoint {
+	return &jacobianPoint{x: big.NewInt(0), y: big.NewInt(1), z: big.NewInt(0)}
+}
+
+func (p *jacobianPoint) isInfinity() bool {
+	return p.z.Sign() == 0
+}
+
+func (p *jacobianPoint) affine() (*big.Int, *big.Int) {
+	zInv := new(big.Int).ModInverse(p.z, P)
+	zInv2 := mulMod(zInv, zInv)
+	x := mulMod(p.x, zInv2)
+	y := mulMod(p.y, mulMod(zInv2, zInv))
+	return x, y
+}
+
+func mulMod(a, b *big.Int) *big.Int {
+	r := new(big.Int).Mul(a, b)
+	return r.Mod(r, P)
+}
+
+func subMod(a, b *big.Int) *big.Int {
+	r := new(big.Int).Sub(a, b)
+	return r.Mod(r, P)
+}
+
+func (p *jacobianPoint) double() *jacobianPoint {
+	if p.isInfinity() || p.y.Sign() == 0 {
+		return infinity()
+	}
+	// dbl-2009-l formulas, since a = 0
+	a := mulMod(p.x, p.x)
+	b := mulMod(p.y, p.y)
+	c := mulMod(b, b)
+	d := new(big.Int).Add(p.x, b)
+	d = subMod(subMod(mulMod(d, d), a), c)
+	d.Lsh(d, 1)
+	e := new(big.Int).Mul(a, big.NewInt(3))
+	f := mulMod(e, e)
+
+	x := subMod(f, new(big.Int).Lsh(d, 1))
+	y := subMod(mulMod(e, subMod(d, x)), new(big.Int).Lsh(c, 3))
+	z := mulMod(new(big.Int).Lsh(p.y, 1), p.z)
+	return &jacobianPoint{x: x, y: y, z: z}
+}
+
+func (p *jacobianPoint) add(q *jacobianPoint) *jacobianPoint {
+	if p.isInfinity() {
+		return q
+	}
+	if q.isInfinity() {
+		return p
+	}
+	// add-2007-bl formulas
+	z1z1 := mulMod(p.z, p.z)
+	z2z2 := mulMod(q.z, q.z)
+	u1 := mulMod(p.x, z2z2)
+	u2 := mulMod(q.x, z1z1)
+	s1 := mulMod(p.y, mulMod(q.z, z2z2))
+	s2 := mulMod(q.y, mulMod(p.z, z1z1))
+	if u1.Cmp(u2) == 0 {
+		if s1.Cmp(s2) == 0 {
+			return p.double()
+		}
+		return infinity()
+	}
+	h := subMod(u2, u1)
+	i := new(big.Int).Lsh(h, 1)
+	i = mulMod(i, i)
+	j := mulMod(h, i)
+	r := new(big.Int).Lsh(subMod(s2, s1), 1)
+	v := mulMod(u1, i)
+
+	x := subMod(subMod(mulMod(r, r), j), new(big.Int).Lsh(v, 1))
+	y := subMod(mulMod(r, subMod(v, x)), new(big.Int).Lsh(mulMod(s1, j), 1))
+	z := new(big.Int).Add(p.z, q.z)
+	z = mulMod(subMod(subMod(mulMod(z, z), z1z1), z2z2), h)
+	return &jacobianPoint{x: x, y: y, z: z}
+}
+
+func (p *jacobianPoint) mul(k *big.Int) *jacobianPoint {
+	res := infinity()
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		res = res.double()
+		if k.Bit(i) == 1 {
+			res = res.add(p)
+		}
+	}
+	return res
+}
+
+// Reports whether the affine point (x, y) is on the curve
+func isOnCurve(x, y *big.Int) bool {
+	if x.Sign() < 0 || x.Cmp(P) >= 0 || y.Sign() < 0 || y.Cmp(P) >= 0 {
+		return false
+	}
+	return mulMod(y, y).Cmp(curveRHS(x)) == 0
+}
+
+// Returns x^3 + 7
+func curveRHS(x *big.Int) *big.Int {
+	r := mulMod(mulMod(x, x), x)
+	r.Add(r, B)
+	return r.Mod(r, P)
+}
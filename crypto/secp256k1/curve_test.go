@@ -0,0 +1,63 @@
+package secp256k1
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+var curveMulTests = []genericTest{
+	{s: "G * 0 is infinity", in: big.NewInt(0), exp: true},
+	{s: "G * N is infinity", in: N, exp: true},
+	{s: "G * 1 is on curve", in: big.NewInt(1), exp: true},
+	{s: "G * 2 is on curve", in: big.NewInt(2), exp: true},
+	{s: "G * (N - 1) is on curve", in: new(big.Int).Sub(N, big.NewInt(1)), exp: true},
+}
+
+func Test_Curve_Mul(t *testing.T) {
+	anyTestFailed := false
+	G := newJacobianPoint(Gx, Gy)
+	for _, test := range curveMulTests {
+		k := test.in.(*big.Int)
+		res := G.mul(k)
+		if k.Sign() == 0 || k.Cmp(N) == 0 {
+			test.act = res.isInfinity()
+		} else {
+			test.act = isOnCurve(res.affine())
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// G * 2 computed by doubling and by adding G * 1 and G * 1 must match,
+// and G * (N - 1) must be the negation of G
+var curveAddTests = []genericTest{
+	{s: "G + G is 2G", in: big.NewInt(2), exp: true},
+	{s: "G + (N - 1)G is infinity", in: new(big.Int).Sub(N, big.NewInt(1)), exp: true},
+}
+
+func Test_Curve_Add(t *testing.T) {
+	anyTestFailed := false
+	G := newJacobianPoint(Gx, Gy)
+	for _, test := range curveAddTests {
+		k := test.in.(*big.Int)
+		if k.Cmp(big.NewInt(2)) == 0 {
+			x1, y1 := G.add(G).affine()
+			x2, y2 := G.double().affine()
+			test.act = x1.Cmp(x2) == 0 && y1.Cmp(y2) == 0
+		} else {
+			test.act = G.add(G.mul(k)).isInfinity()
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
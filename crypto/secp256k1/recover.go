@@ -0,0 +1,103 @@
+package secp256k1
+
+import (
+	"errors"
+	"math/big"
+)
+
+const (
+	// SignatureLength is the length of [R || S || V] signatures
+	SignatureLength = 65
+	// PubkeyLength is the length of uncompressed public keys
+	PubkeyLength = 65
+)
+
+var (
+	ErrInvalidHashLen      = errors.New("invalid hash length")
+	ErrInvalidSignatureLen = errors.New("invalid signature length")
+	ErrInvalidRecoveryID   = errors.New("invalid signature recovery id")
+	ErrInvalidSignature    = errors.New("invalid signature")
+	ErrRecoverFailed       = errors.New("public key recovery failed")
+)
+
+// Reports whether r and s are valid signature values. If
+// lowS is set, s is also required to be in the lower half
+// of the curve order to disallow malleable signatures.
+func ValidateSignatureValues(r, s *big.Int, lowS bool) bool {
+	if r.Sign() <= 0 || s.Sign() <= 0 {
+		return false
+	}
+	if r.Cmp(N) >= 0 || s.Cmp(N) >= 0 {
+		return false
+	}
+	return !lowS || s.Cmp(halfN) <= 0
+}
+
+// RecoverPubkey returns the uncompressed public key which created
+// the given 65 bytes [R || S || V] signature of the 32 bytes hash.
+// V is the recovery id, which is expected to be 0 or 1.
+func RecoverPubkey(hash []byte, sig []byte) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, ErrInvalidHashLen
+	}
+	if len(sig) != SignatureLength {
+		return nil, ErrInvalidSignatureLen
+	}
+	v := sig[64]
+	if v > 1 {
+		return nil, ErrInvalidRecoveryID
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:64])
+	if !ValidateSignatureValues(r, s, false) {
+		return nil, ErrInvalidSignature
+	}
+
+	// r is the x coordinate of the random point R, and the
+	// recovery id determines the parity of its y coordinate
+	ry, ok := decompressY(r, v == 1)
+	if !ok {
+		return nil, ErrRecoverFailed
+	}
+	R := newJacobianPoint(r, ry)
+
+	// Q = r^-1 * (s*R - e*G)
+	e := new(big.Int).SetBytes(hash)
+	rInv := new(big.Int).ModInverse(r, N)
+	u1 := new(big.Int).Mul(e, rInv)
+	u1.Neg(u1).Mod(u1, N)
+	u2 := new(big.Int).Mul(s, rInv)
+	u2.Mod(u2, N)
+
+	G := newJacobianPoint(Gx, Gy)
+	Q := G.mul(u1).add(R.mul(u2))
+	if Q.isInfinity() {
+		return nil, ErrRecoverFailed
+	}
+	qx, qy := Q.affine()
+	return marshalPubkey(qx, qy), nil
+}
+
+// Returns the y coordinate of the point with the given x coordinate
+// and parity, and reports false if x is not on the curve
+func decompressY(x *big.Int, odd bool) (*big.Int, bool) {
+	if x.Cmp(P) >= 0 {
+		return nil, false
+	}
+	y := new(big.Int).Exp(curveRHS(x), sqrtExp, P)
+	if !isOnCurve(x, y) {
+		return nil, false
+	}
+	if (y.Bit(0) == 1) != odd {
+		y.Sub(P, y)
+	}
+	return y, true
+}
+
+func marshalPubkey(x, y *big.Int) []byte {
+	pubkey := make([]byte, PubkeyLength)
+	pubkey[0] = 4
+	x.FillBytes(pubkey[1:33])
+	y.FillBytes(pubkey[33:])
+	return pubkey
+}
@@ -0,0 +1,74 @@
+package secp256k1
+
+import (
+	"fmt"
+	"testing"
+)
+
+var testHash = hexToBytes("ce0677bb30baa8cf067c88db9811f4333d131bf8bcf12fe7065d211dce971008")
+var testSig = hexToBytes("90f27b8b488db00b00606796d2987f6a5f59ae62ea05effe84fef5b8b0e549984a691139ad57a3f0b906637673aa2f63d1f55cb1a69199d4009eea23ceaddc9301")
+var testPubkey = hexToBytes("04e32df42865e97135acfb65f3bae71bdc86f4d49150ad6a440b6f15878109880a0a2b2667f7e725ceea70c673093bf67663e0312623c8e091b13cf2c0f11ef652")
+
+// in is the [hash, signature]
+var recoverPubkeyTests = []genericTest{
+	{
+		s:   "recover public key",
+		in:  [][]byte{testHash, testSig},
+		exp: testPubkey,
+	},
+	{
+		s:          "invalid hash length",
+		in:         [][]byte{testHash[1:], testSig},
+		exp:        ErrInvalidHashLen,
+		shouldFail: true,
+	},
+	{
+		s:          "invalid signature length",
+		in:         [][]byte{testHash, testSig[1:]},
+		exp:        ErrInvalidSignatureLen,
+		shouldFail: true,
+	},
+	{
+		s:          "invalid recovery id",
+		in:         [][]byte{testHash, append(append([]byte{}, testSig[:64]...), 2)},
+		exp:        ErrInvalidRecoveryID,
+		shouldFail: true,
+	},
+	{
+		s:          "zero r",
+		in:         [][]byte{testHash, append(make([]byte, 32), testSig[32:]...)},
+		exp:        ErrInvalidSignature,
+		shouldFail: true,
+	},
+	{
+		s:          "s equal to curve order",
+		in:         [][]byte{testHash, append(append(append([]byte{}, testSig[:32]...), N.Bytes()...), 1)},
+		exp:        ErrInvalidSignature,
+		shouldFail: true,
+	},
+	{
+		s:          "r is not on the curve",
+		in:         [][]byte{testHash, append(hexToBytes("0000000000000000000000000000000000000000000000000000000000000005"), testSig[32:]...)},
+		exp:        ErrRecoverFailed,
+		shouldFail: true,
+	},
+}
+
+func Test_Recover_RecoverPubkey(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range recoverPubkeyTests {
+		testIn := test.in.([][]byte)
+		pubkey, err := RecoverPubkey(testIn[0], testIn[1])
+		if !test.shouldFail {
+			test.act = pubkey
+		} else {
+			test.act = err
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
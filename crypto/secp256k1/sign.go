@@ -0,0 +1,127 @@
+package secp256k1
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// SeckeyLength is the length of secret keys
+const SeckeyLength = 32
+
+var ErrInvalidSeckey = errors.New("invalid secret key")
+
+// Returns the uncompressed public key of the 32 bytes secret key
+func PubkeyFromSeckey(seckey []byte) ([]byte, error) {
+	d, err := parseSeckey(seckey)
+	if err != nil {
+		return nil, err
+	}
+	qx, qy := newJacobianPoint(Gx, Gy).mul(d).affine()
+	return marshalPubkey(qx, qy), nil
+}
+
+// Sign returns the 65 bytes [R || S || V] signature of the 32 bytes
+// hash, where V is the recovery id, which is 0 or 1. Nonce is derived
+// deterministically from the key and the hash as in RFC 6979, and S is
+// always in the lower half of the curve order.
+func Sign(hash []byte, seckey []byte) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, ErrInvalidHashLen
+	}
+	d, err := parseSeckey(seckey)
+	if err != nil {
+		return nil, err
+	}
+	e := new(big.Int).SetBytes(hash)
+	G := newJacobianPoint(Gx, Gy)
+
+	nonces := newRFC6979(seckey, hash)
+	for {
+		k := nonces.next()
+		rx, ry := G.mul(k).affine()
+		// recovery ids of the r values which are not less
+		// than the curve order are not supported by recovery
+		if rx.Cmp(N) >= 0 {
+			continue
+		}
+		r := rx
+		s := new(big.Int).Mul(r, d)
+		s.Add(s, e)
+		s.Mul(s, new(big.Int).ModInverse(k, N))
+		s.Mod(s, N)
+		if r.Sign() == 0 || s.Sign() == 0 {
+			continue
+		}
+
+		v := byte(ry.Bit(0))
+		if s.Cmp(halfN) > 0 {
+			s.Sub(N, s)
+			v ^= 1
+		}
+		sig := make([]byte, SignatureLength)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:64])
+		sig[64] = v
+		return sig, nil
+	}
+}
+
+func parseSeckey(seckey []byte) (*big.Int, error) {
+	if len(seckey) != SeckeyLength {
+		return nil, ErrInvalidSeckey
+	}
+	d := new(big.Int).SetBytes(seckey)
+	if d.Sign() == 0 || d.Cmp(N) >= 0 {
+		return nil, ErrInvalidSeckey
+	}
+	return d, nil
+}
+
+// rfc6979 generates the nonces of a signature with HMAC-SHA256
+type rfc6979 struct {
+	k, v  []byte
+	first bool
+}
+
+func newRFC6979(seckey []byte, hash []byte) *rfc6979 {
+	// hash is reduced modulo the curve order as bits2octets
+	h := new(big.Int).SetBytes(hash)
+	h.Mod(h, N)
+	seed := append(append([]byte{}, seckey...), h.FillBytes(make([]byte, 32))...)
+
+	g := &rfc6979{k: make([]byte, 32), v: make([]byte, 32), first: true}
+	for i := range g.v {
+		g.v[i] = 1
+	}
+	g.k = g.mac(g.v, []byte{0}, seed)
+	g.v = g.mac(g.v)
+	g.k = g.mac(g.v, []byte{1}, seed)
+	g.v = g.mac(g.v)
+	return g
+}
+
+func (g *rfc6979) mac(data ...[]byte) []byte {
+	h := hmac.New(sha256.New, g.k)
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// Returns the next nonce, which is in [1, N - 1]
+func (g *rfc6979) next() *big.Int {
+	for {
+		if !g.first {
+			g.k = g.mac(g.v, []byte{0})
+			g.v = g.mac(g.v)
+		}
+		g.first = false
+		g.v = g.mac(g.v)
+		k := new(big.Int).SetBytes(g.v)
+		if k.Sign() > 0 && k.Cmp(N) < 0 {
+			return k
+		}
+	}
+}
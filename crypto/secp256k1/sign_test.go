@@ -0,0 +1,78 @@
+package secp256k1
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+)
+
+var satoshiHash = sha256.Sum256([]byte("Satoshi Nakamoto"))
+
+// in is the [hash, secret key], and exp is the signature
+var signTests = []genericTest{
+	{
+		s:   "secret key 1",
+		in:  [][]byte{satoshiHash[:], hexToBytes("0000000000000000000000000000000000000000000000000000000000000001")},
+		exp: hexToBytes("934b1ea10a4b3c1757e2b0c017d0b6143ce3c9a7e6a4a49860d7a6ab210ee3d82442ce9d2b916064108014783e923ec36b49743e2ffa1c4496f01a512aafd9e501"),
+	},
+	{
+		s:   "EIP-155 example transaction",
+		in:  [][]byte{hexToBytes("daf5a779ae972f972197303d7b574746c7ef83eadac0f2791ad23db92e4c8e53"), hexToBytes("4646464646464646464646464646464646464646464646464646464646464646")},
+		exp: hexToBytes("28ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa63627667cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d8300"),
+	},
+	{
+		s:          "invalid hash length",
+		in:         [][]byte{satoshiHash[1:], hexToBytes("0000000000000000000000000000000000000000000000000000000000000001")},
+		exp:        ErrInvalidHashLen,
+		shouldFail: true,
+	},
+	{
+		s:          "zero secret key",
+		in:         [][]byte{satoshiHash[:], make([]byte, 32)},
+		exp:        ErrInvalidSeckey,
+		shouldFail: true,
+	},
+	{
+		s:          "secret key equal to curve order",
+		in:         [][]byte{satoshiHash[:], N.Bytes()},
+		exp:        ErrInvalidSeckey,
+		shouldFail: true,
+	},
+}
+
+func Test_Sign_Sign(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range signTests {
+		testIn := test.in.([][]byte)
+		sig, err := Sign(testIn[0], testIn[1])
+		if !test.shouldFail {
+			// signature must also recover the public key of the secret key
+			pubkey, _ := PubkeyFromSeckey(testIn[1])
+			recovered, _ := RecoverPubkey(testIn[0], sig)
+			test.exp = []interface{}{test.exp, pubkey}
+			test.act = []interface{}{sig, recovered}
+		} else {
+			test.act = err
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+func Test_Sign_PubkeyFromSeckey(t *testing.T) {
+	pubkey, _ := PubkeyFromSeckey(hexToBytes("0000000000000000000000000000000000000000000000000000000000000001"))
+	test := genericTest{
+		s:   "public key of secret key 1 is the generator",
+		exp: marshalPubkey(Gx, Gy),
+		act: pubkey,
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,29 @@
+package database
+
+// batchOp is a put or a delete collected by a batch
+type batchOp struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+// batch collects the writes, which are written into
+// the store by the batch implementation of the store
+type batch struct {
+	ops []batchOp
+}
+
+// Stores a copy of the value, hence the caller can modify it afterwards
+func (b *batch) Put(key []byte, value []byte) error {
+	b.ops = append(b.ops, batchOp{key: append([]byte{}, key...), value: append([]byte{}, value...)})
+	return nil
+}
+
+func (b *batch) Delete(key []byte) error {
+	b.ops = append(b.ops, batchOp{key: append([]byte{}, key...), delete: true})
+	return nil
+}
+
+func (b *batch) Reset() {
+	b.ops = b.ops[:0]
+}
@@ -0,0 +1,47 @@
+// Package database defines the key/value stores which the
+// tries and the world state are persisted into, and provides
+// an in-memory and a disk-backed implementation of them
+package database
+
+import (
+	"errors"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// KeyValueReader reads the values of the keys from a store
+type KeyValueReader interface {
+	// Returns ErrNotFound if the key is not in the store
+	Get(key []byte) ([]byte, error)
+	Has(key []byte) (bool, error)
+}
+
+// KeyValueWriter writes the values of the keys into a store
+type KeyValueWriter interface {
+	Put(key []byte, value []byte) error
+	Delete(key []byte) error
+}
+
+// Batch collects the writes in memory, and writes
+// all of them into its store at once with Write
+type Batch interface {
+	KeyValueWriter
+	// Writes the collected writes into the store. Either all
+	// or none of them are written if the store crashes.
+	Write() error
+	// Drops the collected writes, so that the batch can be reused
+	Reset()
+}
+
+// Batcher creates batches which write into its store
+type Batcher interface {
+	NewBatch() Batch
+}
+
+// KeyValueStore is a store which can be both read and written
+type KeyValueStore interface {
+	KeyValueReader
+	KeyValueWriter
+	Batcher
+	Close() error
+}
@@ -0,0 +1,456 @@
+package database
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	ErrFileDBClosed    = errors.New("file database closed")
+	ErrFileDBCorrupted = errors.New("file database corrupted")
+	errCorruptedRecord = errors.New("corrupted record")
+)
+
+const (
+	logFileName     = "data.log"
+	compactFileName = "data.log.compact"
+
+	// Record header holds the crc32 and the size of the payload
+	recordHeaderLen = 8
+	// Records written during compaction are split at this payload size
+	compactRecordSize = 4 * 1024 * 1024
+	// Log is compacted automatically once it has at least this many bytes
+	// of stale records, and they are more than the bytes of live records
+	autoCompactMinStale = 64 * 1024 * 1024
+
+	opPut    byte = 1
+	opDelete byte = 2
+)
+
+// indexEntry is the location of a value in the log,
+// with the size of the operation which wrote it
+type indexEntry struct {
+	offset int64
+	size   int
+	opSize int64
+}
+
+// FileDB is a disk-backed key/value store. Every write is appended to a
+// log file as a record, and an in-memory index holds the location of the
+// latest value of each key in the log. Records are checksummed, and they
+// are synced to the disk before the writes return, hence a crash can
+// only leave a partial record at the end of the log, which is discarded
+// when the database is opened again. Corrupted records followed by the
+// rest of the log can not be left by a crash, hence the database is not
+// opened until it is repaired with RepairFileDB. Stale records of
+// overwritten and deleted keys are removed by compacting the log into a
+// new file, which atomically replaces the old one.
+type FileDB struct {
+	lock  sync.RWMutex
+	dir   string
+	file  *os.File
+	index map[string]indexEntry
+	size  int64 // size of the log
+	live  int64 // size of the operations of the live keys
+	// size of the partial or the repaired records which
+	// are discarded when the log is opened
+	discarded int64
+}
+
+// Opens the database in the directory, which is created if it does not
+// exist. Partial record at the end of the log is discarded, while the
+// corrupted records before the end of the log fail the opening with
+// ErrFileDBCorrupted.
+func OpenFileDB(dir string) (*FileDB, error) {
+	return openFileDB(dir, false)
+}
+
+// Repairs the log of the database in the directory by discarding the
+// first corrupted record with all of the records after it, and returns
+// the number of the discarded bytes. Values written by the discarded
+// records are lost.
+func RepairFileDB(dir string) (int64, error) {
+	db, err := openFileDB(dir, true)
+	if err != nil {
+		return 0, err
+	}
+	discarded := db.discarded
+	return discarded, db.Close()
+}
+
+func openFileDB(dir string, repair bool) (*FileDB, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	// compaction did not complete, hence the log is still valid
+	if err := os.Remove(filepath.Join(dir, compactFileName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	db := &FileDB{dir: dir}
+	if err := db.open(repair); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// Opens the log file, and rebuilds the index from its records. Log is
+// truncated at the first corrupted record if it is a partial record,
+// or if repair is set.
+func (db *FileDB) open(repair bool) error {
+	file, err := os.OpenFile(filepath.Join(db.dir, logFileName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	buff, err := os.ReadFile(file.Name())
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	db.file, db.index, db.size, db.live, db.discarded = file, make(map[string]indexEntry), 0, 0, 0
+	for db.size < int64(len(buff)) {
+		ops, err := decodeRecord(buff[db.size:])
+		if err != nil {
+			break
+		}
+		db.applyOps(ops, db.size)
+		db.size += recordHeaderLen + int64(binary.BigEndian.Uint32(buff[db.size+4:]))
+	}
+	if db.size == int64(len(buff)) {
+		return nil
+	}
+	if !repair && !isPartialRecord(buff[db.size:]) {
+		file.Close()
+		db.file = nil
+		return fmt.Errorf("%w: corrupted record at offset %d of %d bytes", ErrFileDBCorrupted, db.size, len(buff))
+	}
+	// drop the partial record written during a crash
+	if err := file.Truncate(db.size); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	db.discarded = int64(len(buff)) - db.size
+	return nil
+}
+
+// Returns whether the corrupted bytes at the end of the log are a
+// partial record, which is left by a crash while it is appended.
+// Partial record runs up to the end of the log, or it is a zeroed
+// header followed only by zeroes.
+func isPartialRecord(buff []byte) bool {
+	if len(buff) < recordHeaderLen {
+		return true
+	}
+	size := binary.BigEndian.Uint32(buff[4:])
+	if size == 0 {
+		for _, b := range buff {
+			if b != 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return uint64(size) >= uint64(len(buff)-recordHeaderLen)
+}
+
+// Record is made of the crc32 of its payload, the size of its payload,
+// and the payload itself, which is the list of operations. Each operation
+// is its kind, and the size prefixed key followed by the size prefixed
+// value for puts.
+func encodeRecord(ops []batchOp) []byte {
+	buff := make([]byte, recordHeaderLen, recordHeaderLen+64*len(ops))
+	for _, op := range ops {
+		buff = append(buff, encodeOp(op)...)
+	}
+	binary.BigEndian.PutUint32(buff[4:], uint32(len(buff)-recordHeaderLen))
+	binary.BigEndian.PutUint32(buff, crc32.ChecksumIEEE(buff[recordHeaderLen:]))
+	return buff
+}
+
+func encodeOp(op batchOp) []byte {
+	buff := make([]byte, 0, 1+2*binary.MaxVarintLen64+len(op.key)+len(op.value))
+	if op.delete {
+		buff = append(buff, opDelete)
+		buff = binary.AppendUvarint(buff, uint64(len(op.key)))
+		return append(buff, op.key...)
+	}
+	buff = append(buff, opPut)
+	buff = binary.AppendUvarint(buff, uint64(len(op.key)))
+	buff = append(buff, op.key...)
+	buff = binary.AppendUvarint(buff, uint64(len(op.value)))
+	return append(buff, op.value...)
+}
+
+func decodeRecord(buff []byte) ([]batchOp, error) {
+	if len(buff) < recordHeaderLen {
+		return nil, errCorruptedRecord
+	}
+	// records are never empty, which also rejects zeroed tails
+	size := binary.BigEndian.Uint32(buff[4:])
+	if size == 0 || uint64(size) > uint64(len(buff)-recordHeaderLen) {
+		return nil, errCorruptedRecord
+	}
+	payload := buff[recordHeaderLen : recordHeaderLen+int(size)]
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(buff) {
+		return nil, errCorruptedRecord
+	}
+
+	var ops []batchOp
+	for len(payload) > 0 {
+		var op batchOp
+		kind := payload[0]
+		key, rest, err := readSized(payload[1:])
+		if err != nil {
+			return nil, err
+		}
+		switch kind {
+		case opPut:
+			op.value, rest, err = readSized(rest)
+			if err != nil {
+				return nil, err
+			}
+		case opDelete:
+			op.delete = true
+		default:
+			return nil, errCorruptedRecord
+		}
+		op.key = key
+		ops = append(ops, op)
+		payload = rest
+	}
+	return ops, nil
+}
+
+func readSized(buff []byte) ([]byte, []byte, error) {
+	size, n := binary.Uvarint(buff)
+	if n <= 0 || size > uint64(len(buff)-n) {
+		return nil, nil, errCorruptedRecord
+	}
+	return buff[n : n+int(size)], buff[n+int(size):], nil
+}
+
+// Updates the index with the operations of the record at the offset
+func (db *FileDB) applyOps(ops []batchOp, offset int64) {
+	offset += recordHeaderLen
+	for _, op := range ops {
+		opSize := int64(len(encodeOp(op)))
+		if prev, ok := db.index[string(op.key)]; ok {
+			db.live -= prev.opSize
+		}
+		if op.delete {
+			delete(db.index, string(op.key))
+		} else {
+			valueOffset := offset + opSize - int64(len(op.value))
+			db.index[string(op.key)] = indexEntry{offset: valueOffset, size: len(op.value), opSize: opSize}
+			db.live += opSize
+		}
+		offset += opSize
+	}
+}
+
+func (db *FileDB) Get(key []byte) ([]byte, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.file == nil {
+		return nil, ErrFileDBClosed
+	}
+	entry, ok := db.index[string(key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	value := make([]byte, entry.size)
+	if _, err := db.file.ReadAt(value, entry.offset); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (db *FileDB) Has(key []byte) (bool, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.file == nil {
+		return false, ErrFileDBClosed
+	}
+	_, ok := db.index[string(key)]
+	return ok, nil
+}
+
+func (db *FileDB) Put(key []byte, value []byte) error {
+	return db.write([]batchOp{{key: key, value: value}})
+}
+
+func (db *FileDB) Delete(key []byte) error {
+	return db.write([]batchOp{{key: key, delete: true}})
+}
+
+func (db *FileDB) NewBatch() Batch {
+	return &fileBatch{db: db}
+}
+
+// fileBatch writes all of its writes as a single record,
+// hence either all or none of them survive a crash
+type fileBatch struct {
+	batch
+	db *FileDB
+}
+
+func (b *fileBatch) Write() error {
+	if len(b.ops) == 0 {
+		return nil
+	}
+	return b.db.write(b.ops)
+}
+
+// Appends the operations to the log as a single record, and
+// syncs it to the disk before the index is updated
+func (db *FileDB) write(ops []batchOp) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.file == nil {
+		return ErrFileDBClosed
+	}
+	record := encodeRecord(ops)
+	if _, err := db.file.WriteAt(record, db.size); err != nil {
+		// partial record would be discarded on the next open anyway
+		if truncErr := db.file.Truncate(db.size); truncErr != nil {
+			return fmt.Errorf("%w, and the partial record is not truncated: %v", err, truncErr)
+		}
+		return err
+	}
+	if err := db.file.Sync(); err != nil {
+		return err
+	}
+	db.applyOps(ops, db.size)
+	db.size += int64(len(record))
+
+	if stale := db.size - db.live; stale >= autoCompactMinStale && stale > db.live {
+		return db.compact()
+	}
+	return nil
+}
+
+// Returns the number of keys in the store
+func (db *FileDB) Len() int {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return len(db.index)
+}
+
+// Returns the size of the log in bytes
+func (db *FileDB) Size() int64 {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return db.size
+}
+
+// Rewrites the log with only the latest values of the live keys
+func (db *FileDB) Compact() error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.file == nil {
+		return ErrFileDBClosed
+	}
+	return db.compact()
+}
+
+// Live values are written into a new file, which replaces the log once
+// it is synced to the disk. Rename is atomic, hence a crash leaves either
+// the old or the new log in place.
+func (db *FileDB) compact() error {
+	compactPath := filepath.Join(db.dir, compactFileName)
+	file, err := os.OpenFile(compactPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(compactPath)
+
+	var (
+		ops  []batchOp
+		size int
+	)
+	flush := func() error {
+		if len(ops) == 0 {
+			return nil
+		}
+		if _, err := file.Write(encodeRecord(ops)); err != nil {
+			return err
+		}
+		ops, size = ops[:0], 0
+		return nil
+	}
+	for key, entry := range db.index {
+		value := make([]byte, entry.size)
+		if _, err := db.file.ReadAt(value, entry.offset); err != nil {
+			file.Close()
+			return err
+		}
+		ops = append(ops, batchOp{key: []byte(key), value: value})
+		if size += int(entry.opSize); size >= compactRecordSize {
+			if err := flush(); err != nil {
+				file.Close()
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(compactPath, filepath.Join(db.dir, logFileName)); err != nil {
+		return err
+	}
+	if err := syncDir(db.dir); err != nil {
+		return err
+	}
+	db.file.Close()
+	if err := db.open(false); err != nil {
+		db.file = nil
+		return fmt.Errorf("reopen compacted log: %w", err)
+	}
+	return nil
+}
+
+// Syncs the directory, so that the renamed file survives a crash
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func (db *FileDB) Close() error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.file == nil {
+		return nil
+	}
+	err := db.file.Close()
+	db.file = nil
+	return err
+}
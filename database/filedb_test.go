@@ -0,0 +1,245 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Returns the values of the keys in the store,
+// where missing keys have the not found error
+func readKeys(db KeyValueReader, keys ...string) []interface{} {
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		value, err := db.Get([]byte(key))
+		if err != nil {
+			values[i] = err
+		} else {
+			values[i] = string(value)
+		}
+	}
+	return values
+}
+
+func writeTestKeys(t *testing.T, db KeyValueStore) {
+	db.Put([]byte("dog"), []byte("puppy"))
+	db.Put([]byte("horse"), []byte("foal"))
+	db.Put([]byte("horse"), []byte("stallion"))
+	db.Put([]byte("cat"), []byte("kitten"))
+	db.Delete([]byte("cat"))
+	batch := db.NewBatch()
+	batch.Put([]byte("doe"), []byte("reindeer"))
+	batch.Put([]byte("empty"), []byte{})
+	batch.Delete([]byte("dog"))
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var testKeys = []string{"dog", "horse", "cat", "doe", "empty"}
+var testValues = []interface{}{ErrNotFound, "stallion", ErrNotFound, "reindeer", ""}
+
+func Test_FileDB_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	db, err := OpenFileDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestKeys(t, db)
+	beforeClose := readKeys(db, testKeys...)
+	db.Close()
+	_, closedErr := db.Get([]byte("horse"))
+
+	db, err = OpenFileDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	test := genericTest{
+		s:   "values survive reopening",
+		exp: []interface{}{testValues, ErrFileDBClosed, testValues, 3},
+		act: []interface{}{beforeClose, closedErr, readKeys(db, testKeys...), db.Len()},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+// in modifies the log of the test keys as if the database
+// crashed while writing a record with the key "crash"
+var crashTests = []genericTest{
+	{
+		s: "partial record",
+		in: func(log []byte) []byte {
+			record := encodeRecord([]batchOp{{key: []byte("crash"), value: []byte("value")}})
+			return append(log, record[:len(record)-2]...)
+		},
+	},
+	{
+		s: "partial header",
+		in: func(log []byte) []byte {
+			return append(log, 0, 0, 0)
+		},
+	},
+	{
+		s: "record with invalid checksum",
+		in: func(log []byte) []byte {
+			record := encodeRecord([]batchOp{{key: []byte("crash"), value: []byte("value")}})
+			record[len(record)-1] ^= 1
+			return append(log, record...)
+		},
+	},
+	{
+		s: "zeroes written after the log",
+		in: func(log []byte) []byte {
+			return append(log, make([]byte, 100)...)
+		},
+	},
+}
+
+func Test_FileDB_CrashRecovery(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range crashTests {
+		dir := t.TempDir()
+		db, _ := OpenFileDB(dir)
+		writeTestKeys(t, db)
+		size := db.Size()
+		db.Close()
+
+		path := filepath.Join(dir, logFileName)
+		log, _ := os.ReadFile(path)
+		os.WriteFile(path, test.in.(func([]byte) []byte)(log), 0644)
+
+		db, err := OpenFileDB(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recoveredSize := db.Size()
+		recovered := readKeys(db, append(testKeys, "crash")...)
+		// writes after the recovery must survive another reopen
+		db.Put([]byte("after"), []byte("crash"))
+		db.Close()
+		db, _ = OpenFileDB(dir)
+		afterCrash := readKeys(db, "after")
+		db.Close()
+
+		test.exp = []interface{}{size, append(testValues, ErrNotFound), []interface{}{"crash"}}
+		test.act = []interface{}{recoveredSize, recovered, afterCrash}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+func Test_FileDB_Corruption(t *testing.T) {
+	dir := t.TempDir()
+	db, _ := OpenFileDB(dir)
+	writeTestKeys(t, db)
+	db.Close()
+
+	// corrupts the value of the second record, which is followed by the
+	// rest of the log, hence it can not be a partial record of a crash
+	path := filepath.Join(dir, logFileName)
+	log, _ := os.ReadFile(path)
+	first := encodeRecord([]batchOp{{key: []byte("dog"), value: []byte("puppy")}})
+	log[len(first)+recordHeaderLen+10] ^= 1
+	os.WriteFile(path, log, 0644)
+
+	_, openErr := OpenFileDB(dir)
+	discarded, repairErr := RepairFileDB(dir)
+	db, err := OpenFileDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	test := genericTest{
+		s: "corrupted record is repaired",
+		exp: []interface{}{
+			true,
+			nil,
+			int64(len(log) - len(first)),
+			int64(len(first)),
+			[]interface{}{"puppy", ErrNotFound, ErrNotFound, ErrNotFound, ErrNotFound},
+		},
+		act: []interface{}{
+			errors.Is(openErr, ErrFileDBCorrupted),
+			repairErr,
+			discarded,
+			db.Size(),
+			readKeys(db, testKeys...),
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+func Test_FileDB_Compact(t *testing.T) {
+	dir := t.TempDir()
+	db, _ := OpenFileDB(dir)
+	writeTestKeys(t, db)
+	for i := 0; i < 100; i++ {
+		db.Put([]byte("counter"), []byte(fmt.Sprint(i)))
+	}
+	sizeBefore := db.Size()
+	if err := db.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	sizeAfter := db.Size()
+	compacted := readKeys(db, append(testKeys, "counter")...)
+	db.Put([]byte("after"), []byte("compaction"))
+	db.Close()
+
+	// leftover file of an interrupted compaction is ignored
+	os.WriteFile(filepath.Join(dir, compactFileName), []byte("partial"), 0644)
+	db, _ = OpenFileDB(dir)
+	defer db.Close()
+	_, statErr := os.Stat(filepath.Join(dir, compactFileName))
+
+	test := genericTest{
+		s: "compact and reopen",
+		exp: []interface{}{
+			true,
+			append(testValues, "99"),
+			append(testValues, "99", "compaction"),
+			true,
+		},
+		act: []interface{}{
+			sizeAfter < sizeBefore/10,
+			compacted,
+			readKeys(db, append(testKeys, "counter", "after")...),
+			errors.Is(statErr, os.ErrNotExist),
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+func Test_MemoryDB_Batch(t *testing.T) {
+	db := NewMemoryDB()
+	writeTestKeys(t, db)
+	test := genericTest{
+		s:   "batch writes",
+		exp: []interface{}{testValues, 3},
+		act: []interface{}{readKeys(db, testKeys...), db.Len()},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"errors"
+	"sync"
+)
+
+var ErrMemoryDBClosed = errors.New("memory database closed")
+
+// MemoryDB is a key/value store which keeps everything in memory
+type MemoryDB struct {
+	lock sync.RWMutex
+	db   map[string][]byte
+}
+
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{
+		db: make(map[string][]byte),
+	}
+}
+
+func (m *MemoryDB) Get(key []byte) ([]byte, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if m.db == nil {
+		return nil, ErrMemoryDBClosed
+	}
+	value, ok := m.db[string(key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte{}, value...), nil
+}
+
+func (m *MemoryDB) Has(key []byte) (bool, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if m.db == nil {
+		return false, ErrMemoryDBClosed
+	}
+	_, ok := m.db[string(key)]
+	return ok, nil
+}
+
+// Stores a copy of the value, hence the caller can modify it afterwards
+func (m *MemoryDB) Put(key []byte, value []byte) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.db == nil {
+		return ErrMemoryDBClosed
+	}
+	m.db[string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+func (m *MemoryDB) Delete(key []byte) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.db == nil {
+		return ErrMemoryDBClosed
+	}
+	delete(m.db, string(key))
+	return nil
+}
+
+func (m *MemoryDB) NewBatch() Batch {
+	return &memoryBatch{db: m}
+}
+
+// memoryBatch writes all of its writes into
+// the memory database under a single lock
+type memoryBatch struct {
+	batch
+	db *MemoryDB
+}
+
+func (b *memoryBatch) Write() error {
+	b.db.lock.Lock()
+	defer b.db.lock.Unlock()
+
+	if b.db.db == nil {
+		return ErrMemoryDBClosed
+	}
+	for _, op := range b.ops {
+		if op.delete {
+			delete(b.db.db, string(op.key))
+		} else {
+			b.db.db[string(op.key)] = op.value
+		}
+	}
+	return nil
+}
+
+// Returns the number of keys in the store
+func (m *MemoryDB) Len() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return len(m.db)
+}
+
+func (m *MemoryDB) Close() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.db = nil
+	return nil
+}
@@ -0,0 +1,117 @@
+package space_evm
+
+import (
+	"space/rlp"
+	"space/trie"
+
+	"github.com/holiman/uint256"
+)
+
+// EmptyUncleHash is the keccak256 of the encoded empty list,
+// which is the uncle hash of the blocks without uncles
+var EmptyUncleHash = BytesToHash(keccak256(rlp.EmptyList))
+
+// Header is the header of a block. Fields which are added by the later
+// Ethereum forks are optional, and they are left out of the encoding
+// if they are nil, so that the hashes of the blocks of the earlier
+// forks stay the same.
+type Header struct {
+	ParentHash       Hash
+	UncleHash        Hash
+	Coinbase         Address
+	Root             Hash
+	TxHash           Hash
+	ReceiptHash      Hash
+	Bloom            Bloom
+	Difficulty       *uint256.Int
+	Number           uint64
+	GasLimit         uint64
+	GasUsed          uint64
+	Time             uint64
+	Extra            []byte
+	MixDigest        Hash
+	Nonce            [8]byte
+	BaseFee          *uint256.Int `rlp:"optional"`
+	WithdrawalsHash  *Hash        `rlp:"optional"`
+	BlobGasUsed      *uint64      `rlp:"optional"`
+	ExcessBlobGas    *uint64      `rlp:"optional"`
+	ParentBeaconRoot *Hash        `rlp:"optional"`
+}
+
+// Returns the hash of the block, which is the
+// keccak256 of the encoding of its header
+func (h *Header) Hash() Hash {
+	// header only has the types which can be encoded
+	enc, _ := rlp.Encode(h)
+	return BytesToHash(keccak256(enc))
+}
+
+// Body is the part of a block which the header commits to with its
+// roots, and it is stored separately from the header of the block
+type Body struct {
+	Transactions []*Transaction
+}
+
+// Block is the header of a block with its transactions. Blocks are
+// encoded as the list of the header and the list of the transactions.
+type Block struct {
+	Header       *Header
+	Transactions []*Transaction
+}
+
+// Returns the block with a copy of the header, whose transactions
+// root, receipts root and logs bloom are set for the transactions
+// and their receipts. Uncle hash is set to the hash of no uncles.
+func NewBlock(header *Header, txs []*Transaction, receipts []*Receipt) *Block {
+	h := *header
+	h.UncleHash = EmptyUncleHash
+	h.TxHash = TxsRoot(txs)
+	h.ReceiptHash = ReceiptsRoot(receipts)
+	h.Bloom = CreateBloom(receipts)
+	return &Block{Header: &h, Transactions: txs}
+}
+
+// Returns the block of the header and the body
+func NewBlockWithBody(header *Header, body *Body) *Block {
+	return &Block{Header: header, Transactions: body.Transactions}
+}
+
+// Returns the hash of the block, which is the hash of its header
+func (b *Block) Hash() Hash {
+	return b.Header.Hash()
+}
+
+func (b *Block) Number() uint64 {
+	return b.Header.Number
+}
+
+func (b *Block) Body() *Body {
+	return &Body{Transactions: b.Transactions}
+}
+
+// Returns the root of the trie from the encoded indexes of the
+// items to the encodings of the items, which are the transactions
+// or the receipts of a block
+func deriveRoot(n int, encode func(i int) ([]byte, error)) Hash {
+	items, _ := trie.New([32]byte{}, nil)
+	for i := 0; i < n; i++ {
+		// items only have the types which can be encoded
+		enc, _ := encode(i)
+		items.Update(rlp.EncodeUint64(uint64(i)), enc)
+	}
+	return items.Hash()
+}
+
+// Returns the transactions root of the header
+func TxsRoot(txs []*Transaction) Hash {
+	return deriveRoot(len(txs), func(i int) ([]byte, error) {
+		return txs[i].MarshalBinary()
+	})
+}
+
+// Returns the receipts root of the header
+func ReceiptsRoot(receipts []*Receipt) Hash {
+	return deriveRoot(len(receipts), func(i int) ([]byte, error) {
+		return receipts[i].MarshalBinary()
+	})
+}
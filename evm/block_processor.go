@@ -0,0 +1,205 @@
+package space_evm
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/holiman/uint256"
+)
+
+// Blob gas parameters of the blocks (see EIP-4844)
+const (
+	maxBlobGasPerBlock        uint64 = 6 * blobGasPerBlob
+	targetBlobGasPerBlock     uint64 = 3 * blobGasPerBlob
+	blobBaseFeeUpdateFraction        = 3338477
+	minBlobBaseFee                   = 1
+)
+
+// Base fee parameters of the blocks (see EIP-1559)
+const (
+	// InitialBaseFee is the base fee of the first block with a base fee
+	InitialBaseFee           = 1000000000
+	baseFeeChangeDenominator = 8
+	elasticityMultiplier     = 2
+)
+
+// BlockResult is the result of processing the transactions of a block,
+// which holds the fields of the header derived from the transactions
+type BlockResult struct {
+	Receipts    []*Receipt
+	GasUsed     uint64
+	BlobGasUsed uint64
+	ReceiptHash Hash
+	Bloom       Bloom
+	Root        Hash
+}
+
+// Applies the transactions of the block to the state in their order,
+// and returns their receipts with the receipts root, the logs bloom
+// and the state root after the block. Coinbase and the gas limit
+// of the block are taken from its header. Transactions can use at
+// most the gas left by the earlier transactions of the block. If any
+// transaction can not be applied, the block is invalid, and the state
+// is left as it was before the block.
+func (evm *EVM) ProcessBlock(state *StateDB, block *Block) (*BlockResult, error) {
+	header := block.Header
+	defer evm.enterBlock(state, header)()
+
+	snapshot := state.snapshot()
+	result := &BlockResult{Receipts: make([]*Receipt, 0, len(block.Transactions))}
+	for i, tx := range block.Transactions {
+		receipt, err := evm.processTx(tx, header.GasLimit-result.GasUsed, result.BlobGasUsed)
+		if err != nil {
+			state.revertToSnapshot(snapshot)
+			return nil, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+		}
+		result.GasUsed += receipt.GasUsed
+		result.BlobGasUsed += receipt.BlobGasUsed
+		result.Receipts = append(result.Receipts, receipt)
+	}
+	deriveReceiptFields(result.Receipts, block)
+
+	result.ReceiptHash = ReceiptsRoot(result.Receipts)
+	result.Bloom = CreateBloom(result.Receipts)
+	result.Root = state.IntermediateRoot()
+	return result, nil
+}
+
+// Sets the state and the block context of the header for the
+// interpreter, and returns the function which restores them
+func (evm *EVM) enterBlock(state *StateDB, header *Header) func() {
+	in := evm.interpreter
+	prevState, prevBlockCtx := in.state, in.blockCtx
+	in.state = state
+	in.blockCtx = NewBlockContext(header)
+	return func() { in.state, in.blockCtx = prevState, prevBlockCtx }
+}
+
+// Builds the block of the header with the transactions of the selector
+// on top of the state. Transactions are applied in the order of the
+// selector, and the ones which can not be applied, or do not fit in the
+// gas left in the block, are skipped with the rest of the transactions
+// of their senders. Returned block has the roots, bloom and gas used of
+// the result, which is the result of processing the block, and its blob
+// gas used if the header has the blob fields.
+func (evm *EVM) BuildBlock(state *StateDB, header *Header, selector *TxSelector) (*Block, *BlockResult) {
+	defer evm.enterBlock(state, header)()
+
+	var txs []*Transaction
+	result := &BlockResult{}
+	for tx := selector.Peek(); tx != nil; tx = selector.Peek() {
+		receipt, err := evm.processTx(tx, header.GasLimit-result.GasUsed, result.BlobGasUsed)
+		if err != nil {
+			selector.Pop()
+			continue
+		}
+		selector.Shift()
+		txs = append(txs, tx)
+		result.GasUsed += receipt.GasUsed
+		result.BlobGasUsed += receipt.BlobGasUsed
+		result.Receipts = append(result.Receipts, receipt)
+	}
+
+	h := *header
+	h.GasUsed, h.Root = result.GasUsed, state.IntermediateRoot()
+	if h.ExcessBlobGas != nil {
+		blobGasUsed := result.BlobGasUsed
+		h.BlobGasUsed = &blobGasUsed
+	}
+	block := NewBlock(&h, txs, result.Receipts)
+	deriveReceiptFields(result.Receipts, block)
+	result.ReceiptHash, result.Bloom, result.Root = block.Header.ReceiptHash, block.Header.Bloom, block.Header.Root
+	return block, result
+}
+
+// Applies the transaction if it fits in the gas and the blob gas
+// left in the block
+func (evm *EVM) processTx(tx *Transaction, gasLeft uint64, blobGasUsed uint64) (*Receipt, error) {
+	if tx.Gas() > gasLeft {
+		return nil, fmt.Errorf("%w: tx gas %d, block gas left %d", ErrGasLimitReached, tx.Gas(), gasLeft)
+	}
+	if blobGas := uint64(len(tx.BlobHashes())) * blobGasPerBlob; blobGas > maxBlobGasPerBlock-blobGasUsed {
+		return nil, fmt.Errorf("%w: tx blob gas %d, block blob gas left %d", ErrBlobGasLimitReached, blobGas, maxBlobGasPerBlock-blobGasUsed)
+	}
+	return evm.ApplyTransaction(tx)
+}
+
+// Returns the base fee of the child of the parent block. Base fee is
+// raised if the parent used more gas than its target, which is half of
+// its gas limit, and it is lowered if it used less, by at most 1/8 of
+// the parent base fee. Child of a block without a base fee has the
+// initial base fee.
+func CalcBaseFee(parent *Header) *uint256.Int {
+	if parent.BaseFee == nil {
+		return uint256.NewInt(InitialBaseFee)
+	}
+	gasTarget := parent.GasLimit / elasticityMultiplier
+	if parent.GasUsed == gasTarget || gasTarget == 0 {
+		return new(uint256.Int).Set(parent.BaseFee)
+	}
+
+	var gasDelta uint64
+	if parent.GasUsed > gasTarget {
+		gasDelta = parent.GasUsed - gasTarget
+	} else {
+		gasDelta = gasTarget - parent.GasUsed
+	}
+	// parent base fee * gas delta / gas target / denominator
+	delta := new(uint256.Int).Mul(parent.BaseFee, uint256.NewInt(gasDelta))
+	delta.Div(delta, uint256.NewInt(gasTarget))
+	delta.Div(delta, uint256.NewInt(baseFeeChangeDenominator))
+
+	if parent.GasUsed > gasTarget {
+		// base fee is raised by at least 1
+		if delta.IsZero() {
+			delta.SetOne()
+		}
+		return delta.Add(parent.BaseFee, delta)
+	}
+	if delta.Gt(parent.BaseFee) {
+		return new(uint256.Int)
+	}
+	return delta.Sub(parent.BaseFee, delta)
+}
+
+// Returns the excess blob gas of the child of the parent block, which
+// is the blob gas used above the target accumulated over the blocks.
+// Blob fields of the parent are treated as zero if it has none, which
+// is the case for the last block before the blob forks.
+func CalcExcessBlobGas(parent *Header) uint64 {
+	var excessBlobGas, blobGasUsed uint64
+	if parent.ExcessBlobGas != nil {
+		excessBlobGas = *parent.ExcessBlobGas
+	}
+	if parent.BlobGasUsed != nil {
+		blobGasUsed = *parent.BlobGasUsed
+	}
+	if excessBlobGas+blobGasUsed < targetBlobGasPerBlock {
+		return 0
+	}
+	return excessBlobGas + blobGasUsed - targetBlobGasPerBlock
+}
+
+// Returns the blob base fee of the block with the excess blob gas,
+// which is approximately minBlobBaseFee * e^(excess / fraction)
+func CalcBlobBaseFee(excessBlobGas uint64) *uint256.Int {
+	fee, _ := uint256.FromBig(fakeExponential(
+		big.NewInt(minBlobBaseFee),
+		new(big.Int).SetUint64(excessBlobGas),
+		big.NewInt(blobBaseFeeUpdateFraction),
+	))
+	return fee
+}
+
+// Approximates factor * e^(numerator / denominator) with Taylor expansion
+func fakeExponential(factor, numerator, denominator *big.Int) *big.Int {
+	output := new(big.Int)
+	accum := new(big.Int).Mul(factor, denominator)
+	for i := int64(1); accum.Sign() > 0; i++ {
+		output.Add(output, accum)
+		accum.Mul(accum, numerator)
+		accum.Div(accum, denominator)
+		accum.Div(accum, big.NewInt(i))
+	}
+	return output.Div(output, denominator)
+}
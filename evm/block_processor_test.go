@@ -0,0 +1,183 @@
+package space_evm
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"space/rlp"
+	"space/trie"
+)
+
+// Returns the block of the signed transactions of the EIP-155
+// example sender with the given gas limit and gas of each transaction
+func genTestBlock(t *testing.T, gasLimit uint64, txs ...TxData) *Block {
+	block := &Block{Header: &Header{Number: 1, Coinbase: testCoinbase, GasLimit: gasLimit}}
+	for _, tx := range txs {
+		signed, err := SignTx(NewTx(tx), u256(DefaultChainID), eip155Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block.Transactions = append(block.Transactions, signed)
+	}
+	return block
+}
+
+func Test_BlockProcessor_ProcessBlock(t *testing.T) {
+	evm := genTransitionEVM(Moon)
+	state := evm.StateDB().Copy()
+	block := genTestBlock(t, 100000,
+		&LegacyTx{Nonce: 0, GasPrice: u256(1), Gas: 21000, To: testTo(testRecipient), Value: u256(1)},
+		&LegacyTx{Nonce: 1, GasPrice: u256(1), Gas: 30000, To: testTo(testLogger), Value: u256(0)},
+		&LegacyTx{Nonce: 2, GasPrice: u256(1), Gas: 30000, To: testTo(testFailer), Value: u256(0)},
+	)
+	result, err := evm.ProcessBlock(state, block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// same block is processed into the same result on another copy
+	again, _ := evm.ProcessBlock(evm.StateDB().Copy(), block)
+
+	// receipts root is built from the manually encoded receipts
+	receipts, _ := trie.New([32]byte{}, nil)
+	encodeReceipt := func(status, cumulativeGas uint64, bloom Bloom, logs ...[]byte) []byte {
+		return rlp.EncodeList(rlp.EncodeUint64(status), rlp.EncodeUint64(cumulativeGas), rlp.EncodeBytes(bloom[:]), rlp.EncodeList(logs...))
+	}
+	log := rlp.EncodeList(rlp.EncodeBytes(testLogger[:]), rlp.EncodeList(rlp.EncodeBytes(BytesToHash([]byte{7}).Bytes())), rlp.EncodeBytes(BytesToHash([]byte{0x2a}).Bytes()))
+	receipts.Update(rlp.EncodeUint64(0), encodeReceipt(1, 21000, Bloom{}))
+	receipts.Update(rlp.EncodeUint64(1), encodeReceipt(1, 43027, result.Receipts[1].Bloom, log))
+	receipts.Update(rlp.EncodeUint64(2), encodeReceipt(0, 73027, Bloom{}))
+
+	test := genericTest{
+		s: "receipts, roots and bloom of the block",
+		exp: []interface{}{
+			[]uint64{21000, 43027, 73027}, []uint64{1, 1, 0}, uint64(73027),
+			Hash(receipts.Hash()), true, false, state.IntermediateRoot(), uint64(3),
+			[]interface{}{uint64(1), block.Hash(), uint(1), uint(0)},
+			[]interface{}{again.Root, again.ReceiptHash, again.Bloom},
+			evm.StateDB().GetNonce(eip155Sender),
+		},
+		act: []interface{}{
+			[]uint64{result.Receipts[0].CumulativeGasUsed, result.Receipts[1].CumulativeGasUsed, result.Receipts[2].CumulativeGasUsed},
+			[]uint64{result.Receipts[0].Status, result.Receipts[1].Status, result.Receipts[2].Status}, result.GasUsed,
+			result.ReceiptHash, result.Bloom.Test(testLogger[:]), result.Bloom.Test(testFailer[:]), result.Root, state.GetNonce(eip155Sender),
+			[]interface{}{result.Receipts[1].Logs[0].BlockNumber, result.Receipts[1].Logs[0].BlockHash, result.Receipts[1].Logs[0].TxIndex, result.Receipts[1].Logs[0].Index},
+			[]interface{}{result.Root, result.ReceiptHash, result.Bloom},
+			// state of the EVM is not changed
+			uint64(0),
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+// in is the block, and exp is the error of processing it
+var invalidBlockTests = []genericTest{
+	{
+		s: "block gas limit",
+		in: func(t *testing.T) *Block {
+			return genTestBlock(t, 50000,
+				&LegacyTx{Nonce: 0, GasPrice: u256(1), Gas: 30000, To: testTo(testRecipient), Value: u256(1)},
+				&LegacyTx{Nonce: 1, GasPrice: u256(1), Gas: 30000, To: testTo(testRecipient), Value: u256(1)},
+			)
+		},
+		exp: ErrGasLimitReached,
+	},
+	{
+		s: "invalid nonce",
+		in: func(t *testing.T) *Block {
+			return genTestBlock(t, 50000,
+				&LegacyTx{Nonce: 0, GasPrice: u256(1), Gas: 21000, To: testTo(testRecipient), Value: u256(1)},
+				&LegacyTx{Nonce: 0, GasPrice: u256(1), Gas: 21000, To: testTo(testRecipient), Value: u256(1)},
+			)
+		},
+		exp: ErrNonceTooLow,
+	},
+	{
+		s: "fee cap below the base fee of the header",
+		in: func(t *testing.T) *Block {
+			block := genTestBlock(t, 50000,
+				&LegacyTx{Nonce: 0, GasPrice: u256(1), Gas: 21000, To: testTo(testRecipient), Value: u256(1)},
+			)
+			block.Header.BaseFee = u256(2)
+			return block
+		},
+		exp: ErrFeeCapTooLow,
+	},
+}
+
+func Test_BlockProcessor_InvalidBlock(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range invalidBlockTests {
+		evm := genTransitionEVM(Moon)
+		state := evm.StateDB()
+		root := state.IntermediateRoot()
+		_, err := evm.ProcessBlock(state, test.in.(func(*testing.T) *Block)(t))
+		// state is reverted to the state before the block
+		test.exp = []interface{}{test.exp, root}
+		test.act = []interface{}{err, state.IntermediateRoot()}
+		if errors.Is(err, test.exp.([]interface{})[0].(error)) {
+			test.act.([]interface{})[0] = test.exp.([]interface{})[0]
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the [factor, numerator, denominator]
+var fakeExponentialTests = []genericTest{
+	{s: "zero numerator", in: []int64{1, 0, 1}, exp: int64(1)},
+	{s: "zero numerator with factor", in: []int64{38493, 0, 1000}, exp: int64(38493)},
+	{s: "zero factor", in: []int64{0, 1234, 2345}, exp: int64(0)},
+	{s: "e^2", in: []int64{1, 2, 1}, exp: int64(6)},
+	{s: "e^(4/2)", in: []int64{1, 4, 2}, exp: int64(6)},
+	{s: "e^3", in: []int64{1, 3, 1}, exp: int64(16)},
+	{s: "e^(6/2)", in: []int64{1, 6, 2}, exp: int64(18)},
+	{s: "e^(8/2)", in: []int64{1, 8, 2}, exp: int64(50)},
+}
+
+func Test_BlockProcessor_FakeExponential(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range fakeExponentialTests {
+		testIn := test.in.([]int64)
+		test.act = fakeExponential(big.NewInt(testIn[0]), big.NewInt(testIn[1]), big.NewInt(testIn[2])).Int64()
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the parent header, and exp is the base fee of its child
+var calcBaseFeeTests = []genericTest{
+	{s: "parent without base fee", in: &Header{GasLimit: 20000000, GasUsed: 10000000}, exp: u256(InitialBaseFee)},
+	{s: "gas target", in: &Header{GasLimit: 20000000, GasUsed: 10000000, BaseFee: u256(1000000000)}, exp: u256(1000000000)},
+	{s: "full block", in: &Header{GasLimit: 20000000, GasUsed: 20000000, BaseFee: u256(1000000000)}, exp: u256(1125000000)},
+	{s: "empty block", in: &Header{GasLimit: 20000000, GasUsed: 0, BaseFee: u256(1000000000)}, exp: u256(875000000)},
+	{s: "below the target", in: &Header{GasLimit: 20000000, GasUsed: 9000000, BaseFee: u256(1000000000)}, exp: u256(987500000)},
+	{s: "raised by at least 1", in: &Header{GasLimit: 20000000, GasUsed: 10000001, BaseFee: u256(7)}, exp: u256(8)},
+	{s: "lowered by rounded down delta", in: &Header{GasLimit: 20000000, GasUsed: 9999999, BaseFee: u256(7)}, exp: u256(7)},
+}
+
+func Test_BlockProcessor_CalcBaseFee(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range calcBaseFeeTests {
+		test.act = CalcBaseFee(test.in.(*Header))
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,65 @@
+package space_evm
+
+import (
+	"fmt"
+	"testing"
+
+	"space/rlp"
+)
+
+// in is the header, and exp is its hash
+var headerHashTests = []genericTest{
+	{
+		s: "mainnet genesis",
+		in: &Header{
+			UncleHash:   EmptyUncleHash,
+			Root:        BytesToHash(hexToBytes("d7f8974fb5ac78d9ac099b9ad5018bedc2ce0a72dad1827a1709da30580f0544")),
+			TxHash:      EmptyRootHash,
+			ReceiptHash: EmptyRootHash,
+			Difficulty:  u256(0x400000000),
+			GasLimit:    5000,
+			Extra:       hexToBytes("11bbe8db4e347b4e8c937c1c8370e4b5ed33adb3db69cbdb7a38e1e50b1b82fa"),
+			Nonce:       [8]byte{0, 0, 0, 0, 0, 0, 0, 0x42},
+		},
+		exp: BytesToHash(hexToBytes("d4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3")),
+	},
+}
+
+func Test_Block_HeaderHash(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range headerHashTests {
+		test.act = test.in.(*Header).Hash()
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+func Test_Block_Encoding(t *testing.T) {
+	tx := NewTx(eip155Tx)
+	block := NewBlock(&Header{Number: 1, GasLimit: 5000, BaseFee: u256(7)}, []*Transaction{tx}, nil)
+	enc, err := rlp.Encode(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(Block)
+	err = rlp.Decode(enc, decoded)
+
+	test := genericTest{
+		s: "block is decoded from its encoding",
+		exp: []interface{}{
+			nil, block.Hash(), tx.Hash(), TxsRoot([]*Transaction{tx}), EmptyRootHash, EmptyUncleHash,
+		},
+		act: []interface{}{
+			err, decoded.Hash(), decoded.Transactions[0].Hash(), decoded.Header.TxHash, decoded.Header.ReceiptHash, decoded.Header.UncleHash,
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,102 @@
+package space_evm
+
+import (
+	"fmt"
+	"math"
+)
+
+// Bounds of the gas limits of the blocks. Gas limit of a block can
+// differ from the gas limit of its parent by less than 1/1024 of it.
+const (
+	minGasLimit          uint64 = 5000
+	maxGasLimit          uint64 = math.MaxInt64
+	gasLimitBoundDivisor uint64 = 1024
+)
+
+// Checks whether the header can follow the parent header, which is
+// the case if it is the child of the parent with a later timestamp,
+// its gas limit is within the bounds of the gas limit of the parent,
+// and its base fee and excess blob gas are calculated from the parent
+func ValidateHeader(parent, header *Header) error {
+	if header.ParentHash != parent.Hash() {
+		return fmt.Errorf("%w: have %v, want %v", ErrInvalidParentHash, header.ParentHash.Hex(), parent.Hash().Hex())
+	}
+	if header.Number != parent.Number+1 {
+		return fmt.Errorf("%w: have %d, want %d", ErrInvalidNumber, header.Number, parent.Number+1)
+	}
+	if header.Time <= parent.Time {
+		return fmt.Errorf("%w: time %d, parent time %d", ErrInvalidTimestamp, header.Time, parent.Time)
+	}
+	if header.GasLimit < minGasLimit || header.GasLimit > maxGasLimit {
+		return fmt.Errorf("%w: have %d, bounds [%d, %d]", ErrInvalidGasLimit, header.GasLimit, minGasLimit, maxGasLimit)
+	}
+	diff := header.GasLimit - parent.GasLimit
+	if header.GasLimit < parent.GasLimit {
+		diff = parent.GasLimit - header.GasLimit
+	}
+	if limit := parent.GasLimit / gasLimitBoundDivisor; diff >= limit {
+		return fmt.Errorf("%w: have %d, parent %d, max change %d", ErrInvalidGasLimit, header.GasLimit, parent.GasLimit, limit-1)
+	}
+	if header.GasUsed > header.GasLimit {
+		return fmt.Errorf("%w: have %d, gas limit %d", ErrInvalidGasUsed, header.GasUsed, header.GasLimit)
+	}
+	if baseFee := CalcBaseFee(parent); header.BaseFee == nil || !header.BaseFee.Eq(baseFee) {
+		return fmt.Errorf("%w: have %v, want %v", ErrInvalidBaseFee, header.BaseFee, baseFee)
+	}
+	return validateBlobFields(parent, header)
+}
+
+// Checks the blob fields of the header (see EIP-4844). Headers before
+// the blob forks have none of them, and the children of the headers
+// with the blob fields must have them as well.
+func validateBlobFields(parent, header *Header) error {
+	if header.ExcessBlobGas == nil && header.BlobGasUsed == nil {
+		if parent.ExcessBlobGas != nil {
+			return fmt.Errorf("%w: missing blob fields, parent has them", ErrInvalidExcessBlobGas)
+		}
+		return nil
+	}
+	if header.ExcessBlobGas == nil {
+		return fmt.Errorf("%w: missing excess blob gas", ErrInvalidExcessBlobGas)
+	}
+	if header.BlobGasUsed == nil {
+		return fmt.Errorf("%w: missing blob gas used", ErrInvalidBlobGasUsed)
+	}
+	if used := *header.BlobGasUsed; used > maxBlobGasPerBlock || used%blobGasPerBlob != 0 {
+		return fmt.Errorf("%w: have %d, max %d in multiples of %d", ErrInvalidBlobGasUsed, used, maxBlobGasPerBlock, blobGasPerBlob)
+	}
+	if excessBlobGas := CalcExcessBlobGas(parent); *header.ExcessBlobGas != excessBlobGas {
+		return fmt.Errorf("%w: have %d, want %d", ErrInvalidExcessBlobGas, *header.ExcessBlobGas, excessBlobGas)
+	}
+	return nil
+}
+
+// Checks whether the header of the block commits to its transactions
+// and their receipts, which are the result of processing the block
+func ValidateBody(block *Block, receipts []*Receipt) error {
+	header := block.Header
+	if root := TxsRoot(block.Transactions); header.TxHash != root {
+		return fmt.Errorf("%w: have %v, want %v", ErrInvalidTxsRoot, header.TxHash.Hex(), root.Hex())
+	}
+	if len(receipts) != len(block.Transactions) {
+		return fmt.Errorf("%w: %d receipts of %d transactions", ErrInvalidReceiptsRoot, len(receipts), len(block.Transactions))
+	}
+	if root := ReceiptsRoot(receipts); header.ReceiptHash != root {
+		return fmt.Errorf("%w: have %v, want %v", ErrInvalidReceiptsRoot, header.ReceiptHash.Hex(), root.Hex())
+	}
+	if bloom := CreateBloom(receipts); header.Bloom != bloom {
+		return ErrInvalidBloom
+	}
+	gasUsed, blobGasUsed := uint64(0), uint64(0)
+	for _, receipt := range receipts {
+		gasUsed += receipt.GasUsed
+		blobGasUsed += receipt.BlobGasUsed
+	}
+	if header.GasUsed != gasUsed {
+		return fmt.Errorf("%w: have %d, receipts used %d", ErrInvalidGasUsed, header.GasUsed, gasUsed)
+	}
+	if header.BlobGasUsed != nil && *header.BlobGasUsed != blobGasUsed || header.BlobGasUsed == nil && blobGasUsed > 0 {
+		return fmt.Errorf("%w: receipts used %d", ErrInvalidBlobGasUsed, blobGasUsed)
+	}
+	return nil
+}
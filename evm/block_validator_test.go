@@ -0,0 +1,136 @@
+package space_evm
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+var testParentHeader = &Header{Number: 7, GasLimit: 30000000, GasUsed: 15000000, Time: 100, BaseFee: u256(InitialBaseFee)}
+
+// Returns the valid child of the test parent header
+func genChildHeader() *Header {
+	return &Header{
+		ParentHash: testParentHeader.Hash(),
+		Number:     8,
+		GasLimit:   30000000,
+		Time:       101,
+		BaseFee:    u256(InitialBaseFee),
+	}
+}
+
+// in modifies the valid child of the test parent
+// header, and exp is the error of validating it
+var validateHeaderTests = []genericTest{
+	{s: "valid header", in: func(h *Header) {}, exp: nil},
+	{s: "highest gas limit", in: func(h *Header) { h.GasLimit = 30000000 + 29295 }, exp: nil},
+	{s: "lowest gas limit", in: func(h *Header) { h.GasLimit = 30000000 - 29295 }, exp: nil},
+	{s: "unknown parent", in: func(h *Header) { h.ParentHash = Hash{} }, exp: ErrInvalidParentHash},
+	{s: "skipped number", in: func(h *Header) { h.Number = 9 }, exp: ErrInvalidNumber},
+	{s: "same timestamp", in: func(h *Header) { h.Time = 100 }, exp: ErrInvalidTimestamp},
+	{s: "gas limit raised too much", in: func(h *Header) { h.GasLimit = 30000000 + 29296 }, exp: ErrInvalidGasLimit},
+	{s: "gas limit lowered too much", in: func(h *Header) { h.GasLimit = 30000000 - 29296 }, exp: ErrInvalidGasLimit},
+	{s: "gas used above gas limit", in: func(h *Header) { h.GasUsed = 30000001 }, exp: ErrInvalidGasUsed},
+	{s: "wrong base fee", in: func(h *Header) { h.BaseFee = u256(InitialBaseFee + 1) }, exp: ErrInvalidBaseFee},
+	{s: "missing base fee", in: func(h *Header) { h.BaseFee = nil }, exp: ErrInvalidBaseFee},
+	{s: "first header with the blob fields", in: func(h *Header) { setBlobFields(h, 0, 2*blobGasPerBlob) }, exp: nil},
+	{s: "most blob gas used", in: func(h *Header) { setBlobFields(h, 0, maxBlobGasPerBlock) }, exp: nil},
+	{s: "blob gas used above the max", in: func(h *Header) { setBlobFields(h, 0, maxBlobGasPerBlock+blobGasPerBlob) }, exp: ErrInvalidBlobGasUsed},
+	{s: "blob gas used of a partial blob", in: func(h *Header) { setBlobFields(h, 0, blobGasPerBlob+1) }, exp: ErrInvalidBlobGasUsed},
+	{s: "wrong excess blob gas", in: func(h *Header) { setBlobFields(h, blobGasPerBlob, 0) }, exp: ErrInvalidExcessBlobGas},
+	{s: "missing blob gas used", in: func(h *Header) { setBlobFields(h, 0, 0); h.BlobGasUsed = nil }, exp: ErrInvalidBlobGasUsed},
+	{s: "missing excess blob gas", in: func(h *Header) { setBlobFields(h, 0, 0); h.ExcessBlobGas = nil }, exp: ErrInvalidExcessBlobGas},
+}
+
+// Sets the blob fields of the header with the fields which precede them
+func setBlobFields(h *Header, excessBlobGas, blobGasUsed uint64) {
+	withdrawalsHash, parentBeaconRoot := EmptyRootHash, Hash{}
+	h.WithdrawalsHash, h.ParentBeaconRoot = &withdrawalsHash, &parentBeaconRoot
+	h.ExcessBlobGas, h.BlobGasUsed = &excessBlobGas, &blobGasUsed
+}
+
+func Test_BlockValidator_ValidateHeader(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range validateHeaderTests {
+		header := genChildHeader()
+		test.in.(func(*Header))(header)
+		err := ValidateHeader(testParentHeader, header)
+		test.act = err
+		if test.exp != nil && errors.Is(err, test.exp.(error)) {
+			test.act = test.exp
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the excess blob gas and the blob gas used of the parent header,
+// and exp is the excess blob gas of its child or the error without them
+var excessBlobGasTests = []genericTest{
+	{s: "below the target", in: []uint64{0, 2 * blobGasPerBlob}, exp: uint64(0)},
+	{s: "at the target", in: []uint64{blobGasPerBlob, 3 * blobGasPerBlob}, exp: blobGasPerBlob},
+	{s: "above the target", in: []uint64{blobGasPerBlob, 6 * blobGasPerBlob}, exp: 4 * blobGasPerBlob},
+	{s: "excess used up", in: []uint64{blobGasPerBlob, 0}, exp: uint64(0)},
+}
+
+func Test_BlockValidator_ExcessBlobGas(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range excessBlobGasTests {
+		in := test.in.([]uint64)
+		parent := genChildHeader()
+		setBlobFields(parent, in[0], in[1])
+		child := &Header{ParentHash: parent.Hash(), Number: 9, GasLimit: 30000000, Time: 102, BaseFee: CalcBaseFee(parent)}
+		// child of a header with the blob fields must have them
+		errMissing := ValidateHeader(parent, child)
+		setBlobFields(child, CalcExcessBlobGas(parent), 0)
+		test.act = []interface{}{*child.ExcessBlobGas, ValidateHeader(parent, child), errors.Is(errMissing, ErrInvalidExcessBlobGas)}
+		test.exp = []interface{}{test.exp, nil, true}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in modifies the header of the valid block of the log call,
+// and exp is the error of validating it with its receipts
+var validateBodyTests = []genericTest{
+	{s: "valid body", in: func(h *Header) {}, exp: nil},
+	{s: "wrong transactions root", in: func(h *Header) { h.TxHash = EmptyRootHash }, exp: ErrInvalidTxsRoot},
+	{s: "wrong receipts root", in: func(h *Header) { h.ReceiptHash = EmptyRootHash }, exp: ErrInvalidReceiptsRoot},
+	{s: "wrong bloom", in: func(h *Header) { h.Bloom = Bloom{} }, exp: ErrInvalidBloom},
+	{s: "wrong gas used", in: func(h *Header) { h.GasUsed = 21000 }, exp: ErrInvalidGasUsed},
+	{s: "blob gas used without blob transactions", in: func(h *Header) { setBlobFields(h, 0, blobGasPerBlob) }, exp: ErrInvalidBlobGasUsed},
+}
+
+func Test_BlockValidator_ValidateBody(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range validateBodyTests {
+		evm := genTransitionEVM(Moon)
+		block := genTestBlock(t, 100000, &LegacyTx{GasPrice: u256(1), Gas: 30000, To: testTo(testLogger), Value: u256(0)})
+		result, err := evm.ProcessBlock(evm.StateDB(), block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block.Header.GasUsed = result.GasUsed
+		block = NewBlock(block.Header, block.Transactions, result.Receipts)
+		test.in.(func(*Header))(block.Header)
+		err = ValidateBody(block, result.Receipts)
+		test.act = err
+		if test.exp != nil && errors.Is(err, test.exp.(error)) {
+			test.act = test.exp
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,79 @@
+package space_evm
+
+// BloomLength is the length of the bloom filters in bytes
+const BloomLength = 256
+
+// Bloom is the 2048 bits bloom filter of the logs, which is
+// set for the addresses and the topics of the logs. Each value
+// sets 3 of the bits, which are chosen by the first 6 bytes of
+// its keccak256, taking 11 bits from each pair of bytes.
+type Bloom [BloomLength]byte
+
+// Returns the bloom filter of the logs
+func LogsBloom(logs []*Log) Bloom {
+	var bloom Bloom
+	for _, log := range logs {
+		bloom.Add(log.Address[:])
+		for _, topic := range log.Topics {
+			bloom.Add(topic[:])
+		}
+	}
+	return bloom
+}
+
+// Returns the bloom filter of all the logs of the receipts
+func CreateBloom(receipts []*Receipt) Bloom {
+	var bloom Bloom
+	for _, receipt := range receipts {
+		bloom.Or(receipt.Bloom)
+	}
+	return bloom
+}
+
+// Sets the bits of the value
+func (b *Bloom) Add(value []byte) {
+	hash := keccak256(value)
+	for i := 0; i < 6; i += 2 {
+		index, bit := bloomBit(hash[i], hash[i+1])
+		b[index] |= bit
+	}
+}
+
+// Sets the bits of the other bloom filter
+func (b *Bloom) Or(other Bloom) {
+	for i := range b {
+		b[i] |= other[i]
+	}
+}
+
+// Reports whether the bits of the value are set, which
+// means that the value may have been added to the filter
+func (b Bloom) Test(value []byte) bool {
+	hash := keccak256(value)
+	for i := 0; i < 6; i += 2 {
+		index, bit := bloomBit(hash[i], hash[i+1])
+		if b[index]&bit == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the byte index and the bit of the 11 bits of the pair,
+// where the bits are counted from the end of the filter
+func bloomBit(hi, lo byte) (int, byte) {
+	pos := (uint(hi)<<8 | uint(lo)) & 2047
+	return BloomLength - 1 - int(pos/8), 1 << (pos % 8)
+}
+
+func (b Bloom) Bytes() []byte {
+	return b[:]
+}
+
+func (b Bloom) MarshalText() ([]byte, error) {
+	return HexBytes(b[:]).MarshalText()
+}
+
+func (b *Bloom) UnmarshalText(input []byte) error {
+	return decodeFixedHexText(input, b[:])
+}
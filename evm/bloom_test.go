@@ -0,0 +1,31 @@
+package space_evm
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Test_Bloom_Add(t *testing.T) {
+	var bloom Bloom
+	for i := 0; i < 100; i++ {
+		bloom.Add([]byte(fmt.Sprintf("xxxxxxxxxx data %d yyyyyyyyyyyyyy", i)))
+	}
+	logsBloom := LogsBloom([]*Log{{Address: testLogger, Topics: []Hash{BytesToHash([]byte{7})}}})
+
+	test := genericTest{
+		s: "bloom of 100 values, and of a log",
+		exp: []interface{}{
+			BytesToHash(hexToBytes("c8d3ca65cdb4874300a9e39475508f23ed6da09fdbc487f89a2dcf50b09eb263")),
+			true, true, false,
+		},
+		act: []interface{}{
+			BytesToHash(keccak256(bloom.Bytes())),
+			logsBloom.Test(testLogger[:]), logsBloom.Test(BytesToHash([]byte{7}).Bytes()), logsBloom.Test(testRecipient[:]),
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,307 @@
+package space_evm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"space/database"
+	"space/rlp"
+
+	"github.com/holiman/uint256"
+)
+
+// Keys of the chain in the store. Blocks are stored by their number and
+// hash, and the canonical hashes are stored by the numbers. Keys have a
+// prefix and never have 32 bytes, hence they do not collide with the
+// trie nodes and the code of the state, which are stored by their hash.
+var (
+	headBlockKey      = []byte("LastBlock")
+	headerPrefix      = []byte("h") // headerPrefix + number + hash -> header
+	bodyPrefix        = []byte("b") // bodyPrefix + number + hash -> body
+	receiptsPrefix    = []byte("r") // receiptsPrefix + number + hash -> receipts
+	canonicalPrefix   = []byte("c") // canonicalPrefix + number -> hash
+	blockNumberPrefix = []byte("H") // blockNumberPrefix + hash -> number
+	txLookupPrefix    = []byte("l") // txLookupPrefix + tx hash -> number
+)
+
+func encodeBlockNumber(number uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, number)
+	return enc
+}
+
+func blockKey(prefix []byte, number uint64, hash Hash) []byte {
+	return append(append(append([]byte{}, prefix...), encodeBlockNumber(number)...), hash[:]...)
+}
+
+func hashKey(prefix []byte, hash Hash) []byte {
+	return append(append([]byte{}, prefix...), hash[:]...)
+}
+
+func canonicalKey(number uint64) []byte {
+	return append(append([]byte{}, canonicalPrefix...), encodeBlockNumber(number)...)
+}
+
+// storedReceipt is the encoding of the receipts in the chain store, which
+// has the fields of the receipts that can not be derived from the block
+type storedReceipt struct {
+	Type              byte
+	Status            uint64
+	Logs              []*Log
+	ContractAddress   *Address `rlp:"nil"`
+	GasUsed           uint64
+	EffectiveGasPrice *uint256.Int
+	BlobGasUsed       uint64
+	BlobGasPrice      *uint256.Int
+}
+
+// Chain is the canonical chain of blocks, whose headers, bodies and
+// receipts are persisted into a key/value store. Chain starts with the
+// genesis block, and it is extended by inserting the children of its
+// head block, which are validated against their parent. State of the
+// blocks is not stored by the chain.
+type Chain struct {
+	db      database.KeyValueStore
+	genesis *Header
+	lock    sync.RWMutex
+	head    *Header
+}
+
+// Opens the chain in the store, which starts with the genesis header.
+// Genesis block is written into the store if the store has no chain,
+// and otherwise the chain in the store must have the same genesis.
+func NewChain(db database.KeyValueStore, genesis *Header) (*Chain, error) {
+	chain := &Chain{db: db, genesis: genesis, head: genesis}
+	headHash, err := db.Get(headBlockKey)
+	if errors.Is(err, database.ErrNotFound) {
+		if err := chain.writeBlock(&Block{Header: genesis}, nil); err != nil {
+			return nil, err
+		}
+		return chain, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if hash, err := chain.canonicalHash(genesis.Number); err != nil || hash != genesis.Hash() {
+		return nil, fmt.Errorf("%w: stored %v, given %v", ErrGenesisMismatch, hash.Hex(), genesis.Hash().Hex())
+	}
+	if chain.head, err = chain.GetHeaderByHash(BytesToHash(headHash)); err != nil {
+		return nil, err
+	}
+	return chain, nil
+}
+
+func (c *Chain) Genesis() *Header {
+	return c.genesis
+}
+
+// Returns the header of the head block of the chain
+func (c *Chain) CurrentHeader() *Header {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.head
+}
+
+// Returns the head block of the chain
+func (c *Chain) CurrentBlock() (*Block, error) {
+	return c.GetBlockByHash(c.CurrentHeader().Hash())
+}
+
+// Validates the block with its receipts, and writes them into the store
+// as the new head of the chain. Block must be the child of the head,
+// and its header must commit to its transactions and receipts.
+func (c *Chain) InsertBlock(block *Block, receipts []*Receipt) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err := ValidateHeader(c.head, block.Header); err != nil {
+		return err
+	}
+	if err := ValidateBody(block, receipts); err != nil {
+		return err
+	}
+	if err := c.writeBlock(block, receipts); err != nil {
+		return err
+	}
+	c.head = block.Header
+	return nil
+}
+
+// Writes the block with its receipts as the head of the chain in a
+// single batch, hence the store has either all or none of them
+func (c *Chain) writeBlock(block *Block, receipts []*Receipt) error {
+	header, hash := block.Header, block.Hash()
+	headerEnc, err := rlp.Encode(header)
+	if err != nil {
+		return err
+	}
+	bodyEnc, err := rlp.Encode(block.Body())
+	if err != nil {
+		return err
+	}
+	stored := make([]*storedReceipt, len(receipts))
+	for i, receipt := range receipts {
+		stored[i] = &storedReceipt{
+			Type:              receipt.Type,
+			Status:            receipt.Status,
+			Logs:              receipt.Logs,
+			ContractAddress:   receipt.ContractAddress,
+			GasUsed:           receipt.GasUsed,
+			EffectiveGasPrice: receipt.EffectiveGasPrice,
+			BlobGasUsed:       receipt.BlobGasUsed,
+			BlobGasPrice:      receipt.BlobGasPrice,
+		}
+	}
+	receiptsEnc, err := rlp.Encode(stored)
+	if err != nil {
+		return err
+	}
+
+	batch := c.db.NewBatch()
+	batch.Put(blockKey(headerPrefix, header.Number, hash), headerEnc)
+	batch.Put(blockKey(bodyPrefix, header.Number, hash), bodyEnc)
+	batch.Put(blockKey(receiptsPrefix, header.Number, hash), receiptsEnc)
+	batch.Put(hashKey(blockNumberPrefix, hash), encodeBlockNumber(header.Number))
+	batch.Put(canonicalKey(header.Number), hash[:])
+	for _, tx := range block.Transactions {
+		batch.Put(hashKey(txLookupPrefix, tx.Hash()), encodeBlockNumber(header.Number))
+	}
+	batch.Put(headBlockKey, hash[:])
+	return batch.Write()
+}
+
+// Returns the hash of the canonical block with the number
+func (c *Chain) canonicalHash(number uint64) (Hash, error) {
+	hash, err := c.db.Get(canonicalKey(number))
+	if errors.Is(err, database.ErrNotFound) {
+		return Hash{}, fmt.Errorf("%w: number %d", ErrUnknownBlock, number)
+	}
+	return BytesToHash(hash), err
+}
+
+// Returns the number of the block with the hash
+func (c *Chain) blockNumber(hash Hash) (uint64, error) {
+	number, err := c.db.Get(hashKey(blockNumberPrefix, hash))
+	if errors.Is(err, database.ErrNotFound) {
+		return 0, fmt.Errorf("%w: hash %v", ErrUnknownBlock, hash.Hex())
+	}
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(number), nil
+}
+
+// Decodes the value of the key of the block into val
+func (c *Chain) readBlockValue(prefix []byte, hash Hash, val interface{}) error {
+	number, err := c.blockNumber(hash)
+	if err != nil {
+		return err
+	}
+	enc, err := c.db.Get(blockKey(prefix, number, hash))
+	if err != nil {
+		return err
+	}
+	return rlp.Decode(enc, val)
+}
+
+func (c *Chain) GetHeaderByHash(hash Hash) (*Header, error) {
+	header := new(Header)
+	if err := c.readBlockValue(headerPrefix, hash, header); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+func (c *Chain) GetHeaderByNumber(number uint64) (*Header, error) {
+	hash, err := c.canonicalHash(number)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetHeaderByHash(hash)
+}
+
+func (c *Chain) GetBlockByHash(hash Hash) (*Block, error) {
+	header, err := c.GetHeaderByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	body := new(Body)
+	if err := c.readBlockValue(bodyPrefix, hash, body); err != nil {
+		return nil, err
+	}
+	return NewBlockWithBody(header, body), nil
+}
+
+func (c *Chain) GetBlockByNumber(number uint64) (*Block, error) {
+	hash, err := c.canonicalHash(number)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetBlockByHash(hash)
+}
+
+// Returns the receipts of the transactions of the block,
+// with their fields which are derived from the block
+func (c *Chain) GetReceipts(hash Hash) ([]*Receipt, error) {
+	block, err := c.GetBlockByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	var stored []*storedReceipt
+	if err := c.readBlockValue(receiptsPrefix, hash, &stored); err != nil {
+		return nil, err
+	}
+	receipts := make([]*Receipt, len(stored))
+	for i, s := range stored {
+		receipts[i] = &Receipt{
+			Type:              s.Type,
+			Status:            s.Status,
+			Bloom:             LogsBloom(s.Logs),
+			Logs:              s.Logs,
+			ContractAddress:   s.ContractAddress,
+			GasUsed:           s.GasUsed,
+			EffectiveGasPrice: s.EffectiveGasPrice,
+		}
+		if s.BlobGasUsed > 0 {
+			receipts[i].BlobGasUsed, receipts[i].BlobGasPrice = s.BlobGasUsed, s.BlobGasPrice
+		}
+	}
+	deriveReceiptFields(receipts, block)
+	return receipts, nil
+}
+
+// Returns the transaction with the hash, with the
+// canonical block which includes it and its index
+func (c *Chain) GetTransaction(hash Hash) (*Transaction, *Block, uint64, error) {
+	number, err := c.db.Get(hashKey(txLookupPrefix, hash))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil, 0, fmt.Errorf("%w: hash %v", ErrUnknownTransaction, hash.Hex())
+	}
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	block, err := c.GetBlockByNumber(binary.BigEndian.Uint64(number))
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	for i, tx := range block.Transactions {
+		if tx.Hash() == hash {
+			return tx, block, uint64(i), nil
+		}
+	}
+	return nil, nil, 0, fmt.Errorf("%w: hash %v", ErrUnknownTransaction, hash.Hex())
+}
+
+// Returns the receipt of the transaction with the hash
+func (c *Chain) GetReceipt(hash Hash) (*Receipt, error) {
+	_, block, index, err := c.GetTransaction(hash)
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := c.GetReceipts(block.Hash())
+	if err != nil {
+		return nil, err
+	}
+	return receipts[index], nil
+}
@@ -0,0 +1,123 @@
+package space_evm
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"space/database"
+)
+
+// Returns the genesis whose alloc has the sender of the
+// EIP-155 example with 1 ether, and the logger contract
+func genTestGenesis() *Genesis {
+	return &Genesis{
+		GasLimit: 30000000,
+		Alloc: GenesisAlloc{
+			eip155Sender: {Balance: (*HexOrDecimal256)(u256(1000000000000000000))},
+			testLogger:   {Code: hexToBytes("602a600052" + "600760206000a1"), Balance: (*HexOrDecimal256)(u256(0))},
+		},
+	}
+}
+
+// Processes the transactions on top of the head of the chain, and
+// returns the sealed child of the head block with its receipts
+func genChildBlock(t *testing.T, chain *Chain, state *StateDB, txs ...TxData) (*Block, []*Receipt) {
+	parent := chain.CurrentHeader()
+	header := &Header{
+		ParentHash: parent.Hash(),
+		Coinbase:   testCoinbase,
+		Number:     parent.Number + 1,
+		GasLimit:   parent.GasLimit,
+		Time:       parent.Time + 1,
+		BaseFee:    CalcBaseFee(parent),
+	}
+	block := genTestBlock(t, header.GasLimit, txs...)
+	block.Header = header
+	result, err := NewEVM(Moon).ProcessBlock(state, block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header.GasUsed, header.Root = result.GasUsed, result.Root
+	return NewBlock(header, block.Transactions, result.Receipts), result.Receipts
+}
+
+func Test_Chain_InsertBlock(t *testing.T) {
+	dir := t.TempDir()
+	db, err := database.OpenFileDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis := genTestGenesis()
+	state, genesisHeader, err := genesis.Commit(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := NewChain(db, genesisHeader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block1, receipts1 := genChildBlock(t, chain, state,
+		&LegacyTx{Nonce: 0, GasPrice: u256(InitialBaseFee), Gas: 21000, To: testTo(testRecipient), Value: u256(1)},
+		&LegacyTx{Nonce: 1, GasPrice: u256(InitialBaseFee), Gas: 30000, To: testTo(testLogger), Value: u256(0)},
+	)
+	if err := chain.InsertBlock(block1, receipts1); err != nil {
+		t.Fatal(err)
+	}
+	block2, receipts2 := genChildBlock(t, chain, state,
+		&LegacyTx{Nonce: 2, GasPrice: u256(InitialBaseFee), Gas: 100000, Value: u256(0), Data: testInitCode},
+	)
+	if err := chain.InsertBlock(block2, receipts2); err != nil {
+		t.Fatal(err)
+	}
+	// block can only be the child of the head
+	errNotChild := chain.InsertBlock(block1, receipts1)
+
+	// chain is read back from the reopened store
+	db.Close()
+	db, err = database.OpenFileDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	chain, err = NewChain(db, genesisHeader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := chain.CurrentHeader()
+	byNumber, _ := chain.GetBlockByNumber(1)
+	byHash, _ := chain.GetHeaderByHash(block2.Hash())
+	receipts, _ := chain.GetReceipts(block1.Hash())
+	createReceipt, _ := chain.GetReceipt(block2.Transactions[0].Hash())
+	tx, txBlock, txIndex, _ := chain.GetTransaction(block1.Transactions[1].Hash())
+	_, errUnknownBlock := chain.GetBlockByNumber(3)
+	_, _, _, errUnknownTx := chain.GetTransaction(Hash{})
+	_, errMismatch := NewChain(db, &Header{Number: 0, GasLimit: 1})
+	contractAddr := CreateAddress(eip155Sender, 2)
+
+	test := genericTest{
+		s: "blocks and receipts are read from the store",
+		exp: []interface{}{
+			block2.Hash(), block1.Hash(), block1.Header.TxHash, block2.Hash(), genesisHeader.Hash(),
+			[]uint64{21000, 43027}, block1.Header.ReceiptHash, block1.Hash(), uint(1), uint64(1),
+			&contractAddr, block2.Hash(), uint64(2), u256(InitialBaseFee),
+			block1.Transactions[1].Hash(), block1.Hash(), uint64(1),
+			true, true, true, true,
+		},
+		act: []interface{}{
+			head.Hash(), byNumber.Hash(), TxsRoot(byNumber.Transactions), byHash.Hash(), chain.Genesis().Hash(),
+			[]uint64{receipts[0].CumulativeGasUsed, receipts[1].CumulativeGasUsed}, ReceiptsRoot(receipts),
+			receipts[1].Logs[0].BlockHash, receipts[1].Logs[0].TxIndex, receipts[1].Logs[0].BlockNumber,
+			createReceipt.ContractAddress, createReceipt.BlockHash, createReceipt.BlockNumber, createReceipt.EffectiveGasPrice,
+			tx.Hash(), txBlock.Hash(), txIndex,
+			errors.Is(errNotChild, ErrInvalidParentHash), errors.Is(errUnknownBlock, ErrUnknownBlock),
+			errors.Is(errUnknownTx, ErrUnknownTransaction), errors.Is(errMismatch, ErrGenesisMismatch),
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
@@ -1,13 +1,237 @@
 package space_evm
 
 import (
+	"bytes"
 	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
 	"strings"
 
+	"space/crypto/secp256k1"
+	"space/rlp"
+
 	"github.com/holiman/uint256"
 	"golang.org/x/crypto/sha3"
 )
 
+const (
+	AddressLength = 20
+	HashLength    = 32
+)
+
+// Address is the 20 bytes identifier of an account
+type Address [AddressLength]byte
+
+// Convert byte slice to Address. If the slice is longer than
+// the address length, only the last 20 bytes are used, and
+// if it is shorter, it is left-padded with zeroes.
+func BytesToAddress(buff []byte) Address {
+	var addr Address
+	if len(buff) > AddressLength {
+		buff = buff[len(buff)-AddressLength:]
+	}
+	copy(addr[AddressLength-len(buff):], buff)
+	return addr
+}
+
+func (addr Address) Bytes() []byte {
+	return addr[:]
+}
+
+func (addr Address) Hex() string {
+	return "0x" + hex.EncodeToString(addr[:])
+}
+
+// Hash is the 32 bytes keccak256 or versioned hash of some data
+type Hash [HashLength]byte
+
+// Convert byte slice to Hash. If the slice is longer than
+// the hash length, only the last 32 bytes are used, and
+// if it is shorter, it is left-padded with zeroes.
+func BytesToHash(buff []byte) Hash {
+	var hash Hash
+	if len(buff) > HashLength {
+		buff = buff[len(buff)-HashLength:]
+	}
+	copy(hash[HashLength-len(buff):], buff)
+	return hash
+}
+
+func (hash Hash) Bytes() []byte {
+	return hash[:]
+}
+
+func (hash Hash) Hex() string {
+	return "0x" + hex.EncodeToString(hash[:])
+}
+
+// Addresses and hashes are encoded as 0x prefixed hex strings in JSON
+func (addr Address) MarshalText() ([]byte, error) {
+	return []byte(addr.Hex()), nil
+}
+
+func (addr *Address) UnmarshalText(input []byte) error {
+	return decodeFixedHexText(input, addr[:])
+}
+
+func (hash Hash) MarshalText() ([]byte, error) {
+	return []byte(hash.Hex()), nil
+}
+
+func (hash *Hash) UnmarshalText(input []byte) error {
+	return decodeFixedHexText(input, hash[:])
+}
+
+// HexBytes is a byte slice which is encoded
+// as a 0x prefixed hex string in JSON
+type HexBytes []byte
+
+func (b HexBytes) MarshalText() ([]byte, error) {
+	return []byte("0x" + hex.EncodeToString(b)), nil
+}
+
+func (b *HexBytes) UnmarshalText(input []byte) error {
+	buff, err := decodeHexText(input)
+	if err != nil {
+		return err
+	}
+	*b = buff
+	return nil
+}
+
+// HexUint64 is an integer which is encoded as a 0x
+// prefixed hex string without leading zeroes in JSON
+type HexUint64 uint64
+
+func (i HexUint64) MarshalText() ([]byte, error) {
+	return []byte("0x" + strconv.FormatUint(uint64(i), 16)), nil
+}
+
+func (i *HexUint64) UnmarshalText(input []byte) error {
+	str := string(input)
+	if !strings.HasPrefix(str, "0x") || len(str) == 2 || (len(str) > 3 && str[2] == '0') {
+		return fmt.Errorf("%w %q", ErrInvalidHexString, str)
+	}
+	val, err := strconv.ParseUint(str[2:], 16, 64)
+	if err != nil {
+		return fmt.Errorf("%w %q", ErrInvalidHexString, str)
+	}
+	*i = HexUint64(val)
+	return nil
+}
+
+// HexOrDecimal64 is an integer which is decoded from either a 0x prefixed
+// hex string or a decimal string in JSON, and it is encoded as hex
+type HexOrDecimal64 uint64
+
+func (i HexOrDecimal64) MarshalText() ([]byte, error) {
+	return HexUint64(i).MarshalText()
+}
+
+// Unquoted JSON numbers are also accepted
+func (i *HexOrDecimal64) UnmarshalJSON(input []byte) error {
+	return i.UnmarshalText(bytes.Trim(input, `"`))
+}
+
+func (i *HexOrDecimal64) UnmarshalText(input []byte) error {
+	str := string(input)
+	var (
+		val uint64
+		err error
+	)
+	if strings.HasPrefix(str, "0x") {
+		val, err = strconv.ParseUint(str[2:], 16, 64)
+	} else {
+		val, err = strconv.ParseUint(str, 10, 64)
+	}
+	if err != nil {
+		return fmt.Errorf("%w %q", ErrInvalidHexOrDecimal, str)
+	}
+	*i = HexOrDecimal64(val)
+	return nil
+}
+
+// HexOrDecimal256 is a 256 bits integer which is decoded from either a 0x
+// prefixed hex string or a decimal string in JSON, and it is encoded as hex
+type HexOrDecimal256 uint256.Int
+
+func (i *HexOrDecimal256) MarshalText() ([]byte, error) {
+	return (*uint256.Int)(i).MarshalText()
+}
+
+// Unquoted JSON numbers are also accepted
+func (i *HexOrDecimal256) UnmarshalJSON(input []byte) error {
+	return i.UnmarshalText(bytes.Trim(input, `"`))
+}
+
+func (i *HexOrDecimal256) UnmarshalText(input []byte) error {
+	str := string(input)
+	val, ok := new(big.Int), false
+	if strings.HasPrefix(str, "0x") {
+		val, ok = val.SetString(str[2:], 16)
+	} else {
+		val, ok = val.SetString(str, 10)
+	}
+	if !ok || val.Sign() < 0 {
+		return fmt.Errorf("%w %q", ErrInvalidHexOrDecimal, str)
+	}
+	if overflow := (*uint256.Int)(i).SetFromBig(val); overflow {
+		return fmt.Errorf("%w %q: more than 256 bits", ErrInvalidHexOrDecimal, str)
+	}
+	return nil
+}
+
+func decodeHexText(input []byte) ([]byte, error) {
+	str := string(input)
+	if !strings.HasPrefix(str, "0x") {
+		return nil, fmt.Errorf("%w %q", ErrInvalidHexString, str)
+	}
+	buff, err := hex.DecodeString(str[2:])
+	if err != nil {
+		return nil, fmt.Errorf("%w %q", ErrInvalidHexString, str)
+	}
+	return buff, nil
+}
+
+// Decodes the hex string into the buffer, which must have the same size
+func decodeFixedHexText(input []byte, buff []byte) error {
+	decoded, err := decodeHexText(input)
+	if err != nil {
+		return err
+	}
+	if len(decoded) != len(buff) {
+		return fmt.Errorf("%w %q: expected %d bytes", ErrInvalidHexString, input, len(buff))
+	}
+	copy(buff, decoded)
+	return nil
+}
+
+// Returns the address of the given uncompressed public key,
+// which is the last 20 bytes of the keccak256 of its coordinates
+func PubkeyToAddress(pubkey []byte) Address {
+	return BytesToAddress(keccak256(pubkey[1:]))
+}
+
+// Returns the address of the contract which is created by
+// the sender with the nonce, which is the last 20 bytes of
+// the keccak256 of the encoded list of the sender and nonce
+func CreateAddress(sender Address, nonce uint64) Address {
+	enc := rlp.EncodeList(rlp.EncodeBytes(sender[:]), rlp.EncodeUint64(nonce))
+	return BytesToAddress(keccak256(enc))
+}
+
+// Returns the address of the account which created the given
+// 65 bytes [R || S || V] signature of the hash, where V is 0 or 1
+func Ecrecover(hash []byte, sig []byte) (Address, error) {
+	pubkey, err := secp256k1.RecoverPubkey(hash, sig)
+	if err != nil {
+		return Address{}, err
+	}
+	return PubkeyToAddress(pubkey), nil
+}
+
 // Convert byte slice to *uint256.Int, left-padded with zeroes
 func byteSliceToUint256(buff []byte) (*uint256.Int, error) {
 	hexStr := hex.EncodeToString(buff)
@@ -25,6 +249,47 @@ func keccak256(buff []byte) []byte {
 	return hash.Sum(nil)
 }
 
+// Returns the number of 32 bytes words required to fit size bytes
+func toWordSize(size uint64) uint64 {
+	if size > math.MaxUint64-31 {
+		return math.MaxUint64/32 + 1
+	}
+	return (size + 31) / 32
+}
+
+// Returns a copy of the buffer right-padded with zeroes to the given
+// size, and the buffer itself if it is already at least that long
+func rightPad(buff []byte, size int) []byte {
+	if len(buff) >= size {
+		return buff
+	}
+	padded := make([]byte, size)
+	copy(padded, buff)
+	return padded
+}
+
+// Returns size bytes of the buffer starting from offset, right-padded
+// with zeroes if the buffer does not have enough bytes to read
+func getPaddedSlice(buff []byte, offset uint64, size uint64) []byte {
+	if offset > uint64(len(buff)) {
+		offset = uint64(len(buff))
+	}
+	end := offset + size
+	if end > uint64(len(buff)) || end < offset {
+		end = uint64(len(buff))
+	}
+	return rightPad(buff[offset:end], int(size))
+}
+
+func allZero(buff []byte) bool {
+	for _, b := range buff {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // Ceil value to closest multiple of 32
 func ceil32(val uint64) uint64 {
 	r := val % 32
@@ -1,9 +1,12 @@
 package space_evm
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"testing"
+
+	"space/crypto/secp256k1"
 )
 
 var byteSliceToUint256Tests = []genericTest{
@@ -66,3 +69,179 @@ func Test_Common_Ceil32(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+var bytesToAddressTests = []genericTest{
+	{s: "convert empty", in: []byte{}, exp: Address{}},
+	{s: "convert 1 byte", in: []byte{4}, exp: Address{19: 4}},
+	{
+		s:   "convert 20 bytes",
+		in:  hexToBytes("0102030405060708090a0b0c0d0e0f1011121314"),
+		exp: Address{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20},
+	},
+	{
+		s:   "convert 32 bytes",
+		in:  hexToBytes("ffffffffffffffffffffffff0102030405060708090a0b0c0d0e0f1011121314"),
+		exp: Address{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20},
+	},
+}
+
+func Test_Common_BytesToAddress(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range bytesToAddressTests {
+		test.act = BytesToAddress(test.in.([]byte))
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+var toWordSizeTests = []genericTest{
+	{s: "0 bytes is 0 words", in: uint64(0), exp: uint64(0)},
+	{s: "1 byte is 1 word", in: uint64(1), exp: uint64(1)},
+	{s: "32 bytes is 1 word", in: uint64(32), exp: uint64(1)},
+	{s: "33 bytes is 2 words", in: uint64(33), exp: uint64(2)},
+	{s: "max uint64 does not overflow", in: MaxUint64, exp: MaxUint64/32 + 1},
+}
+
+func Test_Common_ToWordSize(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range toWordSizeTests {
+		test.act = toWordSize(test.in.(uint64))
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the [hash, signature]
+var ecrecoverTests = []genericTest{
+	{
+		s: "recover address",
+		in: [][]byte{
+			hexToBytes("18c547e4f7b0f325ad1e56f57e26c745b09a3e503d86e00e5255ff7f715d3d1c"),
+			hexToBytes("73b1693892219d736caba55bdb67216e485557ea6b6af75f37096c9aa6a5a75feeb940b1d03b21e36b0e47e79769f095fe2ab855bd91e3a38756b7d75a9c454901"),
+		},
+		exp: BytesToAddress(hexToBytes("a94f5374fce5edbc8e2a8697c15331677e6ebf0b")),
+	},
+	{
+		s: "invalid recovery id",
+		in: [][]byte{
+			hexToBytes("18c547e4f7b0f325ad1e56f57e26c745b09a3e503d86e00e5255ff7f715d3d1c"),
+			hexToBytes("73b1693892219d736caba55bdb67216e485557ea6b6af75f37096c9aa6a5a75feeb940b1d03b21e36b0e47e79769f095fe2ab855bd91e3a38756b7d75a9c45491c"),
+		},
+		exp:        secp256k1.ErrInvalidRecoveryID,
+		shouldFail: true,
+	},
+}
+
+func Test_Common_Ecrecover(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range ecrecoverTests {
+		testIn := test.in.([][]byte)
+		addr, err := Ecrecover(testIn[0], testIn[1])
+		if !test.shouldFail {
+			test.act = addr
+		} else {
+			test.act = err
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the buffer and the size to pad to
+var rightPadTests = []genericTest{
+	{s: "pad nil", in: []interface{}{[]byte(nil), 2}, exp: []byte{0, 0}},
+	{s: "pad shorter buffer", in: []interface{}{[]byte{1}, 3}, exp: []byte{1, 0, 0}},
+	{s: "do not pad longer buffer", in: []interface{}{[]byte{1, 2, 3}, 2}, exp: []byte{1, 2, 3}},
+}
+
+func Test_Common_RightPad(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range rightPadTests {
+		testIn := test.in.([]interface{})
+		test.act = rightPad(testIn[0].([]byte), testIn[1].(int))
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the JSON string, and exp is the decoded integer or the error
+var hexUint64Tests = []genericTest{
+	{s: "zero", in: `"0x0"`, exp: HexUint64(0)},
+	{s: "integer", in: `"0x3e8"`, exp: HexUint64(1000)},
+	{s: "max uint64", in: `"0xffffffffffffffff"`, exp: HexUint64(MaxUint64)},
+	{s: "leading zero", in: `"0x01"`, exp: ErrInvalidHexString},
+	{s: "missing prefix", in: `"1"`, exp: ErrInvalidHexString},
+	{s: "empty", in: `"0x"`, exp: ErrInvalidHexString},
+	{s: "uint64 overflow", in: `"0x10000000000000000"`, exp: ErrInvalidHexString},
+}
+
+func Test_Common_HexUint64(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range hexUint64Tests {
+		var i HexUint64
+		err := json.Unmarshal([]byte(test.in.(string)), &i)
+		if expErr, ok := test.exp.(error); ok {
+			test.act = err
+			if errors.Is(err, expErr) {
+				test.act = expErr
+			}
+		} else {
+			test.act = i
+			// encoded back to the same string
+			buff, _ := json.Marshal(i)
+			if string(buff) != test.in.(string) {
+				test.act = string(buff)
+			}
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the [sender, nonce] of the created contract
+var createAddressTests = []genericTest{
+	{
+		s:   "first contract of the sender",
+		in:  []interface{}{BytesToAddress(hexToBytes("970e8128ab834e8eac17ab8e3812f010678cf791")), uint64(0)},
+		exp: BytesToAddress(hexToBytes("333c3310824b7c685133f2bedb2ca4b8b4df633d")),
+	},
+	{
+		s:   "second contract of the sender",
+		in:  []interface{}{BytesToAddress(hexToBytes("970e8128ab834e8eac17ab8e3812f010678cf791")), uint64(1)},
+		exp: BytesToAddress(hexToBytes("8bda78331c916a08481428e4b07c96d3e916d165")),
+	},
+}
+
+func Test_Common_CreateAddress(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range createAddressTests {
+		testIn := test.in.([]interface{})
+		test.act = CreateAddress(testIn[0].(Address), testIn[1].(uint64))
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,45 @@
+package space_evm
+
+import (
+	"github.com/holiman/uint256"
+)
+
+// TxContext holds the information about the transaction whose
+// code is executed, which can be read by the environment opcodes
+type TxContext struct {
+	// Versioned hashes of the blobs carried by the transaction (see EIP-4844)
+	BlobHashes []Hash
+}
+
+// BlockContext holds the information about the block in which the
+// code is executed, which can be read by the environment opcodes
+type BlockContext struct {
+	// Address which receives the priority fees of the transactions
+	Coinbase Address
+	// Number and the timestamp of the block
+	Number uint64
+	Time   uint64
+	// Maximum gas of a transaction, which is not limited if it is zero
+	GasLimit uint64
+	// Price of a unit of gas which is burned for every transaction of
+	// the block (see EIP-1559). Fees are not burned if it is not set.
+	BaseFee *uint256.Int
+	// Price of a unit of blob gas in the block (see EIP-4844).
+	// It is treated as zero if it is not set.
+	BlobBaseFee *uint256.Int
+}
+
+// Returns the context of the block of the header
+func NewBlockContext(header *Header) BlockContext {
+	blockCtx := BlockContext{
+		Coinbase: header.Coinbase,
+		Number:   header.Number,
+		Time:     header.Time,
+		GasLimit: header.GasLimit,
+		BaseFee:  header.BaseFee,
+	}
+	if header.ExcessBlobGas != nil {
+		blockCtx.BlobBaseFee = CalcBlobBaseFee(*header.ExcessBlobGas)
+	}
+	return blockCtx
+}
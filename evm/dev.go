@@ -0,0 +1,329 @@
+package space_evm
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"space/crypto/hdwallet"
+	"space/crypto/secp256k1"
+	"space/database"
+
+	"github.com/holiman/uint256"
+)
+
+// DevMnemonic is the mnemonic of the dev accounts, which is the same
+// as the mnemonic of the dev accounts of anvil and hardhat, hence the
+// dev accounts have their well-known addresses and keys
+const DevMnemonic = "test test test test test test test test test test test junk"
+
+// DevAccount is a pre-funded account of the dev chain with its secret key
+type DevAccount struct {
+	Address Address
+	Key     HexBytes
+}
+
+// Returns the first n accounts of the dev mnemonic,
+// which are derived with the paths m/44'/60'/0'/0/i
+func DevAccounts(n int) ([]DevAccount, error) {
+	seed := hdwallet.SeedFromMnemonic(DevMnemonic, "")
+	accounts := make([]DevAccount, n)
+	for i := range accounts {
+		key, err := hdwallet.DeriveKey(seed, fmt.Sprintf("m/44'/60'/0'/0/%d", i))
+		if err != nil {
+			return nil, err
+		}
+		pubkey, err := secp256k1.PubkeyFromSeckey(key)
+		if err != nil {
+			return nil, err
+		}
+		accounts[i] = DevAccount{Address: PubkeyToAddress(pubkey), Key: key}
+	}
+	return accounts, nil
+}
+
+// DevConfig is the configuration of the dev chain
+type DevConfig struct {
+	Fork    EVMFork
+	ChainID *uint256.Int
+	// Interval of the sealing of the blocks. If it is zero, a block
+	// is sealed as soon as a transaction is sent to the chain.
+	Period time.Duration
+	// Number of the dev accounts, and the balance which is given
+	// to each of them in the genesis
+	Accounts int
+	Balance  *uint256.Int
+	GasLimit uint64
+	Coinbase Address
+	TxPool   TxPoolConfig
+	// Directory of the database which the chain and the states of
+	// the blocks are persisted into. Chain is kept in memory if it
+	// is empty, and otherwise it is reopened from the directory.
+	DataDir string
+	// Called with the errors of sealing the blocks on the interval,
+	// which have no caller to return them to
+	OnSealError func(err error)
+}
+
+var DefaultDevConfig = DevConfig{
+	Fork:     Saturn,
+	ChainID:  uint256.NewInt(DefaultChainID),
+	Accounts: 10,
+	// 10000 ether
+	Balance:  new(uint256.Int).Mul(uint256.NewInt(10000), uint256.NewInt(1000000000000000000)),
+	GasLimit: 30000000,
+	TxPool:   DefaultTxPoolConfig,
+}
+
+// DevChain is a chain for the local development, which seals the blocks
+// by itself. It starts with a genesis which funds the dev accounts, and
+// seals the blocks with the transactions of its pool either as soon as
+// they arrive, or on a fixed interval. Chain and the states of the blocks
+// are written into its database, but only the state of the head block
+// is available.
+type DevChain struct {
+	config   DevConfig
+	accounts []DevAccount
+	db       database.KeyValueStore
+	chain    *Chain
+	pool     *TxPool
+
+	// lock serializes the sealing of the blocks, and guards the
+	// EVM and the state of the head block
+	lock  sync.Mutex
+	evm   *EVM
+	state *StateDB
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+// Returns the dev chain with the genesis block, whose state has the
+// dev accounts with their balance. Chain is reopened at its head block
+// if the data directory has a chain with the same genesis. Interval
+// sealing is started with DevChain.Start.
+func NewDevChain(config DevConfig) (*DevChain, error) {
+	if config.ChainID == nil {
+		config.ChainID = DefaultDevConfig.ChainID
+	}
+	if config.Balance == nil {
+		config.Balance = DefaultDevConfig.Balance
+	}
+	if config.GasLimit == 0 {
+		config.GasLimit = DefaultDevConfig.GasLimit
+	}
+	accounts, err := DevAccounts(config.Accounts)
+	if err != nil {
+		return nil, err
+	}
+	genesis := &Genesis{
+		GasLimit: HexOrDecimal64(config.GasLimit),
+		BaseFee:  (*HexOrDecimal256)(uint256.NewInt(InitialBaseFee)),
+		Alloc:    GenesisAlloc{},
+	}
+	// blocks of the blob forks have the blob fields from the genesis
+	if config.Fork >= Saturn {
+		genesis.ExcessBlobGas = new(HexOrDecimal64)
+	}
+	for _, account := range accounts {
+		genesis.Alloc[account.Address] = GenesisAccount{Balance: (*HexOrDecimal256)(new(uint256.Int).Set(config.Balance))}
+	}
+
+	var db database.KeyValueStore = database.NewMemoryDB()
+	if config.DataDir != "" {
+		if db, err = database.OpenFileDB(config.DataDir); err != nil {
+			return nil, err
+		}
+	}
+	dev, err := newDevChain(config, accounts, genesis, db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return dev, nil
+}
+
+func newDevChain(config DevConfig, accounts []DevAccount, genesis *Genesis, db database.KeyValueStore) (*DevChain, error) {
+	chain, err := NewChain(db, genesis.ToHeader())
+	if err != nil {
+		return nil, err
+	}
+	// state of the genesis is written as long as the chain is at the
+	// genesis, and otherwise the state of the head block is loaded
+	var (
+		state *StateDB
+		head  = chain.CurrentHeader()
+	)
+	if head.Number == 0 {
+		batch := db.NewBatch()
+		if state, _, err = genesis.Commit(batch); err != nil {
+			return nil, err
+		}
+		if err := batch.Write(); err != nil {
+			return nil, err
+		}
+	} else if state, err = LoadStateDB(head.Root, db); err != nil {
+		return nil, err
+	}
+	evm := NewEVM(config.Fork)
+	evm.ChainID = config.ChainID
+	return &DevChain{
+		config:   config,
+		accounts: accounts,
+		db:       db,
+		chain:    chain,
+		pool:     NewTxPool(config.TxPool, config.Fork, config.ChainID, state, head),
+		evm:      evm,
+		state:    state,
+	}, nil
+}
+
+func (d *DevChain) Config() DevConfig {
+	return d.config
+}
+
+func (d *DevChain) ChainID() *uint256.Int {
+	return d.config.ChainID
+}
+
+func (d *DevChain) Fork() EVMFork {
+	return d.config.Fork
+}
+
+func (d *DevChain) Accounts() []DevAccount {
+	return d.accounts
+}
+
+func (d *DevChain) Chain() *Chain {
+	return d.chain
+}
+
+func (d *DevChain) TxPool() *TxPool {
+	return d.pool
+}
+
+// Returns a copy of the state of the head block
+func (d *DevChain) State() *StateDB {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.state.Copy()
+}
+
+// Returns a copy of the state of the head block with the head header
+func (d *DevChain) HeadState() (*StateDB, *Header) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.state.Copy(), d.chain.CurrentHeader()
+}
+
+// Adds the transaction into the pool, and seals a block right away if
+// the blocks are not sealed on an interval. Blocks are only sealed if
+// they include transactions, hence the transactions queued behind a
+// nonce gap, or which can not be applied to the head state, such as the
+// ones below the base fee, wait in the pool without an empty block.
+func (d *DevChain) SendTransaction(tx *Transaction) error {
+	if err := d.pool.Add(tx); err != nil {
+		return err
+	}
+	if d.config.Period == 0 {
+		_, err := d.seal(false)
+		return err
+	}
+	return nil
+}
+
+// Seals the child of the head block with the pending transactions
+// of the pool, and appends it to the chain as the new head
+func (d *DevChain) Seal() (*Block, error) {
+	return d.seal(true)
+}
+
+// Seals the child of the head block. Block without transactions is
+// dropped unless sealEmpty is set, and then nil block is returned.
+func (d *DevChain) seal(sealEmpty bool) (*Block, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	parent := d.chain.CurrentHeader()
+	header := &Header{
+		ParentHash: parent.Hash(),
+		Coinbase:   d.config.Coinbase,
+		Difficulty: new(uint256.Int),
+		Number:     parent.Number + 1,
+		GasLimit:   parent.GasLimit,
+		Time:       uint64(time.Now().Unix()),
+		BaseFee:    CalcBaseFee(parent),
+	}
+	if header.Time <= parent.Time {
+		header.Time = parent.Time + 1
+	}
+	// blob gas used is set by the block builder
+	if d.config.Fork >= Saturn {
+		withdrawalsHash, parentBeaconRoot, excessBlobGas := EmptyRootHash, Hash{}, CalcExcessBlobGas(parent)
+		header.WithdrawalsHash, header.ParentBeaconRoot = &withdrawalsHash, &parentBeaconRoot
+		header.ExcessBlobGas = &excessBlobGas
+	}
+
+	state := d.state.Copy()
+	block, result := d.evm.BuildBlock(state, header, d.pool.Selector())
+	if len(block.Transactions) == 0 && !sealEmpty {
+		return nil, nil
+	}
+	// state is written before the block, hence the head
+	// block always has its state in the database
+	batch := d.db.NewBatch()
+	if _, err := state.Commit(batch); err != nil {
+		return nil, err
+	}
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	if err := d.chain.InsertBlock(block, result.Receipts); err != nil {
+		return nil, err
+	}
+	d.state = state
+	d.pool.Reset(state, block.Header)
+	return block, nil
+}
+
+// Starts sealing a block on every period, if the period is set.
+// Errors of the sealing are passed to the OnSealError of the config.
+func (d *DevChain) Start() {
+	if d.config.Period == 0 || d.quit != nil {
+		return
+	}
+	d.quit, d.done = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(d.done)
+		ticker := time.NewTicker(d.config.Period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				// sealing is retried on the next period
+				if _, err := d.Seal(); err != nil && d.config.OnSealError != nil {
+					d.config.OnSealError(err)
+				}
+			case <-d.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stops the interval sealing, and waits for the block being sealed
+func (d *DevChain) Stop() {
+	if d.quit == nil {
+		return
+	}
+	close(d.quit)
+	<-d.done
+	d.quit, d.done = nil, nil
+}
+
+// Stops the interval sealing, and closes the database of the chain
+func (d *DevChain) Close() error {
+	d.Stop()
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.db.Close()
+}
@@ -0,0 +1,271 @@
+package space_evm
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"space/database"
+)
+
+// Returns the transfer of the dev account with the nonce and the fees
+func genDevTx(t *testing.T, account DevAccount, nonce uint64, tip, feeCap uint64) *Transaction {
+	tx, err := SignTx(NewTx(&DynamicFeeTx{
+		ChainID: u256(DefaultChainID), Nonce: nonce, GasTipCap: u256(tip), GasFeeCap: u256(feeCap),
+		Gas: 21000, To: testTo(testRecipient), Value: u256(1),
+	}), u256(DefaultChainID), account.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func Test_Dev_Accounts(t *testing.T) {
+	accounts, err := DevAccounts(2)
+	test := genericTest{
+		s: "well-known dev accounts",
+		exp: []interface{}{
+			nil,
+			BytesToAddress(hexToBytes("f39fd6e51aad88f6f4ce6ab8827279cfffb92266")),
+			BytesToAddress(hexToBytes("70997970c51812dc3a010c7d01b50e0d17dc79c8")),
+			HexBytes(hexToBytes("ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80")),
+		},
+		act: []interface{}{err, accounts[0].Address, accounts[1].Address, accounts[0].Key},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+func Test_Dev_InstantSealing(t *testing.T) {
+	dev, err := NewDevChain(DefaultDevConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := dev.Accounts()[0]
+	tx := genDevTx(t, account, 0, 1000000000, 2000000000)
+	if err := dev.SendTransaction(tx); err != nil {
+		t.Fatal(err)
+	}
+	// replayed transaction is rejected, and no block is sealed
+	errReplay := dev.SendTransaction(genDevTx(t, account, 0, 1000000000, 3000000000))
+	// transactions which can not be included yet wait in the pool without a block
+	gapped := genDevTx(t, account, 2, 1000000000, 2000000000)
+	errGap := dev.SendTransaction(gapped)
+	errUnderpriced := dev.SendTransaction(genDevTx(t, dev.Accounts()[1], 0, 1, 1))
+	// blob transaction below the blob base fee is not applied to the block
+	blobTx, _ := SignTx(NewTx(&BlobTx{
+		ChainID: u256(DefaultChainID), GasTipCap: u256(1), GasFeeCap: u256(2000000000), Gas: 21000, To: testRecipient,
+		Value: u256(0), BlobFeeCap: u256(0), BlobHashes: []Hash{BytesToHash(append([]byte{1}, make([]byte, 31)...))},
+	}), u256(DefaultChainID), dev.Accounts()[2].Key)
+	errBlob := dev.SendTransaction(blobTx)
+	receipt, _ := dev.Chain().GetReceipt(tx.Hash())
+	head := dev.Chain().CurrentHeader()
+	state := dev.State()
+
+	test := genericTest{
+		s: "block is sealed when a transaction arrives",
+		exp: []interface{}{
+			uint64(1), ReceiptStatusSuccessful, head.Hash(), u256(875000000 + 1000000000),
+			u256(1), uint64(1), 3, true, DefaultDevConfig.Balance, 10, new(uint64), new(uint64),
+			nil, nil, nil, gapped,
+		},
+		act: []interface{}{
+			head.Number, receipt.Status, receipt.BlockHash, receipt.EffectiveGasPrice,
+			state.GetBalance(testRecipient), state.GetNonce(account.Address), dev.TxPool().Len(),
+			errors.Is(errReplay, ErrNonceTooLow), state.GetBalance(dev.Accounts()[9].Address), len(dev.Accounts()),
+			head.ExcessBlobGas, head.BlobGasUsed, errGap, errUnderpriced, errBlob, dev.TxPool().Get(gapped.Hash()),
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+func Test_Dev_IntervalSealing(t *testing.T) {
+	config := DefaultDevConfig
+	config.Period = 10 * time.Millisecond
+	dev, err := NewDevChain(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accounts := dev.Accounts()
+	tx := genDevTx(t, accounts[0], 0, 1, 2000000000)
+	// fee cap is below the base fee, hence it waits in the pool
+	underpriced := genDevTx(t, accounts[1], 0, 1, 1)
+	dev.SendTransaction(tx)
+	dev.SendTransaction(underpriced)
+	numberBefore := dev.Chain().CurrentHeader().Number
+
+	dev.Start()
+	for deadline := time.Now().Add(5 * time.Second); dev.Chain().CurrentHeader().Number < 2 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	dev.Stop()
+	block, _ := dev.Chain().GetBlockByNumber(1)
+	second, _ := dev.Chain().GetBlockByNumber(2)
+
+	test := genericTest{
+		s: "blocks are sealed on the interval",
+		exp: []interface{}{
+			uint64(0), TxsRoot([]*Transaction{tx}), 0, underpriced, true,
+		},
+		act: []interface{}{
+			numberBefore, TxsRoot(block.Transactions), len(second.Transactions), dev.TxPool().Get(underpriced.Hash()),
+			second.Header.Time > block.Header.Time,
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+func Test_Dev_Reopen(t *testing.T) {
+	config := DefaultDevConfig
+	config.DataDir = t.TempDir()
+	dev, err := NewDevChain(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := dev.Accounts()[0]
+	if err := dev.SendTransaction(genDevTx(t, account, 0, 1000000000, 2000000000)); err != nil {
+		t.Fatal(err)
+	}
+	head, root := dev.Chain().CurrentHeader(), dev.State().IntermediateRoot()
+	if err := dev.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// chain and the state of its head are read back from the directory
+	dev, err = NewDevChain(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := dev.State()
+	// chain continues from the reopened head
+	errSend := dev.SendTransaction(genDevTx(t, account, 1, 1000000000, 2000000000))
+	number, balance := dev.Chain().CurrentHeader().Number, dev.State().GetBalance(testRecipient)
+	dev.Close()
+	otherConfig := config
+	otherConfig.Accounts = 1
+	_, errGenesis := NewDevChain(otherConfig)
+
+	test := genericTest{
+		s: "chain is reopened at its head",
+		exp: []interface{}{
+			head.Hash(), head.Root, root, u256(1), uint64(1), nil, uint64(2), u256(2), true,
+		},
+		act: []interface{}{
+			dev.Chain().CurrentHeader().ParentHash, state.IntermediateRoot(), head.Root, state.GetBalance(testRecipient),
+			state.GetNonce(account.Address), errSend, number, balance,
+			errors.Is(errGenesis, ErrGenesisMismatch),
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+func Test_Dev_IntervalSealingError(t *testing.T) {
+	errs := make(chan error, 1)
+	config := DefaultDevConfig
+	config.DataDir, config.Period = t.TempDir(), 10*time.Millisecond
+	config.OnSealError = func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}
+	dev, err := NewDevChain(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// blocks can not be written after the database is closed
+	dev.db.Close()
+	dev.Start()
+	var sealErr error
+	select {
+	case sealErr = <-errs:
+	case <-time.After(5 * time.Second):
+	}
+	dev.Stop()
+
+	test := genericTest{
+		s:   "errors of the interval sealing are reported",
+		exp: []interface{}{true, uint64(0)},
+		act: []interface{}{errors.Is(sealErr, database.ErrFileDBClosed), dev.Chain().CurrentHeader().Number},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+// recordingDB records the keys written by the batches of the store,
+// and counts the writes of the keys which are already in the store
+type recordingDB struct {
+	*database.MemoryDB
+	written   map[string]struct{}
+	rewritten int
+}
+
+func (db *recordingDB) NewBatch() database.Batch {
+	return &recordingBatch{Batch: db.MemoryDB.NewBatch(), db: db}
+}
+
+type recordingBatch struct {
+	database.Batch
+	db *recordingDB
+}
+
+func (b *recordingBatch) Put(key []byte, value []byte) error {
+	if ok, _ := b.db.Has(key); ok {
+		b.db.rewritten++
+	}
+	b.db.written[string(key)] = struct{}{}
+	return b.Batch.Put(key, value)
+}
+
+func Test_Dev_SealWritesChanges(t *testing.T) {
+	dev, err := NewDevChain(DefaultDevConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	memory := dev.db.(*database.MemoryDB)
+	db := &recordingDB{MemoryDB: memory, written: map[string]struct{}{}}
+	dev.db = db
+
+	// nothing of the state is written again for an empty block
+	dev.Seal()
+	emptyWritten := len(db.written)
+	// only the accounts of the sender, the recipient and the coinbase
+	// change, and the keys of the store are not written again
+	if err := dev.SendTransaction(genDevTx(t, dev.Accounts()[0], 0, 1000000000, 2000000000)); err != nil {
+		t.Fatal(err)
+	}
+	all := mapStore{}
+	fresh, _ := ImportState(dev.State().Dump())
+	fresh.Commit(all)
+	loaded, errLoad := LoadStateDB(dev.Chain().CurrentHeader().Root, memory)
+
+	test := genericTest{
+		s:   "sealed blocks write only the changes of the state",
+		exp: []interface{}{0, 0, true, nil, dev.State().Dump()},
+		act: []interface{}{emptyWritten, db.rewritten, len(db.written) < len(all), errLoad, loaded.Dump()},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,101 @@
+package space_evm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/holiman/uint256"
+)
+
+// Dump is the JSON snapshot of the whole world state. Accounts are
+// encoded in the ascending order of their addresses, and the state
+// root is checked against the accounts when the dump is imported.
+type Dump struct {
+	Root     Hash                    `json:"root"`
+	Accounts map[Address]DumpAccount `json:"accounts"`
+}
+
+// DumpAccount is an account in the dump. Its storage root and code
+// hash are informational, and they are checked on import as well.
+type DumpAccount struct {
+	Balance  *HexOrDecimal256 `json:"balance"`
+	Nonce    HexOrDecimal64   `json:"nonce"`
+	Root     Hash             `json:"root"`
+	CodeHash Hash             `json:"codeHash"`
+	Code     HexBytes         `json:"code,omitempty"`
+	Storage  map[Hash]Hash    `json:"storage,omitempty"`
+}
+
+// Returns the snapshot of the current state
+func (s *StateDB) Dump() *Dump {
+	dump := &Dump{
+		Root:     s.IntermediateRoot(),
+		Accounts: make(map[Address]DumpAccount, len(s.objects)),
+	}
+	for addr, obj := range s.objects {
+		account := DumpAccount{
+			Balance:  (*HexOrDecimal256)(new(uint256.Int).Set(obj.balance)),
+			Nonce:    HexOrDecimal64(obj.nonce),
+			Root:     s.StorageRoot(addr),
+			CodeHash: s.GetCodeHash(addr),
+			Code:     append(HexBytes{}, obj.code...),
+		}
+		if len(obj.storage) > 0 {
+			account.Storage = make(map[Hash]Hash, len(obj.storage))
+			for slot, value := range obj.storage {
+				account.Storage[slot] = value
+			}
+		}
+		dump.Accounts[addr] = account
+	}
+	return dump
+}
+
+// Returns the snapshot of the current state as indented JSON
+func (s *StateDB) DumpJSON() ([]byte, error) {
+	return json.MarshalIndent(s.Dump(), "", "  ")
+}
+
+// Returns the state of the dump. Storage roots and code hashes of the
+// accounts, and the state root must match the imported state, unless
+// they are left zero in the dump.
+func ImportState(dump *Dump) (*StateDB, error) {
+	state := NewStateDB()
+	for addr, account := range dump.Accounts {
+		state.SetNonce(addr, uint64(account.Nonce))
+		if account.Balance != nil {
+			state.SetBalance(addr, (*uint256.Int)(account.Balance))
+		}
+		state.SetCode(addr, account.Code)
+		for slot, value := range account.Storage {
+			state.SetState(addr, slot, value)
+		}
+
+		if root := state.StorageRoot(addr); account.Root != (Hash{}) && account.Root != root {
+			return nil, fmt.Errorf("%w: storage root of %v is %v, dump has %v",
+				ErrDumpMismatch, addr.Hex(), root.Hex(), account.Root.Hex())
+		}
+		if codeHash := state.GetCodeHash(addr); account.CodeHash != (Hash{}) && account.CodeHash != codeHash {
+			return nil, fmt.Errorf("%w: code hash of %v is %v, dump has %v",
+				ErrDumpMismatch, addr.Hex(), codeHash.Hex(), account.CodeHash.Hex())
+		}
+	}
+	if root := state.IntermediateRoot(); dump.Root != (Hash{}) && dump.Root != root {
+		return nil, fmt.Errorf("%w: state root is %v, dump has %v", ErrDumpMismatch, root.Hex(), dump.Root.Hex())
+	}
+	return state, nil
+}
+
+// Reads the dump from the given JSON file, and returns its state
+func LoadStateDump(path string) (*StateDB, error) {
+	buff, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dump := new(Dump)
+	if err := json.Unmarshal(buff, dump); err != nil {
+		return nil, fmt.Errorf("invalid state dump %s: %w", path, err)
+	}
+	return ImportState(dump)
+}
@@ -0,0 +1,107 @@
+package space_evm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// State of the genesis file is dumped into the golden file
+func Test_Dump_Golden(t *testing.T) {
+	genesis, err := LoadGenesis("testdata/genesis.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump, err := genesis.ToState().DumpJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden, err := os.ReadFile("testdata/state_dump.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := LoadStateDump("testdata/state_dump.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test := genericTest{
+		s:   "dump matches the golden file and is imported back",
+		exp: []interface{}{string(golden), genesis.ToHeader().Root},
+		act: []interface{}{string(dump) + "\n", imported.IntermediateRoot()},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+// in modifies the dump of the test state, and exp is the
+// error of the import, or the state root of the imported state
+var importStateTests = []genericTest{
+	{s: "unmodified dump", in: func(dump *Dump) {}, exp: genTestState().IntermediateRoot()},
+	{
+		s: "zero roots and code hashes are not checked",
+		in: func(dump *Dump) {
+			dump.Root = Hash{}
+			for addr, account := range dump.Accounts {
+				account.Root, account.CodeHash = Hash{}, Hash{}
+				dump.Accounts[addr] = account
+			}
+		},
+		exp: genTestState().IntermediateRoot(),
+	},
+	{s: "state root mismatch", in: func(dump *Dump) { dump.Root[0] ^= 1 }, exp: ErrDumpMismatch},
+	{
+		s: "storage root mismatch",
+		in: func(dump *Dump) {
+			account := dump.Accounts[testAddr2]
+			account.Storage[BytesToHash([]byte{1})] = BytesToHash([]byte{1})
+			dump.Root = Hash{}
+		},
+		exp: ErrDumpMismatch,
+	},
+	{
+		s: "code hash mismatch",
+		in: func(dump *Dump) {
+			account := dump.Accounts[testAddr2]
+			account.Code = hexToBytes("00")
+			dump.Accounts[testAddr2] = account
+			dump.Root = Hash{}
+		},
+		exp: ErrDumpMismatch,
+	},
+}
+
+func Test_Dump_ImportState(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range importStateTests {
+		// dump goes through JSON as it would in a file
+		buff, _ := genTestState().DumpJSON()
+		dump := new(Dump)
+		if err := json.Unmarshal(buff, dump); err != nil {
+			t.Fatal(err)
+		}
+		test.in.(func(*Dump))(dump)
+		state, err := ImportState(dump)
+		if expErr, ok := test.exp.(error); ok {
+			test.act = err
+			if errors.Is(err, expErr) {
+				test.act = expErr
+			}
+		} else if err != nil {
+			test.act = err
+		} else {
+			test.act = state.IntermediateRoot()
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
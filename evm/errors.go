@@ -10,6 +10,72 @@ var (
 	ErrStackUnderflow  = errors.New("stack underflow")
 	ErrGasUintOverflow = errors.New("gas uint64 overflow")
 	ErrOutOfGas        = errors.New("out of gas")
+	ErrMaxCallDepth    = errors.New("max call depth exceeded")
+	ErrWriteProtection = errors.New("write protection")
+
+	// ErrExecutionReverted is returned by REVERT, and unlike the other
+	// errors, the frame keeps its remaining gas and its return data
+	ErrExecutionReverted = errors.New("execution reverted")
+
+	// errStopToken is returned by the operations which halt the
+	// execution successfully, and it is never returned to callers
+	errStopToken = errors.New("stop token")
+
+	ErrRefundCounterUnderflow                 = errors.New("refund counter underflow")
+	ErrModExpLengthTooLarge                   = errors.New("modexp length too large")
+	ErrBadPairingInputLen                     = errors.New("bad elliptic curve pairing input length")
+	ErrBlake2FInvalidInputLen                 = errors.New("invalid blake2f input length")
+	ErrBlake2FInvalidFinalFlag                = errors.New("invalid blake2f final block indicator flag")
+	ErrBLS12381InvalidInputLen                = errors.New("invalid bls12-381 input length")
+	ErrBLS12381InvalidFieldElementTopBytes    = errors.New("invalid bls12-381 field element top bytes")
+	ErrBLS12381PointNotInSubgroup             = errors.New("bls12-381 point is not in the correct subgroup")
+	ErrPointEvaluationInvalidInputLen         = errors.New("invalid point evaluation input length")
+	ErrPointEvaluationMismatchedVersionedHash = errors.New("mismatched versioned hash")
+	ErrInvalidHexString                       = errors.New("invalid hex string")
+	ErrInvalidHexOrDecimal                    = errors.New("invalid hex or decimal integer")
+	ErrDumpMismatch                           = errors.New("state dump mismatch")
+	ErrInvalidTx                              = errors.New("invalid transaction")
+	ErrTxTypeNotSupported                     = errors.New("transaction type not supported")
+	ErrInvalidTxSignature                     = errors.New("invalid transaction signature")
+	ErrInvalidChainID                         = errors.New("invalid chain id")
+	ErrNonceTooLow                            = errors.New("nonce too low")
+	ErrNonceTooHigh                           = errors.New("nonce too high")
+	ErrNonceMax                               = errors.New("nonce has max value")
+	ErrSenderNoEOA                            = errors.New("sender not an eoa")
+	ErrGasLimitReached                        = errors.New("gas limit reached")
+	ErrTipAboveFeeCap                         = errors.New("max priority fee per gas higher than max fee per gas")
+	ErrFeeCapTooLow                           = errors.New("max fee per gas less than block base fee")
+	ErrInsufficientFunds                      = errors.New("insufficient funds for gas * price + value")
+	ErrIntrinsicGas                           = errors.New("intrinsic gas too low")
+	ErrMissingBlobHashes                      = errors.New("blob transaction missing blob hashes")
+	ErrInvalidBlobHashVersion                 = errors.New("invalid blob hash version")
+	ErrBlobGasLimitReached                    = errors.New("blob gas limit reached")
+	ErrBlobFeeCapTooLow                       = errors.New("max fee per blob gas less than block blob gas fee")
+	ErrMaxInitCodeSizeExceeded                = errors.New("max initcode size exceeded")
+	ErrContractAddressCollision               = errors.New("contract address collision")
+	ErrMaxCodeSizeExceeded                    = errors.New("max code size exceeded")
+	ErrInvalidCode                            = errors.New("invalid code: must not begin with 0xef")
+	ErrInvalidAccountProof                    = errors.New("invalid account proof")
+	ErrInvalidStorageProof                    = errors.New("invalid storage proof")
+	ErrAlreadyKnown                           = errors.New("already known")
+	ErrReplaceUnderpriced                     = errors.New("replacement transaction underpriced")
+	ErrTxPoolOverflow                         = errors.New("txpool is full")
+	ErrUnknownBlock                           = errors.New("unknown block")
+	ErrUnknownTransaction                     = errors.New("unknown transaction")
+	ErrGenesisMismatch                        = errors.New("genesis mismatch")
+	ErrInvalidParentHash                      = errors.New("invalid parent hash")
+	ErrInvalidNumber                          = errors.New("invalid block number")
+	ErrInvalidTimestamp                       = errors.New("invalid timestamp")
+	ErrInvalidGasLimit                        = errors.New("invalid gas limit")
+	ErrInvalidGasUsed                         = errors.New("invalid gas used")
+	ErrInvalidBaseFee                         = errors.New("invalid base fee")
+	ErrInvalidExcessBlobGas                   = errors.New("invalid excess blob gas")
+	ErrInvalidBlobGasUsed                     = errors.New("invalid blob gas used")
+	ErrInvalidTxsRoot                         = errors.New("invalid transactions root")
+	ErrInvalidReceiptsRoot                    = errors.New("invalid receipts root")
+	ErrInvalidBloom                           = errors.New("invalid logs bloom")
+	ErrGasRequiredExceedsAllowance            = errors.New("gas required exceeds allowance")
+	ErrInvalidOverride                        = errors.New("invalid override")
 )
 
 func ErrInvalidOpcode(opcode byte) error {
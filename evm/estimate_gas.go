@@ -0,0 +1,164 @@
+package space_evm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/holiman/uint256"
+)
+
+// Selector of Error(string), which is the ABI encoding
+// of the revert reasons of the Solidity contracts
+var revertSelector = keccak256([]byte("Error(string)"))[:4]
+
+// Returns the reason of the ABI encoded Error(string) in the return data
+func UnpackRevertReason(data []byte) (string, error) {
+	if len(data) < 4 || !bytes.Equal(data[:4], revertSelector) {
+		return "", errors.New("invalid revert data")
+	}
+	data = data[4:]
+	if len(data) < 64 {
+		return "", errors.New("invalid revert data")
+	}
+	offset, overflow := new(uint256.Int).SetBytes(data[:32]).Uint64WithOverflow()
+	if overflow || offset > uint64(len(data))-32 {
+		return "", errors.New("invalid revert reason offset")
+	}
+	size, overflow := new(uint256.Int).SetBytes(data[offset : offset+32]).Uint64WithOverflow()
+	if overflow || size > uint64(len(data))-offset-32 {
+		return "", errors.New("invalid revert reason length")
+	}
+	return string(data[offset+32 : offset+32+size]), nil
+}
+
+// ExecutionError is the error of a call whose execution fails, with
+// its return data, and the revert reason if the data is an Error(string)
+type ExecutionError struct {
+	Err    error
+	Reason string
+	Data   []byte
+}
+
+// Returns the execution error of the failed
+// call with the error and the return data
+func NewExecutionError(err error, data []byte) *ExecutionError {
+	reason, _ := UnpackRevertReason(data)
+	return &ExecutionError{Err: err, Reason: reason, Data: data}
+}
+
+func (e *ExecutionError) Error() string {
+	if e.Reason == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v: %v", e.Err, e.Reason)
+}
+
+func (e *ExecutionError) Unwrap() error {
+	return e.Err
+}
+
+// Returns the copy of the transaction with the gas
+func txWithGas(tx *Transaction, gas uint64) *Transaction {
+	switch inner := tx.inner.(type) {
+	case *LegacyTx:
+		cpy := *inner
+		cpy.Gas = gas
+		return NewTx(&cpy)
+	case *AccessListTx:
+		cpy := *inner
+		cpy.Gas = gas
+		return NewTx(&cpy)
+	case *DynamicFeeTx:
+		cpy := *inner
+		cpy.Gas = gas
+		return NewTx(&cpy)
+	case *BlobTx:
+		cpy := *inner
+		cpy.Gas = gas
+		return NewTx(&cpy)
+	}
+	return tx
+}
+
+// Returns the lowest gas limit with which the call of the transaction
+// from the address succeeds. Gas limits are binary searched between the
+// intrinsic gas and the cap, which is the gas limit of the block, or the
+// gas of the transaction if it is lower. Cap is lowered further to the
+// gas which the sender can pay for at the fee cap, after the value and
+// the blob gas at the blob fee cap are paid. Each gas limit is run
+// as with EVM.ApplyCall, and its changes of the state are reverted,
+// hence the state is not changed. If the call fails at the cap, the error of the execution is
+// returned with the return data and the revert reason, unless it runs
+// out of gas.
+func (evm *EVM) EstimateGas(tx *Transaction, from Address) (uint64, error) {
+	state, blockCtx := evm.StateDB(), evm.interpreter.blockCtx
+	defer evm.SetStateDB(state)
+
+	intrinsicGas, err := IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil)
+	if err != nil {
+		return 0, err
+	}
+	hi := blockCtx.GasLimit
+	if tx.Gas() >= intrinsicGas && (hi == 0 || tx.Gas() < hi) {
+		hi = tx.Gas()
+	}
+	if hi == 0 {
+		return 0, fmt.Errorf("%w: no gas limit for the estimation", ErrGasLimitReached)
+	}
+	if feeCap := tx.GasFeeCap(); !feeCap.IsZero() {
+		balance := state.GetBalance(from)
+		if balance.Lt(tx.Value()) {
+			return 0, fmt.Errorf("%w: address %v have %v want %v", ErrInsufficientFunds, from.Hex(), balance, tx.Value())
+		}
+		allowance := new(uint256.Int).Sub(balance, tx.Value())
+		// blob gas is paid at the blob fee cap, regardless of the gas
+		if blobGas := uint64(len(tx.BlobHashes())) * blobGasPerBlob; blobGas > 0 {
+			blobFee, overflow := new(uint256.Int).MulOverflow(tx.BlobGasFeeCap(), uint256.NewInt(blobGas))
+			if overflow || allowance.Lt(blobFee) {
+				return 0, fmt.Errorf("%w: address %v have %v want %v blob fee", ErrInsufficientFunds, from.Hex(), allowance, blobFee)
+			}
+			allowance.Sub(allowance, blobFee)
+		}
+		if allowance.Div(allowance, feeCap); allowance.IsUint64() && allowance.Uint64() < hi {
+			hi = allowance.Uint64()
+		}
+	}
+
+	// runs the transaction with the gas, and reverts its changes
+	run := func(gas uint64) (*Receipt, error) {
+		snapshot := state.snapshot()
+		defer state.revertToSnapshot(snapshot)
+		return evm.ApplyCall(txWithGas(tx, gas), from)
+	}
+	receipt, err := run(hi)
+	if err != nil {
+		return 0, err
+	}
+	if receipt.Status == ReceiptStatusFailed {
+		if errors.Is(receipt.Err, ErrOutOfGas) {
+			return 0, fmt.Errorf("%w (%d)", ErrGasRequiredExceedsAllowance, hi)
+		}
+		return 0, NewExecutionError(receipt.Err, receipt.ReturnData)
+	}
+
+	// gas used with the refund is below the gas which the call needs,
+	// and the calls fail with the gas limits up to the lower bound
+	lo := intrinsicGas - 1
+	if receipt.GasUsed-1 > lo {
+		lo = receipt.GasUsed - 1
+	}
+	for lo+1 < hi {
+		mid := lo + (hi-lo)/2
+		receipt, err := run(mid)
+		if err != nil {
+			return 0, err
+		}
+		if receipt.Status == ReceiptStatusFailed {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi, nil
+}
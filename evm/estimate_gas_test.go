@@ -0,0 +1,183 @@
+package space_evm
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/holiman/uint256"
+)
+
+// in is the transaction which is sent from the sender of the EIP-155
+// example, and exp is the estimated gas and the error
+var estimateGasTests = []genericTest{
+	{
+		s:   "value transfer",
+		in:  &LegacyTx{GasPrice: u256(0), To: testTo(testRecipient), Value: u256(1)},
+		exp: []interface{}{uint64(21000), nil},
+	},
+	{
+		s:   "call of a contract",
+		in:  &DynamicFeeTx{GasTipCap: u256(0), GasFeeCap: u256(1), To: testTo(testBaseFeeReader), Value: u256(0)},
+		exp: []interface{}{uint64(21017), nil},
+	},
+	{
+		s:   "contract creation",
+		in:  &LegacyTx{GasPrice: u256(1), Value: u256(0), Data: testInitCode},
+		exp: []interface{}{uint64(53584), nil},
+	},
+	{
+		s:   "gas of the transaction is the cap",
+		in:  &LegacyTx{GasPrice: u256(0), Gas: 21016, To: testTo(testBaseFeeReader), Value: u256(0)},
+		exp: []interface{}{uint64(0), ErrGasRequiredExceedsAllowance},
+	},
+	{
+		s:   "balance is the cap",
+		in:  &LegacyTx{GasPrice: u256(1000000000000000000 / 21016), To: testTo(testBaseFeeReader), Value: u256(0)},
+		exp: []interface{}{uint64(0), ErrGasRequiredExceedsAllowance},
+	},
+	{
+		s:   "value above the balance",
+		in:  &LegacyTx{GasPrice: u256(1), To: testTo(testRecipient), Value: u256(1000000000000000001)},
+		exp: []interface{}{uint64(0), ErrInsufficientFunds},
+	},
+}
+
+func Test_EstimateGas(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range estimateGasTests {
+		evm := genTransitionEVM(Moon)
+		evm.StateDB().SetCode(testBaseFeeReader, hexToBytes("48600052"+"60206000f3"))
+		root := evm.StateDB().IntermediateRoot()
+		gas, err := evm.EstimateGas(NewTx(test.in.(TxData)), eip155Sender)
+		if exp := test.exp.([]interface{})[1]; exp != nil && errors.Is(err, exp.(error)) {
+			err = exp.(error)
+		}
+		// state is not changed by the estimation
+		if evm.StateDB().IntermediateRoot() != root {
+			err = errors.New("state changed")
+		}
+		test.act = []interface{}{gas, err}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the blob fee cap of the blob transaction which calls the
+// contract, and exp is the estimated gas and the error. Sender can
+// pay for 30000 gas at the fee cap without the blob gas.
+var estimateBlobGasTests = []genericTest{
+	{s: "blob fee below the allowance", in: u256(1), exp: []interface{}{uint64(21017), nil}},
+	{s: "blob fee lowers the cap", in: u256(2284495035808), exp: []interface{}{uint64(0), ErrGasRequiredExceedsAllowance}},
+	{s: "blob fee above the balance", in: u256(7629394531251), exp: []interface{}{uint64(0), ErrInsufficientFunds}},
+}
+
+func Test_EstimateGas_BlobGas(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range estimateBlobGasTests {
+		evm := genTransitionEVM(Saturn)
+		evm.StateDB().SetCode(testBaseFeeReader, hexToBytes("48600052"+"60206000f3"))
+		tx := NewTx(&BlobTx{
+			ChainID: u256(DefaultChainID), GasTipCap: u256(0), GasFeeCap: u256(1000000000000000000 / 30000), To: testBaseFeeReader,
+			Value: u256(0), BlobFeeCap: test.in.(*uint256.Int), BlobHashes: []Hash{BytesToHash(append([]byte{1}, make([]byte, 31)...))},
+		})
+		gas, err := evm.EstimateGas(tx, eip155Sender)
+		if exp := test.exp.([]interface{})[1]; exp != nil && errors.Is(err, exp.(error)) {
+			err = exp.(error)
+		}
+		test.act = []interface{}{gas, err}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+func Test_EstimateGas_ExecutionError(t *testing.T) {
+	evm := genTransitionEVM(Moon)
+	_, err := evm.EstimateGas(NewTx(&LegacyTx{GasPrice: u256(1), To: testTo(testFailer), Value: u256(0)}), eip155Sender)
+	var execErr *ExecutionError
+	isExecErr := errors.As(err, &execErr)
+
+	test := genericTest{
+		s:   "call which always fails",
+		exp: []interface{}{true, ErrInvalidOpcode(0xfe).Error(), ""},
+		act: []interface{}{isExecErr, err.Error(), execErr.Reason},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+func Test_EstimateGas_Revert(t *testing.T) {
+	evm := genTransitionEVM(Moon)
+	_, err := evm.EstimateGas(NewTx(&LegacyTx{GasPrice: u256(1), To: testTo(testReverter), Value: u256(0)}), eip155Sender)
+	var execErr *ExecutionError
+	isExecErr := errors.As(err, &execErr)
+
+	test := genericTest{
+		s:   "call which reverts with a reason",
+		exp: []interface{}{true, true, "execution reverted: revert reason", "revert reason", 100},
+		act: []interface{}{isExecErr, errors.Is(err, ErrExecutionReverted), err.Error(), execErr.Reason, len(execErr.Data)},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+// in is the return data, and exp is the reason
+var revertReasonTests = []genericTest{
+	{
+		s: "revert reason",
+		in: hexToBytes("08c379a0" +
+			"0000000000000000000000000000000000000000000000000000000000000020" +
+			"000000000000000000000000000000000000000000000000000000000000000d" +
+			"72657665727420726561736f6e00000000000000000000000000000000000000"),
+		exp: "revert reason",
+	},
+	{
+		s: "length out of the data",
+		in: hexToBytes("08c379a0" +
+			"0000000000000000000000000000000000000000000000000000000000000020" +
+			"0000000000000000000000000000000000000000000000000000000000000021" +
+			"72657665727420726561736f6e00000000000000000000000000000000000000"),
+		shouldFail: true,
+	},
+	{
+		s:          "other selector",
+		in:         hexToBytes("4e487b71" + "0000000000000000000000000000000000000000000000000000000000000001"),
+		shouldFail: true,
+	},
+	{
+		s:          "no data",
+		in:         []byte{},
+		shouldFail: true,
+	},
+}
+
+func Test_EstimateGas_UnpackRevertReason(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range revertReasonTests {
+		reason, err := UnpackRevertReason(test.in.([]byte))
+		test.act = reason
+		if test.shouldFail {
+			test.exp, test.act = true, err != nil
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
@@ -1,16 +1,26 @@
 package space_evm
 
+import (
+	"github.com/holiman/uint256"
+)
+
 // EVM is the Ethereum Virtual Machine
 // which is capable of executing bytecode
 type EVM struct {
-	Fork        EVMFork
+	Fork EVMFork
+	// Chain ID which the transactions are signed for (see EIP-155)
+	ChainID     *uint256.Int
 	interpreter *Interpreter
 }
 
+// Chain ID of the EVMs, unless it is set otherwise
+const DefaultChainID uint64 = 1337
+
 // Create an EVM instance
 func NewEVM(fork EVMFork) *EVM {
 	return &EVM{
 		Fork:        fork,
+		ChainID:     uint256.NewInt(DefaultChainID),
 		interpreter: NewInterpreter(fork),
 	}
 }
@@ -20,6 +30,38 @@ func NewEVM(fork EVMFork) *EVM {
 // Instruction set to interpret the code
 // is determined by the given EVM fork.
 func (evm *EVM) RunCode(code []byte, gasLimit uint64) {
-	result := evm.interpreter.Run(code, gasLimit)
-	result.Display()
+	evm.Run(code, gasLimit).Display()
+}
+
+// Run the code with the given gasLimit,
+// and return the results of the execution
+func (evm *EVM) Run(code []byte, gasLimit uint64) *RunResult {
+	return evm.interpreter.Run(code, gasLimit)
+}
+
+// Register a precompiled contract at the given address. Calls to
+// the address are routed to the precompile instead of the code,
+// and any existing precompile at the address is replaced.
+func (evm *EVM) RegisterPrecompile(addr Address, p *Precompile) {
+	evm.interpreter.precompiles[addr] = p
+}
+
+// Set the context of the transaction whose code is run
+func (evm *EVM) SetTxContext(txCtx TxContext) {
+	evm.interpreter.txCtx = txCtx
+}
+
+// Set the context of the block in which the code is run
+func (evm *EVM) SetBlockContext(blockCtx BlockContext) {
+	evm.interpreter.blockCtx = blockCtx
+}
+
+// Returns the world state which the code is run against
+func (evm *EVM) StateDB() *StateDB {
+	return evm.interpreter.state
+}
+
+// Set the world state which the code is run against
+func (evm *EVM) SetStateDB(state *StateDB) {
+	evm.interpreter.state = state
 }
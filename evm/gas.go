@@ -1,5 +1,27 @@
 package space_evm
 
+import (
+	"encoding/binary"
+	"math"
+	"math/big"
+
+	"github.com/holiman/uint256"
+)
+
+// Returns the memory byte length required to access size bytes
+// starting from offset. Memory accesses which do not fit in uint64
+// can never be paid for, hence false is returned in such a case.
+func memoryByteLen(offset *uint256.Int, size uint64) (uint64, bool) {
+	// accessing zero bytes never expands memory, regardless of offset
+	if size == 0 {
+		return 0, true
+	}
+	if !offset.IsUint64() || offset.Uint64() > math.MaxUint64-size {
+		return 0, false
+	}
+	return offset.Uint64() + size, true
+}
+
 func memoryExpansionGasCost(runState *RunState) (uint64, error) {
 	var size uint64
 	if runState.Opcode == 0x52 {
@@ -12,12 +34,62 @@ func memoryExpansionGasCost(runState *RunState) (uint64, error) {
 	if err != nil {
 		return 0, err
 	}
-	newMemByteLen := ceil32(offset.Uint64() + size)
-	// any newMemByteLen above the constant number
-	// 0x1FFFFFFFE0 causes square operation to overflow
-	if newMemByteLen > 0x1FFFFFFFE0 {
+	memByteLen, ok := memoryByteLen(offset, size)
+	if !ok {
+		return 0, ErrOutOfGas
+	}
+	return memoryGasCost(runState, memByteLen)
+}
+
+// Returns the memory expansion gas of the operations which access
+// size bytes starting from offset, with offset and size on the top
+// of the stack, such as RETURN
+func memoryRangeGasCost(runState *RunState) (uint64, error) {
+	offset, err1 := runState.Stack.peek(0)
+	size, err2 := runState.Stack.peek(1)
+	if err1 != nil || err2 != nil {
+		return 0, ErrStackUnderflow
+	}
+	if !size.IsUint64() {
+		return 0, ErrOutOfGas
+	}
+	memByteLen, ok := memoryByteLen(offset, size.Uint64())
+	if !ok {
+		return 0, ErrOutOfGas
+	}
+	return memoryGasCost(runState, memByteLen)
+}
+
+// Gas of a log operation without its topics, and of each
+// topic, which are paid as the constant gas of the operation
+const (
+	logGas      uint64 = 375
+	logTopicGas uint64 = 375
+	logDataGas  uint64 = 8
+)
+
+// Returns the memory expansion gas with the gas of the log data
+func logGasCost(runState *RunState) (uint64, error) {
+	memGas, err := memoryRangeGasCost(runState)
+	if err != nil {
+		return 0, err
+	}
+	size, _ := runState.Stack.peek(1)
+	if size.Uint64() > (math.MaxUint64-memGas)/logDataGas {
 		return 0, ErrGasUintOverflow
 	}
+	return memGas + size.Uint64()*logDataGas, nil
+}
+
+// Returns the gas cost of expanding memory to fit memByteLen bytes
+func memoryGasCost(runState *RunState, memByteLen uint64) (uint64, error) {
+	// any memByteLen above the constant number 0x1FFFFFFFE0 causes
+	// square operation to overflow, and such memory can never be paid
+	// for, hence it runs out of gas as any other unaffordable expansion
+	if memByteLen > 0x1FFFFFFFE0 {
+		return 0, ErrOutOfGas
+	}
+	newMemByteLen := ceil32(memByteLen)
 	if newMemByteLen <= uint64(runState.Memory.ByteLen()) {
 		return 0, nil
 	}
@@ -35,5 +107,314 @@ func expGasCost(runState *RunState) (uint64, error) {
 	if err != nil {
 		return 0, err
 	}
-	return uint64(exp.ByteLen()) * 50, nil
+	// exponent fits in 32 bytes, hence its bit length fits in uint64
+	bitLen := expBitLen(exp, new(big.Int)).Uint64()
+	return (bitLen + 7) / 8 * 50, nil
+}
+
+// Returns the bit length of the exponent, whose most significant 32 bytes
+// are the head, and which has the extra bytes after them. Extra bytes are
+// counted as 8 bits each, whatever their value (see EIP-2565).
+func expBitLen(head *uint256.Int, extraBytes *big.Int) *big.Int {
+	bitLen := new(big.Int).Lsh(extraBytes, 3)
+	return bitLen.Add(bitLen, big.NewInt(int64(head.BitLen())))
+}
+
+// Gas of the modular exponentiation precompile (see EIP-2565).
+// Input starts with the 32 bytes lengths of the base, exponent and
+// modulus, which are followed by the values themselves. Lengths can
+// be arbitrarily large, hence the gas saturates at max uint64.
+func modExpGasCost(input []byte) uint64 {
+	baseLen := new(big.Int).SetBytes(getPaddedSlice(input, 0, 32))
+	expLen := new(big.Int).SetBytes(getPaddedSlice(input, 32, 32))
+	modLen := new(big.Int).SetBytes(getPaddedSlice(input, 64, 32))
+	if len(input) > 96 {
+		input = input[96:]
+	} else {
+		input = nil
+	}
+
+	// exponent head is the most significant 32 bytes of the
+	// exponent, and it is read as the exponent of EXP operation
+	expHead := new(uint256.Int)
+	if baseLen.Cmp(big.NewInt(int64(len(input)))) < 0 {
+		headLen := uint64(32)
+		if expLen.Cmp(big.NewInt(32)) < 0 {
+			headLen = expLen.Uint64()
+		}
+		expHead.SetBytes(getPaddedSlice(input, baseLen.Uint64(), headLen))
+	}
+
+	// iteration count is the bit length of the exponent, minus one
+	// unless the head is zero, and it is at least one
+	extraBytes := new(big.Int)
+	if expLen.Cmp(big.NewInt(32)) > 0 {
+		extraBytes.Sub(expLen, big.NewInt(32))
+	}
+	iterCount := expBitLen(expHead, extraBytes)
+	if !expHead.IsZero() {
+		iterCount.Sub(iterCount, big.NewInt(1))
+	}
+	if iterCount.Sign() == 0 {
+		iterCount.SetUint64(1)
+	}
+
+	// multiplication complexity is the square of the number of
+	// 8 bytes words required to fit the longer of base and modulus
+	maxLen := baseLen
+	if modLen.Cmp(maxLen) > 0 {
+		maxLen = modLen
+	}
+	words := new(big.Int).Add(maxLen, big.NewInt(7))
+	words.Rsh(words, 3)
+	gas := new(big.Int).Mul(words, words)
+	gas.Mul(gas, iterCount)
+	gas.Div(gas, big.NewInt(3))
+
+	if !gas.IsUint64() {
+		return math.MaxUint64
+	}
+	if gas.Uint64() < 200 {
+		return 200
+	}
+	return gas.Uint64()
+}
+
+// Gas of the storage operations (see EIP-2200). Storing into a slot
+// is not allowed with the gas of a call stipend or less left.
+const (
+	sloadGas        uint64 = 800
+	sstoreSetGas    uint64 = 20000
+	sstoreResetGas  uint64 = 5000
+	sstoreSentryGas uint64 = 2300
+)
+
+// Returns the gas of storing the value into the slot with respect to
+// the original value of the slot, which is its value at the start of
+// the run (see EIP-2200). Slots which are already written in the run
+// only cost sloadGas.
+func sstoreGasCost(runState *RunState) (uint64, error) {
+	key, err1 := runState.Stack.peek(0)
+	val, err2 := runState.Stack.peek(1)
+	if err1 != nil || err2 != nil {
+		return 0, ErrStackUnderflow
+	}
+	if runState.RemainingGas <= sstoreSentryGas {
+		return 0, ErrOutOfGas
+	}
+	in := runState.interpreter
+	slot, value := Hash(key.Bytes32()), Hash(val.Bytes32())
+	current := in.state.GetState(runState.Address, slot)
+	if current == value {
+		return sloadGas, nil
+	}
+	original := in.originalState(runState.Address, slot)
+	if original == current {
+		if original == (Hash{}) {
+			return sstoreSetGas, nil
+		}
+		return sstoreResetGas, nil
+	}
+	return sloadGas, nil
+}
+
+// Updates the refund counter for storing the value into the slot
+// (see EIP-2200). Clearing a slot adds the refund of the fork, and
+// restoring the original value of a slot which is already written
+// in the run refunds the rest of its first write. It is called by
+// the operation, so that the refunds of the operations which run
+// out of gas are never counted.
+func sstoreRefund(runState *RunState, original, current, value Hash) error {
+	if current == value {
+		return nil
+	}
+	clearsRefund := runState.interpreter.fork.sstoreClearsRefund()
+	if original == current {
+		if original != (Hash{}) && value == (Hash{}) {
+			runState.addRefund(clearsRefund)
+		}
+		return nil
+	}
+	// slot is already written in the run
+	if original != (Hash{}) {
+		if current == (Hash{}) {
+			if err := runState.subRefund(clearsRefund); err != nil {
+				return err
+			}
+		} else if value == (Hash{}) {
+			runState.addRefund(clearsRefund)
+		}
+	}
+	if original == value {
+		if original == (Hash{}) {
+			runState.addRefund(sstoreSetGas - sloadGas)
+		} else {
+			runState.addRefund(sstoreResetGas - sloadGas)
+		}
+	}
+	return nil
+}
+
+// Constant gas of the call operations, which is
+// paid before calculating the gas sent to the callee
+const callConstGas uint64 = 700
+
+// Returns the memory expansion gas and the gas sent to the callee.
+// Callee can be given at most 63/64 of the remaining gas after
+// paying for the call itself (see EIP-150). Calculated callee gas
+// is saved in the run state, so that the call can use it later on.
+func staticCallGasCost(runState *RunState) (uint64, error) {
+	requestedGas, err1 := runState.Stack.peek(0)
+	argsOffset, err2 := runState.Stack.peek(2)
+	argsSize, err3 := runState.Stack.peek(3)
+	retOffset, err4 := runState.Stack.peek(4)
+	retSize, err5 := runState.Stack.peek(5)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil || err5 != nil {
+		return 0, ErrStackUnderflow
+	}
+	if !argsSize.IsUint64() || !retSize.IsUint64() {
+		return 0, ErrOutOfGas
+	}
+	argsByteLen, ok1 := memoryByteLen(argsOffset, argsSize.Uint64())
+	retByteLen, ok2 := memoryByteLen(retOffset, retSize.Uint64())
+	if !ok1 || !ok2 {
+		return 0, ErrOutOfGas
+	}
+	if retByteLen > argsByteLen {
+		argsByteLen = retByteLen
+	}
+	memGas, err := memoryGasCost(runState, argsByteLen)
+	if err != nil {
+		return 0, err
+	}
+
+	if runState.RemainingGas < callConstGas+memGas {
+		return 0, ErrOutOfGas
+	}
+	availableGas := runState.RemainingGas - callConstGas - memGas
+	availableGas -= availableGas / 64
+	if !requestedGas.IsUint64() || requestedGas.Uint64() > availableGas {
+		runState.CallGas = availableGas
+	} else {
+		runState.CallGas = requestedGas.Uint64()
+	}
+	return memGas + runState.CallGas, nil
+}
+
+func ecrecoverGasCost(input []byte) uint64 {
+	return 3000
+}
+
+func sha256GasCost(input []byte) uint64 {
+	return 60 + 12*toWordSize(uint64(len(input)))
+}
+
+func ripemd160GasCost(input []byte) uint64 {
+	return 600 + 120*toWordSize(uint64(len(input)))
+}
+
+func identityGasCost(input []byte) uint64 {
+	return 15 + 3*toWordSize(uint64(len(input)))
+}
+
+func ecAddGasCost(input []byte) uint64 {
+	return 150
+}
+
+func ecMulGasCost(input []byte) uint64 {
+	return 6000
+}
+
+func ecPairingGasCost(input []byte) uint64 {
+	return 45000 + 34000*uint64(len(input)/192)
+}
+
+// Gas of the BLAKE2 compression function is the number of rounds.
+// Input with an invalid length costs nothing, since it fails anyways.
+func blake2FGasCost(input []byte) uint64 {
+	if len(input) != blake2FInputLength {
+		return 0
+	}
+	return uint64(binary.BigEndian.Uint32(input[:4]))
+}
+
+func pointEvaluationGasCost(input []byte) uint64 {
+	return 50000
+}
+
+// Input lengths of the BLS12-381 precompiled contracts (see EIP-2537)
+const (
+	blsFieldElementLength = 64
+	blsG1PointLength      = 2 * blsFieldElementLength
+	blsG2PointLength      = 4 * blsFieldElementLength
+	blsScalarLength       = 32
+	blsG1MSMPairLength    = blsG1PointLength + blsScalarLength
+	blsG2MSMPairLength    = blsG2PointLength + blsScalarLength
+	blsPairingPairLength  = blsG1PointLength + blsG2PointLength
+)
+
+// Discounts of the multi scalar multiplications per number of pairs, in
+// permille. Inputs with more pairs than the tables get the last discount.
+var (
+	blsG1MSMDiscountTable = [128]uint64{
+		1000, 949, 848, 797, 764, 750, 738, 728, 719, 712, 705, 698, 692, 687, 682, 677,
+		673, 669, 665, 661, 658, 654, 651, 648, 645, 642, 640, 637, 635, 632, 630, 627,
+		625, 623, 621, 619, 617, 615, 613, 611, 609, 608, 606, 604, 603, 601, 599, 598,
+		596, 595, 593, 592, 591, 589, 588, 586, 585, 584, 582, 581, 580, 579, 577, 576,
+		575, 574, 573, 572, 570, 569, 568, 567, 566, 565, 564, 563, 562, 561, 560, 559,
+		558, 557, 556, 555, 554, 553, 552, 551, 550, 549, 548, 547, 547, 546, 545, 544,
+		543, 542, 541, 540, 540, 539, 538, 537, 536, 536, 535, 534, 533, 532, 532, 531,
+		530, 529, 528, 528, 527, 526, 525, 525, 524, 523, 522, 522, 521, 520, 520, 519,
+	}
+	blsG2MSMDiscountTable = [128]uint64{
+		1000, 1000, 923, 884, 855, 832, 812, 796, 782, 770, 759, 749, 740, 732, 724, 717,
+		711, 704, 699, 693, 688, 683, 679, 674, 670, 666, 663, 659, 655, 652, 649, 646,
+		643, 640, 637, 634, 632, 629, 627, 624, 622, 620, 618, 615, 613, 611, 609, 607,
+		606, 604, 602, 600, 598, 597, 595, 593, 592, 590, 589, 587, 586, 584, 583, 582,
+		580, 579, 578, 576, 575, 574, 573, 571, 570, 569, 568, 567, 566, 565, 563, 562,
+		561, 560, 559, 558, 557, 556, 555, 554, 553, 552, 552, 551, 550, 549, 548, 547,
+		546, 545, 545, 544, 543, 542, 541, 541, 540, 539, 538, 537, 537, 536, 535, 535,
+		534, 533, 532, 532, 531, 530, 530, 529, 528, 528, 527, 526, 526, 525, 524, 524,
+	}
+)
+
+func blsG1AddGasCost(input []byte) uint64 {
+	return 375
+}
+
+func blsG2AddGasCost(input []byte) uint64 {
+	return 600
+}
+
+func blsG1MSMGasCost(input []byte) uint64 {
+	return blsMSMGasCost(uint64(len(input)/blsG1MSMPairLength), 12000, &blsG1MSMDiscountTable)
+}
+
+func blsG2MSMGasCost(input []byte) uint64 {
+	return blsMSMGasCost(uint64(len(input)/blsG2MSMPairLength), 22500, &blsG2MSMDiscountTable)
+}
+
+// Gas of a multi scalar multiplication with k pairs is
+// k * multiplicationCost * discount(k) / 1000
+func blsMSMGasCost(k uint64, multiplicationCost uint64, discountTable *[128]uint64) uint64 {
+	if k == 0 {
+		return 0
+	}
+	discount := discountTable[len(discountTable)-1]
+	if k <= uint64(len(discountTable)) {
+		discount = discountTable[k-1]
+	}
+	return k * multiplicationCost * discount / 1000
+}
+
+func blsPairingGasCost(input []byte) uint64 {
+	return 37700 + 32600*uint64(len(input)/blsPairingPairLength)
+}
+
+func blsMapG1GasCost(input []byte) uint64 {
+	return 5500
+}
+
+func blsMapG2GasCost(input []byte) uint64 {
+	return 23800
 }
@@ -2,7 +2,11 @@ package space_evm
 
 import (
 	"fmt"
+	"math/big"
+	"strings"
 	"testing"
+
+	"github.com/holiman/uint256"
 )
 
 // stackValue in genRunState is the offset to read
@@ -33,9 +37,20 @@ var memoryExpansionGasCostTests = []genericTest{
 		exp: uint64(2045),
 	},
 	{
-		s:          "gas uint64 overflow",
+		s:   "gas for 0 to max memory",
+		in:  genRunState("", 0x52, []uint64{0x1FFFFFFFE0 - 32}, genZeroMem(0)),
+		exp: uint64(36028809887088637),
+	},
+	{
+		s:          "memory above max memory",
+		in:         genRunState("", 0x52, []uint64{0x1FFFFFFFE0 - 31}, genZeroMem(0)),
+		exp:        ErrOutOfGas,
+		shouldFail: true,
+	},
+	{
+		s:          "memory far above max memory",
 		in:         genRunState("", 0x52, []uint64{0x1FFFFFFFE0}, genZeroMem(0)),
-		exp:        ErrGasUintOverflow,
+		exp:        ErrOutOfGas,
 		shouldFail: true,
 	},
 }
@@ -57,6 +72,90 @@ func Test_Gas_MemoryExpansionGasCost(t *testing.T) {
 	}
 }
 
+// in is the 256-bit offset to read, and exp is the error,
+// since memory can never be expanded to such offsets
+var memoryExpansionGasCostOffsetOverflowTests = []genericTest{
+	{
+		s:   "mstore offset 2^64",
+		in:  []interface{}{byte(0x52), u256Hex("0x10000000000000000")},
+		exp: ErrOutOfGas,
+	},
+	{
+		s:   "mstore offset max256",
+		in:  []interface{}{byte(0x52), MaxUint256},
+		exp: ErrOutOfGas,
+	},
+	{
+		s:   "mstore offset max64 - 31",
+		in:  []interface{}{byte(0x52), u256(MaxUint64 - 31)},
+		exp: ErrOutOfGas,
+	},
+	{
+		s:   "mstore offset max64 - 32",
+		in:  []interface{}{byte(0x52), u256(MaxUint64 - 32)},
+		exp: ErrOutOfGas,
+	},
+	{
+		s:   "mstore8 offset 2^64",
+		in:  []interface{}{byte(0x53), u256Hex("0x10000000000000000")},
+		exp: ErrOutOfGas,
+	},
+	{
+		s:   "mstore8 offset max256",
+		in:  []interface{}{byte(0x53), MaxUint256},
+		exp: ErrOutOfGas,
+	},
+	{
+		s:   "mstore8 offset max64",
+		in:  []interface{}{byte(0x53), u256(MaxUint64)},
+		exp: ErrOutOfGas,
+	},
+}
+
+func Test_Gas_MemoryExpansionGasCostOffsetOverflow(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range memoryExpansionGasCostOffsetOverflowTests {
+		testIn := test.in.([]interface{})
+		runSt := genRunState("", testIn[0].(byte), []uint64{}, genZeroMem(0))
+		runSt.Stack.push(testIn[1].(*uint256.Int))
+		_, test.act = memoryExpansionGasCost(runSt)
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the offset and size, exp is the memory byte len
+// and whether it fits in uint64
+var memoryByteLenTests = []genericTest{
+	{s: "0 bytes at 0", in: []interface{}{u256(0), uint64(0)}, exp: []interface{}{uint64(0), true}},
+	{s: "32 bytes at 0", in: []interface{}{u256(0), uint64(32)}, exp: []interface{}{uint64(32), true}},
+	{s: "1 byte at 100", in: []interface{}{u256(100), uint64(1)}, exp: []interface{}{uint64(101), true}},
+	{s: "0 bytes at max256", in: []interface{}{MaxUint256, uint64(0)}, exp: []interface{}{uint64(0), true}},
+	{s: "1 byte at max64 - 1", in: []interface{}{u256(MaxUint64 - 1), uint64(1)}, exp: []interface{}{MaxUint64, true}},
+	{s: "1 byte at max64", in: []interface{}{u256(MaxUint64), uint64(1)}, exp: []interface{}{uint64(0), false}},
+	{s: "32 bytes at 2^64", in: []interface{}{u256Hex("0x10000000000000000"), uint64(32)}, exp: []interface{}{uint64(0), false}},
+	{s: "32 bytes at max256", in: []interface{}{MaxUint256, uint64(32)}, exp: []interface{}{uint64(0), false}},
+}
+
+func Test_Gas_MemoryByteLen(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range memoryByteLenTests {
+		testIn := test.in.([]interface{})
+		memByteLen, ok := memoryByteLen(testIn[0].(*uint256.Int), testIn[1].(uint64))
+		test.act = []interface{}{memByteLen, ok}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
 // stackValues first element is the exp
 var expGasCostTests = []genericTest{
 	{
@@ -99,3 +198,85 @@ func Test_Gas_ExpGasCost(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+// in is the [exponent head, extra bytes] and exp is the bit length
+var expBitLenTests = []genericTest{
+	{s: "zero exponent", in: []interface{}{u256(0), int64(0)}, exp: big.NewInt(0)},
+	{s: "1 bit exponent", in: []interface{}{u256(1), int64(0)}, exp: big.NewInt(1)},
+	{s: "256 bits exponent", in: []interface{}{MaxUint256, int64(0)}, exp: big.NewInt(256)},
+	{s: "zero head with extra bytes", in: []interface{}{u256(0), int64(2)}, exp: big.NewInt(16)},
+	{s: "head with extra bytes", in: []interface{}{u256(0xff), int64(2)}, exp: big.NewInt(24)},
+}
+
+func Test_Gas_ExpBitLen(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range expBitLenTests {
+		testIn := test.in.([]interface{})
+		test.act = expBitLen(testIn[0].(*uint256.Int), big.NewInt(testIn[1].(int64)))
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the lengths of base, exponent and modulus, followed by the
+// exponent head, and the base is always given as zero-length
+var modExpGasCostTests = []genericTest{
+	{s: "minimum gas", in: []interface{}{[]uint64{0, 1, 1}, "01"}, exp: uint64(200)},
+	{s: "zero exponent counts as 1 iteration", in: []interface{}{[]uint64{0, 1, 256}, "00"}, exp: uint64(341)},
+	{s: "1 byte exponent", in: []interface{}{[]uint64{0, 1, 256}, "ff"}, exp: uint64(2389)},
+	{s: "32 bytes exponent", in: []interface{}{[]uint64{0, 32, 64}, "ff" + strings.Repeat("00", 31)}, exp: uint64(5440)},
+	{s: "33 bytes exponent", in: []interface{}{[]uint64{0, 33, 64}, "ff" + strings.Repeat("00", 32)}, exp: uint64(5610)},
+	{s: "33 bytes zero exponent head", in: []interface{}{[]uint64{0, 33, 64}, strings.Repeat("00", 32) + "ff"}, exp: uint64(200)},
+	{s: "huge exponent length", in: []interface{}{[]uint64{0, 1 << 62, 64}, "01"}, exp: MaxUint64},
+	{s: "huge modulus length", in: []interface{}{[]uint64{0, 1, 1 << 40}, "02"}, exp: MaxUint64},
+}
+
+func Test_Gas_ModExpGasCost(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range modExpGasCostTests {
+		testIn := test.in.([]interface{})
+		input := []byte{}
+		for _, l := range testIn[0].([]uint64) {
+			b := u256(l).Bytes32()
+			input = append(input, b[:]...)
+		}
+		input = append(input, hexToBytes(testIn[1].(string))...)
+		test.act = modExpGasCost(input)
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the number of pairs and exp is the [G1 MSM gas, G2 MSM gas]
+var blsMSMGasCostTests = []genericTest{
+	{s: "no pairs", in: 0, exp: []uint64{0, 0}},
+	{s: "1 pair without discount", in: 1, exp: []uint64{12000, 22500}},
+	{s: "2 pairs", in: 2, exp: []uint64{22776, 45000}},
+	{s: "128 pairs", in: 128, exp: []uint64{797184, 1509120}},
+	{s: "more pairs than discount table", in: 200, exp: []uint64{1245600, 2358000}},
+}
+
+func Test_Gas_BlsMSMGasCost(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range blsMSMGasCostTests {
+		k := test.in.(int)
+		test.act = []uint64{
+			blsG1MSMGasCost(make([]byte, k*blsG1MSMPairLength)),
+			blsG2MSMGasCost(make([]byte, k*blsG2MSMPairLength)),
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
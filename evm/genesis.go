@@ -0,0 +1,176 @@
+package space_evm
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"space/database"
+
+	"github.com/holiman/uint256"
+)
+
+// Default gas limit, difficulty and base fee of the genesis
+// block, which are used if they are not set in the genesis file
+const genesisGasLimit uint64 = 4712388
+
+var (
+	genesisDifficulty = uint256.NewInt(131072)
+	genesisBaseFee    = uint256.NewInt(InitialBaseFee)
+)
+
+// Genesis is the geth-style genesis.json, which defines the initial
+// world state with its alloc section, and the fields of the genesis
+// block header. Chain config is ignored, since forks of the EVM are
+// chosen separately.
+type Genesis struct {
+	Nonce         HexOrDecimal64   `json:"nonce"`
+	Timestamp     HexOrDecimal64   `json:"timestamp"`
+	ExtraData     HexBytes         `json:"extraData"`
+	GasLimit      HexOrDecimal64   `json:"gasLimit"`
+	Difficulty    *HexOrDecimal256 `json:"difficulty"`
+	Mixhash       Hash             `json:"mixHash"`
+	Coinbase      Address          `json:"coinbase"`
+	Alloc         GenesisAlloc     `json:"alloc"`
+	Number        HexOrDecimal64   `json:"number"`
+	GasUsed       HexOrDecimal64   `json:"gasUsed"`
+	ParentHash    Hash             `json:"parentHash"`
+	BaseFee       *HexOrDecimal256 `json:"baseFeePerGas"`
+	ExcessBlobGas *HexOrDecimal64  `json:"excessBlobGas"`
+	BlobGasUsed   *HexOrDecimal64  `json:"blobGasUsed"`
+}
+
+// GenesisAccount is an account in the alloc section of the genesis
+type GenesisAccount struct {
+	Code    HexBytes         `json:"code,omitempty"`
+	Storage map[Hash]Hash    `json:"storage,omitempty"`
+	Balance *HexOrDecimal256 `json:"balance"`
+	Nonce   HexOrDecimal64   `json:"nonce,omitempty"`
+}
+
+// GenesisAlloc is the alloc section of the genesis,
+// which holds the initial accounts by their addresses
+type GenesisAlloc map[Address]GenesisAccount
+
+// Addresses of the alloc section can be given without the 0x prefix
+func (alloc *GenesisAlloc) UnmarshalJSON(input []byte) error {
+	var accounts map[string]GenesisAccount
+	if err := json.Unmarshal(input, &accounts); err != nil {
+		return err
+	}
+	*alloc = make(GenesisAlloc, len(accounts))
+	for key, account := range accounts {
+		if !strings.HasPrefix(key, "0x") {
+			key = "0x" + key
+		}
+		var addr Address
+		if err := addr.UnmarshalText([]byte(key)); err != nil {
+			return err
+		}
+		(*alloc)[addr] = account
+	}
+	return nil
+}
+
+// Reads the genesis from the given genesis.json file
+func LoadGenesis(path string) (*Genesis, error) {
+	buff, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	genesis := new(Genesis)
+	if err := json.Unmarshal(buff, genesis); err != nil {
+		return nil, fmt.Errorf("invalid genesis file %s: %w", path, err)
+	}
+	return genesis, nil
+}
+
+// Returns the world state with the accounts of the alloc section
+func (g *Genesis) ToState() *StateDB {
+	state := NewStateDB()
+	for addr, account := range g.Alloc {
+		state.SetNonce(addr, uint64(account.Nonce))
+		if account.Balance != nil {
+			state.SetBalance(addr, (*uint256.Int)(account.Balance))
+		}
+		state.SetCode(addr, account.Code)
+		for slot, value := range account.Storage {
+			state.SetState(addr, slot, value)
+		}
+	}
+	return state
+}
+
+// Returns the header of the genesis block, whose root is
+// the state root of the accounts of the alloc section
+func (g *Genesis) ToHeader() *Header {
+	_, header := g.ToBlock()
+	return header
+}
+
+// Returns the world state of the alloc section with the header of the
+// genesis block, whose root is taken from the state built only once
+func (g *Genesis) ToBlock() (*StateDB, *Header) {
+	state := g.ToState()
+	return state, g.header(state.IntermediateRoot())
+}
+
+func (g *Genesis) header(root Hash) *Header {
+	header := &Header{
+		ParentHash:  g.ParentHash,
+		UncleHash:   EmptyUncleHash,
+		Coinbase:    g.Coinbase,
+		Root:        root,
+		TxHash:      EmptyRootHash,
+		ReceiptHash: EmptyRootHash,
+		Number:      uint64(g.Number),
+		GasLimit:    uint64(g.GasLimit),
+		GasUsed:     uint64(g.GasUsed),
+		Time:        uint64(g.Timestamp),
+		Extra:       g.ExtraData,
+		MixDigest:   g.Mixhash,
+	}
+	binary.BigEndian.PutUint64(header.Nonce[:], uint64(g.Nonce))
+	if header.GasLimit == 0 {
+		header.GasLimit = genesisGasLimit
+	}
+	if g.Difficulty != nil {
+		header.Difficulty = new(uint256.Int).Set((*uint256.Int)(g.Difficulty))
+	} else if g.Mixhash == (Hash{}) {
+		header.Difficulty = new(uint256.Int).Set(genesisDifficulty)
+	}
+	if g.BaseFee != nil {
+		header.BaseFee = new(uint256.Int).Set((*uint256.Int)(g.BaseFee))
+	}
+	// blob fields come after the fields of the earlier forks,
+	// hence those fields are also set to their defaults
+	if g.ExcessBlobGas != nil || g.BlobGasUsed != nil {
+		if header.BaseFee == nil {
+			header.BaseFee = new(uint256.Int).Set(genesisBaseFee)
+		}
+		withdrawalsHash, parentBeaconRoot := EmptyRootHash, Hash{}
+		header.WithdrawalsHash, header.ParentBeaconRoot = &withdrawalsHash, &parentBeaconRoot
+		excessBlobGas, blobGasUsed := uint64(0), uint64(0)
+		if g.ExcessBlobGas != nil {
+			excessBlobGas = uint64(*g.ExcessBlobGas)
+		}
+		if g.BlobGasUsed != nil {
+			blobGasUsed = uint64(*g.BlobGasUsed)
+		}
+		header.ExcessBlobGas, header.BlobGasUsed = &excessBlobGas, &blobGasUsed
+	}
+	return header
+}
+
+// Writes the genesis state into the writer, and returns
+// the genesis state with the header of the genesis block
+func (g *Genesis) Commit(writer database.KeyValueWriter) (*StateDB, *Header, error) {
+	state := g.ToState()
+	root, err := state.Commit(writer)
+	if err != nil {
+		return nil, nil, err
+	}
+	return state, g.header(root), nil
+}
@@ -0,0 +1,127 @@
+package space_evm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"space/database"
+
+	"github.com/holiman/uint256"
+)
+
+// State root and block hash of the genesis of the Ethereum mainnet,
+// whose alloc is in the fixture, as they are published by the clients
+var (
+	mainnetGenesisRoot = BytesToHash(hexToBytes("d7f8974fb5ac78d9ac099b9ad5018bedc2ce0a72dad1827a1709da30580f0544"))
+	mainnetGenesisHash = BytesToHash(hexToBytes("d4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3"))
+)
+
+func Test_Genesis_Mainnet(t *testing.T) {
+	genesis, err := LoadGenesis("testdata/mainnet_genesis.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := genesis.ToHeader()
+
+	test := genericTest{
+		s:   "mainnet genesis state root and hash",
+		exp: []interface{}{8893, mainnetGenesisRoot, mainnetGenesisHash},
+		act: []interface{}{len(genesis.Alloc), header.Root, header.Hash()},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+func Test_Genesis_Load(t *testing.T) {
+	genesis, err := LoadGenesis("testdata/genesis.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, header := genesis.ToBlock()
+
+	db := database.NewMemoryDB()
+	_, committedHeader, err := genesis.Commit(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := db.Get(keccak256(hexToBytes("6001600201")))
+
+	test := genericTest{
+		s: "load genesis file",
+		exp: []interface{}{
+			uint64(1), u256Hex("0x3635c9adc5dea00000"), hexToBytes("6001600201"), BytesToHash([]byte{0x2a}), u256(5),
+			state.IntermediateRoot(), uint64(30000000), uint64(1700000000), [8]byte{0, 0, 0, 0, 0, 0, 0, 0x42},
+			header.Hash(), header.Hash(), hexToBytes("6001600201"),
+		},
+		act: []interface{}{
+			state.GetNonce(testAddr1), state.GetBalance(testAddr1), state.GetCode(testAddr2),
+			state.GetState(testAddr2, BytesToHash([]byte{1})), state.GetBalance(BytesToAddress([]byte{0xcc})),
+			header.Root, header.GasLimit, header.Time, header.Nonce,
+			genesis.ToHeader().Hash(), committedHeader.Hash(), code,
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+var nilU256 = (*uint256.Int)(nil)
+
+// in is the genesis JSON, and exp is the [gas limit, difficulty,
+// base fee, withdrawals hash, excess blob gas] of the header or the error
+var genesisHeaderTests = []genericTest{
+	{
+		s:   "defaults",
+		in:  `{"alloc": {}}`,
+		exp: []interface{}{uint64(4712388), u256(131072), nilU256, (*Hash)(nil), (*uint64)(nil)},
+	},
+	{
+		s:   "no default difficulty with mix hash",
+		in:  `{"mixHash": "0x0000000000000000000000000000000000000000000000000000000000000001", "gasLimit": 8000000}`,
+		exp: []interface{}{uint64(8000000), nilU256, nilU256, (*Hash)(nil), (*uint64)(nil)},
+	},
+	{
+		s:   "base fee",
+		in:  `{"baseFeePerGas": "0x7"}`,
+		exp: []interface{}{uint64(4712388), u256(131072), u256(7), (*Hash)(nil), (*uint64)(nil)},
+	},
+	{
+		s:   "blob fields",
+		in:  `{"excessBlobGas": "0x20000"}`,
+		exp: []interface{}{uint64(4712388), u256(131072), u256(1000000000), &EmptyRootHash, &[]uint64{0x20000}[0]},
+	},
+	{s: "invalid balance", in: `{"alloc": {"aa": {"balance": "1e18"}}}`, exp: ErrInvalidHexOrDecimal},
+	{s: "negative balance", in: `{"alloc": {"aa": {"balance": "-1"}}}`, exp: ErrInvalidHexOrDecimal},
+	{s: "invalid address", in: `{"alloc": {"0xaa": {"balance": "1"}}}`, exp: ErrInvalidHexString},
+	{s: "invalid storage value", in: `{"alloc": {"0x00000000000000000000000000000000000000aa": {"storage": {"0x01": "0x01"}}}}`, exp: ErrInvalidHexString},
+}
+
+func Test_Genesis_Header(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range genesisHeaderTests {
+		var genesis Genesis
+		err := json.Unmarshal([]byte(test.in.(string)), &genesis)
+		if expErr, ok := test.exp.(error); ok {
+			test.act = err
+			if errors.Is(err, expErr) {
+				test.act = expErr
+			}
+		} else {
+			header := genesis.ToHeader()
+			test.act = []interface{}{header.GasLimit, header.Difficulty, header.BaseFee, header.WithdrawalsHash, header.ExcessBlobGas}
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
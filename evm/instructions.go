@@ -1,5 +1,9 @@
 package space_evm
 
+import (
+	"github.com/holiman/uint256"
+)
+
 func opAdd(runState *RunState) error {
 	x, err1 := runState.Stack.pop()
 	y, err2 := runState.Stack.peek(0)
@@ -69,6 +73,11 @@ func opMStore(runState *RunState) error {
 		// hence it is ok to return the last error
 		return err2
 	}
+	// offsets above uint64 are already rejected while
+	// calculating the gas, but never truncate them here
+	if !offset.IsUint64() {
+		return ErrOutOfGas
+	}
 	runState.Memory.store32(offset.Uint64(), val)
 	return nil
 }
@@ -79,6 +88,173 @@ func opMStore8(runState *RunState) error {
 	if err1 != nil || err2 != nil {
 		return err2
 	}
+	if !offset.IsUint64() {
+		return ErrOutOfGas
+	}
 	runState.Memory.store1(offset.Uint64(), byte(val.Uint64()))
 	return nil
 }
+
+// Replaces the slot on top of the stack with its value
+// in the storage of the account whose code is run
+func opSLoad(runState *RunState) error {
+	slot, err := runState.Stack.peek(0)
+	if err != nil {
+		return err
+	}
+	value := runState.interpreter.state.GetState(runState.Address, slot.Bytes32())
+	slot.SetBytes32(value.Bytes())
+	return nil
+}
+
+// Stores the value into the storage slot of the account whose code is
+// run, and updates the refund counter with respect to the original
+// value of the slot. It is not allowed in the callees of STATICCALL.
+func opSStore(runState *RunState) error {
+	if runState.interpreter.readOnly {
+		return ErrWriteProtection
+	}
+	key, err1 := runState.Stack.pop()
+	val, err2 := runState.Stack.pop()
+	if err1 != nil || err2 != nil {
+		return ErrStackUnderflow
+	}
+	in := runState.interpreter
+	addr, slot, value := runState.Address, Hash(key.Bytes32()), Hash(val.Bytes32())
+	err := sstoreRefund(runState, in.originalState(addr, slot), in.state.GetState(addr, slot), value)
+	if err != nil {
+		return err
+	}
+	in.setState(addr, slot, value)
+	return nil
+}
+
+func opStaticCall(runState *RunState) error {
+	// requested gas is ignored, since the gas to send to
+	// the callee is already calculated by the gas handler
+	vals, err := runState.Stack.popN(6)
+	if err != nil {
+		return err
+	}
+	addr := BytesToAddress(vals[1].Bytes())
+	argsOffset, argsSize := vals[2].Uint64(), vals[3].Uint64()
+	retOffset, retSize := vals[4].Uint64(), vals[5].Uint64()
+
+	// memory is expanded to fit both the args and the
+	// return data, regardless of the call result
+	args := runState.Memory.load(argsOffset, argsSize)
+	if retSize > 0 {
+		runState.Memory.extend(retOffset + retSize)
+	}
+	// callee can not modify the state, nor emit logs
+	in := runState.interpreter
+	readOnly := in.readOnly
+	in.readOnly = true
+	ret, remainingGas, refund, err := in.call(addr, args, runState.CallGas)
+	in.readOnly = readOnly
+	// unused gas of the callee is given back to the caller
+	runState.RemainingGas += remainingGas
+	runState.ConsumedGas -= remainingGas
+	runState.RefundCounter += refund
+
+	// output of the callee is copied even if it reverts
+	success := uint256.NewInt(0)
+	if err == nil {
+		success.SetOne()
+	}
+	if err == nil || err == ErrExecutionReverted {
+		if uint64(len(ret)) < retSize {
+			retSize = uint64(len(ret))
+		}
+		runState.Memory.store(retOffset, ret[:retSize])
+	}
+	return runState.Stack.push(success)
+}
+
+// Emits a log with the memory from offset to offset + size as
+// its data, and with the number of topics given by the opcode
+func opLog(runState *RunState) error {
+	if runState.interpreter.readOnly {
+		return ErrWriteProtection
+	}
+	// log opcodes are in range 0xa0 to 0xa4, hence
+	// (opcode - 0xa0) gives the number of topics
+	n := int(runState.Opcode) - 0xa0
+	vals, err := runState.Stack.popN(2 + n)
+	if err != nil {
+		return err
+	}
+	topics := make([]Hash, n)
+	for i := range topics {
+		topics[i] = vals[2+i].Bytes32()
+	}
+	log := &Log{
+		Address: runState.Address,
+		Topics:  topics,
+		Data:    runState.Memory.load(vals[0].Uint64(), vals[1].Uint64()),
+	}
+	runState.interpreter.logs = append(runState.interpreter.logs, log)
+	return nil
+}
+
+// Halts the execution with the memory from offset
+// to offset + size as the output of the frame
+func opReturn(runState *RunState) error {
+	vals, err := runState.Stack.popN(2)
+	if err != nil {
+		return err
+	}
+	runState.ReturnData = runState.Memory.load(vals[0].Uint64(), vals[1].Uint64())
+	return errStopToken
+}
+
+// Halts the execution with the memory from offset to offset + size
+// as the output of the frame, and reverts the changes of the frame
+func opRevert(runState *RunState) error {
+	vals, err := runState.Stack.popN(2)
+	if err != nil {
+		return err
+	}
+	runState.ReturnData = runState.Memory.load(vals[0].Uint64(), vals[1].Uint64())
+	return ErrExecutionReverted
+}
+
+// Replaces the index on top of the stack with the versioned hash of
+// the blob at the index, or zero if the transaction has no such blob
+func opBlobHash(runState *RunState) error {
+	index, err := runState.Stack.peek(0)
+	if err != nil {
+		return err
+	}
+	blobHashes := runState.interpreter.txCtx.BlobHashes
+	if index.IsUint64() && index.Uint64() < uint64(len(blobHashes)) {
+		index.SetBytes32(blobHashes[index.Uint64()].Bytes())
+	} else {
+		index.Clear()
+	}
+	return nil
+}
+
+func opTimestamp(runState *RunState) error {
+	return runState.Stack.push(uint256.NewInt(runState.interpreter.blockCtx.Time))
+}
+
+func opNumber(runState *RunState) error {
+	return runState.Stack.push(uint256.NewInt(runState.interpreter.blockCtx.Number))
+}
+
+func opBaseFee(runState *RunState) error {
+	baseFee := new(uint256.Int)
+	if fee := runState.interpreter.blockCtx.BaseFee; fee != nil {
+		baseFee.Set(fee)
+	}
+	return runState.Stack.push(baseFee)
+}
+
+func opBlobBaseFee(runState *RunState) error {
+	blobBaseFee := new(uint256.Int)
+	if fee := runState.interpreter.blockCtx.BlobBaseFee; fee != nil {
+		blobBaseFee.Set(fee)
+	}
+	return runState.Stack.push(blobBaseFee)
+}
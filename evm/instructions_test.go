@@ -199,6 +199,37 @@ var opMStoreTests = []genericTest{
 	},
 }
 
+// in is the 256-bit offset to store at
+var opMStoreOffsetOverflowTests = []genericTest{
+	{s: "mstore at 2^64", in: u256Hex("0x10000000000000000"), exp: ErrOutOfGas},
+	{s: "mstore at max256", in: MaxUint256, exp: ErrOutOfGas},
+}
+
+func Test_Op_MStoreOffsetOverflow(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range opMStoreOffsetOverflowTests {
+		for _, opcode := range []byte{0x52, 0x53} {
+			runSt := genRunState("", opcode, []uint64{1}, genZeroMem(0))
+			runSt.Stack.push(test.in.(*uint256.Int))
+			if opcode == 0x52 {
+				test.act = opMStore(runSt)
+			} else {
+				test.act = opMStore8(runSt)
+			}
+			// memory must not be touched
+			if runSt.Memory.ByteLen() != 0 {
+				test.act = runSt.Memory
+			}
+			msg, failed := test.Check()
+			anyTestFailed = anyTestFailed || failed
+			fmt.Print(msg)
+		}
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
 func Test_Op_MStore(t *testing.T) {
 	anyTestFailed := false
 	for _, test := range opMStoreTests {
@@ -238,4 +269,80 @@ func Test_Op_MStore8(t *testing.T) {
 	if anyTestFailed {
 		t.FailNow()
 	}
-}
\ No newline at end of file
+}
+var testBlobHashes = []Hash{
+	BytesToHash(hexToBytes("01e798154708fe7789429634053cbf9f99b619f9f084048927333fce637f549b")),
+	BytesToHash(hexToBytes("01a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")),
+}
+
+// in is the blob index and exp is the value pushed to the stack
+var opBlobHashTests = []genericTest{
+	{s: "first blob", in: u256(0), exp: u256Hex("0x1e798154708fe7789429634053cbf9f99b619f9f084048927333fce637f549b")},
+	{s: "second blob", in: u256(1), exp: u256Hex("0x1a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")},
+	{s: "out of range index", in: u256(2), exp: u256(0)},
+	{s: "index not fitting in uint64", in: MaxUint256, exp: u256(0)},
+}
+
+func Test_Op_BlobHash(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range opBlobHashTests {
+		runSt := genRunState("", 0x49, nil, nil)
+		runSt.interpreter = NewInterpreter(Saturn)
+		runSt.interpreter.txCtx = TxContext{BlobHashes: testBlobHashes}
+		runSt.Stack.push(new(uint256.Int).Set(test.in.(*uint256.Int)))
+		opBlobHash(runSt)
+		test.act, _ = runSt.Stack.peek(0)
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the base fee of the block and exp is the value pushed to the stack
+var opBaseFeeTests = []genericTest{
+	{s: "base fee", in: u256(1000000000), exp: u256(1000000000)},
+	{s: "unset base fee is zero", in: (*uint256.Int)(nil), exp: u256(0)},
+}
+
+func Test_Op_BaseFee(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range opBaseFeeTests {
+		runSt := genRunState("", 0x48, nil, nil)
+		runSt.interpreter = NewInterpreter(Moon)
+		runSt.interpreter.blockCtx = BlockContext{BaseFee: test.in.(*uint256.Int)}
+		opBaseFee(runSt)
+		test.act, _ = runSt.Stack.peek(0)
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the blob base fee of the block and exp is the value pushed to the stack
+var opBlobBaseFeeTests = []genericTest{
+	{s: "blob base fee", in: u256(17), exp: u256(17)},
+	{s: "unset blob base fee is zero", in: (*uint256.Int)(nil), exp: u256(0)},
+}
+
+func Test_Op_BlobBaseFee(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range opBlobBaseFeeTests {
+		runSt := genRunState("", 0x4a, nil, nil)
+		runSt.interpreter = NewInterpreter(Saturn)
+		runSt.interpreter.blockCtx = BlockContext{BlobBaseFee: test.in.(*uint256.Int)}
+		opBlobBaseFee(runSt)
+		test.act, _ = runSt.Stack.peek(0)
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
@@ -14,8 +14,13 @@ type RunState struct {
 	HighestMemoryGasCost uint64
 	RemainingGas         uint64
 	ConsumedGas          uint64
+	RefundCounter        uint64
+	CallGas              uint64
 	ProgramCounter       int
 	Opcode               byte
+	Address              Address
+	ReturnData           []byte
+	interpreter          *Interpreter
 }
 
 func NewRunState(code []byte, gasLimit uint64) *RunState {
@@ -48,11 +53,28 @@ func (runSt *RunState) useGas(gas uint64) bool {
 	return true
 }
 
+// Refund counter is only applied at the end of a successful
+// execution, and it is capped with respect to the used gas
+func (runSt *RunState) addRefund(gas uint64) {
+	runSt.RefundCounter += gas
+}
+
+func (runSt *RunState) subRefund(gas uint64) error {
+	if gas > runSt.RefundCounter {
+		return ErrRefundCounterUnderflow
+	}
+	runSt.RefundCounter -= gas
+	return nil
+}
+
 // RunResult is used to track and display the result of the interpreter run
 type RunResult struct {
 	HashedMemory []byte
-	ConsumedGas  uint64
-	GasRefund    uint64
+	GasUsed      uint64
+	GasRemaining uint64
+	GasRefunded  uint64
+	StateRoot    Hash
+	StorageRoots map[Address]Hash
 	EvmError     error
 }
 
@@ -60,12 +82,35 @@ func NewRunResult() *RunResult {
 	return &RunResult{}
 }
 
-func (res *RunResult) setResult(runState *RunState) {
-	res.ConsumedGas = runState.ConsumedGas
-	res.GasRefund = runState.RemainingGas
+// Refund is only applied if the execution is successful, and
+// it can be at most 1/refundQuotient of the used gas
+func (res *RunResult) setResult(runState *RunState, refundQuotient uint64) {
+	res.GasUsed = runState.ConsumedGas
+	res.GasRemaining = runState.RemainingGas
+	if res.EvmError == nil {
+		refund := runState.RefundCounter
+		if maxRefund := res.GasUsed / refundQuotient; refund > maxRefund {
+			refund = maxRefund
+		}
+		res.GasUsed -= refund
+		res.GasRemaining += refund
+		res.GasRefunded = refund
+	}
 	res.HashedMemory = keccak256([]byte(*runState.Memory))
 }
 
+// State root and the storage roots of the accounts are computed
+// after the execution, regardless of whether it is successful
+func (res *RunResult) setRoots(state *StateDB) {
+	res.StateRoot = state.IntermediateRoot()
+	for _, addr := range state.Addresses() {
+		if res.StorageRoots == nil {
+			res.StorageRoots = make(map[Address]Hash)
+		}
+		res.StorageRoots[addr] = state.StorageRoot(addr)
+	}
+}
+
 func (res *RunResult) setError(err error) {
 	res.EvmError = errors.New("evm error: " + err.Error())
 }
@@ -74,11 +119,22 @@ func (res *RunResult) Display() {
 	fmt.Println("--------------------------------------------------")
 	if res.EvmError == nil {
 		fmt.Printf("%-22s%v\n", "Memory Keccak256:", hex.EncodeToString(res.HashedMemory))
-		fmt.Printf("%-22s%v\n", "Total Gas Consumed:", res.ConsumedGas)
-		fmt.Printf("%-22s%v\n", "Gas Refund:", res.GasRefund)
+		fmt.Printf("%-22s%v\n", "Gas Used:", res.GasUsed)
+		fmt.Printf("%-22s%v\n", "Gas Remaining:", res.GasRemaining)
+		fmt.Printf("%-22s%v\n", "Gas Refunded:", res.GasRefunded)
 	} else {
 		fmt.Println(res.EvmError)
 	}
+	fmt.Printf("%-22s%v\n", "State Root:", hex.EncodeToString(res.StateRoot[:]))
+	addrs := make([]Address, 0, len(res.StorageRoots))
+	for addr := range res.StorageRoots {
+		addrs = append(addrs, addr)
+	}
+	sortAddresses(addrs)
+	for _, addr := range addrs {
+		root := res.StorageRoots[addr]
+		fmt.Printf("%-22s%v %v\n", "Storage Root:", addr.Hex(), hex.EncodeToString(root[:]))
+	}
 	fmt.Println("--------------------------------------------------")
 }
 
@@ -86,67 +142,178 @@ func (res *RunResult) Display() {
 // given gasLimit with respect to instruction set it holds. Instruction set
 // is automatically determined by the selected fork of the EVM.
 type Interpreter struct {
-	runState  *RunState
-	runResult *RunResult
-	jumpTable *JumpTable
+	fork        EVMFork
+	runState    *RunState
+	runResult   *RunResult
+	jumpTable   *JumpTable
+	precompiles Precompiles
+	state       *StateDB
+	original    map[storageKey]Hash
+	depth       int
+	readOnly    bool
+	logs        []*Log
+	txCtx       TxContext
+	blockCtx    BlockContext
 }
 
 func NewInterpreter(fork EVMFork) *Interpreter {
 	var jumpTable *JumpTable
+	var precompiles Precompiles
 
 	switch fork {
 	case Moon:
 		jumpTable = newMoonInstructionSet()
+		precompiles = newMoonPrecompiles()
+	case Mars:
+		jumpTable = newMarsInstructionSet()
+		precompiles = newMarsPrecompiles()
+	case Jupiter:
+		jumpTable = newJupiterInstructionSet()
+		precompiles = newJupiterPrecompiles()
+	case Saturn:
+		jumpTable = newSaturnInstructionSet()
+		precompiles = newSaturnPrecompiles()
 	default:
 		jumpTable = newMoonInstructionSet()
+		precompiles = newMoonPrecompiles()
 	}
 
 	return &Interpreter{
-		jumpTable: jumpTable,
+		fork:        fork,
+		jumpTable:   jumpTable,
+		precompiles: precompiles,
+		state:       NewStateDB(),
+	}
+}
+
+// Maximum depth of the nested calls
+const maxCallDepth = 1024
+
+// Calls the given address with the input and gas, and returns the
+// output with the remaining gas and the refund counter. Precompiled
+// contracts are run natively, and the code of the other accounts is
+// run in a new frame. Calls to accounts without code succeed without
+// running anything. Failed calls consume all of their gas, unless they
+// revert.
+func (in *Interpreter) call(addr Address, input []byte, gas uint64) ([]byte, uint64, uint64, error) {
+	if p, ok := in.precompiles[addr]; ok {
+		ret, remainingGas, err := runPrecompile(p, input, gas)
+		return ret, remainingGas, 0, err
+	}
+	code := in.state.GetCode(addr)
+	if len(code) == 0 {
+		return nil, gas, 0, nil
+	}
+	return in.runFrame(addr, code, gas)
+}
+
+// Runs the code of the account at addr in a new frame. Logs of
+// the frame are discarded if it fails, and the refund counter
+// is returned only if it succeeds. Frames which revert return
+// their output and their remaining gas with the error.
+func (in *Interpreter) runFrame(addr Address, code []byte, gas uint64) ([]byte, uint64, uint64, error) {
+	if in.depth >= maxCallDepth {
+		return nil, gas, 0, ErrMaxCallDepth
+	}
+
+	callerState, logs := in.runState, len(in.logs)
+	in.runState = NewRunState(code, gas)
+	in.runState.interpreter = in
+	in.runState.Address = addr
+	in.depth++
+	err := in.execute(in.runState)
+	calleeState := in.runState
+	in.runState = callerState
+	in.depth--
+
+	if err != nil {
+		in.logs = in.logs[:logs]
+		if err == ErrExecutionReverted {
+			return calleeState.ReturnData, calleeState.RemainingGas, 0, err
+		}
+		return nil, 0, 0, err
+	}
+	return calleeState.ReturnData, calleeState.RemainingGas, calleeState.RefundCounter, nil
+}
+
+// storageKey is a storage slot of an account
+type storageKey struct {
+	addr Address
+	slot Hash
+}
+
+// Returns the original value of the storage slot, which is its value
+// at the start of the run or of the transaction (see EIP-2200). Values
+// of the slots are recorded as original before their first write.
+func (in *Interpreter) originalState(addr Address, slot Hash) Hash {
+	if value, ok := in.original[storageKey{addr, slot}]; ok {
+		return value
 	}
+	return in.state.GetState(addr, slot)
+}
+
+// Stores the value into the storage slot, and records the
+// original value of the slot if it is its first write
+func (in *Interpreter) setState(addr Address, slot, value Hash) {
+	key := storageKey{addr, slot}
+	if _, ok := in.original[key]; !ok {
+		in.original[key] = in.state.GetState(addr, slot)
+	}
+	in.state.SetState(addr, slot, value)
 }
 
 func (in *Interpreter) Run(code []byte, gasLimit uint64) *RunResult {
 	in.runState = NewRunState(code, gasLimit)
+	in.runState.interpreter = in
 	in.runResult = NewRunResult()
-	// Main execution loop of interpreter. Continues
-	// until encountering end of the code or error.
+	in.logs = nil
+	in.original = make(map[storageKey]Hash)
+	if err := in.execute(in.runState); err != nil {
+		in.runResult.setError(err)
+	}
+	in.runResult.setResult(in.runState, in.fork.maxRefundQuotient())
+	in.runResult.setRoots(in.state)
+	return in.runResult
+}
+
+// Main execution loop of interpreter. Continues
+// until encountering end of the code or error.
+func (in *Interpreter) execute(runState *RunState) error {
+	code := runState.Code
 	for pc := 0; pc < len(code); {
 		opcode := code[pc]
 		opInfo := in.jumpTable.getOpInfo(opcode)
 		// return error if opcode invalid
 		if opInfo.handler == nil {
-			in.runResult.setError(ErrInvalidOpcode(opcode))
-			break
+			return ErrInvalidOpcode(opcode)
 		}
-		in.runState.Opcode = opcode
-		in.runState.ProgramCounter += 1
+		runState.Opcode = opcode
+		runState.ProgramCounter += 1
 
 		gas := opInfo.constGas
 		if opInfo.dynGasHandler != nil {
 			// dynamic gas handlers can return some
 			// of the errors prior to execution
-			dynGas, err := opInfo.dynGasHandler(in.runState)
+			dynGas, err := opInfo.dynGasHandler(runState)
 			if err != nil {
-				in.runResult.setError(err)
-				break
+				return err
 			}
 			gas += dynGas
 		}
 		// use gas, return error if not enough gas
-		if !in.runState.useGas(gas) {
-			in.runResult.setError(ErrOutOfGas)
-			break
+		if !runState.useGas(gas) {
+			return ErrOutOfGas
 		}
 
-		// execute operation
-		err := opInfo.handler(in.runState)
-		if err != nil {
-			in.runResult.setError(err)
-			break
+		// execute operation, stop token
+		// halts the execution successfully
+		if err := opInfo.handler(runState); err != nil {
+			if err == errStopToken {
+				return nil
+			}
+			return err
 		}
-		pc = in.runState.ProgramCounter
+		pc = runState.ProgramCounter
 	}
-	in.runResult.setResult(in.runState)
-	return in.runResult
+	return nil
 }
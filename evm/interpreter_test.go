@@ -3,6 +3,7 @@ package space_evm
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -16,8 +17,9 @@ var interpreterRunTests = []genericTest{
 		exp: interpreterRunTestExp{
 			runRes: &RunResult{
 				HashedMemory: hexToBytes("ab2744998886b708acadc0a32428d0aa1953e83924383d21c6de5dac852ccbcc"),
-				ConsumedGas:  538445872,
-				GasRefund:    MaxUint64 - 538445872,
+				GasUsed:      538445872,
+				GasRemaining: MaxUint64 - 538445872,
+				StateRoot:    EmptyRootHash,
 			},
 			stack: &Stack{},
 		},
@@ -31,8 +33,9 @@ var interpreterRunTests = []genericTest{
 		exp: interpreterRunTestExp{
 			runRes: &RunResult{
 				HashedMemory: hexToBytes("b9a07dba38aa24923a611fced9d2eede3bfbfa281e5e498d60f4bd99e5ce6a15"),
-				ConsumedGas:  58,
-				GasRefund:    MaxUint64 - 58,
+				GasUsed:      58,
+				GasRemaining: MaxUint64 - 58,
+				StateRoot:    EmptyRootHash,
 			},
 			stack: &Stack{},
 		},
@@ -46,8 +49,9 @@ var interpreterRunTests = []genericTest{
 		exp: interpreterRunTestExp{
 			runRes: &RunResult{
 				HashedMemory: hexToBytes("afe1e714d2cd3ed5b0fa0a04ee95cd564b955ab8661c5665588758b48b66e263"),
-				ConsumedGas:  4875,
-				GasRefund:    MaxUint64 - 4875,
+				GasUsed:      4875,
+				GasRemaining: MaxUint64 - 4875,
+				StateRoot:    EmptyRootHash,
 			},
 			stack: &Stack{},
 		},
@@ -61,8 +65,9 @@ var interpreterRunTests = []genericTest{
 		exp: interpreterRunTestExp{
 			runRes: &RunResult{
 				HashedMemory: EmptyMemHash,
-				ConsumedGas:  3,
-				GasRefund:    MaxUint64 - 3,
+				GasUsed:      3,
+				GasRemaining: MaxUint64 - 3,
+				StateRoot:    EmptyRootHash,
 			},
 			stack: &Stack{*u256(1)},
 		},
@@ -76,8 +81,9 @@ var interpreterRunTests = []genericTest{
 		exp: interpreterRunTestExp{
 			runRes: &RunResult{
 				HashedMemory: EmptyMemHash,
-				ConsumedGas:  3,
-				GasRefund:    MaxUint64 - 3,
+				GasUsed:      3,
+				GasRemaining: MaxUint64 - 3,
+				StateRoot:    EmptyRootHash,
 			},
 			stack: &Stack{*u256(1)},
 		},
@@ -91,8 +97,9 @@ var interpreterRunTests = []genericTest{
 		exp: interpreterRunTestExp{
 			runRes: &RunResult{
 				HashedMemory: EmptyMemHash,
-				ConsumedGas:  3,
-				GasRefund:    MaxUint64 - 3,
+				GasUsed:      3,
+				GasRemaining: MaxUint64 - 3,
+				StateRoot:    EmptyRootHash,
 			},
 			stack: &Stack{*u256(1)},
 		},
@@ -106,8 +113,9 @@ var interpreterRunTests = []genericTest{
 		exp: interpreterRunTestExp{
 			runRes: &RunResult{
 				HashedMemory: EmptyMemHash,
-				ConsumedGas:  3,
-				GasRefund:    MaxUint64 - 3,
+				GasUsed:      3,
+				GasRemaining: MaxUint64 - 3,
+				StateRoot:    EmptyRootHash,
 			},
 			stack: &Stack{*u256(1)},
 		},
@@ -121,8 +129,9 @@ var interpreterRunTests = []genericTest{
 		exp: interpreterRunTestExp{
 			runRes: &RunResult{
 				HashedMemory: EmptyMemHash,
-				ConsumedGas:  9,
-				GasRefund:    MaxUint64 - 9,
+				GasUsed:      9,
+				GasRemaining: MaxUint64 - 9,
+				StateRoot:    EmptyRootHash,
 			},
 			stack: &Stack{*u256(2)},
 		},
@@ -136,8 +145,9 @@ var interpreterRunTests = []genericTest{
 		exp: interpreterRunTestExp{
 			runRes: &RunResult{
 				HashedMemory: EmptyMemHash,
-				ConsumedGas:  11,
-				GasRefund:    MaxUint64 - 11,
+				GasUsed:      11,
+				GasRemaining: MaxUint64 - 11,
+				StateRoot:    EmptyRootHash,
 			},
 			stack: &Stack{*u256(1)},
 		},
@@ -151,8 +161,9 @@ var interpreterRunTests = []genericTest{
 		exp: interpreterRunTestExp{
 			runRes: &RunResult{
 				HashedMemory: EmptyMemHash,
-				ConsumedGas:  11,
-				GasRefund:    MaxUint64 - 11,
+				GasUsed:      11,
+				GasRemaining: MaxUint64 - 11,
+				StateRoot:    EmptyRootHash,
 			},
 			stack: &Stack{*MaxUint256},
 		},
@@ -166,8 +177,9 @@ var interpreterRunTests = []genericTest{
 		exp: interpreterRunTestExp{
 			runRes: &RunResult{
 				HashedMemory: EmptyMemHash,
-				ConsumedGas:  66,
-				GasRefund:    MaxUint64 - 66,
+				GasUsed:      66,
+				GasRemaining: MaxUint64 - 66,
+				StateRoot:    EmptyRootHash,
 			},
 			stack: &Stack{*u256(16)},
 		},
@@ -181,8 +193,9 @@ var interpreterRunTests = []genericTest{
 		exp: interpreterRunTestExp{
 			runRes: &RunResult{
 				HashedMemory: hexToBytes("ad3228b676f7d3cd4284a5443f17f1962b36e491b30a40b2405849e597ba5fb5"),
-				ConsumedGas:  15,
-				GasRefund:    MaxUint64 - 15,
+				GasUsed:      15,
+				GasRemaining: MaxUint64 - 15,
+				StateRoot:    EmptyRootHash,
 			},
 			stack: &Stack{},
 		},
@@ -196,8 +209,9 @@ var interpreterRunTests = []genericTest{
 		exp: interpreterRunTestExp{
 			runRes: &RunResult{
 				HashedMemory: hexToBytes("290decd9548b62a8d60345a988386fc84ba6bc95484008f6362f93160ef3e563"),
-				ConsumedGas:  12,
-				GasRefund:    MaxUint64 - 12,
+				GasUsed:      12,
+				GasRemaining: MaxUint64 - 12,
+				StateRoot:    EmptyRootHash,
 			},
 			stack: &Stack{},
 		},
@@ -211,8 +225,9 @@ var interpreterRunTests = []genericTest{
 		exp: interpreterRunTestExp{
 			runRes: &RunResult{
 				HashedMemory: hexToBytes("7e5130c77fb0eb5f9b14f9fc0a37569fb344b1ba6c214486998eb3444bcf4998"),
-				ConsumedGas:  98,
-				GasRefund:    MaxUint64 - 98,
+				GasUsed:      98,
+				GasRemaining: MaxUint64 - 98,
+				StateRoot:    EmptyRootHash,
 			},
 			stack: &Stack{},
 		},
@@ -226,12 +241,61 @@ var interpreterRunTests = []genericTest{
 		exp: interpreterRunTestExp{
 			runRes: &RunResult{
 				HashedMemory: hexToBytes("7936548dcf1970696184a2a10f10cd6144bc36786b2492c64602171747439274"),
-				ConsumedGas:  10594474,
-				GasRefund:    MaxUint64 - 10594474,
+				GasUsed:      10594474,
+				GasRemaining: MaxUint64 - 10594474,
+				StateRoot:    EmptyRootHash,
 			},
 			stack: &Stack{},
 		},
 	},
+	{
+		s: "staticcall identity",
+		in: interpreterRunTestIn{
+			code:     hexToBytes("602a6000526020602060206000600462fffffffa"),
+			gasLimit: MaxUint64,
+		},
+		exp: interpreterRunTestExp{
+			runRes: &RunResult{
+				HashedMemory: keccak256(hexToBytes("000000000000000000000000000000000000000000000000000000000000002a000000000000000000000000000000000000000000000000000000000000002a")),
+				GasUsed:      751,
+				GasRemaining: MaxUint64 - 751,
+				StateRoot:    EmptyRootHash,
+			},
+			stack: &Stack{*u256(1)},
+		},
+	},
+	{
+		s: "staticcall identity out of gas",
+		in: interpreterRunTestIn{
+			code:     hexToBytes("602a60005260206020602060006004600afa"),
+			gasLimit: MaxUint64,
+		},
+		exp: interpreterRunTestExp{
+			runRes: &RunResult{
+				HashedMemory: keccak256(hexToBytes("000000000000000000000000000000000000000000000000000000000000002a0000000000000000000000000000000000000000000000000000000000000000")),
+				GasUsed:      743,
+				GasRemaining: MaxUint64 - 743,
+				StateRoot:    EmptyRootHash,
+			},
+			stack: &Stack{*u256(0)},
+		},
+	},
+	{
+		s: "staticcall with 63/64 of remaining gas",
+		in: interpreterRunTestIn{
+			code:     hexToBytes("6000600060006000600462fffffffa"),
+			gasLimit: 1000,
+		},
+		exp: interpreterRunTestExp{
+			runRes: &RunResult{
+				HashedMemory: EmptyMemHash,
+				GasUsed:      733,
+				GasRemaining: 267,
+				StateRoot:    EmptyRootHash,
+			},
+			stack: &Stack{*u256(1)},
+		},
+	},
 	{
 		s: "invalid opcode",
 		in: interpreterRunTestIn{
@@ -251,12 +315,39 @@ var interpreterRunTests = []genericTest{
 		shouldFail: true,
 	},
 	{
-		s: "gas uint overflow",
+		s: "memory above max memory",
+		in: interpreterRunTestIn{
+			code:     hexToBytes("60017f0000000000000000000000000000000000000000000000000000001fffffffc152"),
+			gasLimit: MaxUint64,
+		},
+		exp:        ErrOutOfGas,
+		shouldFail: true,
+	},
+	{
+		s: "mstore offset 2^64",
 		in: interpreterRunTestIn{
-			code:     hexToBytes("60017f0000000000000000000000000000000000000000000000000000001fffffffe052"),
+			code:     hexToBytes("60017f000000000000000000000000000000000000000000010000000000000000000052"),
 			gasLimit: MaxUint64,
 		},
-		exp:        ErrGasUintOverflow,
+		exp:        ErrOutOfGas,
+		shouldFail: true,
+	},
+	{
+		s: "mstore offset max256",
+		in: interpreterRunTestIn{
+			code:     hexToBytes("60017fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff52"),
+			gasLimit: MaxUint64,
+		},
+		exp:        ErrOutOfGas,
+		shouldFail: true,
+	},
+	{
+		s: "mstore8 offset max256",
+		in: interpreterRunTestIn{
+			code:     hexToBytes("60017fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff53"),
+			gasLimit: MaxUint64,
+		},
+		exp:        ErrOutOfGas,
 		shouldFail: true,
 	},
 	{
@@ -302,3 +393,254 @@ func Test_Interpreter_Run(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+// exp is the [gas used, gas refunded, refund counter, error] after the
+// code is run against the storage slot 0 with the original value
+var sstoreRefundTests = []genericTest{
+	{
+		s:   "set slot without refund",
+		in:  sstoreTestIn{fork: Moon, original: 0, code: "6001600055", gasLimit: MaxUint64},
+		exp: []interface{}{uint64(20006), uint64(0), uint64(0), nil},
+	},
+	{
+		s:   "store same value",
+		in:  sstoreTestIn{fork: Moon, original: 1, code: "6001600055", gasLimit: MaxUint64},
+		exp: []interface{}{uint64(806), uint64(0), uint64(0), nil},
+	},
+	{
+		s:   "clear slot refund capped at half of used gas",
+		in:  sstoreTestIn{fork: Moon, original: 1, code: "6000600055", gasLimit: MaxUint64},
+		exp: []interface{}{uint64(2503), uint64(2503), uint64(15000), nil},
+	},
+	{
+		s:   "clear slot refund capped at one fifth of used gas",
+		in:  sstoreTestIn{fork: Mars, original: 1, code: "6000600055", gasLimit: MaxUint64},
+		exp: []interface{}{uint64(4005), uint64(1001), uint64(4800), nil},
+	},
+	{
+		s:   "clear slot refund below one fifth of used gas",
+		in:  sstoreTestIn{fork: Mars, original: 1, code: "6000600055" + "6001600155", gasLimit: MaxUint64},
+		exp: []interface{}{uint64(20212), uint64(4800), uint64(4800), nil},
+	},
+	{
+		s:   "restore original zero",
+		in:  sstoreTestIn{fork: Moon, original: 0, code: "6001600055" + "6000600055", gasLimit: MaxUint64},
+		exp: []interface{}{uint64(10406), uint64(10406), uint64(19200), nil},
+	},
+	{
+		s:   "restore original value",
+		in:  sstoreTestIn{fork: Mars, original: 1, code: "6002600055" + "6001600055", gasLimit: MaxUint64},
+		exp: []interface{}{uint64(4650), uint64(1162), uint64(4200), nil},
+	},
+	{
+		s:   "set cleared slot takes back refund",
+		in:  sstoreTestIn{fork: Moon, original: 1, code: "6000600055" + "6002600055", gasLimit: MaxUint64},
+		exp: []interface{}{uint64(5812), uint64(0), uint64(0), nil},
+	},
+	{
+		s:   "no refund if execution fails",
+		in:  sstoreTestIn{fork: Moon, original: 1, code: "6000600055" + "fe", gasLimit: MaxUint64},
+		exp: []interface{}{uint64(5006), uint64(0), uint64(15000), errors.New("evm error: " + ErrInvalidOpcode(0xfe).Error())},
+	},
+	{
+		s:   "no refund counted if out of gas",
+		in:  sstoreTestIn{fork: Moon, original: 1, code: "6000600055", gasLimit: 5005},
+		exp: []interface{}{uint64(6), uint64(0), uint64(0), errors.New("evm error: " + ErrOutOfGas.Error())},
+	},
+	{
+		s:   "not allowed with call stipend",
+		in:  sstoreTestIn{fork: Moon, original: 0, code: "6001600055", gasLimit: 2306},
+		exp: []interface{}{uint64(6), uint64(0), uint64(0), errors.New("evm error: " + ErrOutOfGas.Error())},
+	},
+}
+
+func Test_Interpreter_SStoreRefund(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range sstoreRefundTests {
+		testIn := test.in.(sstoreTestIn)
+		in := NewInterpreter(testIn.fork)
+		in.state.SetState(Address{}, Hash{}, BytesToHash([]byte{testIn.original}))
+		runRes := in.Run(hexToBytes(testIn.code), testIn.gasLimit)
+		test.act = []interface{}{runRes.GasUsed, runRes.GasRefunded, in.runState.RefundCounter, runRes.EvmError}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// exp is the state root and the storage root of the account whose
+// code is run, which are computed from the stored slots after the run
+var storageRootTests = []genericTest{
+	{s: "no storage", in: "6001600052", exp: []interface{}{EmptyRootHash, map[Address]Hash(nil)}},
+	{s: "stored slot", in: "6001600055", exp: genStorageRoots(map[Hash]Hash{{}: BytesToHash([]byte{1})})},
+	{s: "stored slots", in: "6001600055" + "6002600155", exp: genStorageRoots(map[Hash]Hash{{}: BytesToHash([]byte{1}), BytesToHash([]byte{1}): BytesToHash([]byte{2})})},
+	{s: "cleared slot", in: "6001600055" + "6000600055", exp: genStorageRoots(map[Hash]Hash{})},
+}
+
+func Test_Interpreter_StorageRoot(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range storageRootTests {
+		runRes := NewInterpreter(Moon).Run(hexToBytes(test.in.(string)), MaxUint64)
+		test.act = []interface{}{runRes.StateRoot, runRes.StorageRoots}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+var forkRefundQuotientTests = []genericTest{
+	{s: "moon refunds up to half", in: Moon, exp: uint64(2)},
+	{s: "mars refunds up to one fifth", in: Mars, exp: uint64(5)},
+	{s: "jupiter keeps mars refund rule", in: Jupiter, exp: uint64(5)},
+	{s: "saturn keeps mars refund rule", in: Saturn, exp: uint64(5)},
+}
+
+func Test_Interpreter_ForkRefundQuotient(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range forkRefundQuotientTests {
+		test.act = NewInterpreter(test.in.(EVMFork)).fork.maxRefundQuotient()
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the fork and exp is the [top of the stack, error] after the
+// code pushes the blob hash at index 1 and the blob base fee
+var blobOpcodesForkTests = []genericTest{
+	{
+		s:   "blob opcodes are invalid before saturn",
+		in:  Jupiter,
+		exp: []interface{}{u256(1), errors.New("evm error: " + ErrInvalidOpcode(0x49).Error())},
+	},
+	{
+		s:   "blob opcodes read the tx and block context",
+		in:  Saturn,
+		exp: []interface{}{u256(7), nil},
+	},
+}
+
+func Test_Interpreter_BlobOpcodesFork(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range blobOpcodesForkTests {
+		in := NewInterpreter(test.in.(EVMFork))
+		in.txCtx = TxContext{BlobHashes: testBlobHashes}
+		in.blockCtx = BlockContext{BlobBaseFee: u256(7)}
+		runRes := in.Run(hexToBytes("6001494a"), MaxUint64)
+		top, _ := in.runState.Stack.peek(0)
+		test.act = []interface{}{top, runRes.EvmError}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+func Test_Interpreter_BlockOpcodes(t *testing.T) {
+	in := NewInterpreter(Moon)
+	in.blockCtx = BlockContext{Number: 7, Time: 100}
+	in.state.SetState(Address{}, BytesToHash([]byte{1}), BytesToHash([]byte{42}))
+	// pushes the timestamp, the number and the value of the slot 1
+	runRes := in.Run(hexToBytes("4243600154"), MaxUint64)
+	value, _ := in.runState.Stack.peek(0)
+	number, _ := in.runState.Stack.peek(1)
+	timestamp, _ := in.runState.Stack.peek(2)
+
+	test := genericTest{
+		s:   "block and storage opcodes read the context and the state",
+		exp: []interface{}{nil, uint64(807), u256(100), u256(7), u256(42)},
+		act: []interface{}{runRes.EvmError, runRes.GasUsed, timestamp, number, value},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+// in is the code of the account 0xbb, and exp is the [success,
+// gas used] after the code static calls it with 0xffffff gas
+var callCodeTests = []genericTest{
+	{s: "code is run", in: "6001600201", exp: []interface{}{u256(1), uint64(727)}},
+	{s: "account without code", in: "", exp: []interface{}{u256(1), uint64(718)}},
+	{s: "failed code consumes all gas", in: "fe", exp: []interface{}{u256(0), uint64(718 + 0xffffff)}},
+	{s: "callee out of gas", in: "600062ffffff52", exp: []interface{}{u256(0), uint64(718 + 0xffffff)}},
+	{s: "callee can not store", in: "6001600055", exp: []interface{}{u256(0), uint64(718 + 0xffffff)}},
+	{s: "reverted code keeps its gas", in: "60006000fd", exp: []interface{}{u256(0), uint64(724)}},
+}
+
+func Test_Interpreter_CallCode(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range callCodeTests {
+		in := NewInterpreter(Moon)
+		in.state.SetCode(testAddr2, hexToBytes(test.in.(string)))
+		runRes := in.Run(hexToBytes("600060006000600060bb62fffffffa"), MaxUint64)
+		top, _ := in.runState.Stack.peek(0)
+		test.act = []interface{}{top, runRes.GasUsed}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+func Test_Interpreter_CallRevert(t *testing.T) {
+	in := NewInterpreter(Moon)
+	// callee reverts with 42 as a word, which is copied into the memory
+	in.state.SetCode(testAddr2, hexToBytes("602a60005260206000fd"))
+	runRes := in.Run(hexToBytes("6020600060006000"+"60bb62fffffffa"), MaxUint64)
+	top, _ := in.runState.Stack.peek(0)
+	// revert of the outermost frame keeps its gas as well
+	revertIn := NewInterpreter(Moon)
+	revertRes := revertIn.Run(hexToBytes("602a60005260206000fd"), 100)
+
+	test := genericTest{
+		s: "reverted callee returns its data",
+		exp: []interface{}{
+			u256(0), nil, BytesToHash([]byte{42}).Bytes(),
+			errors.New("evm error: " + ErrExecutionReverted.Error()), uint64(18), uint64(82), BytesToHash([]byte{42}).Bytes(),
+		},
+		act: []interface{}{
+			top, runRes.EvmError, in.runState.Memory.load(0, 32),
+			revertRes.EvmError, revertRes.GasUsed, revertRes.GasRemaining, revertIn.runState.ReturnData,
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+func Test_Interpreter_CallDepth(t *testing.T) {
+	// account calls itself with all of its gas
+	code := hexToBytes("600060006000600060bb7f" + strings.Repeat("ff", 32) + "fa")
+	in := NewInterpreter(Moon)
+	in.state.SetCode(testAddr2, code)
+	runRes := in.Run(code, MaxUint64)
+	top, _ := in.runState.Stack.peek(0)
+
+	test := genericTest{
+		s:   "calls stop at max depth",
+		exp: []interface{}{u256(1), nil, 0},
+		act: []interface{}{top, runRes.EvmError, in.depth},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
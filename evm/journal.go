@@ -0,0 +1,78 @@
+package space_evm
+
+import (
+	"space/trie"
+
+	"github.com/holiman/uint256"
+)
+
+// journalEntry is a change of the state, which is reverted by restoring
+// the earlier value. Reverted accounts and slots are marked dirty, so
+// that the tries are brought back in line with them.
+type journalEntry interface {
+	revert(s *StateDB)
+}
+
+type (
+	createObjectChange struct {
+		addr Address
+	}
+	balanceChange struct {
+		addr Address
+		prev *uint256.Int
+	}
+	nonceChange struct {
+		addr Address
+		prev uint64
+	}
+	codeChange struct {
+		addr      Address
+		prevCode  []byte
+		prevHash  Hash
+		prevDirty bool
+	}
+	storageChange struct {
+		addr Address
+		slot Hash
+		prev Hash
+	}
+	storageResetChange struct {
+		addr        Address
+		prevStorage map[Hash]Hash
+		prevTrie    *trie.Trie
+		prevDirty   map[Hash]struct{}
+	}
+)
+
+func (ch createObjectChange) revert(s *StateDB) {
+	delete(s.objects, ch.addr)
+	s.dirty[ch.addr] = struct{}{}
+}
+
+func (ch balanceChange) revert(s *StateDB) {
+	s.objects[ch.addr].balance = ch.prev
+	s.dirty[ch.addr] = struct{}{}
+}
+
+func (ch nonceChange) revert(s *StateDB) {
+	s.objects[ch.addr].nonce = ch.prev
+	s.dirty[ch.addr] = struct{}{}
+}
+
+func (ch codeChange) revert(s *StateDB) {
+	obj := s.objects[ch.addr]
+	obj.code, obj.codeHash, obj.dirtyCode = ch.prevCode, ch.prevHash, ch.prevDirty
+	s.dirty[ch.addr] = struct{}{}
+}
+
+func (ch storageChange) revert(s *StateDB) {
+	s.objects[ch.addr].setSlot(ch.slot, ch.prev)
+	s.dirty[ch.addr] = struct{}{}
+}
+
+func (ch storageResetChange) revert(s *StateDB) {
+	obj := s.objects[ch.addr]
+	obj.storage, obj.trie, obj.dirtySlots = ch.prevStorage, ch.prevTrie, ch.prevDirty
+	s.dirty[ch.addr] = struct{}{}
+	s.storageCommits[ch.addr] = struct{}{}
+}
@@ -39,6 +39,24 @@ func newMoonInstructionSet() *JumpTable {
 			constGas:      10,
 			dynGasHandler: expGasCost,
 		},
+		0x42: {
+			name:          "TIMESTAMP",
+			handler:       opTimestamp,
+			constGas:      2,
+			dynGasHandler: nil,
+		},
+		0x43: {
+			name:          "NUMBER",
+			handler:       opNumber,
+			constGas:      2,
+			dynGasHandler: nil,
+		},
+		0x48: {
+			name:          "BASEFEE",
+			handler:       opBaseFee,
+			constGas:      2,
+			dynGasHandler: nil,
+		},
 		0x52: {
 			name:          "MSTORE",
 			handler:       opMStore,
@@ -51,6 +69,18 @@ func newMoonInstructionSet() *JumpTable {
 			constGas:      3,
 			dynGasHandler: memoryExpansionGasCost,
 		},
+		0x54: {
+			name:          "SLOAD",
+			handler:       opSLoad,
+			constGas:      sloadGas,
+			dynGasHandler: nil,
+		},
+		0x55: {
+			name:          "SSTORE",
+			handler:       opSStore,
+			constGas:      0,
+			dynGasHandler: sstoreGasCost,
+		},
 		0x60: {
 			name:          "PUSH1",
 			handler:       opPush,
@@ -75,9 +105,91 @@ func newMoonInstructionSet() *JumpTable {
 			constGas:      3,
 			dynGasHandler: nil,
 		},
+		0xa0: {
+			name:          "LOG0",
+			handler:       opLog,
+			constGas:      logGas,
+			dynGasHandler: logGasCost,
+		},
+		0xa1: {
+			name:          "LOG1",
+			handler:       opLog,
+			constGas:      logGas + logTopicGas,
+			dynGasHandler: logGasCost,
+		},
+		0xa2: {
+			name:          "LOG2",
+			handler:       opLog,
+			constGas:      logGas + 2*logTopicGas,
+			dynGasHandler: logGasCost,
+		},
+		0xa3: {
+			name:          "LOG3",
+			handler:       opLog,
+			constGas:      logGas + 3*logTopicGas,
+			dynGasHandler: logGasCost,
+		},
+		0xa4: {
+			name:          "LOG4",
+			handler:       opLog,
+			constGas:      logGas + 4*logTopicGas,
+			dynGasHandler: logGasCost,
+		},
+		0xf3: {
+			name:          "RETURN",
+			handler:       opReturn,
+			constGas:      0,
+			dynGasHandler: memoryRangeGasCost,
+		},
+		0xfa: {
+			name:          "STATICCALL",
+			handler:       opStaticCall,
+			constGas:      callConstGas,
+			dynGasHandler: staticCallGasCost,
+		},
+		0xfd: {
+			name:          "REVERT",
+			handler:       opRevert,
+			constGas:      0,
+			dynGasHandler: memoryRangeGasCost,
+		},
 	}
 }
 
+// Mars fork keeps the Moon instruction set as is, and only
+// changes the gas refund rules of the executions and of the
+// storage clears (see EIP-3529)
+func newMarsInstructionSet() *JumpTable {
+	jt := newMoonInstructionSet()
+	return jt
+}
+
+// Jupiter fork keeps the Mars instruction set as is, and
+// only adds the BLS12-381 precompiled contracts (see EIP-2537)
+func newJupiterInstructionSet() *JumpTable {
+	jt := newMarsInstructionSet()
+	return jt
+}
+
+// Saturn fork adds the blob opcodes and the
+// KZG point evaluation precompile (see EIP-4844)
+func newSaturnInstructionSet() *JumpTable {
+	jt := newJupiterInstructionSet()
+	jt[0x49] = opInfo{
+		name:          "BLOBHASH",
+		handler:       opBlobHash,
+		constGas:      3,
+		dynGasHandler: nil,
+	}
+	jt[0x4a] = opInfo{
+		name:          "BLOBBASEFEE",
+		handler:       opBlobBaseFee,
+		constGas:      2,
+		dynGasHandler: nil,
+	}
+	return jt
+}
+
 func (jt *JumpTable) getOpInfo(opcode byte) *opInfo {
 	return &(*jt)[opcode]
 }
@@ -87,9 +199,32 @@ type EVMFork int
 
 const (
 	Moon EVMFork = iota
+	Mars
+	Jupiter
+	Saturn
 )
 
 // Return stringified enum
 func (fork EVMFork) String() string {
-	return []string{"Moon"}[fork]
+	return []string{"Moon", "Mars", "Jupiter", "Saturn"}[fork]
+}
+
+// Returns the divisor of the used gas, which caps the refund
+// applied at the end of the execution. Moon allows refunding
+// up to half of the used gas, and Mars lowers it to one fifth.
+// Later forks keep the Mars rule.
+func (fork EVMFork) maxRefundQuotient() uint64 {
+	if fork >= Mars {
+		return 5
+	}
+	return 2
+}
+
+// Returns the refund of clearing a storage slot. Moon refunds 15000
+// gas as in EIP-2200, and Mars lowers it to 4800 (see EIP-3529).
+func (fork EVMFork) sstoreClearsRefund() uint64 {
+	if fork >= Mars {
+		return 4800
+	}
+	return 15000
 }
@@ -25,6 +25,26 @@ func (m *Memory) store32(offset uint64, val *uint256.Int) {
 	copy((*m)[offset:offset+32], buff[:])
 }
 
+// Store data in memory starting from offset
+func (m *Memory) store(offset uint64, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	m.extend(offset + uint64(len(data)))
+	copy((*m)[offset:], data)
+}
+
+// Returns a copy of size bytes in memory starting from offset
+func (m *Memory) load(offset uint64, size uint64) []byte {
+	if size == 0 {
+		return nil
+	}
+	m.extend(offset + size)
+	buff := make([]byte, size)
+	copy(buff, (*m)[offset:offset+size])
+	return buff
+}
+
 // Extend memory with zeroes to the given size's closest multiple of 32
 func (m *Memory) extend(size uint64) {
 	newSize := ceil32(size)
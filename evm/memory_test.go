@@ -137,3 +137,56 @@ func Test_Memory_Store32(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+var memoryStoreTests = []genericTest{
+	{s: "store nothing", in: []interface{}{uint64(10), []byte{}}, exp: []byte{}},
+	{s: "store 2 bytes at 0", in: []interface{}{uint64(0), []byte{1, 2}}, exp: hexToBytes("0102000000000000000000000000000000000000000000000000000000000000")},
+	{
+		s:   "store 2 bytes at 31",
+		in:  []interface{}{uint64(31), []byte{1, 2}},
+		exp: hexToBytes("00000000000000000000000000000000000000000000000000000000000000010200000000000000000000000000000000000000000000000000000000000000"),
+	},
+}
+
+func Test_Memory_Store(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range memoryStoreTests {
+		mem := NewMemory()
+		testIn := test.in.([]interface{})
+		mem.store(testIn[0].(uint64), testIn[1].([]byte))
+		test.act = []byte(*mem)
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// memory is initialized with bytes from 0 to 63,
+// and in is the offset and size of the bytes to load
+var memoryLoadTests = []genericTest{
+	{s: "load nothing", in: []uint64{10, 0}, exp: []interface{}{[]byte(nil), 64}},
+	{s: "load 2 bytes at 0", in: []uint64{0, 2}, exp: []interface{}{[]byte{0, 1}, 64}},
+	{s: "load 3 bytes at 62", in: []uint64{62, 3}, exp: []interface{}{[]byte{62, 63, 0}, 96}},
+	{s: "load 2 bytes at 100", in: []uint64{100, 2}, exp: []interface{}{[]byte{0, 0}, 128}},
+}
+
+func Test_Memory_Load(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range memoryLoadTests {
+		mem := NewMemory()
+		for i := 0; i < 64; i++ {
+			mem.store1(uint64(i), byte(i))
+		}
+		testIn := test.in.([]uint64)
+		test.act = []interface{}{mem.load(testIn[0], testIn[1]), mem.ByteLen()}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
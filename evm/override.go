@@ -0,0 +1,95 @@
+package space_evm
+
+import (
+	"fmt"
+
+	"github.com/holiman/uint256"
+)
+
+// OverrideAccount holds the fields of an account which are replaced
+// before a call. State replaces the whole storage of the account, while
+// StateDiff only replaces the given slots, hence they are exclusive.
+type OverrideAccount struct {
+	Nonce     *HexUint64    `json:"nonce"`
+	Code      *HexBytes     `json:"code"`
+	Balance   *uint256.Int  `json:"balance"`
+	State     map[Hash]Hash `json:"state"`
+	StateDiff map[Hash]Hash `json:"stateDiff"`
+}
+
+// StateOverride is the set of the accounts which are overridden
+// before a call, in the same JSON form as the state overrides of geth
+type StateOverride map[Address]OverrideAccount
+
+// Overrides the accounts in the state. Overrides are meant to be applied
+// to a copy of the state, which is thrown away after the call.
+func (o StateOverride) Apply(state *StateDB) error {
+	for addr, account := range o {
+		if account.State != nil && account.StateDiff != nil {
+			return fmt.Errorf("%w: account %v has both state and stateDiff", ErrInvalidOverride, addr.Hex())
+		}
+	}
+	for addr, account := range o {
+		if account.Nonce != nil {
+			state.SetNonce(addr, uint64(*account.Nonce))
+		}
+		if account.Code != nil {
+			state.SetCode(addr, *account.Code)
+		}
+		if account.Balance != nil {
+			state.SetBalance(addr, account.Balance)
+		}
+		if account.State != nil {
+			state.SetStorage(addr, account.State)
+		}
+		for slot, value := range account.StateDiff {
+			state.SetState(addr, slot, value)
+		}
+	}
+	return nil
+}
+
+// BlockOverrides holds the fields of the block which are replaced for
+// a call, in the same JSON form as the block overrides of geth.
+// Difficulty and prevRandao are not part of the block context, since
+// none of the opcodes read them, hence they can not be overridden.
+type BlockOverrides struct {
+	Number        *HexUint64   `json:"number"`
+	Difficulty    *uint256.Int `json:"difficulty"`
+	Time          *HexUint64   `json:"time"`
+	GasLimit      *HexUint64   `json:"gasLimit"`
+	FeeRecipient  *Address     `json:"feeRecipient"`
+	PrevRandao    *Hash        `json:"prevRandao"`
+	BaseFeePerGas *uint256.Int `json:"baseFeePerGas"`
+	BlobBaseFee   *uint256.Int `json:"blobBaseFee"`
+}
+
+// Overrides the fields of the block context. Overrides of the fields
+// which are not in the block context are rejected instead of ignored.
+func (o *BlockOverrides) Apply(blockCtx *BlockContext) error {
+	if o.Difficulty != nil {
+		return fmt.Errorf("%w: difficulty can not be overridden", ErrInvalidOverride)
+	}
+	if o.PrevRandao != nil {
+		return fmt.Errorf("%w: prevRandao can not be overridden", ErrInvalidOverride)
+	}
+	if o.Number != nil {
+		blockCtx.Number = uint64(*o.Number)
+	}
+	if o.Time != nil {
+		blockCtx.Time = uint64(*o.Time)
+	}
+	if o.GasLimit != nil {
+		blockCtx.GasLimit = uint64(*o.GasLimit)
+	}
+	if o.FeeRecipient != nil {
+		blockCtx.Coinbase = *o.FeeRecipient
+	}
+	if o.BaseFeePerGas != nil {
+		blockCtx.BaseFee = new(uint256.Int).Set(o.BaseFeePerGas)
+	}
+	if o.BlobBaseFee != nil {
+		blockCtx.BlobBaseFee = new(uint256.Int).Set(o.BlobBaseFee)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package space_evm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func Test_Override_StateOverride(t *testing.T) {
+	state := NewStateDB()
+	for _, addr := range []Address{testLogger, testRecipient} {
+		state.SetBalance(addr, u256(1))
+		state.SetState(addr, BytesToHash([]byte{1}), BytesToHash([]byte{1}))
+		state.SetState(addr, BytesToHash([]byte{2}), BytesToHash([]byte{2}))
+	}
+	var override StateOverride
+	err := json.Unmarshal([]byte(`{
+		"0x00000000000000000000000000000000000000bb": {
+			"nonce": "0x7", "code": "0x6001", "balance": "0x2a",
+			"state": {"0x0000000000000000000000000000000000000000000000000000000000000003": "0x0000000000000000000000000000000000000000000000000000000000000003"}
+		},
+		"0x00000000000000000000000000000000000000cc": {
+			"stateDiff": {
+				"0x0000000000000000000000000000000000000000000000000000000000000001": "0x0000000000000000000000000000000000000000000000000000000000000009",
+				"0x0000000000000000000000000000000000000000000000000000000000000002": "0x0000000000000000000000000000000000000000000000000000000000000000"
+			}
+		}
+	}`), &override)
+	if err == nil {
+		err = override.Apply(state)
+	}
+	errBoth := StateOverride{testLogger: {State: map[Hash]Hash{}, StateDiff: map[Hash]Hash{}}}.Apply(NewStateDB())
+
+	test := genericTest{
+		s: "state and stateDiff overrides",
+		exp: []interface{}{
+			nil, uint64(7), hexToBytes("6001"), u256(42), Hash{}, BytesToHash([]byte{3}),
+			u256(1), BytesToHash([]byte{9}), Hash{}, true,
+		},
+		act: []interface{}{
+			err, state.GetNonce(testLogger), state.GetCode(testLogger), state.GetBalance(testLogger),
+			state.GetState(testLogger, BytesToHash([]byte{1})), state.GetState(testLogger, BytesToHash([]byte{3})),
+			state.GetBalance(testRecipient), state.GetState(testRecipient, BytesToHash([]byte{1})),
+			state.GetState(testRecipient, BytesToHash([]byte{2})), errors.Is(errBoth, ErrInvalidOverride),
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+func Test_Override_BlockOverrides(t *testing.T) {
+	blockCtx := NewBlockContext(&Header{Coinbase: testCoinbase, GasLimit: 30000000, BaseFee: u256(7)})
+	var overrides BlockOverrides
+	err := json.Unmarshal([]byte(`{"number": "0x7", "time": "0x1", "gasLimit": "0x5208", "baseFeePerGas": "0x2a", "blobBaseFee": "0x3"}`), &overrides)
+	if err == nil {
+		err = overrides.Apply(&blockCtx)
+	}
+	// fields which are not in the block context can not be overridden
+	errDifficulty := (&BlockOverrides{Difficulty: u256(1)}).Apply(&blockCtx)
+	errPrevRandao := (&BlockOverrides{PrevRandao: &Hash{}}).Apply(&blockCtx)
+
+	test := genericTest{
+		s: "block overrides",
+		exp: []interface{}{
+			nil, testCoinbase, uint64(7), uint64(1), uint64(21000), u256(42), u256(3), true, true,
+		},
+		act: []interface{}{
+			err, blockCtx.Coinbase, blockCtx.Number, blockCtx.Time, blockCtx.GasLimit, blockCtx.BaseFee, blockCtx.BlobBaseFee,
+			errors.Is(errDifficulty, ErrInvalidOverride), errors.Is(errPrevRandao, ErrInvalidOverride),
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
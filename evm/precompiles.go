@@ -0,0 +1,357 @@
+package space_evm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	"space/crypto/blake2b"
+	"space/crypto/bn254"
+	"space/crypto/kzg4844"
+	"space/crypto/secp256k1"
+
+	"golang.org/x/crypto/ripemd160"
+)
+
+// PrecompileGasFunc returns the gas cost of running
+// the precompiled contract with the given input
+type PrecompileGasFunc func(input []byte) uint64
+
+// PrecompileRunFunc runs the precompiled contract with the given input
+type PrecompileRunFunc func(input []byte) ([]byte, error)
+
+// Precompile is a contract which is implemented natively in Go
+// instead of being interpreted, and it lives at a fixed address
+type Precompile struct {
+	Name    string
+	GasCost PrecompileGasFunc
+	Run     PrecompileRunFunc
+}
+
+// Precompiles contains info about given fork's precompiled contracts
+type Precompiles map[Address]*Precompile
+
+func newMoonPrecompiles() Precompiles {
+	return Precompiles{
+		BytesToAddress([]byte{0x01}): {
+			Name:    "ECRECOVER",
+			GasCost: ecrecoverGasCost,
+			Run:     runEcrecover,
+		},
+		BytesToAddress([]byte{0x02}): {
+			Name:    "SHA256",
+			GasCost: sha256GasCost,
+			Run:     runSha256,
+		},
+		BytesToAddress([]byte{0x03}): {
+			Name:    "RIPEMD160",
+			GasCost: ripemd160GasCost,
+			Run:     runRipemd160,
+		},
+		BytesToAddress([]byte{0x04}): {
+			Name:    "IDENTITY",
+			GasCost: identityGasCost,
+			Run:     runIdentity,
+		},
+		BytesToAddress([]byte{0x05}): {
+			Name:    "MODEXP",
+			GasCost: modExpGasCost,
+			Run:     runModExp,
+		},
+		BytesToAddress([]byte{0x06}): {
+			Name:    "ECADD",
+			GasCost: ecAddGasCost,
+			Run:     runEcAdd,
+		},
+		BytesToAddress([]byte{0x07}): {
+			Name:    "ECMUL",
+			GasCost: ecMulGasCost,
+			Run:     runEcMul,
+		},
+		BytesToAddress([]byte{0x08}): {
+			Name:    "ECPAIRING",
+			GasCost: ecPairingGasCost,
+			Run:     runEcPairing,
+		},
+		BytesToAddress([]byte{0x09}): {
+			Name:    "BLAKE2F",
+			GasCost: blake2FGasCost,
+			Run:     runBlake2F,
+		},
+	}
+}
+
+// Mars fork keeps the Moon precompiled contracts as is
+func newMarsPrecompiles() Precompiles {
+	return newMoonPrecompiles()
+}
+
+// Jupiter fork adds the BLS12-381 precompiled contracts (see EIP-2537)
+func newJupiterPrecompiles() Precompiles {
+	precompiles := newMarsPrecompiles()
+	precompiles[BytesToAddress([]byte{0x0b})] = &Precompile{
+		Name:    "BLS12_G1ADD",
+		GasCost: blsG1AddGasCost,
+		Run:     runBlsG1Add,
+	}
+	precompiles[BytesToAddress([]byte{0x0c})] = &Precompile{
+		Name:    "BLS12_G1MSM",
+		GasCost: blsG1MSMGasCost,
+		Run:     runBlsG1MSM,
+	}
+	precompiles[BytesToAddress([]byte{0x0d})] = &Precompile{
+		Name:    "BLS12_G2ADD",
+		GasCost: blsG2AddGasCost,
+		Run:     runBlsG2Add,
+	}
+	precompiles[BytesToAddress([]byte{0x0e})] = &Precompile{
+		Name:    "BLS12_G2MSM",
+		GasCost: blsG2MSMGasCost,
+		Run:     runBlsG2MSM,
+	}
+	precompiles[BytesToAddress([]byte{0x0f})] = &Precompile{
+		Name:    "BLS12_PAIRING_CHECK",
+		GasCost: blsPairingGasCost,
+		Run:     runBlsPairing,
+	}
+	precompiles[BytesToAddress([]byte{0x10})] = &Precompile{
+		Name:    "BLS12_MAP_FP_TO_G1",
+		GasCost: blsMapG1GasCost,
+		Run:     runBlsMapG1,
+	}
+	precompiles[BytesToAddress([]byte{0x11})] = &Precompile{
+		Name:    "BLS12_MAP_FP2_TO_G2",
+		GasCost: blsMapG2GasCost,
+		Run:     runBlsMapG2,
+	}
+	return precompiles
+}
+
+// Saturn fork adds the KZG point evaluation precompiled contract (see EIP-4844)
+func newSaturnPrecompiles() Precompiles {
+	precompiles := newJupiterPrecompiles()
+	precompiles[BytesToAddress([]byte{0x0a})] = &Precompile{
+		Name:    "POINT_EVALUATION",
+		GasCost: pointEvaluationGasCost,
+		Run:     runPointEvaluation,
+	}
+	return precompiles
+}
+
+// Runs the precompiled contract with the given gas, and returns the
+// output with the remaining gas. All of the gas is consumed on failure.
+func runPrecompile(p *Precompile, input []byte, gas uint64) ([]byte, uint64, error) {
+	gasCost := p.GasCost(input)
+	if gasCost > gas {
+		return nil, 0, ErrOutOfGas
+	}
+	ret, err := p.Run(input)
+	if err != nil {
+		return nil, 0, err
+	}
+	return ret, gas - gasCost, nil
+}
+
+// Input is [hash || v || r || s], each being 32 bytes, and it
+// is right-padded with zeroes if shorter. Address of the signer
+// is returned left-padded to 32 bytes, and an invalid signature
+// results in an empty output rather than a failure.
+func runEcrecover(input []byte) ([]byte, error) {
+	input = rightPad(input, 128)
+	// v must be 27 or 28, and it must not have any other bytes set
+	v := input[63]
+	if !allZero(input[32:63]) || (v != 27 && v != 28) {
+		return nil, nil
+	}
+	r := new(big.Int).SetBytes(input[64:96])
+	s := new(big.Int).SetBytes(input[96:128])
+	if !secp256k1.ValidateSignatureValues(r, s, false) {
+		return nil, nil
+	}
+
+	sig := make([]byte, secp256k1.SignatureLength)
+	copy(sig, input[64:128])
+	sig[64] = v - 27
+	addr, err := Ecrecover(input[:32], sig)
+	if err != nil {
+		return nil, nil
+	}
+	return append(make([]byte, 12), addr.Bytes()...), nil
+}
+
+func runSha256(input []byte) ([]byte, error) {
+	hash := sha256.Sum256(input)
+	return hash[:], nil
+}
+
+// RIPEMD160 hash is returned left-padded to 32 bytes
+func runRipemd160(input []byte) ([]byte, error) {
+	hasher := ripemd160.New()
+	hasher.Write(input)
+	ret := make([]byte, 32)
+	copy(ret[12:], hasher.Sum(nil))
+	return ret, nil
+}
+
+func runIdentity(input []byte) ([]byte, error) {
+	ret := make([]byte, len(input))
+	copy(ret, input)
+	return ret, nil
+}
+
+// Input is [baseLen || expLen || modLen || base || exp || mod], where
+// lengths are 32 bytes each, and the values are right-padded with zeroes
+// if the input is shorter. Result is left-padded to the modulus length.
+func runModExp(input []byte) ([]byte, error) {
+	baseLen := new(big.Int).SetBytes(getPaddedSlice(input, 0, 32))
+	expLen := new(big.Int).SetBytes(getPaddedSlice(input, 32, 32))
+	modLen := new(big.Int).SetBytes(getPaddedSlice(input, 64, 32))
+	if baseLen.Sign() == 0 && modLen.Sign() == 0 {
+		return []byte{}, nil
+	}
+	// gas of such lengths can never be paid for, hence
+	// they can only be reached when called directly
+	if baseLen.BitLen() > 32 || expLen.BitLen() > 32 || modLen.BitLen() > 32 {
+		return nil, ErrModExpLengthTooLarge
+	}
+	if len(input) > 96 {
+		input = input[96:]
+	} else {
+		input = nil
+	}
+
+	bLen, eLen, mLen := baseLen.Uint64(), expLen.Uint64(), modLen.Uint64()
+	base := new(big.Int).SetBytes(getPaddedSlice(input, 0, bLen))
+	exp := new(big.Int).SetBytes(getPaddedSlice(input, bLen, eLen))
+	mod := new(big.Int).SetBytes(getPaddedSlice(input, bLen+eLen, mLen))
+
+	ret := make([]byte, mLen)
+	// anything modulo zero is defined to be zero
+	if mod.Sign() == 0 {
+		return ret, nil
+	}
+	return new(big.Int).Exp(base, exp, mod).FillBytes(ret), nil
+}
+
+// Input is two BN254 G1 points, each being [x || y] with 32 bytes
+// coordinates, and it is right-padded with zeroes if shorter
+func runEcAdd(input []byte) ([]byte, error) {
+	input = rightPad(input, 128)
+	p1, p2 := &bn254.G1{}, &bn254.G1{}
+	if err := p1.Unmarshal(input[:64]); err != nil {
+		return nil, err
+	}
+	if err := p2.Unmarshal(input[64:128]); err != nil {
+		return nil, err
+	}
+	return p1.Add(p2).Marshal(), nil
+}
+
+// Input is a BN254 G1 point followed by a 32 bytes scalar,
+// and it is right-padded with zeroes if shorter
+func runEcMul(input []byte) ([]byte, error) {
+	input = rightPad(input, 96)
+	p := &bn254.G1{}
+	if err := p.Unmarshal(input[:64]); err != nil {
+		return nil, err
+	}
+	k := new(big.Int).SetBytes(input[64:96])
+	return p.ScalarMult(k).Marshal(), nil
+}
+
+// Input is a list of pairs of a BN254 G1 point and a G2 point, 192
+// bytes each. Output is 1 if the product of the pairings is one,
+// and 0 otherwise, left-padded to 32 bytes.
+func runEcPairing(input []byte) ([]byte, error) {
+	if len(input)%192 != 0 {
+		return nil, ErrBadPairingInputLen
+	}
+	g1s, g2s := []*bn254.G1{}, []*bn254.G2{}
+	for i := 0; i < len(input); i += 192 {
+		p1, p2 := &bn254.G1{}, &bn254.G2{}
+		if err := p1.Unmarshal(input[i : i+64]); err != nil {
+			return nil, err
+		}
+		if err := p2.Unmarshal(input[i+64 : i+192]); err != nil {
+			return nil, err
+		}
+		g1s, g2s = append(g1s, p1), append(g2s, p2)
+	}
+
+	ret := make([]byte, 32)
+	if bn254.PairingCheck(g1s, g2s) {
+		ret[31] = 1
+	}
+	return ret, nil
+}
+
+const blake2FInputLength = 213
+
+// Input is [rounds || h || m || t || f], where rounds is 4 bytes
+// big-endian, state h, message block m and offset counter t are
+// 8 bytes little-endian words, and f is the final block flag
+func runBlake2F(input []byte) ([]byte, error) {
+	if len(input) != blake2FInputLength {
+		return nil, ErrBlake2FInvalidInputLen
+	}
+	if input[212] > 1 {
+		return nil, ErrBlake2FInvalidFinalFlag
+	}
+
+	rounds := binary.BigEndian.Uint32(input[:4])
+	var h [8]uint64
+	for i := range h {
+		h[i] = binary.LittleEndian.Uint64(input[4+i*8:])
+	}
+	var m [16]uint64
+	for i := range m {
+		m[i] = binary.LittleEndian.Uint64(input[68+i*8:])
+	}
+	t := [2]uint64{
+		binary.LittleEndian.Uint64(input[196:]),
+		binary.LittleEndian.Uint64(input[204:]),
+	}
+	blake2b.F(&h, &m, t, input[212] == 1, rounds)
+
+	ret := make([]byte, 64)
+	for i := range h {
+		binary.LittleEndian.PutUint64(ret[i*8:], h[i])
+	}
+	return ret, nil
+}
+
+const pointEvaluationInputLength = 192
+
+// Input is [versionedHash || z || y || commitment || proof], where the
+// commitment and the proof are 48 bytes compressed G1 points, and the
+// others are 32 bytes. Proof is verified against the commitment whose
+// versioned hash is given. Output is the number of field elements
+// in a blob followed by the BLS modulus, 32 bytes each.
+func runPointEvaluation(input []byte) ([]byte, error) {
+	if len(input) != pointEvaluationInputLength {
+		return nil, ErrPointEvaluationInvalidInputLen
+	}
+	var (
+		commitment kzg4844.Commitment
+		z, y       kzg4844.Scalar
+		proof      kzg4844.Proof
+	)
+	copy(z[:], input[32:64])
+	copy(y[:], input[64:96])
+	copy(commitment[:], input[96:144])
+	copy(proof[:], input[144:192])
+
+	versionedHash := kzg4844.CalcBlobHashV1(commitment)
+	if !bytes.Equal(versionedHash[:], input[:32]) {
+		return nil, ErrPointEvaluationMismatchedVersionedHash
+	}
+	if err := kzg4844.VerifyProof(commitment, z, y, proof); err != nil {
+		return nil, err
+	}
+
+	ret := make([]byte, 64)
+	new(big.Int).SetUint64(kzg4844.FieldElementsPerBlob).FillBytes(ret[:32])
+	kzg4844.BLSModulus.FillBytes(ret[32:])
+	return ret, nil
+}
@@ -0,0 +1,252 @@
+package space_evm
+
+import (
+	"math/big"
+
+	bls12381 "github.com/kilic/bls12-381"
+)
+
+// BLS12-381 precompiled contracts encode a base field element as 64 bytes
+// big-endian, whose top 16 bytes must be zero. An extension field element
+// is [c0 || c1], G1 points are [x || y] and G2 points are [x || y] with
+// extension field coordinates. Point at infinity is encoded as all zeroes.
+// Scalars are 32 bytes big-endian, and they are not required to be
+// less than the subgroup order.
+
+const blsFieldElementPadding = 16
+
+// Strips the zero padding of a 64 bytes field element
+func decodeBlsFieldElement(in []byte) ([]byte, error) {
+	if !allZero(in[:blsFieldElementPadding]) {
+		return nil, ErrBLS12381InvalidFieldElementTopBytes
+	}
+	return in[blsFieldElementPadding:], nil
+}
+
+// Converts [c0 || c1] extension field element into
+// [c1 || c0] without padding, as expected by the library
+func decodeBlsFieldElement2(in []byte) ([]byte, error) {
+	c0, err := decodeBlsFieldElement(in[:blsFieldElementLength])
+	if err != nil {
+		return nil, err
+	}
+	c1, err := decodeBlsFieldElement(in[blsFieldElementLength:])
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, c1...), c0...), nil
+}
+
+func encodeBlsFieldElement(out []byte, in []byte) {
+	copy(out[blsFieldElementPadding:blsFieldElementLength], in)
+}
+
+// Decodes a G1 point which is on the curve, but not
+// necessarily in the correct subgroup
+func decodeBlsG1Point(g *bls12381.G1, in []byte) (*bls12381.PointG1, error) {
+	x, err := decodeBlsFieldElement(in[:blsFieldElementLength])
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeBlsFieldElement(in[blsFieldElementLength:])
+	if err != nil {
+		return nil, err
+	}
+	return g.FromBytes(append(append([]byte{}, x...), y...))
+}
+
+// Decodes a G2 point which is on the curve, but not
+// necessarily in the correct subgroup
+func decodeBlsG2Point(g *bls12381.G2, in []byte) (*bls12381.PointG2, error) {
+	x, err := decodeBlsFieldElement2(in[:2*blsFieldElementLength])
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeBlsFieldElement2(in[2*blsFieldElementLength:])
+	if err != nil {
+		return nil, err
+	}
+	return g.FromBytes(append(x, y...))
+}
+
+func encodeBlsG1Point(g *bls12381.G1, p *bls12381.PointG1) []byte {
+	in := g.ToBytes(p)
+	out := make([]byte, blsG1PointLength)
+	encodeBlsFieldElement(out[:blsFieldElementLength], in[:48])
+	encodeBlsFieldElement(out[blsFieldElementLength:], in[48:])
+	return out
+}
+
+// Library encodes the extension field elements as [c1 || c0]
+func encodeBlsG2Point(g *bls12381.G2, p *bls12381.PointG2) []byte {
+	in := g.ToBytes(p)
+	out := make([]byte, blsG2PointLength)
+	for i := 0; i < 4; i += 2 {
+		encodeBlsFieldElement(out[i*blsFieldElementLength:], in[(i+1)*48:(i+2)*48])
+		encodeBlsFieldElement(out[(i+1)*blsFieldElementLength:], in[i*48:(i+1)*48])
+	}
+	return out
+}
+
+// Reduces the scalar by the subgroup order, which does not change
+// the result of the multiplication of a point in the subgroup
+func decodeBlsScalar(in []byte, order *big.Int) *big.Int {
+	k := new(big.Int).SetBytes(in[:blsScalarLength])
+	return k.Mod(k, order)
+}
+
+// Input is two G1 points. Points are not checked to be in the subgroup.
+func runBlsG1Add(input []byte) ([]byte, error) {
+	if len(input) != 2*blsG1PointLength {
+		return nil, ErrBLS12381InvalidInputLen
+	}
+	g := bls12381.NewG1()
+	p1, err := decodeBlsG1Point(g, input[:blsG1PointLength])
+	if err != nil {
+		return nil, err
+	}
+	p2, err := decodeBlsG1Point(g, input[blsG1PointLength:])
+	if err != nil {
+		return nil, err
+	}
+	return encodeBlsG1Point(g, g.Add(g.New(), p1, p2)), nil
+}
+
+// Input is a non-empty list of pairs of a G1 point and a scalar
+func runBlsG1MSM(input []byte) ([]byte, error) {
+	k := len(input) / blsG1MSMPairLength
+	if len(input) == 0 || len(input)%blsG1MSMPairLength != 0 {
+		return nil, ErrBLS12381InvalidInputLen
+	}
+	g := bls12381.NewG1()
+	points, scalars := make([]*bls12381.PointG1, k), make([]*big.Int, k)
+	for i := 0; i < k; i++ {
+		offset := i * blsG1MSMPairLength
+		p, err := decodeBlsG1Point(g, input[offset:offset+blsG1PointLength])
+		if err != nil {
+			return nil, err
+		}
+		if !g.InCorrectSubgroup(p) {
+			return nil, ErrBLS12381PointNotInSubgroup
+		}
+		points[i] = p
+		scalars[i] = decodeBlsScalar(input[offset+blsG1PointLength:], g.Q())
+	}
+
+	r, err := g.MultiExpBig(g.New(), points, scalars)
+	if err != nil {
+		return nil, err
+	}
+	return encodeBlsG1Point(g, r), nil
+}
+
+// Input is two G2 points. Points are not checked to be in the subgroup.
+func runBlsG2Add(input []byte) ([]byte, error) {
+	if len(input) != 2*blsG2PointLength {
+		return nil, ErrBLS12381InvalidInputLen
+	}
+	g := bls12381.NewG2()
+	p1, err := decodeBlsG2Point(g, input[:blsG2PointLength])
+	if err != nil {
+		return nil, err
+	}
+	p2, err := decodeBlsG2Point(g, input[blsG2PointLength:])
+	if err != nil {
+		return nil, err
+	}
+	return encodeBlsG2Point(g, g.Add(g.New(), p1, p2)), nil
+}
+
+// Input is a non-empty list of pairs of a G2 point and a scalar
+func runBlsG2MSM(input []byte) ([]byte, error) {
+	k := len(input) / blsG2MSMPairLength
+	if len(input) == 0 || len(input)%blsG2MSMPairLength != 0 {
+		return nil, ErrBLS12381InvalidInputLen
+	}
+	g := bls12381.NewG2()
+	points, scalars := make([]*bls12381.PointG2, k), make([]*big.Int, k)
+	for i := 0; i < k; i++ {
+		offset := i * blsG2MSMPairLength
+		p, err := decodeBlsG2Point(g, input[offset:offset+blsG2PointLength])
+		if err != nil {
+			return nil, err
+		}
+		if !g.InCorrectSubgroup(p) {
+			return nil, ErrBLS12381PointNotInSubgroup
+		}
+		points[i] = p
+		scalars[i] = decodeBlsScalar(input[offset+blsG2PointLength:], g.Q())
+	}
+
+	r, err := g.MultiExpBig(g.New(), points, scalars)
+	if err != nil {
+		return nil, err
+	}
+	return encodeBlsG2Point(g, r), nil
+}
+
+// Input is a non-empty list of pairs of a G1 point and a G2 point.
+// Output is 1 if the product of the pairings is one, and 0
+// otherwise, left-padded to 32 bytes.
+func runBlsPairing(input []byte) ([]byte, error) {
+	if len(input) == 0 || len(input)%blsPairingPairLength != 0 {
+		return nil, ErrBLS12381InvalidInputLen
+	}
+	engine := bls12381.NewEngine()
+	for i := 0; i < len(input); i += blsPairingPairLength {
+		p1, err := decodeBlsG1Point(engine.G1, input[i:i+blsG1PointLength])
+		if err != nil {
+			return nil, err
+		}
+		p2, err := decodeBlsG2Point(engine.G2, input[i+blsG1PointLength:i+blsPairingPairLength])
+		if err != nil {
+			return nil, err
+		}
+		if !engine.G1.InCorrectSubgroup(p1) || !engine.G2.InCorrectSubgroup(p2) {
+			return nil, ErrBLS12381PointNotInSubgroup
+		}
+		engine.AddPair(p1, p2)
+	}
+
+	ret := make([]byte, 32)
+	if engine.Check() {
+		ret[31] = 1
+	}
+	return ret, nil
+}
+
+// Input is a base field element, which is mapped to a G1 point
+// with the simplified SWU map and cleared cofactor
+func runBlsMapG1(input []byte) ([]byte, error) {
+	if len(input) != blsFieldElementLength {
+		return nil, ErrBLS12381InvalidInputLen
+	}
+	fe, err := decodeBlsFieldElement(input)
+	if err != nil {
+		return nil, err
+	}
+	g := bls12381.NewG1()
+	p, err := g.MapToCurve(fe)
+	if err != nil {
+		return nil, err
+	}
+	return encodeBlsG1Point(g, p), nil
+}
+
+// Input is an extension field element, which is mapped to a G2
+// point with the simplified SWU map and cleared cofactor
+func runBlsMapG2(input []byte) ([]byte, error) {
+	if len(input) != 2*blsFieldElementLength {
+		return nil, ErrBLS12381InvalidInputLen
+	}
+	fe, err := decodeBlsFieldElement2(input)
+	if err != nil {
+		return nil, err
+	}
+	g := bls12381.NewG2()
+	p, err := g.MapToCurve(fe)
+	if err != nil {
+		return nil, err
+	}
+	return encodeBlsG2Point(g, p), nil
+}
@@ -0,0 +1,265 @@
+package space_evm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Pads 48 bytes hex encoded base field elements to 64 bytes
+func blsFp(elements ...string) string {
+	var s string
+	for _, e := range elements {
+		s += strings.Repeat("00", blsFieldElementPadding) + e
+	}
+	return s
+}
+
+func blsScalar(k uint64) string {
+	b := u256(k).Bytes32()
+	return fmt.Sprintf("%x", b[:])
+}
+
+// BLS12-381 G1 generator, its negation and double, G2 generator
+// and its double, and a G1 point on the curve outside of the subgroup
+var (
+	blsG1 = blsFp(
+		"17f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb",
+		"08b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1",
+	)
+	blsNegG1 = blsFp(
+		"17f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb",
+		"114d1d6855d545a8aa7d76c8cf2e21f267816aef1db507c96655b9d5caac42364e6f38ba0ecb751bad54dcd6b939c2ca",
+	)
+	blsTwoG1 = blsFp(
+		"0572cbea904d67468808c8eb50a9450c9721db309128012543902d0ac358a62ae28f75bb8f1c7c42c39a8c5529bf0f4e",
+		"166a9d8cabc673a322fda673779d8e3822ba3ecb8670e461f73bb9021d5fd76a4c56d9d4cd16bd1bba86881979749d28",
+	)
+	blsG2 = blsFp(
+		"024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8",
+		"13e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e",
+		"0ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801",
+		"0606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be",
+	)
+	blsTwoG2 = blsFp(
+		"1638533957d540a9d2370f17cc7ed5863bc0b995b8825e0ee1ea1e1e4d00dbae81f14b0bf3611b78c952aacab827a053",
+		"0a4edef9c1ed7f729f520e47730a124fd70662a904ba1074728114d1031e1572c6c886f6b57ec72a6178288c47c33577",
+		"0468fb440d82b0630aeb8dca2b5256789a66da69bf91009cbfe6bd221e47aa8ae88dece9764bf3bd999d95d71e4c9899",
+		"0f6d4552fa65dd2638b361543f887136a43253d9c66c411697003f7a13c308f5422e1aa0a59c8967acdefd8b6e36ccf3",
+	)
+	// x = 0 is on the curve, as y^2 = 4 has a square root
+	blsG1NotInSubgroup = blsFp(
+		"000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		"000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000002",
+	)
+	blsG1Zero = genZeroMem(4)
+	blsG2Zero = genZeroMem(8)
+)
+
+// Field elements are validated by the library
+var blsErrFieldElementNotLessThanModulus = errors.New("must be less than modulus")
+
+// in is the [precompile address, input] and exp is the
+// [output, gas cost, error] of the BLS12-381 precompiles
+var blsPrecompilesTests = []genericTest{
+	{
+		s:   "g1add G + G",
+		in:  []interface{}{byte(0x0b), hexToBytes(blsG1 + blsG1)},
+		exp: []interface{}{hexToBytes(blsTwoG1), uint64(375), nil},
+	},
+	{
+		s:   "g1add G + -G",
+		in:  []interface{}{byte(0x0b), hexToBytes(blsG1 + blsNegG1)},
+		exp: []interface{}{blsG1Zero, uint64(375), nil},
+	},
+	{
+		s:   "g1add G + 0",
+		in:  []interface{}{byte(0x0b), append(hexToBytes(blsG1), blsG1Zero...)},
+		exp: []interface{}{hexToBytes(blsG1), uint64(375), nil},
+	},
+	{
+		s:   "g1add point outside of subgroup",
+		in:  []interface{}{byte(0x0b), append(hexToBytes(blsG1NotInSubgroup), blsG1Zero...)},
+		exp: []interface{}{hexToBytes(blsG1NotInSubgroup), uint64(375), nil},
+	},
+	{
+		s:   "g1add invalid input length",
+		in:  []interface{}{byte(0x0b), hexToBytes(blsG1)},
+		exp: []interface{}{[]byte(nil), uint64(375), ErrBLS12381InvalidInputLen},
+	},
+	{
+		s:   "g1add invalid field element top bytes",
+		in:  []interface{}{byte(0x0b), hexToBytes("01" + blsG1[2:] + blsG1)},
+		exp: []interface{}{[]byte(nil), uint64(375), ErrBLS12381InvalidFieldElementTopBytes},
+	},
+	{
+		s:   "g1msm G * 2",
+		in:  []interface{}{byte(0x0c), hexToBytes(blsG1 + blsScalar(2))},
+		exp: []interface{}{hexToBytes(blsTwoG1), uint64(12000), nil},
+	},
+	{
+		s:   "g1msm G * 1 + G * 1",
+		in:  []interface{}{byte(0x0c), hexToBytes(blsG1 + blsScalar(1) + blsG1 + blsScalar(1))},
+		exp: []interface{}{hexToBytes(blsTwoG1), uint64(22776), nil},
+	},
+	{
+		s:   "g1msm G * 0",
+		in:  []interface{}{byte(0x0c), hexToBytes(blsG1 + blsScalar(0))},
+		exp: []interface{}{blsG1Zero, uint64(12000), nil},
+	},
+	{
+		s:   "g1msm scalar not reduced by the subgroup order",
+		in:  []interface{}{byte(0x0c), hexToBytes(blsG1 + "73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000003")},
+		exp: []interface{}{hexToBytes(blsTwoG1), uint64(12000), nil},
+	},
+	{
+		s:   "g1msm point outside of subgroup",
+		in:  []interface{}{byte(0x0c), hexToBytes(blsG1NotInSubgroup + blsScalar(1))},
+		exp: []interface{}{[]byte(nil), uint64(12000), ErrBLS12381PointNotInSubgroup},
+	},
+	{
+		s:   "g1msm empty input",
+		in:  []interface{}{byte(0x0c), []byte{}},
+		exp: []interface{}{[]byte(nil), uint64(0), ErrBLS12381InvalidInputLen},
+	},
+	{
+		s:   "g2add G + G",
+		in:  []interface{}{byte(0x0d), hexToBytes(blsG2 + blsG2)},
+		exp: []interface{}{hexToBytes(blsTwoG2), uint64(600), nil},
+	},
+	{
+		s:   "g2add G + 0",
+		in:  []interface{}{byte(0x0d), append(hexToBytes(blsG2), blsG2Zero...)},
+		exp: []interface{}{hexToBytes(blsG2), uint64(600), nil},
+	},
+	{
+		s:   "g2add invalid input length",
+		in:  []interface{}{byte(0x0d), hexToBytes(blsG2)},
+		exp: []interface{}{[]byte(nil), uint64(600), ErrBLS12381InvalidInputLen},
+	},
+	{
+		s:   "g2msm G * 2",
+		in:  []interface{}{byte(0x0e), hexToBytes(blsG2 + blsScalar(2))},
+		exp: []interface{}{hexToBytes(blsTwoG2), uint64(22500), nil},
+	},
+	{
+		s:   "g2msm G * 1 + G * 1",
+		in:  []interface{}{byte(0x0e), hexToBytes(blsG2 + blsScalar(1) + blsG2 + blsScalar(1))},
+		exp: []interface{}{hexToBytes(blsTwoG2), uint64(45000), nil},
+	},
+	{
+		s:   "pairing e(G1, G2) * e(-G1, G2)",
+		in:  []interface{}{byte(0x0f), hexToBytes(blsG1 + blsG2 + blsNegG1 + blsG2)},
+		exp: []interface{}{hexToBytes("0000000000000000000000000000000000000000000000000000000000000001"), uint64(102900), nil},
+	},
+	{
+		s:   "pairing e(2 * G1, G2) * e(-G1, 2 * G2)",
+		in:  []interface{}{byte(0x0f), hexToBytes(blsTwoG1 + blsG2 + blsNegG1 + blsTwoG2)},
+		exp: []interface{}{hexToBytes("0000000000000000000000000000000000000000000000000000000000000001"), uint64(102900), nil},
+	},
+	{
+		s:   "pairing e(G1, G2)",
+		in:  []interface{}{byte(0x0f), hexToBytes(blsG1 + blsG2)},
+		exp: []interface{}{hexToBytes("0000000000000000000000000000000000000000000000000000000000000000"), uint64(70300), nil},
+	},
+	{
+		s:   "pairing with point at infinity",
+		in:  []interface{}{byte(0x0f), append(blsG1Zero, hexToBytes(blsG2)...)},
+		exp: []interface{}{hexToBytes("0000000000000000000000000000000000000000000000000000000000000001"), uint64(70300), nil},
+	},
+	{
+		s:   "pairing point outside of subgroup",
+		in:  []interface{}{byte(0x0f), hexToBytes(blsG1NotInSubgroup + blsG2)},
+		exp: []interface{}{[]byte(nil), uint64(70300), ErrBLS12381PointNotInSubgroup},
+	},
+	{
+		s:   "pairing empty input",
+		in:  []interface{}{byte(0x0f), []byte{}},
+		exp: []interface{}{[]byte(nil), uint64(37700), ErrBLS12381InvalidInputLen},
+	},
+	{
+		s:  "map fp to g1",
+		in: []interface{}{byte(0x10), hexToBytes(blsFp("156c8a6a2c184569d69a76be144b5cdc5141d2d2ca4fe341f011e25e3969c55ad9e9b9ce2eb833c81a908e5fa4ac5f03"))},
+		exp: []interface{}{hexToBytes(blsFp(
+			"184bb665c37ff561a89ec2122dd343f20e0f4cbcaec84e3c3052ea81d1834e192c426074b02ed3dca4e7676ce4ce48ba",
+			"04407b8d35af4dacc809927071fc0405218f1401a6d15af775810e4e460064bcc9468beeba82fdc751be70476c888bf3",
+		)), uint64(5500), nil},
+	},
+	{
+		s:   "map fp to g1 field element not less than modulus",
+		in:  []interface{}{byte(0x10), hexToBytes(blsFp("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab"))},
+		exp: []interface{}{[]byte(nil), uint64(5500), blsErrFieldElementNotLessThanModulus},
+	},
+	{
+		s:  "map fp2 to g2 of zero",
+		in: []interface{}{byte(0x11), genZeroMem(4)},
+		exp: []interface{}{hexToBytes(blsFp(
+			"018320896ec9eef9d5e619848dc29ce266f413d02dd31d9b9d44ec0c79cd61f18b075ddba6d7bd20b7ff27a4b324bfce",
+			"0a67d12118b5a35bb02d2e86b3ebfa7e23410db93de39fb06d7025fa95e96ffa428a7a27c3ae4dd4b40bd251ac658892",
+			"0260e03644d1a2c321256b3246bad2b895cad13890cbe6f85df55106a0d334604fb143c7a042d878006271865bc35941",
+			"04c69777a43f0bda07679d5805e63f18cf4e0e7c6112ac7f70266d199b4f76ae27c6269a3ceebdae30806e9a76aadf5c",
+		)), uint64(23800), nil},
+	},
+	{
+		s:   "map fp2 to g2 invalid input length",
+		in:  []interface{}{byte(0x11), genZeroMem(2)},
+		exp: []interface{}{[]byte(nil), uint64(23800), ErrBLS12381InvalidInputLen},
+	},
+}
+
+func Test_Precompiles_BLS12381(t *testing.T) {
+	anyTestFailed := false
+	precompiles := newJupiterPrecompiles()
+	for _, test := range blsPrecompilesTests {
+		testIn := test.in.([]interface{})
+		p := precompiles[BytesToAddress([]byte{testIn[0].(byte)})]
+		ret, err := p.Run(testIn[1].([]byte))
+		test.act = []interface{}{ret, p.GasCost(testIn[1].([]byte)), err}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the fork and exp is whether the fork
+// has the BLS12-381 precompiled contracts
+var blsPrecompilesForkTests = []genericTest{
+	{
+		s:   "moon",
+		in:  Moon,
+		exp: false,
+	},
+	{
+		s:   "mars",
+		in:  Mars,
+		exp: false,
+	},
+	{
+		s:   "jupiter",
+		in:  Jupiter,
+		exp: true,
+	},
+}
+
+func Test_Precompiles_BLS12381Forks(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range blsPrecompilesForkTests {
+		precompiles := NewInterpreter(test.in.(EVMFork)).precompiles
+		enabled := true
+		for addr := byte(0x0b); addr <= 0x11; addr++ {
+			_, ok := precompiles[BytesToAddress([]byte{addr})]
+			enabled = enabled && ok
+		}
+		test.act = enabled
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
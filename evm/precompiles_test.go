@@ -0,0 +1,533 @@
+package space_evm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"space/crypto/bn254"
+	"space/crypto/kzg4844"
+)
+
+// modexp input is built from the lengths of base, exponent and modulus,
+// followed by their values, each given as a hex string
+func modExpInput(lens []uint64, vals string) []byte {
+	buff := []byte{}
+	for _, l := range lens {
+		b := u256(l).Bytes32()
+		buff = append(buff, b[:]...)
+	}
+	return append(buff, hexToBytes(vals)...)
+}
+
+// BN254 G1 generator, its negation and double, and G2 generator
+var (
+	bn254G1    = "0000000000000000000000000000000000000000000000000000000000000001" + "0000000000000000000000000000000000000000000000000000000000000002"
+	bn254NegG1 = "0000000000000000000000000000000000000000000000000000000000000001" + "30644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd45"
+	bn254TwoG1 = "030644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd3" + "15ed738c0e0a7c92e7845f96b2ae9c0a68a6a449e3538fc7ff3ebf7a5a18a2c4"
+	bn254G2    = "198e9393920d483a7260bfb731fb5d25f1aa493335a9e71297e485b7aef312c2" + "1800deef121f1e76426a00665e5c4479674322d4f75edadd46debd5cd992f6ed" +
+		"090689d0585ff075ec9e99ad690c3395bc4b313370b38ef355acdadcd122975b" + "12c85ea5db8c6deb4aab71808dcb408fe3d1e7690c43d37b4ce6cc0166fa7daa"
+)
+
+// in is the [precompile address, input] and exp is the
+// [output, gas cost, error] of the elliptic curve precompiles
+var bn254PrecompilesTests = []genericTest{
+	{
+		s:   "ecadd G + G",
+		in:  []interface{}{byte(0x06), hexToBytes(bn254G1 + bn254G1)},
+		exp: []interface{}{hexToBytes(bn254TwoG1), uint64(150), nil},
+	},
+	{
+		s:   "ecadd G + -G",
+		in:  []interface{}{byte(0x06), hexToBytes(bn254G1 + bn254NegG1)},
+		exp: []interface{}{genZeroMem(2), uint64(150), nil},
+	},
+	{
+		s:   "ecadd G + 0 with padding",
+		in:  []interface{}{byte(0x06), hexToBytes(bn254G1)},
+		exp: []interface{}{hexToBytes(bn254G1), uint64(150), nil},
+	},
+	{
+		s:   "ecadd not on curve",
+		in:  []interface{}{byte(0x06), hexToBytes(bn254G1 + bn254G1[:127] + "3")},
+		exp: []interface{}{[]byte(nil), uint64(150), bn254.ErrPointNotOnCurve},
+	},
+	{
+		s:   "ecmul G * 2",
+		in:  []interface{}{byte(0x07), hexToBytes(bn254G1 + "0000000000000000000000000000000000000000000000000000000000000002")},
+		exp: []interface{}{hexToBytes(bn254TwoG1), uint64(6000), nil},
+	},
+	{
+		s:   "ecmul G * 0 with padding",
+		in:  []interface{}{byte(0x07), hexToBytes(bn254G1)},
+		exp: []interface{}{genZeroMem(2), uint64(6000), nil},
+	},
+	{
+		s:   "ecmul coordinate not in field",
+		in:  []interface{}{byte(0x07), hexToBytes("30644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd47" + bn254G1[64:])},
+		exp: []interface{}{[]byte(nil), uint64(6000), bn254.ErrCoordinateRange},
+	},
+	{
+		s:   "ecpairing empty input",
+		in:  []interface{}{byte(0x08), []byte{}},
+		exp: []interface{}{hexToBytes("0000000000000000000000000000000000000000000000000000000000000001"), uint64(45000), nil},
+	},
+	{
+		s:   "ecpairing e(G1, G2) * e(-G1, G2)",
+		in:  []interface{}{byte(0x08), hexToBytes(bn254G1 + bn254G2 + bn254NegG1 + bn254G2)},
+		exp: []interface{}{hexToBytes("0000000000000000000000000000000000000000000000000000000000000001"), uint64(113000), nil},
+	},
+	{
+		s:   "ecpairing e(G1, G2) * e(G1, G2)",
+		in:  []interface{}{byte(0x08), hexToBytes(bn254G1 + bn254G2 + bn254G1 + bn254G2)},
+		exp: []interface{}{hexToBytes("0000000000000000000000000000000000000000000000000000000000000000"), uint64(113000), nil},
+	},
+	{
+		s:   "ecpairing invalid input length",
+		in:  []interface{}{byte(0x08), hexToBytes(bn254G1 + bn254G2)[:191]},
+		exp: []interface{}{[]byte(nil), uint64(45000), ErrBadPairingInputLen},
+	},
+	{
+		s:   "ecpairing g2 not on curve",
+		in:  []interface{}{byte(0x08), hexToBytes(bn254G1 + bn254G2[64:128] + bn254G2[:64] + bn254G2[128:])},
+		exp: []interface{}{[]byte(nil), uint64(79000), bn254.ErrPointNotOnCurve},
+	},
+}
+
+func Test_Precompiles_BN254(t *testing.T) {
+	anyTestFailed := false
+	precompiles := newMoonPrecompiles()
+	for _, test := range bn254PrecompilesTests {
+		testIn := test.in.([]interface{})
+		p := precompiles[BytesToAddress([]byte{testIn[0].(byte)})]
+		ret, err := p.Run(testIn[1].([]byte))
+		test.act = []interface{}{ret, p.GasCost(testIn[1].([]byte)), err}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// Test vectors of the EIP-196 and EIP-197 precompiles, in the format
+// of the precompile tests of go-ethereum. Vectors of the fail files
+// give the error of the invalid points, which includes the G2 points
+// on the twist curve out of the subgroup.
+func Test_Precompiles_BN254Vectors(t *testing.T) {
+	anyTestFailed := false
+	precompiles := newMoonPrecompiles()
+	for addr, name := range map[byte]string{0x06: "bn256Add", 0x07: "bn256ScalarMul", 0x08: "bn256Pairing"} {
+		p := precompiles[BytesToAddress([]byte{addr})]
+		for _, file := range []string{name, "fail-" + name} {
+			var vectors []struct {
+				Input, Expected, ExpectedError, Name string
+				Gas                                  uint64
+			}
+			buff, err := os.ReadFile("testdata/precompiles/" + file + ".json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(buff, &vectors); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, vector := range vectors {
+				input := hexToBytes(vector.Input)
+				ret, err := p.Run(input)
+				test := genericTest{s: file + " " + vector.Name}
+				if vector.ExpectedError != "" {
+					test.exp, test.act = []interface{}{[]byte(nil), vector.ExpectedError}, []interface{}{ret, fmt.Sprint(err)}
+				} else {
+					test.exp = []interface{}{hexToBytes(vector.Expected), vector.Gas, nil}
+					test.act = []interface{}{ret, p.GasCost(input), err}
+				}
+				msg, failed := test.Check()
+				anyTestFailed = anyTestFailed || failed
+				fmt.Print(msg)
+			}
+		}
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// blake2f input of EIP-152 test vectors, which is built from the
+// rounds and the final block flag given as hex strings
+func blake2FInput(rounds string, f string) []byte {
+	return hexToBytes(rounds +
+		"48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b" +
+		"6162630000000000000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000000" +
+		"03000000000000000000000000000000" + f)
+}
+
+// in is the input and exp is the [output, gas cost, error]
+// of the blake2f precompile, as given by the EIP-152
+var blake2FTests = []genericTest{
+	{
+		s:   "eip-152 vector 0, empty input",
+		in:  []byte{},
+		exp: []interface{}{[]byte(nil), uint64(0), ErrBlake2FInvalidInputLen},
+	},
+	{
+		s:   "eip-152 vector 1, short input",
+		in:  blake2FInput("00000c", "01"),
+		exp: []interface{}{[]byte(nil), uint64(0), ErrBlake2FInvalidInputLen},
+	},
+	{
+		s:   "eip-152 vector 2, long input",
+		in:  blake2FInput("000000000c", "01"),
+		exp: []interface{}{[]byte(nil), uint64(0), ErrBlake2FInvalidInputLen},
+	},
+	{
+		s:   "eip-152 vector 3, invalid final block flag",
+		in:  blake2FInput("0000000c", "02"),
+		exp: []interface{}{[]byte(nil), uint64(12), ErrBlake2FInvalidFinalFlag},
+	},
+	{
+		s:   "eip-152 vector 4, 0 rounds",
+		in:  blake2FInput("00000000", "01"),
+		exp: []interface{}{hexToBytes("08c9bcf367e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d282e6ad7f520e511f6c3e2b8c68059b9442be0454267ce079217e1319cde05b"), uint64(0), nil},
+	},
+	{
+		s:   "eip-152 vector 5, 12 rounds",
+		in:  blake2FInput("0000000c", "01"),
+		exp: []interface{}{hexToBytes("ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923"), uint64(12), nil},
+	},
+	{
+		s:   "eip-152 vector 6, not final block",
+		in:  blake2FInput("0000000c", "00"),
+		exp: []interface{}{hexToBytes("75ab69d3190a562c51aef8d88f1c2775876944407270c42c9844252c26d2875298743e7f6d5ea2f2d3e8d226039cd31b4e426ac4f2d3d666a610c2116fde4735"), uint64(12), nil},
+	},
+	{
+		s:   "eip-152 vector 7, 1 round",
+		in:  blake2FInput("00000001", "01"),
+		exp: []interface{}{hexToBytes("b63a380cb2897d521994a85234ee2c181b5f844d2c624c002677e9703449d2fba551b3a8333bcdf5f2f7e08993d53923de3d64fcc68c034e717b9293fed7a421"), uint64(1), nil},
+	},
+}
+
+// vector 8 takes minutes to run, hence it is only
+// run if SPACE_EVM_SLOW_TESTS environment variable is set
+var blake2FSlowTests = []genericTest{
+	{
+		s:   "eip-152 vector 8, max rounds",
+		in:  blake2FInput("ffffffff", "01"),
+		exp: []interface{}{hexToBytes("fc59093aafa9ab43daae0e914c57635c5402d8e3d2130eb9b3cc181de7f0ecf9b22bf99a7815ce16419e200e01846e6b5df8cc7703041bbceb571de6631d2615"), uint64(4294967295), nil},
+	},
+}
+
+func Test_Precompiles_Blake2F(t *testing.T) {
+	anyTestFailed := false
+	p := newMoonPrecompiles()[BytesToAddress([]byte{0x09})]
+	tests := blake2FTests
+	if os.Getenv("SPACE_EVM_SLOW_TESTS") != "" {
+		tests = append(tests, blake2FSlowTests...)
+	}
+	for _, test := range tests {
+		ret, err := p.Run(test.in.([]byte))
+		test.act = []interface{}{ret, p.GasCost(test.in.([]byte)), err}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+var ecrecoverTestInput = "18c547e4f7b0f325ad1e56f57e26c745b09a3e503d86e00e5255ff7f715d3d1c000000000000000000000000000000000000000000000000000000000000001c73b1693892219d736caba55bdb67216e485557ea6b6af75f37096c9aa6a5a75feeb940b1d03b21e36b0e47e79769f095fe2ab855bd91e3a38756b7d75a9c4549"
+
+// in is the [precompile address, input] and
+// exp is the [output, gas cost] of the precompile
+var moonPrecompilesTests = []genericTest{
+	{
+		s:   "ecrecover",
+		in:  []interface{}{byte(0x01), hexToBytes(ecrecoverTestInput)},
+		exp: []interface{}{hexToBytes("000000000000000000000000a94f5374fce5edbc8e2a8697c15331677e6ebf0b"), uint64(3000)},
+	},
+	{
+		s:   "ecrecover with invalid v",
+		in:  []interface{}{byte(0x01), hexToBytes(strings.Replace(ecrecoverTestInput, "001c73b1", "001d73b1", 1))},
+		exp: []interface{}{[]byte(nil), uint64(3000)},
+	},
+	{
+		s:   "ecrecover with dirty v",
+		in:  []interface{}{byte(0x01), hexToBytes(strings.Replace(ecrecoverTestInput, "0000001c73b1", "0001001c73b1", 1))},
+		exp: []interface{}{[]byte(nil), uint64(3000)},
+	},
+	{
+		s:   "ecrecover with zero s",
+		in:  []interface{}{byte(0x01), hexToBytes(ecrecoverTestInput[:192])},
+		exp: []interface{}{[]byte(nil), uint64(3000)},
+	},
+	{
+		s:   "ecrecover of empty input",
+		in:  []interface{}{byte(0x01), []byte{}},
+		exp: []interface{}{[]byte(nil), uint64(3000)},
+	},
+	{
+		s:   "sha256 of empty input",
+		in:  []interface{}{byte(0x02), []byte{}},
+		exp: []interface{}{hexToBytes("e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"), uint64(60)},
+	},
+	{
+		s:   "sha256 of abc",
+		in:  []interface{}{byte(0x02), []byte("abc")},
+		exp: []interface{}{hexToBytes("ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"), uint64(72)},
+	},
+	{
+		s:   "ripemd160 of empty input",
+		in:  []interface{}{byte(0x03), []byte{}},
+		exp: []interface{}{hexToBytes("0000000000000000000000009c1185a5c5e9fc54612808977ee8f548b2258d31"), uint64(600)},
+	},
+	{
+		s:   "ripemd160 of abc",
+		in:  []interface{}{byte(0x03), []byte("abc")},
+		exp: []interface{}{hexToBytes("0000000000000000000000008eb208f7e05d987a9b044a8e98c6b087f15a0bfc"), uint64(720)},
+	},
+	{
+		s:   "identity of empty input",
+		in:  []interface{}{byte(0x04), []byte{}},
+		exp: []interface{}{[]byte{}, uint64(15)},
+	},
+	{
+		s:   "identity of 33 bytes",
+		in:  []interface{}{byte(0x04), genZeroMem(1)},
+		exp: []interface{}{genZeroMem(1), uint64(18)},
+	},
+}
+
+var modExpTests = []genericTest{
+	{
+		s: "fermat little theorem",
+		in: modExpInput([]uint64{1, 32, 32}, "03"+
+			"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e"+
+			"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f"),
+		exp: []interface{}{hexToBytes("0000000000000000000000000000000000000000000000000000000000000001"), uint64(1360)},
+	},
+	{
+		s: "zero length base",
+		in: modExpInput([]uint64{0, 32, 32}, ""+
+			"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e"+
+			"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f"),
+		exp: []interface{}{hexToBytes("0000000000000000000000000000000000000000000000000000000000000000"), uint64(1360)},
+	},
+	{
+		s:   "zero length exponent",
+		in:  modExpInput([]uint64{1, 0, 1}, "0705"),
+		exp: []interface{}{[]byte{1}, uint64(200)},
+	},
+	{
+		s:   "zero length modulus",
+		in:  modExpInput([]uint64{1, 1, 0}, "0705"),
+		exp: []interface{}{[]byte{}, uint64(200)},
+	},
+	{
+		s:   "zero length base and modulus with huge exponent length",
+		in:  append(modExpInput([]uint64{0}, ""), append(MaxUint256.Bytes(), genZeroMem(1)...)...),
+		exp: []interface{}{[]byte{}, uint64(200)},
+	},
+	{
+		s:   "zero modulus",
+		in:  modExpInput([]uint64{1, 1, 2}, "07050000"),
+		exp: []interface{}{[]byte{0, 0}, uint64(200)},
+	},
+	{
+		s:   "modulus one",
+		in:  modExpInput([]uint64{1, 1, 1}, "070001"),
+		exp: []interface{}{[]byte{0}, uint64(200)},
+	},
+	{
+		s:   "values right-padded with zeroes",
+		in:  modExpInput([]uint64{1, 1, 2}, "0203"),
+		exp: []interface{}{[]byte{0, 0}, uint64(200)},
+	},
+	{
+		s:   "output left-padded to modulus length",
+		in:  modExpInput([]uint64{1, 1, 3}, "0203000100"),
+		exp: []interface{}{[]byte{0, 0, 8}, uint64(200)},
+	},
+}
+
+func Test_Precompiles_ModExp(t *testing.T) {
+	anyTestFailed := false
+	p := newMoonPrecompiles()[BytesToAddress([]byte{0x05})]
+	for _, test := range modExpTests {
+		ret, _ := p.Run(test.in.([]byte))
+		test.act = []interface{}{ret, p.GasCost(test.in.([]byte))}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+func Test_Precompiles_Moon(t *testing.T) {
+	anyTestFailed := false
+	precompiles := newMoonPrecompiles()
+	for _, test := range moonPrecompilesTests {
+		testIn := test.in.([]interface{})
+		p := precompiles[BytesToAddress([]byte{testIn[0].(byte)})]
+		ret, _ := p.Run(testIn[1].([]byte))
+		test.act = []interface{}{ret, p.GasCost(testIn[1].([]byte))}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+var errTestPrecompile = errors.New("test precompile error")
+
+var testPrecompile = &Precompile{
+	Name:    "TEST",
+	GasCost: func(input []byte) uint64 { return 100 },
+	Run: func(input []byte) ([]byte, error) {
+		if len(input) == 0 {
+			return nil, errTestPrecompile
+		}
+		return input[len(input)-1:], nil
+	},
+}
+
+// in is the [input, gas] and exp is the
+// [output, remaining gas, error] of the run
+var runPrecompileTests = []genericTest{
+	{
+		s:   "run with exact gas",
+		in:  []interface{}{[]byte{7, 8}, uint64(100)},
+		exp: []interface{}{[]byte{8}, uint64(0), nil},
+	},
+	{
+		s:   "run with more gas",
+		in:  []interface{}{[]byte{7, 8}, uint64(150)},
+		exp: []interface{}{[]byte{8}, uint64(50), nil},
+	},
+	{
+		s:   "out of gas",
+		in:  []interface{}{[]byte{7, 8}, uint64(99)},
+		exp: []interface{}{[]byte(nil), uint64(0), ErrOutOfGas},
+	},
+	{
+		s:   "precompile error consumes all gas",
+		in:  []interface{}{[]byte{}, uint64(150)},
+		exp: []interface{}{[]byte(nil), uint64(0), errTestPrecompile},
+	},
+}
+
+func Test_Precompiles_RunPrecompile(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range runPrecompileTests {
+		testIn := test.in.([]interface{})
+		ret, gas, err := runPrecompile(testPrecompile, testIn[0].([]byte), testIn[1].(uint64))
+		test.act = []interface{}{ret, gas, err}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is whether the custom precompile is registered, and exp is
+// the [memory, gas used] after the code stores 0x2a at offset 0, and
+// static calls the address 0xaa with it to write 1 byte at offset 32
+var registerPrecompileTests = []genericTest{
+	{
+		s:  "registered precompile is called",
+		in: true,
+		exp: []interface{}{
+			hexToBytes("000000000000000000000000000000000000000000000000000000000000002a2a00000000000000000000000000000000000000000000000000000000000000"),
+			uint64(833),
+		},
+	},
+	{
+		s:  "unregistered address has no code",
+		in: false,
+		exp: []interface{}{
+			hexToBytes("000000000000000000000000000000000000000000000000000000000000002a0000000000000000000000000000000000000000000000000000000000000000"),
+			uint64(733),
+		},
+	},
+}
+
+func Test_Precompiles_RegisterPrecompile(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range registerPrecompileTests {
+		evm := NewEVM(Moon)
+		if test.in.(bool) {
+			evm.RegisterPrecompile(BytesToAddress([]byte{0xaa}), testPrecompile)
+		}
+		runRes := evm.interpreter.Run(hexToBytes("602a600052600160206020600060aa62fffffffa"), MaxUint64)
+		test.act = []interface{}{[]byte(*evm.interpreter.runState.Memory), runRes.GasUsed}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+var pointEvaluationTestInput = "01e798154708fe7789429634053cbf9f99b619f9f084048927333fce637f549b" +
+	"564c0a11a0f704f4fc3e8acfe0f8245f0ad1347b378fbf96e206da11a5d36306" +
+	"24d25032e67a7e6a4910df5834b8fe70e6bcfeeac0352434196bdf4b2485d5a1" +
+	"8f59a8d2a1a625a17f3fea0fe5eb8c896db3764f3185481bc22f91b4aaffcca25f26936857bc3a7c2539ea8ec3a952b7" +
+	"873033e038326e87ed3e1276fd140253fa08e9fc25fb2d9a98527fc22a2c9612fbeafdad446cbc7bcdbdcd780af2c16a"
+
+// in is the input and exp is the [output, gas cost, error]
+var pointEvaluationTests = []genericTest{
+	{
+		s:  "valid proof",
+		in: hexToBytes(pointEvaluationTestInput),
+		exp: []interface{}{
+			hexToBytes("0000000000000000000000000000000000000000000000000000000000001000" +
+				"73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001"),
+			uint64(50000),
+			nil,
+		},
+	},
+	{
+		s:   "invalid input length",
+		in:  hexToBytes(pointEvaluationTestInput[2:]),
+		exp: []interface{}{[]byte(nil), uint64(50000), ErrPointEvaluationInvalidInputLen},
+	},
+	{
+		s:   "mismatched versioned hash",
+		in:  hexToBytes("02" + pointEvaluationTestInput[2:]),
+		exp: []interface{}{[]byte(nil), uint64(50000), ErrPointEvaluationMismatchedVersionedHash},
+	},
+	{
+		s:   "wrong claimed value",
+		in:  hexToBytes(strings.Replace(pointEvaluationTestInput, "2485d5a1", "2485d5a2", 1)),
+		exp: []interface{}{[]byte(nil), uint64(50000), kzg4844.ErrProofVerification},
+	},
+}
+
+func Test_Precompiles_PointEvaluation(t *testing.T) {
+	anyTestFailed := false
+	p := newSaturnPrecompiles()[BytesToAddress([]byte{0x0a})]
+	for _, test := range pointEvaluationTests {
+		ret, err := p.Run(test.in.([]byte))
+		test.act = []interface{}{ret, p.GasCost(test.in.([]byte)), err}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
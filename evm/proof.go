@@ -0,0 +1,163 @@
+package space_evm
+
+import (
+	"fmt"
+
+	"space/database"
+	"space/rlp"
+	"space/trie"
+
+	"github.com/holiman/uint256"
+)
+
+// AccountResult is the Merkle proof of an account and some of its
+// storage slots, which has the same shape as the eth_getProof result
+type AccountResult struct {
+	Address      Address         `json:"address"`
+	AccountProof []HexBytes      `json:"accountProof"`
+	Balance      *uint256.Int    `json:"balance"`
+	CodeHash     Hash            `json:"codeHash"`
+	Nonce        HexUint64       `json:"nonce"`
+	StorageHash  Hash            `json:"storageHash"`
+	StorageProof []StorageResult `json:"storageProof"`
+}
+
+// StorageResult is the Merkle proof of a storage slot of an account
+type StorageResult struct {
+	Key   Hash         `json:"key"`
+	Value *uint256.Int `json:"value"`
+	Proof []HexBytes   `json:"proof"`
+}
+
+// Returns the proofs of the account and its storage slots in the state
+// with the given root, whose tries are read from the reader. Proofs of
+// missing accounts and slots show that they are not in the tries.
+func GetProof(reader database.KeyValueReader, root Hash, addr Address, slots []Hash) (*AccountResult, error) {
+	accounts, err := trie.New(root, reader)
+	if err != nil {
+		return nil, err
+	}
+	return proveAccount(accounts, func(root Hash) (*trie.Trie, error) {
+		return trie.New(root, reader)
+	}, addr, slots)
+}
+
+// Returns the proofs of the account in the state trie and its slots
+// in its storage trie, which is opened with the storage root
+func proveAccount(accounts *trie.Trie, openStorage func(root Hash) (*trie.Trie, error), addr Address, slots []Hash) (*AccountResult, error) {
+	accountProof, err := accounts.Prove(keccak256(addr[:]))
+	if err != nil {
+		return nil, err
+	}
+	account := stateAccount{Balance: new(uint256.Int), Root: EmptyRootHash, CodeHash: EmptyCodeHash}
+	enc, err := accounts.Get(keccak256(addr[:]))
+	if err != nil {
+		return nil, err
+	}
+	if enc != nil {
+		if err := rlp.Decode(enc, &account); err != nil {
+			return nil, err
+		}
+	}
+
+	storage, err := openStorage(account.Root)
+	if err != nil {
+		return nil, err
+	}
+	storageProof := make([]StorageResult, len(slots))
+	for i, slot := range slots {
+		proof, err := storage.Prove(keccak256(slot[:]))
+		if err != nil {
+			return nil, err
+		}
+		value := new(uint256.Int)
+		enc, err := storage.Get(keccak256(slot[:]))
+		if err != nil {
+			return nil, err
+		}
+		if enc != nil {
+			if err := rlp.Decode(enc, value); err != nil {
+				return nil, err
+			}
+		}
+		storageProof[i] = StorageResult{Key: slot, Value: value, Proof: toHexBytesList(proof)}
+	}
+
+	return &AccountResult{
+		Address:      addr,
+		AccountProof: toHexBytesList(accountProof),
+		Balance:      account.Balance,
+		CodeHash:     account.CodeHash,
+		Nonce:        HexUint64(account.Nonce),
+		StorageHash:  account.Root,
+		StorageProof: storageProof,
+	}, nil
+}
+
+// Returns the proofs of the account and its storage slots in the current
+// state, which are taken from the tries of the state
+func (s *StateDB) GetProof(addr Address, slots []Hash) (*AccountResult, error) {
+	s.IntermediateRoot()
+	if s.err != nil {
+		return nil, s.err
+	}
+	return proveAccount(s.trie, func(root Hash) (*trie.Trie, error) {
+		if obj, ok := s.objects[addr]; ok {
+			return obj.trie, nil
+		}
+		return trie.New(root, nil)
+	}, addr, slots)
+}
+
+// Verifies that the account and the storage values in the result are
+// proven by its proofs against the state root. Missing accounts must
+// be empty, and missing slots must be zero.
+func VerifyAccountProof(root Hash, res *AccountResult) error {
+	enc, err := trie.VerifyProof(root, keccak256(res.Address[:]), toBytesList(res.AccountProof))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAccountProof, err)
+	}
+	account := stateAccount{Balance: new(uint256.Int), Root: EmptyRootHash, CodeHash: EmptyCodeHash}
+	if enc != nil {
+		if err := rlp.Decode(enc, &account); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidAccountProof, err)
+		}
+	}
+	if res.Balance == nil || !res.Balance.Eq(account.Balance) || uint64(res.Nonce) != account.Nonce ||
+		res.CodeHash != account.CodeHash || res.StorageHash != account.Root {
+		return fmt.Errorf("%w: account of %v does not match the proof", ErrInvalidAccountProof, res.Address.Hex())
+	}
+
+	for _, storageRes := range res.StorageProof {
+		enc, err := trie.VerifyProof(res.StorageHash, keccak256(storageRes.Key[:]), toBytesList(storageRes.Proof))
+		if err != nil {
+			return fmt.Errorf("%w: slot %v: %v", ErrInvalidStorageProof, storageRes.Key.Hex(), err)
+		}
+		value := new(uint256.Int)
+		if enc != nil {
+			if err := rlp.Decode(enc, value); err != nil {
+				return fmt.Errorf("%w: slot %v: %v", ErrInvalidStorageProof, storageRes.Key.Hex(), err)
+			}
+		}
+		if storageRes.Value == nil || !storageRes.Value.Eq(value) {
+			return fmt.Errorf("%w: value of slot %v does not match the proof", ErrInvalidStorageProof, storageRes.Key.Hex())
+		}
+	}
+	return nil
+}
+
+func toHexBytesList(list [][]byte) []HexBytes {
+	res := make([]HexBytes, len(list))
+	for i, buff := range list {
+		res[i] = buff
+	}
+	return res
+}
+
+func toBytesList(list []HexBytes) [][]byte {
+	res := make([][]byte, len(list))
+	for i, buff := range list {
+		res[i] = buff
+	}
+	return res
+}
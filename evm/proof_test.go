@@ -0,0 +1,120 @@
+package space_evm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+var testSlots = []Hash{BytesToHash([]byte{1}), BytesToHash([]byte{2}), BytesToHash([]byte{3})}
+
+// in is the address to prove in the test state, and exp is
+// the balance, nonce, storage hash and storage values
+var getProofTests = []genericTest{
+	{
+		s:   "account without storage",
+		in:  testAddr1,
+		exp: []interface{}{u256(1000), HexUint64(1), EmptyRootHash, []interface{}{u256(0), u256(0), u256(0)}, error(nil)},
+	},
+	{
+		s:   "account with storage",
+		in:  testAddr2,
+		exp: []interface{}{u256(0), HexUint64(0), genTestState().StorageRoot(testAddr2), []interface{}{u256(0x2a), u256(0x100), u256(0)}, error(nil)},
+	},
+	{
+		s:   "missing account",
+		in:  BytesToAddress([]byte{0xcc}),
+		exp: []interface{}{u256(0), HexUint64(0), EmptyRootHash, []interface{}{u256(0), u256(0), u256(0)}, error(nil)},
+	},
+}
+
+func Test_Proof_GetProof(t *testing.T) {
+	anyTestFailed := false
+	state := genTestState()
+	root := state.IntermediateRoot()
+	for _, test := range getProofTests {
+		res, err := state.GetProof(test.in.(Address), testSlots)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values := []interface{}{}
+		for _, storageRes := range res.StorageProof {
+			values = append(values, storageRes.Value)
+		}
+		test.act = []interface{}{res.Balance, res.Nonce, res.StorageHash, values, VerifyAccountProof(root, res)}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+func Test_Proof_JSON(t *testing.T) {
+	state := genTestState()
+	res, _ := state.GetProof(testAddr1, testSlots[:1])
+	buff, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	json.Unmarshal(buff, &fields)
+	var decoded AccountResult
+	err = json.Unmarshal(buff, &decoded)
+
+	test := genericTest{
+		s: "eth_getProof shape",
+		exp: []interface{}{
+			"0x00000000000000000000000000000000000000aa", "0x3e8", "0x1", EmptyCodeHash.Hex(), EmptyRootHash.Hex(),
+			fmt.Sprintf("0x%x", []byte(res.AccountProof[0])), error(nil), error(nil),
+		},
+		act: []interface{}{
+			fields["address"], fields["balance"], fields["nonce"], fields["codeHash"], fields["storageHash"],
+			fields["accountProof"].([]interface{})[0], err, VerifyAccountProof(state.IntermediateRoot(), &decoded),
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+// in modifies the proof of testAddr2, and exp is the error
+var invalidProofTests = []genericTest{
+	{s: "wrong balance", in: func(res *AccountResult) { res.Balance = u256(1) }, exp: ErrInvalidAccountProof},
+	{s: "wrong nonce", in: func(res *AccountResult) { res.Nonce = 1 }, exp: ErrInvalidAccountProof},
+	{s: "wrong code hash", in: func(res *AccountResult) { res.CodeHash = EmptyCodeHash }, exp: ErrInvalidAccountProof},
+	{s: "missing account proof", in: func(res *AccountResult) { res.AccountProof = nil }, exp: ErrInvalidAccountProof},
+	{s: "wrong storage hash", in: func(res *AccountResult) { res.StorageHash = EmptyRootHash }, exp: ErrInvalidAccountProof},
+	{s: "wrong slot value", in: func(res *AccountResult) { res.StorageProof[0].Value = u256(1) }, exp: ErrInvalidStorageProof},
+	{s: "missing slot value", in: func(res *AccountResult) { res.StorageProof[1].Value = nil }, exp: ErrInvalidStorageProof},
+	{s: "missing storage proof", in: func(res *AccountResult) { res.StorageProof[0].Proof = nil }, exp: ErrInvalidStorageProof},
+	{
+		s:   "proof of another slot",
+		in:  func(res *AccountResult) { res.StorageProof[1].Proof = res.StorageProof[0].Proof },
+		exp: ErrInvalidStorageProof,
+	},
+}
+
+func Test_Proof_Invalid(t *testing.T) {
+	anyTestFailed := false
+	state := genTestState()
+	for _, test := range invalidProofTests {
+		res, _ := state.GetProof(testAddr2, testSlots)
+		test.in.(func(*AccountResult))(res)
+		err := VerifyAccountProof(state.IntermediateRoot(), res)
+		test.act = err
+		if errors.Is(err, test.exp.(error)) {
+			test.act = test.exp
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,105 @@
+package space_evm
+
+import (
+	"space/rlp"
+
+	"github.com/holiman/uint256"
+)
+
+// Statuses of the receipts of the failed and successful transactions
+const (
+	ReceiptStatusFailed     uint64 = 0
+	ReceiptStatusSuccessful uint64 = 1
+)
+
+// Log is emitted by the LOG operations, with the address of the
+// account whose code emitted it. Only the address, topics and data
+// are part of the encoding, and the rest of the fields are set when
+// the log is included in a block.
+type Log struct {
+	Address Address
+	Topics  []Hash
+	Data    []byte
+
+	BlockNumber uint64
+	BlockHash   Hash
+	TxHash      Hash
+	TxIndex     uint
+	Index       uint
+}
+
+// Logs are encoded as the list of the address, topics and data
+func (l *Log) EncodeRLP() ([]byte, error) {
+	return rlp.Encode([]interface{}{l.Address, l.Topics, l.Data})
+}
+
+func (l *Log) DecodeRLP(buff []byte) error {
+	var dec struct {
+		Address Address
+		Topics  []Hash
+		Data    []byte
+	}
+	if err := rlp.Decode(buff, &dec); err != nil {
+		return err
+	}
+	*l = Log{Address: dec.Address, Topics: dec.Topics, Data: dec.Data}
+	return nil
+}
+
+// Receipt is the result of applying a transaction to the state. Status,
+// cumulative gas used, bloom and logs are the consensus fields of the
+// receipt, which are committed to with the receipts root of the block.
+// Return data and the execution error are not part of the receipts of
+// the blocks, and they are only kept for the callers.
+type Receipt struct {
+	Type              byte
+	Status            uint64
+	CumulativeGasUsed uint64
+	Bloom             Bloom
+	Logs              []*Log
+	TxHash            Hash
+	ContractAddress   *Address
+	GasUsed           uint64
+	EffectiveGasPrice *uint256.Int
+	BlobGasUsed       uint64
+	BlobGasPrice      *uint256.Int
+
+	BlockNumber      uint64
+	BlockHash        Hash
+	TransactionIndex uint
+
+	ReturnData []byte
+	Err        error
+}
+
+// Returns the consensus encoding of the receipt, which is the RLP
+// list for the receipts of legacy transactions, and the type byte
+// followed by the RLP list for the typed transactions
+func (r *Receipt) MarshalBinary() ([]byte, error) {
+	enc, err := rlp.Encode([]interface{}{r.Status, r.CumulativeGasUsed, r.Bloom, r.Logs})
+	if err != nil || r.Type == LegacyTxType {
+		return enc, err
+	}
+	return append([]byte{r.Type}, enc...), nil
+}
+
+// Sets the fields of the receipts and their logs which are derived
+// from the block, which are the block number and hash, the indexes of
+// the transactions and the logs in the block, and the cumulative gas
+// used, where the receipts are the receipts of the transactions of
+// the block in their order
+func deriveReceiptFields(receipts []*Receipt, block *Block) {
+	header := block.Header
+	blockHash, cumulativeGasUsed, logIndex := block.Hash(), uint64(0), uint(0)
+	for i, receipt := range receipts {
+		cumulativeGasUsed += receipt.GasUsed
+		receipt.CumulativeGasUsed = cumulativeGasUsed
+		receipt.TxHash = block.Transactions[i].Hash()
+		receipt.BlockNumber, receipt.BlockHash, receipt.TransactionIndex = header.Number, blockHash, uint(i)
+		for _, log := range receipt.Logs {
+			log.BlockNumber, log.BlockHash, log.TxHash = header.Number, blockHash, receipt.TxHash
+			log.TxIndex, log.Index = uint(i), logIndex
+			logIndex++
+		}
+	}
+}
@@ -32,6 +32,18 @@ func (st *Stack) pop() (*uint256.Int, error) {
 	return &val, nil
 }
 
+// Pops n items from the stack, the top item being the first
+func (st *Stack) popN(n int) ([]*uint256.Int, error) {
+	if st.Size() < n {
+		return nil, ErrStackUnderflow
+	}
+	vals := make([]*uint256.Int, n)
+	for i := range vals {
+		vals[i], _ = st.pop()
+	}
+	return vals, nil
+}
+
 // Returns a reference to n'th item in the stack.
 // Reference is returned to benefit in-place update.
 func (st *Stack) peek(n int) (*uint256.Int, error) {
@@ -3,6 +3,8 @@ package space_evm
 import (
 	"fmt"
 	"testing"
+
+	"github.com/holiman/uint256"
 )
 
 var stackPushTests = []genericTest{
@@ -65,3 +67,33 @@ func Test_Stack_Pop(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+// input first item size to push, second item size to pop,
+// exp is the popped items with the top item being the first
+var stackPopNTests = []genericTest{
+	{s: "pop 0 item from 0", in: []uint64{0, 0}, exp: []*uint256.Int{}},
+	{s: "pop 1 item from 2", in: []uint64{2, 1}, exp: []*uint256.Int{u256(2)}},
+	{s: "pop 3 item from 3", in: []uint64{3, 3}, exp: []*uint256.Int{u256(4), u256(2), u256(0)}},
+	{s: "underflow pop 3 item from 2", in: []uint64{2, 3}, exp: ErrStackUnderflow, shouldFail: true},
+}
+
+func Test_Stack_PopN(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range stackPopNTests {
+		stack := NewStack()
+		testIn := test.in.([]uint64)
+		populateStack(stack, genU64Slice(testIn[0])...)
+		vals, err := stack.popN(int(testIn[1]))
+		if !test.shouldFail {
+			test.act = vals
+		} else {
+			test.act = err
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,490 @@
+package space_evm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"space/database"
+	"space/rlp"
+	"space/trie"
+
+	"github.com/holiman/uint256"
+)
+
+// EmptyRootHash is the root of an empty trie, which is both the storage
+// root of an account without storage, and the state root of an empty state
+var EmptyRootHash = Hash(trie.EmptyRootHash)
+
+// EmptyCodeHash is the keccak256 of empty code
+var EmptyCodeHash = BytesToHash(keccak256(nil))
+
+// Tries of the state are keyed by keccak256 of the addresses and the
+// slots, hence the addresses and the slots are stored by their hashes
+// with the prefix, so that the state can be loaded back from its root
+var preimagePrefix = []byte("secure-key-") // preimagePrefix + hash -> preimage
+
+func preimageKey(hash []byte) []byte {
+	return append(append([]byte{}, preimagePrefix...), hash...)
+}
+
+// stateObject is an account in the state. Its storage only holds the
+// non-zero slots, since zero slots are not stored. Storage trie is only
+// brought up to date with the dirty slots once the storage root is
+// computed, hence the slots written many times are hashed once.
+type stateObject struct {
+	nonce    uint64
+	balance  *uint256.Int
+	code     []byte
+	codeHash Hash
+	storage  map[Hash]Hash
+
+	trie       *trie.Trie
+	dirtySlots map[Hash]struct{} // slots changed since the trie was updated
+	dirtyCode  bool              // whether the code is not written yet
+}
+
+func newStateObject() *stateObject {
+	storageTrie, _ := trie.New([32]byte{}, nil)
+	return &stateObject{
+		balance:    new(uint256.Int),
+		codeHash:   EmptyCodeHash,
+		storage:    make(map[Hash]Hash),
+		trie:       storageTrie,
+		dirtySlots: make(map[Hash]struct{}),
+	}
+}
+
+// Returns a deep copy of the object, whose trie shares the nodes
+func (obj *stateObject) copy() *stateObject {
+	cpy := &stateObject{
+		nonce:      obj.nonce,
+		balance:    new(uint256.Int).Set(obj.balance),
+		code:       obj.code,
+		codeHash:   obj.codeHash,
+		storage:    make(map[Hash]Hash, len(obj.storage)),
+		trie:       obj.trie.Copy(),
+		dirtySlots: make(map[Hash]struct{}, len(obj.dirtySlots)),
+		dirtyCode:  obj.dirtyCode,
+	}
+	for slot, value := range obj.storage {
+		cpy.storage[slot] = value
+	}
+	for slot := range obj.dirtySlots {
+		cpy.dirtySlots[slot] = struct{}{}
+	}
+	return cpy
+}
+
+// stateAccount is the encoding of an account in the state trie
+type stateAccount struct {
+	Nonce    uint64
+	Balance  *uint256.Int
+	Root     Hash
+	CodeHash Hash
+}
+
+// StateDB holds the accounts of the world state in memory. Accounts are
+// committed to with the state root, which is the root of the trie from
+// keccak256 of the addresses to the encoded accounts. Storage of each
+// account is committed to with its storage root, which is the root of
+// the trie from keccak256 of the slots to the encoded slot values.
+//
+// Tries are kept along with the accounts, and only the accounts and the
+// slots changed since the last root are updated in the tries, hence
+// only their paths are hashed again. Changes are recorded in a journal,
+// so that the state can be reverted to a snapshot without a copy.
+type StateDB struct {
+	objects map[Address]*stateObject
+
+	trie  *trie.Trie
+	dirty map[Address]struct{} // accounts changed since the trie was updated
+	// storage tries updated since the last commit, and the code and the
+	// preimages of the trie keys which are written by the next commit
+	storageCommits map[Address]struct{}
+	codes          map[Hash][]byte
+	preimages      map[string][]byte
+
+	journal []journalEntry
+	// first error of the tries, which can only fail while the
+	// nodes are read from the store, and it is returned by Commit
+	err error
+}
+
+func NewStateDB() *StateDB {
+	accounts, _ := trie.New([32]byte{}, nil)
+	return &StateDB{
+		objects:        make(map[Address]*stateObject),
+		trie:           accounts,
+		dirty:          make(map[Address]struct{}),
+		storageCommits: make(map[Address]struct{}),
+		codes:          make(map[Hash][]byte),
+		preimages:      make(map[string][]byte),
+	}
+}
+
+// Loads the state with the root from the reader, which has the state
+// committed with StateDB.Commit. All of the accounts with their code
+// and storage are read into memory, while the nodes of the tries are
+// read as they are reached by the changes.
+func LoadStateDB(root Hash, reader database.KeyValueReader) (*StateDB, error) {
+	s := NewStateDB()
+	accounts, err := trie.New(root, reader)
+	if err != nil {
+		return nil, err
+	}
+	s.trie = accounts
+	err = accounts.Iterate(func(key, value []byte) error {
+		addr, err := readPreimage(reader, key)
+		if err != nil {
+			return err
+		}
+		var account stateAccount
+		if err := rlp.Decode(value, &account); err != nil {
+			return fmt.Errorf("account %x: %w", addr, err)
+		}
+		obj := newStateObject()
+		obj.nonce, obj.balance, obj.codeHash = account.Nonce, account.Balance, account.CodeHash
+		if account.CodeHash != EmptyCodeHash {
+			if obj.code, err = reader.Get(account.CodeHash[:]); err != nil {
+				return fmt.Errorf("code %v: %w", account.CodeHash.Hex(), err)
+			}
+		}
+		if obj.trie, err = trie.New(account.Root, reader); err != nil {
+			return err
+		}
+		err = obj.trie.Iterate(func(key, value []byte) error {
+			slot, err := readPreimage(reader, key)
+			if err != nil {
+				return err
+			}
+			val := new(uint256.Int)
+			if err := rlp.Decode(value, val); err != nil {
+				return fmt.Errorf("slot %x: %w", slot, err)
+			}
+			obj.storage[BytesToHash(slot)] = val.Bytes32()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		s.objects[BytesToAddress(addr)] = obj
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func readPreimage(reader database.KeyValueReader, hash []byte) ([]byte, error) {
+	preimage, err := reader.Get(preimageKey(hash))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, fmt.Errorf("missing preimage of %x", hash)
+	}
+	return preimage, err
+}
+
+// Returns a deep copy of the state, which is not affected by the
+// later changes of the original. Tries of the copy share their nodes
+// with the original, hence the copy must be committed into the same
+// store as the original.
+func (s *StateDB) Copy() *StateDB {
+	cpy := &StateDB{
+		objects:        make(map[Address]*stateObject, len(s.objects)),
+		trie:           s.trie.Copy(),
+		dirty:          make(map[Address]struct{}, len(s.dirty)),
+		storageCommits: make(map[Address]struct{}, len(s.storageCommits)),
+		codes:          make(map[Hash][]byte, len(s.codes)),
+		preimages:      make(map[string][]byte, len(s.preimages)),
+		err:            s.err,
+	}
+	for addr, obj := range s.objects {
+		cpy.objects[addr] = obj.copy()
+	}
+	for addr := range s.dirty {
+		cpy.dirty[addr] = struct{}{}
+	}
+	for addr := range s.storageCommits {
+		cpy.storageCommits[addr] = struct{}{}
+	}
+	for hash, code := range s.codes {
+		cpy.codes[hash] = code
+	}
+	for key, preimage := range s.preimages {
+		cpy.preimages[key] = preimage
+	}
+	return cpy
+}
+
+// Returns the identifier of the current state, which the
+// state can be reverted to with revertToSnapshot
+func (s *StateDB) snapshot() int {
+	return len(s.journal)
+}
+
+// Reverts the changes made since the snapshot was taken. Snapshots
+// taken before the last commit can not be reverted to.
+func (s *StateDB) revertToSnapshot(snapshot int) {
+	for i := len(s.journal) - 1; i >= snapshot; i-- {
+		s.journal[i].revert(s)
+	}
+	s.journal = s.journal[:snapshot]
+}
+
+func (s *StateDB) setError(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *StateDB) getOrNewObject(addr Address) *stateObject {
+	obj, ok := s.objects[addr]
+	if !ok {
+		obj = newStateObject()
+		s.objects[addr] = obj
+		s.journal = append(s.journal, createObjectChange{addr: addr})
+	}
+	s.dirty[addr] = struct{}{}
+	return obj
+}
+
+// Returns whether the account exists in the state. Accounts
+// are created once any of their fields is set.
+func (s *StateDB) Exist(addr Address) bool {
+	_, ok := s.objects[addr]
+	return ok
+}
+
+// Returns a copy of the balance, which is zero for missing accounts
+func (s *StateDB) GetBalance(addr Address) *uint256.Int {
+	if obj, ok := s.objects[addr]; ok {
+		return new(uint256.Int).Set(obj.balance)
+	}
+	return new(uint256.Int)
+}
+
+func (s *StateDB) SetBalance(addr Address, balance *uint256.Int) {
+	obj := s.getOrNewObject(addr)
+	s.journal = append(s.journal, balanceChange{addr: addr, prev: obj.balance})
+	obj.balance = new(uint256.Int).Set(balance)
+}
+
+func (s *StateDB) GetNonce(addr Address) uint64 {
+	if obj, ok := s.objects[addr]; ok {
+		return obj.nonce
+	}
+	return 0
+}
+
+func (s *StateDB) SetNonce(addr Address, nonce uint64) {
+	obj := s.getOrNewObject(addr)
+	s.journal = append(s.journal, nonceChange{addr: addr, prev: obj.nonce})
+	obj.nonce = nonce
+}
+
+func (s *StateDB) GetCode(addr Address) []byte {
+	if obj, ok := s.objects[addr]; ok {
+		return obj.code
+	}
+	return nil
+}
+
+func (s *StateDB) SetCode(addr Address, code []byte) {
+	obj := s.getOrNewObject(addr)
+	s.journal = append(s.journal, codeChange{addr: addr, prevCode: obj.code, prevHash: obj.codeHash, prevDirty: obj.dirtyCode})
+	obj.code = append([]byte{}, code...)
+	obj.codeHash, obj.dirtyCode = BytesToHash(keccak256(code)), true
+}
+
+// Returns the keccak256 of the code, which is the
+// empty code hash for accounts without code
+func (s *StateDB) GetCodeHash(addr Address) Hash {
+	if obj, ok := s.objects[addr]; ok {
+		return obj.codeHash
+	}
+	return EmptyCodeHash
+}
+
+// Returns the value of the storage slot, which is zero if it is not set
+func (s *StateDB) GetState(addr Address, slot Hash) Hash {
+	if obj, ok := s.objects[addr]; ok {
+		return obj.storage[slot]
+	}
+	return Hash{}
+}
+
+// Sets the value of the storage slot. Zero value clears the slot.
+func (s *StateDB) SetState(addr Address, slot Hash, value Hash) {
+	obj := s.getOrNewObject(addr)
+	s.journal = append(s.journal, storageChange{addr: addr, slot: slot, prev: obj.storage[slot]})
+	obj.setSlot(slot, value)
+}
+
+func (obj *stateObject) setSlot(slot Hash, value Hash) {
+	if value == (Hash{}) {
+		delete(obj.storage, slot)
+	} else {
+		obj.storage[slot] = value
+	}
+	obj.dirtySlots[slot] = struct{}{}
+}
+
+// Replaces the storage of the account with the slots
+func (s *StateDB) SetStorage(addr Address, storage map[Hash]Hash) {
+	obj := s.getOrNewObject(addr)
+	s.journal = append(s.journal, storageResetChange{
+		addr: addr, prevStorage: obj.storage, prevTrie: obj.trie, prevDirty: obj.dirtySlots,
+	})
+	// maps and the trie are replaced rather than cleared,
+	// since the journal keeps the earlier ones
+	obj.trie, _ = trie.New([32]byte{}, nil)
+	obj.storage, obj.dirtySlots = make(map[Hash]Hash, len(storage)), make(map[Hash]struct{}, len(storage))
+	for slot, value := range storage {
+		if value != (Hash{}) {
+			obj.setSlot(slot, value)
+		}
+	}
+}
+
+// Returns the addresses of the accounts in ascending order
+func (s *StateDB) Addresses() []Address {
+	addrs := make([]Address, 0, len(s.objects))
+	for addr := range s.objects {
+		addrs = append(addrs, addr)
+	}
+	sortAddresses(addrs)
+	return addrs
+}
+
+func sortAddresses(addrs []Address) {
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+}
+
+// Returns the storage root of the account, which
+// is the empty root hash for missing accounts
+func (s *StateDB) StorageRoot(addr Address) Hash {
+	obj, ok := s.objects[addr]
+	if !ok {
+		return EmptyRootHash
+	}
+	s.updateStorage(addr, obj)
+	return Hash(obj.trie.Hash())
+}
+
+// Returns the state root of the current state
+func (s *StateDB) IntermediateRoot() Hash {
+	s.updateTries()
+	return Hash(s.trie.Hash())
+}
+
+// Updates the storage trie of the account with its dirty slots, and
+// buffers the preimages of the keys of the set slots for the commit
+func (s *StateDB) updateStorage(addr Address, obj *stateObject) {
+	if len(obj.dirtySlots) == 0 {
+		return
+	}
+	for slot := range obj.dirtySlots {
+		key := keccak256(slot[:])
+		value, ok := obj.storage[slot]
+		if !ok {
+			s.setError(obj.trie.Delete(key))
+			continue
+		}
+		s.bufferPreimage(obj.trie, key, slot[:])
+		// values are stored as integers, without leading zeroes
+		s.setError(obj.trie.Update(key, rlp.EncodeBytes(bytes.TrimLeft(value[:], "\x00"))))
+	}
+	obj.dirtySlots = make(map[Hash]struct{})
+	s.storageCommits[addr] = struct{}{}
+}
+
+// Updates the state trie with the dirty accounts, which are removed
+// from the trie if they are reverted away, and buffers their code and
+// the preimages of their keys for the commit
+func (s *StateDB) updateTries() {
+	for addr := range s.dirty {
+		key := keccak256(addr[:])
+		obj, ok := s.objects[addr]
+		if !ok {
+			s.setError(s.trie.Delete(key))
+			continue
+		}
+		s.updateStorage(addr, obj)
+		if obj.dirtyCode {
+			if len(obj.code) > 0 {
+				s.codes[obj.codeHash] = obj.code
+			}
+			obj.dirtyCode = false
+		}
+		enc, err := rlp.Encode(&stateAccount{
+			Nonce:    obj.nonce,
+			Balance:  obj.balance,
+			Root:     Hash(obj.trie.Hash()),
+			CodeHash: obj.codeHash,
+		})
+		if err != nil {
+			s.setError(err)
+			continue
+		}
+		s.bufferPreimage(s.trie, key, addr[:])
+		s.setError(s.trie.Update(key, enc))
+	}
+	s.dirty = make(map[Address]struct{})
+}
+
+// Buffers the preimage of the key for the commit if the key is not in
+// the trie yet, since the preimages of the keys in the trie are already
+// written by the previous commits
+func (s *StateDB) bufferPreimage(tr *trie.Trie, key []byte, preimage []byte) {
+	value, err := tr.Get(key)
+	if err != nil {
+		s.setError(err)
+		return
+	}
+	if value == nil {
+		s.preimages[string(key)] = append([]byte{}, preimage...)
+	}
+}
+
+// Writes the trie nodes, the code and the preimages of the trie keys
+// of the accounts and the slots changed since the last commit into the
+// writer, and returns the state root. Nodes, code and preimages which
+// are already in the store are not written again, hence the state must
+// always be committed into the same store. Journal is discarded, and
+// nothing is written if the writer is nil.
+func (s *StateDB) Commit(writer database.KeyValueWriter) (Hash, error) {
+	root := s.IntermediateRoot()
+	if s.err != nil {
+		return Hash{}, s.err
+	}
+	if writer == nil {
+		return root, nil
+	}
+	for addr := range s.storageCommits {
+		if obj, ok := s.objects[addr]; ok {
+			if _, err := obj.trie.Commit(writer); err != nil {
+				return Hash{}, err
+			}
+		}
+	}
+	for hash, code := range s.codes {
+		if err := writer.Put(hash[:], code); err != nil {
+			return Hash{}, err
+		}
+	}
+	for key, preimage := range s.preimages {
+		if err := writer.Put(preimageKey([]byte(key)), preimage); err != nil {
+			return Hash{}, err
+		}
+	}
+	if _, err := s.trie.Commit(writer); err != nil {
+		return Hash{}, err
+	}
+	s.storageCommits, s.codes, s.preimages = make(map[Address]struct{}), make(map[Hash][]byte), make(map[string][]byte)
+	s.journal = nil
+	return root, nil
+}
@@ -0,0 +1,381 @@
+package space_evm
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"space/database"
+	"space/rlp"
+	"space/trie"
+)
+
+var (
+	testAddr1 = BytesToAddress(hexToBytes("aa"))
+	testAddr2 = BytesToAddress(hexToBytes("bb"))
+)
+
+// Returns the root of the trie with the given keys and values,
+// where the keys are hashed as in the state and storage tries
+func secureTrieRoot(kvs ...[]byte) Hash {
+	t, _ := trie.New([32]byte{}, nil)
+	for i := 0; i < len(kvs); i += 2 {
+		t.Update(keccak256(kvs[i]), kvs[i+1])
+	}
+	return Hash(t.Hash())
+}
+
+func encodeTestAccount(nonce uint64, balance []byte, storageRoot Hash, code []byte) []byte {
+	return rlp.EncodeList(
+		rlp.EncodeUint64(nonce),
+		rlp.EncodeBytes(balance),
+		rlp.EncodeBytes(storageRoot[:]),
+		rlp.EncodeBytes(keccak256(code)),
+	)
+}
+
+func genTestState() *StateDB {
+	state := NewStateDB()
+	state.SetNonce(testAddr1, 1)
+	state.SetBalance(testAddr1, u256(1000))
+	state.SetCode(testAddr2, hexToBytes("6001"))
+	state.SetState(testAddr2, BytesToHash([]byte{1}), BytesToHash([]byte{0x2a}))
+	state.SetState(testAddr2, BytesToHash([]byte{2}), BytesToHash(hexToBytes("0100")))
+	return state
+}
+
+// exp is the storage root of testAddr2, and the state root
+var stateRootTests = []genericTest{
+	{
+		s:   "empty state",
+		in:  func(state *StateDB) {},
+		exp: []interface{}{EmptyRootHash, EmptyRootHash},
+	},
+	{
+		s: "accounts with storage",
+		in: func(state *StateDB) {
+			*state = *genTestState()
+		},
+		exp: []interface{}{
+			secureTrieRoot(
+				BytesToHash([]byte{1}).Bytes(), rlp.EncodeBytes([]byte{0x2a}),
+				BytesToHash([]byte{2}).Bytes(), rlp.EncodeBytes(hexToBytes("0100")),
+			),
+			secureTrieRoot(
+				testAddr1.Bytes(), encodeTestAccount(1, hexToBytes("03e8"), EmptyRootHash, nil),
+				testAddr2.Bytes(), encodeTestAccount(0, nil, secureTrieRoot(
+					BytesToHash([]byte{1}).Bytes(), rlp.EncodeBytes([]byte{0x2a}),
+					BytesToHash([]byte{2}).Bytes(), rlp.EncodeBytes(hexToBytes("0100")),
+				), hexToBytes("6001")),
+			),
+		},
+	},
+	{
+		s: "cleared slots are removed from storage",
+		in: func(state *StateDB) {
+			*state = *genTestState()
+			state.SetState(testAddr2, BytesToHash([]byte{1}), Hash{})
+			state.SetState(testAddr2, BytesToHash([]byte{2}), Hash{})
+		},
+		exp: []interface{}{
+			EmptyRootHash,
+			secureTrieRoot(
+				testAddr1.Bytes(), encodeTestAccount(1, hexToBytes("03e8"), EmptyRootHash, nil),
+				testAddr2.Bytes(), encodeTestAccount(0, nil, EmptyRootHash, hexToBytes("6001")),
+			),
+		},
+	},
+	{
+		s: "empty account",
+		in: func(state *StateDB) {
+			state.SetNonce(testAddr1, 0)
+		},
+		exp: []interface{}{
+			EmptyRootHash,
+			secureTrieRoot(testAddr1.Bytes(), encodeTestAccount(0, nil, EmptyRootHash, nil)),
+		},
+	},
+}
+
+func Test_State_Roots(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range stateRootTests {
+		state := NewStateDB()
+		test.in.(func(*StateDB))(state)
+		test.act = []interface{}{state.StorageRoot(testAddr2), state.IntermediateRoot()}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+func Test_State_Commit(t *testing.T) {
+	db := database.NewMemoryDB()
+	state := genTestState()
+	root, err := state.Commit(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accounts, err := trie.New(root, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, _ := accounts.Get(keccak256(testAddr2.Bytes()))
+	storageRoot := state.StorageRoot(testAddr2)
+	storage, err := trie.New(storageRoot, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slot, _ := storage.Get(keccak256(BytesToHash([]byte{1}).Bytes()))
+	code, _ := db.Get(keccak256(hexToBytes("6001")))
+
+	test := genericTest{
+		s: "commit and load tries",
+		exp: []interface{}{
+			state.IntermediateRoot(),
+			encodeTestAccount(0, nil, storageRoot, hexToBytes("6001")),
+			rlp.EncodeBytes([]byte{0x2a}),
+			hexToBytes("6001"),
+		},
+		act: []interface{}{root, account, slot, code},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+func Test_State_Load(t *testing.T) {
+	db := database.NewMemoryDB()
+	state := genTestState()
+	root, err := state.Commit(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadStateDB(root, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty, errEmpty := LoadStateDB(EmptyRootHash, db)
+	_, errMissing := LoadStateDB(BytesToHash([]byte{1}), db)
+
+	test := genericTest{
+		s: "load committed state",
+		exp: []interface{}{
+			root, uint64(1), u256(1000), hexToBytes("6001"), BytesToHash(hexToBytes("0100")),
+			nil, EmptyRootHash, true,
+		},
+		act: []interface{}{
+			loaded.IntermediateRoot(), loaded.GetNonce(testAddr1), loaded.GetBalance(testAddr1),
+			loaded.GetCode(testAddr2), loaded.GetState(testAddr2, BytesToHash([]byte{2})),
+			errEmpty, empty.IntermediateRoot(), errors.Is(errMissing, trie.ErrMissingNode),
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+// Returns the test state with more accounts, whose
+// tries are big enough to have hashed nodes
+func genBigTestState() *StateDB {
+	state := genTestState()
+	for i := 1; i <= 20; i++ {
+		addr := BytesToAddress([]byte{0xc0, byte(i)})
+		state.SetBalance(addr, u256(uint64(i)))
+		state.SetState(testAddr2, BytesToHash([]byte{0xc0, byte(i)}), BytesToHash([]byte{byte(i)}))
+	}
+	return state
+}
+
+// in changes the state after the snapshot
+var stateSnapshotTests = []genericTest{
+	{s: "no changes", in: func(state *StateDB) {}},
+	{s: "balance and nonce", in: func(state *StateDB) {
+		state.SetBalance(testAddr1, u256(1))
+		state.SetNonce(testAddr1, 5)
+	}},
+	{s: "new account", in: func(state *StateDB) {
+		state.SetBalance(BytesToAddress([]byte{0xcc}), u256(1))
+	}},
+	{s: "code", in: func(state *StateDB) {
+		state.SetCode(testAddr2, hexToBytes("6002"))
+		state.SetCode(testAddr1, hexToBytes("6003"))
+	}},
+	{s: "storage", in: func(state *StateDB) {
+		state.SetState(testAddr2, BytesToHash([]byte{1}), BytesToHash([]byte{7}))
+		state.SetState(testAddr2, BytesToHash([]byte{2}), Hash{})
+		state.SetState(testAddr2, BytesToHash([]byte{3}), BytesToHash([]byte{8}))
+	}},
+	{s: "replaced storage", in: func(state *StateDB) {
+		state.SetState(testAddr2, BytesToHash([]byte{1}), BytesToHash([]byte{7}))
+		state.SetStorage(testAddr2, map[Hash]Hash{BytesToHash([]byte{4}): BytesToHash([]byte{9})})
+		state.SetState(testAddr2, BytesToHash([]byte{5}), BytesToHash([]byte{9}))
+	}},
+	{s: "roots computed between the changes", in: func(state *StateDB) {
+		state.SetBalance(BytesToAddress([]byte{0xcc}), u256(1))
+		state.SetState(testAddr2, BytesToHash([]byte{1}), BytesToHash([]byte{7}))
+		state.IntermediateRoot()
+		state.SetStorage(testAddr2, nil)
+		state.SetCode(testAddr2, nil)
+		state.StorageRoot(testAddr2)
+		state.SetNonce(BytesToAddress([]byte{0xcc}), 1)
+	}},
+}
+
+func Test_State_Snapshot(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range stateSnapshotTests {
+		for _, loaded := range []bool{false, true} {
+			state := genBigTestState()
+			if loaded {
+				db := database.NewMemoryDB()
+				root, _ := state.Commit(db)
+				state, _ = LoadStateDB(root, db)
+			}
+			before := state.Dump()
+			snapshot := state.snapshot()
+			test.in.(func(*StateDB))(state)
+			state.revertToSnapshot(snapshot)
+
+			name := test.s
+			if loaded {
+				name += ", loaded from the store"
+			}
+			check := genericTest{s: name, exp: before, act: state.Dump()}
+			msg, failed := check.Check()
+			anyTestFailed = anyTestFailed || failed
+			fmt.Print(msg)
+		}
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// mapStore is the store which records the writes of the commits
+type mapStore map[string][]byte
+
+func (m mapStore) Get(key []byte) ([]byte, error) {
+	if value, ok := m[string(key)]; ok {
+		return value, nil
+	}
+	return nil, database.ErrNotFound
+}
+
+func (m mapStore) Has(key []byte) (bool, error) {
+	_, ok := m[string(key)]
+	return ok, nil
+}
+
+func (m mapStore) Put(key []byte, value []byte) error {
+	m[string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+func (m mapStore) Delete(key []byte) error {
+	delete(m, string(key))
+	return nil
+}
+
+// in changes the committed state, and the next commit must only write
+// the nodes, code and preimages of the changed state which are not in
+// the store yet
+var stateCommitChangesTests = []genericTest{
+	{s: "no changes", in: func(state *StateDB) {}},
+	{s: "same balance", in: func(state *StateDB) { state.SetBalance(testAddr1, u256(1000)) }},
+	{s: "changed balance", in: func(state *StateDB) { state.SetBalance(testAddr1, u256(1)) }},
+	{s: "new account with code", in: func(state *StateDB) { state.SetCode(BytesToAddress([]byte{0xcc}), hexToBytes("6003")) }},
+	{s: "changed slot", in: func(state *StateDB) {
+		state.SetState(testAddr2, BytesToHash([]byte{0xc0, 5}), BytesToHash([]byte{0xff}))
+	}},
+	{s: "cleared slot", in: func(state *StateDB) { state.SetState(testAddr2, BytesToHash([]byte{0xc0, 5}), Hash{}) }},
+	{s: "replaced storage", in: func(state *StateDB) {
+		state.SetStorage(testAddr2, map[Hash]Hash{BytesToHash([]byte{4}): BytesToHash([]byte{9})})
+	}},
+	{s: "reverted changes", in: func(state *StateDB) {
+		snapshot := state.snapshot()
+		state.SetBalance(testAddr1, u256(1))
+		state.SetState(testAddr2, BytesToHash([]byte{0xc0, 5}), BytesToHash([]byte{0xff}))
+		state.revertToSnapshot(snapshot)
+	}},
+}
+
+func Test_State_CommitChanges(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range stateCommitChangesTests {
+		for _, loaded := range []bool{false, true} {
+			db := mapStore{}
+			state := genBigTestState()
+			root, _ := state.Commit(db)
+			if loaded {
+				state, _ = LoadStateDB(root, db)
+			}
+			test.in.(func(*StateDB))(state)
+
+			// writes of the changed state which are not in the store,
+			// where the writes are taken from a new state with the
+			// same accounts
+			fresh, _ := ImportState(state.Dump())
+			all := mapStore{}
+			fresh.Commit(all)
+			missing := mapStore{}
+			for key, value := range all {
+				if _, ok := db[key]; !ok {
+					missing[key] = value
+				}
+			}
+			written := mapStore{}
+			root, err := state.Commit(written)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for key, value := range written {
+				db[key] = value
+			}
+			reloaded, err := LoadStateDB(root, db)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			name := test.s
+			if loaded {
+				name += ", loaded from the store"
+			}
+			check := genericTest{s: name, exp: []interface{}{missing, state.Dump()}, act: []interface{}{written, reloaded.Dump()}}
+			msg, failed := check.Check()
+			anyTestFailed = anyTestFailed || failed
+			fmt.Print(msg)
+		}
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+func Test_Interpreter_StateRoots(t *testing.T) {
+	evm := NewEVM(Moon)
+	evm.SetStateDB(genTestState())
+	runRes := evm.interpreter.Run(hexToBytes("6001"), MaxUint64)
+
+	test := genericTest{
+		s: "roots after execution",
+		exp: []interface{}{
+			genTestState().IntermediateRoot(),
+			map[Address]Hash{testAddr1: EmptyRootHash, testAddr2: genTestState().StorageRoot(testAddr2)},
+		},
+		act: []interface{}{runRes.StateRoot, runRes.StorageRoots},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
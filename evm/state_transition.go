@@ -0,0 +1,388 @@
+package space_evm
+
+import (
+	"fmt"
+	"math"
+
+	"space/crypto/kzg4844"
+
+	"github.com/holiman/uint256"
+)
+
+// Gas of the transactions, which is paid before the execution
+// as the intrinsic gas, and the gas of depositing the code of
+// the created contracts (see EIP-2028, EIP-2930 and EIP-3860)
+const (
+	txGas                     uint64 = 21000
+	txCreateGas               uint64 = 53000
+	txDataZeroGas             uint64 = 4
+	txDataNonZeroGas          uint64 = 16
+	txAccessListAddressGas    uint64 = 2400
+	txAccessListStorageKeyGas uint64 = 1900
+	initCodeWordGas           uint64 = 2
+	codeDepositGas            uint64 = 200
+	blobGasPerBlob            uint64 = 1 << 17
+
+	// Maximum size of the deployed code, and of the init code (see EIP-170)
+	maxCodeSize     = 24576
+	maxInitCodeSize = 2 * maxCodeSize
+)
+
+// Returns the gas which is paid before the execution of the transaction
+// with the data and the access list. Data of the contract creations is
+// the init code, which is charged for each of its words as well.
+func IntrinsicGas(data []byte, accessList AccessList, isCreate bool) (uint64, error) {
+	gas := txGas
+	if isCreate {
+		gas = txCreateGas
+	}
+	nonZero := uint64(0)
+	for _, b := range data {
+		if b != 0 {
+			nonZero++
+		}
+	}
+	zero := uint64(len(data)) - nonZero
+	// data is at most a few megabytes, but the size is not limited
+	if nonZero > (math.MaxUint64-gas)/txDataNonZeroGas {
+		return 0, ErrGasUintOverflow
+	}
+	gas += nonZero * txDataNonZeroGas
+	if zero > (math.MaxUint64-gas)/txDataZeroGas {
+		return 0, ErrGasUintOverflow
+	}
+	gas += zero * txDataZeroGas
+	if isCreate {
+		gas += toWordSize(uint64(len(data))) * initCodeWordGas
+	}
+	gas += uint64(len(accessList)) * txAccessListAddressGas
+	gas += uint64(accessList.StorageKeys()) * txAccessListStorageKeyGas
+	return gas, nil
+}
+
+// stateTransition applies a transaction to the
+// world state of the interpreter of the EVM
+type stateTransition struct {
+	evm          *EVM
+	in           *Interpreter
+	state        *StateDB
+	tx           *Transaction
+	sender       Address
+	gasPrice     *uint256.Int
+	blobGasPrice *uint256.Int
+	// whether the transaction is a call which is not signed
+	isCall bool
+}
+
+// Applies the transaction to the world state, and returns its receipt.
+// Transactions which can not be included in a block, such as the ones
+// with an invalid nonce, or without enough balance to buy their gas,
+// return an error without changing the state. Transactions whose
+// execution fails are included with the failed status, and all of
+// their changes are reverted but the nonce and the fees.
+func (evm *EVM) ApplyTransaction(tx *Transaction) (*Receipt, error) {
+	st := &stateTransition{
+		evm:   evm,
+		in:    evm.interpreter,
+		state: evm.interpreter.state,
+		tx:    tx,
+	}
+	return st.apply()
+}
+
+// Applies the unsigned transaction as if it was sent by the address,
+// which is used for the calls that are not part of the chain, such as
+// eth_call. Signature and nonce of the transaction are ignored, and the
+// sender can have code. Calls without a fee cap and a tip are not
+// charged the base fee, hence they can be made without any funds.
+func (evm *EVM) ApplyCall(tx *Transaction, from Address) (*Receipt, error) {
+	st := &stateTransition{
+		evm:    evm,
+		in:     evm.interpreter,
+		state:  evm.interpreter.state,
+		tx:     tx,
+		sender: from,
+		isCall: true,
+	}
+	return st.apply()
+}
+
+// Checks whether the transaction can be applied to the current state
+func (st *stateTransition) preCheck() error {
+	tx, state, blockCtx := st.tx, st.state, st.in.blockCtx
+	if tx.GasTipCap() == nil || tx.GasFeeCap() == nil || tx.Value() == nil {
+		return fmt.Errorf("%w: missing gas price or value", ErrInvalidTx)
+	}
+	if tx.Type() == BlobTxType && tx.BlobGasFeeCap() == nil {
+		return fmt.Errorf("%w: missing blob fee cap", ErrInvalidTx)
+	}
+	if tx.Type() == BlobTxType && st.evm.Fork < Saturn {
+		return fmt.Errorf("%w: blob transactions before %v", ErrTxTypeNotSupported, Saturn)
+	}
+	if !st.isCall {
+		if err := st.checkSender(); err != nil {
+			return err
+		}
+	} else if nonce := state.GetNonce(st.sender); nonce == math.MaxUint64 {
+		return fmt.Errorf("%w: address %v, nonce: %d", ErrNonceMax, st.sender.Hex(), nonce)
+	}
+	if blockCtx.GasLimit != 0 && tx.Gas() > blockCtx.GasLimit {
+		return fmt.Errorf("%w: tx gas %d, limit %d", ErrGasLimitReached, tx.Gas(), blockCtx.GasLimit)
+	}
+	if tx.GasTipCap().Gt(tx.GasFeeCap()) {
+		return fmt.Errorf("%w: tip %v, fee cap %v", ErrTipAboveFeeCap, tx.GasTipCap(), tx.GasFeeCap())
+	}
+	if blockCtx.BaseFee != nil && tx.GasFeeCap().Lt(blockCtx.BaseFee) && !st.isFreeCall() {
+		return fmt.Errorf("%w: address %v, fee cap %v, base fee %v", ErrFeeCapTooLow, st.sender.Hex(), tx.GasFeeCap(), blockCtx.BaseFee)
+	}
+	if tx.To() == nil && len(tx.Data()) > maxInitCodeSize {
+		return fmt.Errorf("%w: code size %d, limit %d", ErrMaxInitCodeSizeExceeded, len(tx.Data()), maxInitCodeSize)
+	}
+
+	if tx.Type() == BlobTxType {
+		if len(tx.BlobHashes()) == 0 {
+			return ErrMissingBlobHashes
+		}
+		for i, hash := range tx.BlobHashes() {
+			if hash[0] != kzg4844.VersionedHashVersionKZG {
+				return fmt.Errorf("%w: blob %d has version %d", ErrInvalidBlobHashVersion, i, hash[0])
+			}
+		}
+		st.blobGasPrice = new(uint256.Int)
+		if blockCtx.BlobBaseFee != nil {
+			st.blobGasPrice.Set(blockCtx.BlobBaseFee)
+		}
+		if tx.BlobGasFeeCap().Lt(st.blobGasPrice) {
+			return fmt.Errorf("%w: fee cap %v, blob base fee %v", ErrBlobFeeCapTooLow, tx.BlobGasFeeCap(), st.blobGasPrice)
+		}
+	}
+	return nil
+}
+
+// Checks the chain ID and the signature of the transaction, and
+// whether its nonce is the nonce of the sender, which must not
+// have code (see EIP-3607)
+func (st *stateTransition) checkSender() error {
+	tx, state := st.tx, st.state
+	if tx.Protected() && (st.evm.ChainID == nil || !tx.ChainID().Eq(st.evm.ChainID)) {
+		return fmt.Errorf("%w: transaction has %v, chain has %v", ErrInvalidChainID, tx.ChainID(), st.evm.ChainID)
+	}
+	sender, err := tx.Sender()
+	if err != nil {
+		return err
+	}
+	st.sender = sender
+
+	nonce := state.GetNonce(sender)
+	switch {
+	case tx.Nonce() < nonce:
+		return fmt.Errorf("%w: address %v, tx: %d state: %d", ErrNonceTooLow, sender.Hex(), tx.Nonce(), nonce)
+	case tx.Nonce() > nonce:
+		return fmt.Errorf("%w: address %v, tx: %d state: %d", ErrNonceTooHigh, sender.Hex(), tx.Nonce(), nonce)
+	case nonce == math.MaxUint64:
+		return fmt.Errorf("%w: address %v, nonce: %d", ErrNonceMax, sender.Hex(), nonce)
+	}
+	if len(state.GetCode(sender)) > 0 {
+		return fmt.Errorf("%w: address %v", ErrSenderNoEOA, sender.Hex())
+	}
+	return nil
+}
+
+// Reports whether the transaction is a call without a fee cap and a tip
+func (st *stateTransition) isFreeCall() bool {
+	return st.isCall && st.tx.GasFeeCap().IsZero() && st.tx.GasTipCap().IsZero()
+}
+
+// Returns the gas of the blobs of the transaction
+func (st *stateTransition) blobGasUsed() uint64 {
+	return uint64(len(st.tx.BlobHashes())) * blobGasPerBlob
+}
+
+// Returns the price which is paid for a unit of gas of the transaction
+// in a block with the base fee, which is the base fee with the tip,
+// capped with the fee cap. Base fee is treated as zero if it is nil.
+func EffectiveGasPrice(tx *Transaction, baseFee *uint256.Int) *uint256.Int {
+	price := new(uint256.Int).Set(tx.GasTipCap())
+	if baseFee != nil {
+		price.Add(price, baseFee)
+	}
+	if price.Gt(tx.GasFeeCap()) {
+		price.Set(tx.GasFeeCap())
+	}
+	return price
+}
+
+// Checks whether the sender can pay for the gas with the highest
+// price, with the value and the blob gas, and charges the sender
+// for the gas with the effective gas price. Blob gas is paid up
+// front, and it is never refunded.
+func (st *stateTransition) buyGas() error {
+	tx := st.tx
+	st.gasPrice = EffectiveGasPrice(tx, st.in.blockCtx.BaseFee)
+	gas := uint256.NewInt(tx.Gas())
+	cost, overflow1 := new(uint256.Int).MulOverflow(gas, st.gasPrice)
+	maxCost, overflow2 := new(uint256.Int).MulOverflow(gas, tx.GasFeeCap())
+	_, overflow3 := maxCost.AddOverflow(maxCost, tx.Value())
+	overflow := overflow1 || overflow2 || overflow3
+	if st.blobGasPrice != nil {
+		blobGas := uint256.NewInt(st.blobGasUsed())
+		blobCost, overflow4 := new(uint256.Int).MulOverflow(blobGas, st.blobGasPrice)
+		maxBlobCost, overflow5 := new(uint256.Int).MulOverflow(blobGas, tx.BlobGasFeeCap())
+		_, overflow6 := cost.AddOverflow(cost, blobCost)
+		_, overflow7 := maxCost.AddOverflow(maxCost, maxBlobCost)
+		overflow = overflow || overflow4 || overflow5 || overflow6 || overflow7
+	}
+
+	balance := st.state.GetBalance(st.sender)
+	if overflow || balance.Lt(maxCost) {
+		return fmt.Errorf("%w: address %v have %v want %v", ErrInsufficientFunds, st.sender.Hex(), balance, maxCost)
+	}
+	st.state.SetBalance(st.sender, balance.Sub(balance, cost))
+	return nil
+}
+
+func (st *stateTransition) apply() (*Receipt, error) {
+	tx, in, state := st.tx, st.in, st.state
+	if err := st.preCheck(); err != nil {
+		return nil, err
+	}
+	isCreate := tx.To() == nil
+	intrinsicGas, err := IntrinsicGas(tx.Data(), tx.AccessList(), isCreate)
+	if err != nil {
+		return nil, err
+	}
+	if tx.Gas() < intrinsicGas {
+		return nil, fmt.Errorf("%w: have %d, want %d", ErrIntrinsicGas, tx.Gas(), intrinsicGas)
+	}
+	if err := st.buyGas(); err != nil {
+		return nil, err
+	}
+
+	// interpreter runs the transaction from a clean frame, and
+	// the original values of the slots are recorded from the start
+	// of the transaction
+	txCtx := in.txCtx
+	in.txCtx = TxContext{BlobHashes: tx.BlobHashes()}
+	in.runState, in.depth, in.readOnly, in.logs = nil, 0, false, nil
+	in.original = make(map[storageKey]Hash)
+	defer func() { in.txCtx = txCtx }()
+
+	receipt := &Receipt{
+		Type:              tx.Type(),
+		TxHash:            tx.Hash(),
+		EffectiveGasPrice: st.gasPrice,
+	}
+	nonce := state.GetNonce(st.sender)
+	state.SetNonce(st.sender, nonce+1)
+	// nonce and the fees are kept even if the execution fails
+	snapshot := state.snapshot()
+
+	var (
+		ret             []byte
+		gasLeft, refund uint64
+		gas             = tx.Gas() - intrinsicGas
+	)
+	if isCreate {
+		addr := CreateAddress(st.sender, nonce)
+		receipt.ContractAddress = &addr
+		ret, gasLeft, refund, err = st.create(addr, gas)
+	} else {
+		st.transfer(*tx.To())
+		ret, gasLeft, refund, err = in.call(*tx.To(), tx.Data(), gas)
+	}
+	if err != nil {
+		state.revertToSnapshot(snapshot)
+		in.logs = nil
+	}
+
+	// refund is capped with respect to the used gas
+	// as in the interpreter, and only if it succeeds
+	if err == nil {
+		if maxRefund := (tx.Gas() - gasLeft) / st.evm.Fork.maxRefundQuotient(); refund > maxRefund {
+			refund = maxRefund
+		}
+		gasLeft += refund
+	}
+	gasUsed := tx.Gas() - gasLeft
+	// leftover gas is bought back from the sender at the same
+	// price, and the coinbase is paid the tip of the used gas,
+	// while the base fee of the used gas is burned
+	if gasLeft > 0 {
+		leftover := new(uint256.Int).Mul(uint256.NewInt(gasLeft), st.gasPrice)
+		state.SetBalance(st.sender, leftover.Add(leftover, state.GetBalance(st.sender)))
+	}
+	tip := new(uint256.Int).Set(st.gasPrice)
+	if baseFee := in.blockCtx.BaseFee; baseFee != nil {
+		// free calls pay neither the base fee nor the tip
+		if tip.Lt(baseFee) {
+			tip.Clear()
+		} else {
+			tip.Sub(tip, baseFee)
+		}
+	}
+	if fee := new(uint256.Int).Mul(uint256.NewInt(gasUsed), tip); !fee.IsZero() {
+		coinbase := in.blockCtx.Coinbase
+		state.SetBalance(coinbase, fee.Add(fee, state.GetBalance(coinbase)))
+	}
+
+	receipt.Status = ReceiptStatusSuccessful
+	if err != nil {
+		receipt.Status = ReceiptStatusFailed
+	}
+	receipt.Logs, receipt.Bloom = in.logs, LogsBloom(in.logs)
+	receipt.GasUsed, receipt.CumulativeGasUsed = gasUsed, gasUsed
+	if st.blobGasPrice != nil {
+		receipt.BlobGasUsed, receipt.BlobGasPrice = st.blobGasUsed(), st.blobGasPrice
+	}
+	receipt.ReturnData, receipt.Err = ret, err
+	in.logs = nil
+	return receipt, nil
+}
+
+// Transfers the value of the transaction from the sender to the
+// recipient. Accounts are not touched for zero value transfers,
+// so that empty accounts are not created.
+func (st *stateTransition) transfer(to Address) {
+	value := st.tx.Value()
+	if value == nil || value.IsZero() {
+		return
+	}
+	from := st.state.GetBalance(st.sender)
+	st.state.SetBalance(st.sender, from.Sub(from, value))
+	toBalance := st.state.GetBalance(to)
+	st.state.SetBalance(to, toBalance.Add(toBalance, value))
+}
+
+// Creates the contract at the address by running the init code of the
+// transaction, whose output becomes the code of the contract. Code is
+// paid for with the remaining gas, and it must not start with 0xEF,
+// which is reserved for the later code formats (see EIP-3541).
+func (st *stateTransition) create(addr Address, gas uint64) ([]byte, uint64, uint64, error) {
+	state := st.state
+	if state.GetNonce(addr) != 0 || len(state.GetCode(addr)) > 0 {
+		return nil, 0, 0, ErrContractAddressCollision
+	}
+	// nonce of the contracts start from 1 (see EIP-161)
+	state.SetNonce(addr, 1)
+	st.transfer(addr)
+
+	ret, gasLeft, refund, err := st.in.runFrame(addr, st.tx.Data(), gas)
+	if err == ErrExecutionReverted {
+		return ret, gasLeft, 0, err
+	}
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if len(ret) > maxCodeSize {
+		return nil, 0, 0, ErrMaxCodeSizeExceeded
+	}
+	if len(ret) > 0 && ret[0] == 0xef {
+		return nil, 0, 0, ErrInvalidCode
+	}
+	depositGas := uint64(len(ret)) * codeDepositGas
+	if gasLeft < depositGas {
+		return nil, 0, 0, ErrOutOfGas
+	}
+	state.SetCode(addr, ret)
+	return ret, gasLeft - depositGas, refund, nil
+}
@@ -0,0 +1,437 @@
+package space_evm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/holiman/uint256"
+)
+
+var (
+	testCoinbase  = BytesToAddress([]byte{0xc0})
+	testRecipient = BytesToAddress([]byte{0xcc})
+	// logs 0x2a with the topic 7
+	testLogger = BytesToAddress([]byte{0xbb})
+	// logs 0x2a with the topic 7, and fails
+	testFailer = BytesToAddress([]byte{0xdd})
+	// returns the code 6001
+	testInitCode = hexToBytes("616001600052" + "6002601ef3")
+	// reverts with Error("revert reason")
+	testReverter     = BytesToAddress([]byte{0xde})
+	testReverterCode = hexToBytes("7f08c379a0" + strings.Repeat("00", 28) + "600052" + "6020600452" + "600d602452" +
+		"7f72657665727420726561736f6e" + strings.Repeat("00", 19) + "604452" + "60646000fd")
+)
+
+// Returns the EVM of the fork whose state has the sender of the
+// EIP-155 example with 1 ether, and the logger and failer contracts
+func genTransitionEVM(fork EVMFork) *EVM {
+	evm := NewEVM(fork)
+	evm.StateDB().SetBalance(eip155Sender, u256(1000000000000000000))
+	evm.StateDB().SetCode(testLogger, hexToBytes("602a600052"+"600760206000a1"))
+	evm.StateDB().SetCode(testFailer, hexToBytes("602a600052"+"600760206000a1"+"fe"))
+	evm.StateDB().SetCode(testReverter, testReverterCode)
+	evm.SetBlockContext(BlockContext{Coinbase: testCoinbase, GasLimit: 30000000, BlobBaseFee: u256(1)})
+	return evm
+}
+
+// in is the unsigned transaction, and exp is the status, gas used,
+// balances of the sender, coinbase and recipient, and the number of
+// logs. Transactions are signed for the default chain ID.
+type transitionTestIn struct {
+	fork EVMFork
+	tx   TxData
+}
+
+var testTo = func(addr Address) *Address { return &addr }
+
+var transitionTests = []genericTest{
+	{
+		s:   "value transfer",
+		in:  transitionTestIn{Moon, &LegacyTx{GasPrice: u256(10), Gas: 21000, To: testTo(testRecipient), Value: u256(100)}},
+		exp: []interface{}{ReceiptStatusSuccessful, uint64(21000), u256(1000000000000000000 - 210000 - 100), u256(210000), u256(100), 0},
+	},
+	{
+		s:   "calldata",
+		in:  transitionTestIn{Moon, &LegacyTx{GasPrice: u256(1), Gas: 50000, To: testTo(testRecipient), Value: u256(0), Data: hexToBytes("00ff")}},
+		exp: []interface{}{ReceiptStatusSuccessful, uint64(21020), u256(1000000000000000000 - 21020), u256(21020), u256(0), 0},
+	},
+	{
+		s: "access list",
+		in: transitionTestIn{Moon, &AccessListTx{
+			ChainID: u256(DefaultChainID), GasPrice: u256(1), Gas: 50000, To: testTo(testRecipient), Value: u256(0),
+			AccessList: AccessList{{Address: testLogger, StorageKeys: []Hash{{}, BytesToHash([]byte{1})}}},
+		}},
+		exp: []interface{}{ReceiptStatusSuccessful, uint64(27200), u256(1000000000000000000 - 27200), u256(27200), u256(0), 0},
+	},
+	{
+		s: "call emitting a log",
+		in: transitionTestIn{Moon, &DynamicFeeTx{
+			ChainID: u256(DefaultChainID), GasTipCap: u256(2), GasFeeCap: u256(3), Gas: 50000, To: testTo(testLogger), Value: u256(5),
+		}},
+		exp: []interface{}{ReceiptStatusSuccessful, uint64(22027), u256(1000000000000000000 - 2*22027 - 5), u256(2 * 22027), u256(0), 1},
+	},
+	{
+		s: "failed call",
+		in: transitionTestIn{Moon, &DynamicFeeTx{
+			ChainID: u256(DefaultChainID), GasTipCap: u256(1), GasFeeCap: u256(1), Gas: 50000, To: testTo(testFailer), Value: u256(5),
+		}},
+		exp: []interface{}{ReceiptStatusFailed, uint64(50000), u256(1000000000000000000 - 50000), u256(50000), u256(0), 0},
+	},
+	{
+		s: "reverted call keeps its gas",
+		in: transitionTestIn{Moon, &DynamicFeeTx{
+			ChainID: u256(DefaultChainID), GasTipCap: u256(1), GasFeeCap: u256(1), Gas: 50000, To: testTo(testReverter), Value: u256(5),
+		}},
+		exp: []interface{}{ReceiptStatusFailed, uint64(21054), u256(1000000000000000000 - 21054), u256(21054), u256(0), 0},
+	},
+	{
+		s:   "contract creation",
+		in:  transitionTestIn{Moon, &LegacyTx{GasPrice: u256(1), Gas: 100000, Value: u256(7), Data: testInitCode}},
+		exp: []interface{}{ReceiptStatusSuccessful, uint64(53584), u256(1000000000000000000 - 53584 - 7), u256(53584), u256(0), 0},
+	},
+	{
+		s:   "contract creation out of gas for code deposit",
+		in:  transitionTestIn{Moon, &LegacyTx{GasPrice: u256(1), Gas: 53500, Value: u256(7), Data: testInitCode}},
+		exp: []interface{}{ReceiptStatusFailed, uint64(53500), u256(1000000000000000000 - 53500), u256(53500), u256(0), 0},
+	},
+	{
+		s: "blob transaction",
+		in: transitionTestIn{Saturn, &BlobTx{
+			ChainID: u256(DefaultChainID), GasTipCap: u256(1), GasFeeCap: u256(1), Gas: 21000, To: testRecipient, Value: u256(0),
+			BlobFeeCap: u256(1), BlobHashes: []Hash{BytesToHash(append([]byte{1}, make([]byte, 31)...))},
+		}},
+		exp: []interface{}{ReceiptStatusSuccessful, uint64(21000), u256(1000000000000000000 - 21000 - 131072), u256(21000), u256(0), 0},
+	},
+	{
+		s:   "nonce too high",
+		in:  transitionTestIn{Moon, &LegacyTx{Nonce: 1, GasPrice: u256(1), Gas: 21000, To: testTo(testRecipient), Value: u256(0)}},
+		exp: ErrNonceTooHigh,
+	},
+	{
+		s:   "insufficient funds",
+		in:  transitionTestIn{Moon, &LegacyTx{GasPrice: u256(1), Gas: 21000, To: testTo(testRecipient), Value: u256(1000000000000000000)}},
+		exp: ErrInsufficientFunds,
+	},
+	{
+		s:   "intrinsic gas too low",
+		in:  transitionTestIn{Moon, &LegacyTx{GasPrice: u256(1), Gas: 21000, To: testTo(testRecipient), Value: u256(0), Data: []byte{1}}},
+		exp: ErrIntrinsicGas,
+	},
+	{
+		s:   "gas above block gas limit",
+		in:  transitionTestIn{Moon, &LegacyTx{GasPrice: u256(1), Gas: 30000001, To: testTo(testRecipient), Value: u256(0)}},
+		exp: ErrGasLimitReached,
+	},
+	{
+		s: "tip above fee cap",
+		in: transitionTestIn{Moon, &DynamicFeeTx{
+			ChainID: u256(DefaultChainID), GasTipCap: u256(2), GasFeeCap: u256(1), Gas: 21000, To: testTo(testRecipient), Value: u256(0),
+		}},
+		exp: ErrTipAboveFeeCap,
+	},
+	{
+		s: "another chain",
+		in: transitionTestIn{Moon, &DynamicFeeTx{
+			ChainID: u256(1), GasTipCap: u256(1), GasFeeCap: u256(1), Gas: 21000, To: testTo(testRecipient), Value: u256(0),
+		}},
+		exp: ErrInvalidChainID,
+	},
+	{
+		s: "blob transaction before Saturn",
+		in: transitionTestIn{Jupiter, &BlobTx{
+			ChainID: u256(DefaultChainID), GasTipCap: u256(1), GasFeeCap: u256(1), Gas: 21000, To: testRecipient, Value: u256(0),
+			BlobFeeCap: u256(1), BlobHashes: []Hash{BytesToHash(append([]byte{1}, make([]byte, 31)...))},
+		}},
+		exp: ErrTxTypeNotSupported,
+	},
+	{
+		s: "blob transaction with invalid version",
+		in: transitionTestIn{Saturn, &BlobTx{
+			ChainID: u256(DefaultChainID), GasTipCap: u256(1), GasFeeCap: u256(1), Gas: 21000, To: testRecipient, Value: u256(0),
+			BlobFeeCap: u256(1), BlobHashes: []Hash{{}},
+		}},
+		exp: ErrInvalidBlobHashVersion,
+	},
+}
+
+func Test_StateTransition_ApplyTransaction(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range transitionTests {
+		testIn := test.in.(transitionTestIn)
+		evm := genTransitionEVM(testIn.fork)
+		rootBefore := evm.StateDB().IntermediateRoot()
+		chainID := evm.ChainID
+		if testIn.tx.txType() != LegacyTxType {
+			chainID = testIn.tx.chainID()
+		}
+		tx, err := SignTx(NewTx(testIn.tx), chainID, eip155Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		receipt, err := evm.ApplyTransaction(tx)
+		state := evm.StateDB()
+
+		if expErr, ok := test.exp.(error); ok {
+			// invalid transactions do not change the state
+			test.exp = []interface{}{expErr, rootBefore}
+			test.act = []interface{}{err, state.IntermediateRoot()}
+			if errors.Is(err, expErr) {
+				test.act.([]interface{})[0] = expErr
+			}
+		} else if err != nil {
+			test.act = err
+		} else {
+			test.act = []interface{}{
+				receipt.Status, receipt.GasUsed, state.GetBalance(eip155Sender),
+				state.GetBalance(testCoinbase), state.GetBalance(testRecipient), len(receipt.Logs),
+			}
+			// nonce is incremented even if the execution fails
+			test.exp = append(test.exp.([]interface{}), uint64(1))
+			test.act = append(test.act.([]interface{}), state.GetNonce(eip155Sender))
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the unsigned transaction to the account which returns the base
+// fee, and exp is the effective gas price, balances of the sender and
+// the coinbase, and the returned base fee, where the base fee is 10
+var baseFeeTests = []genericTest{
+	{
+		s: "tip below the fee cap",
+		in: &DynamicFeeTx{
+			ChainID: u256(DefaultChainID), GasTipCap: u256(2), GasFeeCap: u256(20), Gas: 50000, To: testTo(testBaseFeeReader), Value: u256(0),
+		},
+		exp: []interface{}{u256(12), u256(1000000000000000000 - 12*21017), u256(2 * 21017), u256(10)},
+	},
+	{
+		s: "tip capped with the fee cap",
+		in: &DynamicFeeTx{
+			ChainID: u256(DefaultChainID), GasTipCap: u256(5), GasFeeCap: u256(11), Gas: 50000, To: testTo(testBaseFeeReader), Value: u256(0),
+		},
+		exp: []interface{}{u256(11), u256(1000000000000000000 - 11*21017), u256(21017), u256(10)},
+	},
+	{
+		s:   "legacy gas price above the base fee is the tip",
+		in:  &LegacyTx{GasPrice: u256(15), Gas: 50000, To: testTo(testBaseFeeReader), Value: u256(0)},
+		exp: []interface{}{u256(15), u256(1000000000000000000 - 15*21017), u256(5 * 21017), u256(10)},
+	},
+	{
+		s: "fee cap below the base fee",
+		in: &DynamicFeeTx{
+			ChainID: u256(DefaultChainID), GasTipCap: u256(1), GasFeeCap: u256(9), Gas: 50000, To: testTo(testBaseFeeReader), Value: u256(0),
+		},
+		exp: ErrFeeCapTooLow,
+	},
+}
+
+// returns the base fee of the block
+var testBaseFeeReader = BytesToAddress([]byte{0xbf})
+
+func Test_StateTransition_BaseFee(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range baseFeeTests {
+		evm := genTransitionEVM(Moon)
+		evm.SetBlockContext(BlockContext{Coinbase: testCoinbase, BaseFee: u256(10)})
+		evm.StateDB().SetCode(testBaseFeeReader, hexToBytes("48600052"+"60206000f3"))
+		tx, _ := SignTx(NewTx(test.in.(TxData)), evm.ChainID, eip155Key)
+		receipt, err := evm.ApplyTransaction(tx)
+		state := evm.StateDB()
+
+		if expErr, ok := test.exp.(error); ok {
+			test.act = err
+			if errors.Is(err, expErr) {
+				test.act = expErr
+			}
+		} else if err != nil {
+			test.act = err
+		} else {
+			// base fee of the used gas is burned
+			test.act = []interface{}{
+				receipt.EffectiveGasPrice, state.GetBalance(eip155Sender), state.GetBalance(testCoinbase),
+				new(uint256.Int).SetBytes(receipt.ReturnData),
+			}
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+func Test_StateTransition_ApplyCall(t *testing.T) {
+	evm := genTransitionEVM(Moon)
+	evm.SetBlockContext(BlockContext{Coinbase: testCoinbase, BaseFee: u256(10)})
+	// calls are not signed, and their nonces are ignored
+	call := NewTx(&DynamicFeeTx{Nonce: 7, GasTipCap: u256(0), GasFeeCap: u256(0), Gas: 50000, To: testTo(testBaseFeeReader), Value: u256(0)})
+	evm.StateDB().SetCode(testBaseFeeReader, hexToBytes("48600052"+"60206000f3"))
+	// sender with code and without funds
+	receipt, err := evm.ApplyCall(call, testFailer)
+	_, errValue := evm.ApplyCall(NewTx(&LegacyTx{GasPrice: u256(0), Gas: 50000, To: testTo(testRecipient), Value: u256(1)}), testFailer)
+
+	test := genericTest{
+		s: "free call from a contract",
+		exp: []interface{}{
+			nil, ReceiptStatusSuccessful, uint64(21017), BytesToHash([]byte{10}).Bytes(), u256(0), uint64(1), true,
+		},
+		act: []interface{}{
+			err, receipt.Status, receipt.GasUsed, receipt.ReturnData, evm.StateDB().GetBalance(testCoinbase),
+			evm.StateDB().GetNonce(testFailer), errors.Is(errValue, ErrInsufficientFunds),
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+func Test_StateTransition_Create(t *testing.T) {
+	evm := genTransitionEVM(Moon)
+	tx, _ := SignTx(NewTx(&LegacyTx{GasPrice: u256(1), Gas: 100000, Value: u256(7), Data: testInitCode}), nil, eip155Key)
+	receipt, err := evm.ApplyTransaction(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := CreateAddress(eip155Sender, 0)
+	// second transaction of the sender is a call to the created contract
+	tx, _ = SignTx(NewTx(&LegacyTx{Nonce: 1, GasPrice: u256(1), Gas: 100000, To: &addr, Value: u256(0)}), nil, eip155Key)
+	callReceipt, err := evm.ApplyTransaction(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test := genericTest{
+		s: "contract is created with the returned code",
+		exp: []interface{}{
+			&addr, hexToBytes("6001"), hexToBytes("6001"), uint64(1), u256(7),
+			ReceiptStatusSuccessful, uint64(21003), uint64(2),
+		},
+		act: []interface{}{
+			receipt.ContractAddress, receipt.ReturnData, evm.StateDB().GetCode(addr), evm.StateDB().GetNonce(addr), evm.StateDB().GetBalance(addr),
+			callReceipt.Status, callReceipt.GasUsed, evm.StateDB().GetNonce(eip155Sender),
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+func Test_StateTransition_Revert(t *testing.T) {
+	evm := genTransitionEVM(Moon)
+	// init code which reverts creates no contract, and keeps its gas
+	tx, _ := SignTx(NewTx(&LegacyTx{GasPrice: u256(1), Gas: 100000, Value: u256(7), Data: testReverterCode}), nil, eip155Key)
+	createReceipt, err := evm.ApplyTransaction(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := NewTx(&LegacyTx{GasPrice: u256(0), Gas: 50000, To: testTo(testReverter), Value: u256(0)})
+	receipt, err := evm.ApplyCall(call, eip155Sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := CreateAddress(eip155Sender, 0)
+	reason, _ := UnpackRevertReason(receipt.ReturnData)
+
+	test := genericTest{
+		s: "reverted calls return their data",
+		exp: []interface{}{
+			ReceiptStatusFailed, ErrExecutionReverted, 100, "revert reason",
+			ReceiptStatusFailed, receipt.ReturnData, []byte(nil), u256(0), true,
+		},
+		act: []interface{}{
+			receipt.Status, receipt.Err, len(receipt.ReturnData), reason,
+			createReceipt.Status, createReceipt.ReturnData, evm.StateDB().GetCode(addr), evm.StateDB().GetBalance(addr),
+			createReceipt.GasUsed < 100000,
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+func Test_StateTransition_Logs(t *testing.T) {
+	evm := genTransitionEVM(Moon)
+	// static call to the logger can not emit the log, hence it fails
+	// and pushes 0, which is stored into the memory of the caller
+	staticCaller := BytesToAddress([]byte{0xee})
+	evm.StateDB().SetCode(staticCaller, hexToBytes("6000600060006000"+"60bb"+"61ffff"+"fa"+"600052"+"60206000a0"))
+	to := testTo(testLogger)
+	tx, _ := SignTx(NewTx(&LegacyTx{GasPrice: u256(1), Gas: 100000, To: to, Value: u256(0)}), nil, eip155Key)
+	receipt, _ := evm.ApplyTransaction(tx)
+	tx, _ = SignTx(NewTx(&LegacyTx{Nonce: 1, GasPrice: u256(1), Gas: 100000, To: &staticCaller, Value: u256(0)}), nil, eip155Key)
+	staticReceipt, _ := evm.ApplyTransaction(tx)
+
+	test := genericTest{
+		s: "logs of the calls",
+		exp: []interface{}{
+			[]*Log{{Address: testLogger, Topics: []Hash{BytesToHash([]byte{7})}, Data: BytesToHash([]byte{0x2a}).Bytes()}},
+			[]*Log{{Address: staticCaller, Topics: []Hash{}, Data: make([]byte, 32)}},
+		},
+		act: []interface{}{receipt.Logs, staticReceipt.Logs},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+// in is the fork, and exp is the gas used by the transaction which
+// clears the storage slot, whose refund is capped with the used gas
+var storageRefundTests = []genericTest{
+	{s: "moon refunds the clear up to half", in: Moon, exp: []interface{}{ReceiptStatusSuccessful, uint64(26006 / 2), Hash{}}},
+	{s: "mars refunds the clear below one fifth", in: Mars, exp: []interface{}{ReceiptStatusSuccessful, uint64(26006 - 4800), Hash{}}},
+}
+
+func Test_StateTransition_StorageRefund(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range storageRefundTests {
+		evm := genTransitionEVM(test.in.(EVMFork))
+		// clears the slot 0
+		clearer := BytesToAddress([]byte{0xce})
+		evm.StateDB().SetCode(clearer, hexToBytes("6000600055"))
+		evm.StateDB().SetState(clearer, Hash{}, BytesToHash([]byte{1}))
+		tx, _ := SignTx(NewTx(&LegacyTx{GasPrice: u256(1), Gas: 100000, To: &clearer, Value: u256(0)}), nil, eip155Key)
+		receipt, err := evm.ApplyTransaction(tx)
+		if err != nil {
+			test.act = err
+		} else {
+			test.act = []interface{}{receipt.Status, receipt.GasUsed, evm.StateDB().GetState(clearer, Hash{})}
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+func Test_StateTransition_IntrinsicGas(t *testing.T) {
+	create, _ := IntrinsicGas(make([]byte, 33), nil, true)
+	call, _ := IntrinsicGas(nil, AccessList{{}}, false)
+	test := genericTest{
+		s:   "create with two words of zeroes, and call with an access list",
+		exp: []interface{}{uint64(53000 + 33*4 + 2*2), uint64(21000 + 2400)},
+		act: []interface{}{create, call},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
@@ -62,6 +62,15 @@ type interpreterRunTestExp struct {
 	stack  *Stack
 }
 
+// sstoreTestIn is the struct used to hold inputs of the storage
+// tests, where original is the value of the slot before the run
+type sstoreTestIn struct {
+	fork     EVMFork
+	original byte
+	code     string
+	gasLimit uint64
+}
+
 // Common helpers
 func stackPush(s *Stack, el uint64) (*uint256.Int, error) {
 	el256 := uint256.NewInt(el)
@@ -138,3 +147,14 @@ func u256Hex(h string) *uint256.Int {
 	v, _ := uint256.FromHex(h)
 	return v
 }
+
+// Returns the state root and the storage roots of the state where
+// only the zero address is set, and its storage holds the slots
+func genStorageRoots(slots map[Hash]Hash) []interface{} {
+	state := NewStateDB()
+	state.SetNonce(Address{}, 0)
+	for slot, value := range slots {
+		state.SetState(Address{}, slot, value)
+	}
+	return []interface{}{state.IntermediateRoot(), map[Address]Hash{{}: state.StorageRoot(Address{})}}
+}
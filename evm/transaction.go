@@ -0,0 +1,467 @@
+package space_evm
+
+import (
+	"fmt"
+
+	"space/crypto/secp256k1"
+	"space/rlp"
+
+	"github.com/holiman/uint256"
+)
+
+// Transaction types of EIP-2718, where the legacy transactions
+// are not typed, and they are encoded as plain RLP lists
+const (
+	LegacyTxType     byte = 0x00
+	AccessListTxType byte = 0x01
+	DynamicFeeTxType byte = 0x02
+	BlobTxType       byte = 0x03
+)
+
+// AccessTuple is an account of the access list of EIP-2930,
+// with the storage slots which are warmed up with it
+type AccessTuple struct {
+	Address     Address `json:"address"`
+	StorageKeys []Hash  `json:"storageKeys"`
+}
+
+// AccessList is the list of the accounts and storage
+// slots which the transaction is going to access
+type AccessList []AccessTuple
+
+// Returns the number of the storage keys in the access list
+func (al AccessList) StorageKeys() int {
+	keys := 0
+	for _, tuple := range al {
+		keys += len(tuple.StorageKeys)
+	}
+	return keys
+}
+
+// LegacyTx is the transaction of before EIP-2718, whose
+// signature optionally holds the chain ID as in EIP-155
+type LegacyTx struct {
+	Nonce    uint64
+	GasPrice *uint256.Int
+	Gas      uint64
+	To       *Address `rlp:"nil"`
+	Value    *uint256.Int
+	Data     []byte
+	V, R, S  *uint256.Int
+}
+
+// AccessListTx is the transaction of EIP-2930
+type AccessListTx struct {
+	ChainID    *uint256.Int
+	Nonce      uint64
+	GasPrice   *uint256.Int
+	Gas        uint64
+	To         *Address `rlp:"nil"`
+	Value      *uint256.Int
+	Data       []byte
+	AccessList AccessList
+	V, R, S    *uint256.Int
+}
+
+// DynamicFeeTx is the transaction of EIP-1559
+type DynamicFeeTx struct {
+	ChainID    *uint256.Int
+	Nonce      uint64
+	GasTipCap  *uint256.Int
+	GasFeeCap  *uint256.Int
+	Gas        uint64
+	To         *Address `rlp:"nil"`
+	Value      *uint256.Int
+	Data       []byte
+	AccessList AccessList
+	V, R, S    *uint256.Int
+}
+
+// BlobTx is the transaction of EIP-4844, which can not create
+// contracts. Blobs, commitments and proofs of the network form
+// are not part of the transaction, only the versioned hashes.
+type BlobTx struct {
+	ChainID    *uint256.Int
+	Nonce      uint64
+	GasTipCap  *uint256.Int
+	GasFeeCap  *uint256.Int
+	Gas        uint64
+	To         Address
+	Value      *uint256.Int
+	Data       []byte
+	AccessList AccessList
+	BlobFeeCap *uint256.Int
+	BlobHashes []Hash
+	V, R, S    *uint256.Int
+}
+
+// TxData is the content of a transaction of one of the types
+type TxData interface {
+	txType() byte
+	chainID() *uint256.Int
+	nonce() uint64
+	gasTipCap() *uint256.Int
+	gasFeeCap() *uint256.Int
+	gas() uint64
+	to() *Address
+	value() *uint256.Int
+	data() []byte
+	accessList() AccessList
+	sigValues() (v, r, s *uint256.Int)
+	// Returns a copy of the transaction with the signature values
+	withSignature(v, r, s *uint256.Int) TxData
+	// Returns the fields which are hashed for the signature
+	sigFields(chainID *uint256.Int) []interface{}
+}
+
+func (tx *LegacyTx) txType() byte {
+	return LegacyTxType
+}
+
+// Chain ID of the legacy transactions is derived from V,
+// and it is nil if the signature is not EIP-155 protected
+func (tx *LegacyTx) chainID() *uint256.Int {
+	if tx.V == nil || !tx.V.IsUint64() || tx.V.Uint64() < 35 {
+		return nil
+	}
+	chainID := new(uint256.Int).SubUint64(tx.V, 35)
+	return chainID.Rsh(chainID, 1)
+}
+
+func (tx *LegacyTx) nonce() uint64                     { return tx.Nonce }
+func (tx *LegacyTx) gasTipCap() *uint256.Int           { return tx.GasPrice }
+func (tx *LegacyTx) gasFeeCap() *uint256.Int           { return tx.GasPrice }
+func (tx *LegacyTx) gas() uint64                       { return tx.Gas }
+func (tx *LegacyTx) to() *Address                      { return tx.To }
+func (tx *LegacyTx) value() *uint256.Int               { return tx.Value }
+func (tx *LegacyTx) data() []byte                      { return tx.Data }
+func (tx *LegacyTx) accessList() AccessList            { return nil }
+func (tx *LegacyTx) sigValues() (v, r, s *uint256.Int) { return tx.V, tx.R, tx.S }
+
+func (tx *LegacyTx) withSignature(v, r, s *uint256.Int) TxData {
+	cpy := *tx
+	cpy.V, cpy.R, cpy.S = v, r, s
+	return &cpy
+}
+
+// Chain ID and two zeroes are appended to the
+// fields of the protected transactions of EIP-155
+func (tx *LegacyTx) sigFields(chainID *uint256.Int) []interface{} {
+	fields := []interface{}{tx.Nonce, tx.GasPrice, tx.Gas, tx.To, tx.Value, tx.Data}
+	if chainID != nil {
+		fields = append(fields, chainID, uint(0), uint(0))
+	}
+	return fields
+}
+
+func (tx *AccessListTx) txType() byte                      { return AccessListTxType }
+func (tx *AccessListTx) chainID() *uint256.Int             { return tx.ChainID }
+func (tx *AccessListTx) nonce() uint64                     { return tx.Nonce }
+func (tx *AccessListTx) gasTipCap() *uint256.Int           { return tx.GasPrice }
+func (tx *AccessListTx) gasFeeCap() *uint256.Int           { return tx.GasPrice }
+func (tx *AccessListTx) gas() uint64                       { return tx.Gas }
+func (tx *AccessListTx) to() *Address                      { return tx.To }
+func (tx *AccessListTx) value() *uint256.Int               { return tx.Value }
+func (tx *AccessListTx) data() []byte                      { return tx.Data }
+func (tx *AccessListTx) accessList() AccessList            { return tx.AccessList }
+func (tx *AccessListTx) sigValues() (v, r, s *uint256.Int) { return tx.V, tx.R, tx.S }
+
+func (tx *AccessListTx) withSignature(v, r, s *uint256.Int) TxData {
+	cpy := *tx
+	cpy.V, cpy.R, cpy.S = v, r, s
+	return &cpy
+}
+
+func (tx *AccessListTx) sigFields(chainID *uint256.Int) []interface{} {
+	return []interface{}{chainID, tx.Nonce, tx.GasPrice, tx.Gas, tx.To, tx.Value, tx.Data, tx.AccessList}
+}
+
+func (tx *DynamicFeeTx) txType() byte                      { return DynamicFeeTxType }
+func (tx *DynamicFeeTx) chainID() *uint256.Int             { return tx.ChainID }
+func (tx *DynamicFeeTx) nonce() uint64                     { return tx.Nonce }
+func (tx *DynamicFeeTx) gasTipCap() *uint256.Int           { return tx.GasTipCap }
+func (tx *DynamicFeeTx) gasFeeCap() *uint256.Int           { return tx.GasFeeCap }
+func (tx *DynamicFeeTx) gas() uint64                       { return tx.Gas }
+func (tx *DynamicFeeTx) to() *Address                      { return tx.To }
+func (tx *DynamicFeeTx) value() *uint256.Int               { return tx.Value }
+func (tx *DynamicFeeTx) data() []byte                      { return tx.Data }
+func (tx *DynamicFeeTx) accessList() AccessList            { return tx.AccessList }
+func (tx *DynamicFeeTx) sigValues() (v, r, s *uint256.Int) { return tx.V, tx.R, tx.S }
+
+func (tx *DynamicFeeTx) withSignature(v, r, s *uint256.Int) TxData {
+	cpy := *tx
+	cpy.V, cpy.R, cpy.S = v, r, s
+	return &cpy
+}
+
+func (tx *DynamicFeeTx) sigFields(chainID *uint256.Int) []interface{} {
+	return []interface{}{chainID, tx.Nonce, tx.GasTipCap, tx.GasFeeCap, tx.Gas, tx.To, tx.Value, tx.Data, tx.AccessList}
+}
+
+func (tx *BlobTx) txType() byte                      { return BlobTxType }
+func (tx *BlobTx) chainID() *uint256.Int             { return tx.ChainID }
+func (tx *BlobTx) nonce() uint64                     { return tx.Nonce }
+func (tx *BlobTx) gasTipCap() *uint256.Int           { return tx.GasTipCap }
+func (tx *BlobTx) gasFeeCap() *uint256.Int           { return tx.GasFeeCap }
+func (tx *BlobTx) gas() uint64                       { return tx.Gas }
+func (tx *BlobTx) value() *uint256.Int               { return tx.Value }
+func (tx *BlobTx) data() []byte                      { return tx.Data }
+func (tx *BlobTx) accessList() AccessList            { return tx.AccessList }
+func (tx *BlobTx) sigValues() (v, r, s *uint256.Int) { return tx.V, tx.R, tx.S }
+
+func (tx *BlobTx) to() *Address {
+	to := tx.To
+	return &to
+}
+
+func (tx *BlobTx) withSignature(v, r, s *uint256.Int) TxData {
+	cpy := *tx
+	cpy.V, cpy.R, cpy.S = v, r, s
+	return &cpy
+}
+
+func (tx *BlobTx) sigFields(chainID *uint256.Int) []interface{} {
+	return []interface{}{
+		chainID, tx.Nonce, tx.GasTipCap, tx.GasFeeCap, tx.Gas, tx.To,
+		tx.Value, tx.Data, tx.AccessList, tx.BlobFeeCap, tx.BlobHashes,
+	}
+}
+
+// Transaction is a transaction of any of the types. Transactions are
+// immutable, and signing returns a new transaction with the signature.
+type Transaction struct {
+	inner TxData
+}
+
+// Returns the transaction with the given content
+func NewTx(inner TxData) *Transaction {
+	return &Transaction{inner: inner}
+}
+
+func (tx *Transaction) Type() byte              { return tx.inner.txType() }
+func (tx *Transaction) ChainID() *uint256.Int   { return tx.inner.chainID() }
+func (tx *Transaction) Nonce() uint64           { return tx.inner.nonce() }
+func (tx *Transaction) GasTipCap() *uint256.Int { return tx.inner.gasTipCap() }
+func (tx *Transaction) GasFeeCap() *uint256.Int { return tx.inner.gasFeeCap() }
+func (tx *Transaction) Gas() uint64             { return tx.inner.gas() }
+func (tx *Transaction) Value() *uint256.Int     { return tx.inner.value() }
+func (tx *Transaction) Data() []byte            { return tx.inner.data() }
+func (tx *Transaction) AccessList() AccessList  { return tx.inner.accessList() }
+
+// Returns the gas price of the transaction, which is
+// the fee cap for the transactions of EIP-1559 fees
+func (tx *Transaction) GasPrice() *uint256.Int {
+	return tx.inner.gasFeeCap()
+}
+
+// Returns the tip which is paid to the coinbase for a unit of gas in a
+// block with the base fee, which is the tip capped with the fee cap
+// less the base fee. Base fee is treated as zero if it is nil.
+func (tx *Transaction) EffectiveGasTip(baseFee *uint256.Int) (*uint256.Int, error) {
+	if baseFee == nil {
+		return new(uint256.Int).Set(tx.GasTipCap()), nil
+	}
+	if tx.GasFeeCap().Lt(baseFee) {
+		return nil, fmt.Errorf("%w: fee cap %v, base fee %v", ErrFeeCapTooLow, tx.GasFeeCap(), baseFee)
+	}
+	tip := new(uint256.Int).Sub(tx.GasFeeCap(), baseFee)
+	if tip.Gt(tx.GasTipCap()) {
+		tip.Set(tx.GasTipCap())
+	}
+	return tip, nil
+}
+
+// Returns the recipient of the transaction,
+// which is nil for contract creations
+func (tx *Transaction) To() *Address {
+	return tx.inner.to()
+}
+
+// Returns the blob fee cap, which is nil for non-blob transactions
+func (tx *Transaction) BlobGasFeeCap() *uint256.Int {
+	if blobTx, ok := tx.inner.(*BlobTx); ok {
+		return blobTx.BlobFeeCap
+	}
+	return nil
+}
+
+// Returns the versioned hashes of the blobs of the transaction
+func (tx *Transaction) BlobHashes() []Hash {
+	if blobTx, ok := tx.inner.(*BlobTx); ok {
+		return blobTx.BlobHashes
+	}
+	return nil
+}
+
+// Returns the V, R and S values of the signature as they are
+// encoded, which are nil if the transaction is not signed
+func (tx *Transaction) RawSignatureValues() (v, r, s *uint256.Int) {
+	return tx.inner.sigValues()
+}
+
+// Reports whether the signature holds the chain ID, which is
+// the case for all the transactions but the legacy ones
+// signed before EIP-155
+func (tx *Transaction) Protected() bool {
+	return tx.inner.chainID() != nil
+}
+
+// Returns the canonical encoding of the transaction, which is the
+// RLP list for legacy transactions, and the type byte followed by
+// the RLP list for the typed transactions of EIP-2718
+func (tx *Transaction) MarshalBinary() ([]byte, error) {
+	enc, err := rlp.Encode(tx.inner)
+	if err != nil {
+		return nil, err
+	}
+	if tx.Type() == LegacyTxType {
+		return enc, nil
+	}
+	return append([]byte{tx.Type()}, enc...), nil
+}
+
+// Decodes the canonical encoding of a transaction
+func (tx *Transaction) UnmarshalBinary(buff []byte) error {
+	if len(buff) == 0 {
+		return fmt.Errorf("%w: empty transaction", ErrInvalidTx)
+	}
+	// lists start from 0xc0, and the types are below 0x80
+	if buff[0] >= 0xc0 {
+		inner := new(LegacyTx)
+		if err := rlp.Decode(buff, inner); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidTx, err)
+		}
+		tx.inner = inner
+		return nil
+	}
+
+	var inner TxData
+	switch buff[0] {
+	case AccessListTxType:
+		inner = new(AccessListTx)
+	case DynamicFeeTxType:
+		inner = new(DynamicFeeTx)
+	case BlobTxType:
+		inner = new(BlobTx)
+	default:
+		return fmt.Errorf("%w: 0x%02x", ErrTxTypeNotSupported, buff[0])
+	}
+	if err := rlp.Decode(buff[1:], inner); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTx, err)
+	}
+	tx.inner = inner
+	return nil
+}
+
+// Transactions are encoded in the lists of the block bodies as
+// their canonical encodings, where the typed transactions are
+// wrapped into RLP strings
+func (tx *Transaction) EncodeRLP() ([]byte, error) {
+	if tx.inner == nil {
+		return nil, fmt.Errorf("%w: no transaction data", ErrInvalidTx)
+	}
+	enc, err := tx.MarshalBinary()
+	if err != nil || tx.Type() == LegacyTxType {
+		return enc, err
+	}
+	return rlp.EncodeBytes(enc), nil
+}
+
+func (tx *Transaction) DecodeRLP(buff []byte) error {
+	kind, content, _, err := rlp.Split(buff)
+	if err != nil {
+		return err
+	}
+	if kind == rlp.List {
+		return tx.UnmarshalBinary(buff)
+	}
+	// legacy transactions can not be wrapped
+	if len(content) > 0 && content[0] >= 0xc0 {
+		return fmt.Errorf("%w: wrapped legacy transaction", ErrInvalidTx)
+	}
+	return tx.UnmarshalBinary(content)
+}
+
+// Returns the hash of the transaction,
+// which is the keccak256 of its encoding
+func (tx *Transaction) Hash() Hash {
+	// transactions only have the types which can be encoded
+	enc, _ := tx.MarshalBinary()
+	return BytesToHash(keccak256(enc))
+}
+
+// Returns the hash which is signed for the given chain ID. Chain ID
+// of the legacy transactions is nil if they are not EIP-155 protected.
+func (tx *Transaction) SigHash(chainID *uint256.Int) Hash {
+	enc, _ := rlp.Encode(tx.inner.sigFields(chainID))
+	if tx.Type() != LegacyTxType {
+		enc = append([]byte{tx.Type()}, enc...)
+	}
+	return BytesToHash(keccak256(enc))
+}
+
+// Returns a copy of the transaction which is signed with the secret
+// key. Legacy transactions are signed for the chain ID as in EIP-155,
+// or without it if the chain ID is nil. Typed transactions hold their
+// chain IDs, which must be the same as the given chain ID.
+func SignTx(tx *Transaction, chainID *uint256.Int, seckey []byte) (*Transaction, error) {
+	if tx.Type() != LegacyTxType && (chainID == nil || tx.ChainID() == nil || !tx.ChainID().Eq(chainID)) {
+		return nil, fmt.Errorf("%w: transaction has %v, signer has %v", ErrInvalidChainID, tx.ChainID(), chainID)
+	}
+	hash := tx.SigHash(chainID)
+	sig, err := secp256k1.Sign(hash[:], seckey)
+	if err != nil {
+		return nil, err
+	}
+
+	r := new(uint256.Int).SetBytes(sig[:32])
+	s := new(uint256.Int).SetBytes(sig[32:64])
+	v := uint256.NewInt(uint64(sig[64]))
+	if tx.Type() == LegacyTxType {
+		if chainID == nil {
+			v.AddUint64(v, 27)
+		} else {
+			v.Add(v, new(uint256.Int).Lsh(chainID, 1)).AddUint64(v, 35)
+		}
+	}
+	return &Transaction{inner: tx.inner.withSignature(v, r, s)}, nil
+}
+
+// Returns the address of the account which signed the transaction.
+// Signatures must have S in the lower half of the curve order.
+func (tx *Transaction) Sender() (Address, error) {
+	v, r, s := tx.inner.sigValues()
+	if v == nil || r == nil || s == nil {
+		return Address{}, fmt.Errorf("%w: transaction is not signed", ErrInvalidTxSignature)
+	}
+	chainID := tx.inner.chainID()
+
+	// recovery id is the parity of y, which is
+	// encoded in V along with the chain ID
+	var recoveryID uint64
+	switch {
+	case tx.Type() != LegacyTxType && v.IsUint64() && v.Uint64() <= 1:
+		recoveryID = v.Uint64()
+	case tx.Type() == LegacyTxType && chainID == nil && v.IsUint64() && (v.Uint64() == 27 || v.Uint64() == 28):
+		recoveryID = v.Uint64() - 27
+	case tx.Type() == LegacyTxType && chainID != nil:
+		recoveryID = new(uint256.Int).SubUint64(v, 35).Uint64() & 1
+	default:
+		return Address{}, fmt.Errorf("%w: invalid v %v", ErrInvalidTxSignature, v)
+	}
+	if !secp256k1.ValidateSignatureValues(r.ToBig(), s.ToBig(), true) {
+		return Address{}, fmt.Errorf("%w: invalid r or s", ErrInvalidTxSignature)
+	}
+
+	hash := tx.SigHash(chainID)
+	sig := make([]byte, secp256k1.SignatureLength)
+	rBytes, sBytes := r.Bytes32(), s.Bytes32()
+	copy(sig[:32], rBytes[:])
+	copy(sig[32:64], sBytes[:])
+	sig[64] = byte(recoveryID)
+	addr, err := Ecrecover(hash[:], sig)
+	if err != nil {
+		return Address{}, fmt.Errorf("%w: %v", ErrInvalidTxSignature, err)
+	}
+	return addr, nil
+}
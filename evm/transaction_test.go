@@ -0,0 +1,202 @@
+package space_evm
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"space/crypto/secp256k1"
+	"space/rlp"
+
+	"github.com/holiman/uint256"
+)
+
+// Example transaction of EIP-155, and its signing key and sender
+var (
+	eip155Key    = hexToBytes("4646464646464646464646464646464646464646464646464646464646464646")
+	eip155Sender = BytesToAddress(hexToBytes("9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f"))
+	eip155To     = BytesToAddress(hexToBytes("3535353535353535353535353535353535353535"))
+	eip155Tx     = &LegacyTx{
+		Nonce:    9,
+		GasPrice: u256(20000000000),
+		Gas:      21000,
+		To:       &eip155To,
+		Value:    u256(1000000000000000000),
+	}
+	eip155SignedTx = "f86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"
+)
+
+func Test_Transaction_EIP155(t *testing.T) {
+	tx := NewTx(eip155Tx)
+	signed, err := SignTx(tx, u256(1), eip155Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, _ := signed.MarshalBinary()
+	decoded := new(Transaction)
+	decodeErr := decoded.UnmarshalBinary(hexToBytes(eip155SignedTx))
+	sender, senderErr := decoded.Sender()
+
+	test := genericTest{
+		s: "sign, encode, decode and recover the example transaction",
+		exp: []interface{}{
+			BytesToHash(hexToBytes("daf5a779ae972f972197303d7b574746c7ef83eadac0f2791ad23db92e4c8e53")),
+			hexToBytes(eip155SignedTx),
+			error(nil), BytesToHash(hexToBytes("33469b22e9f636356c4160a87eb19df52b7412e8eac32a4a55ffe88ea8350788")),
+			u256(1), true, eip155Sender, error(nil),
+		},
+		act: []interface{}{
+			tx.SigHash(u256(1)),
+			enc,
+			decodeErr, decoded.Hash(), decoded.ChainID(), decoded.Protected(), sender, senderErr,
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+// in is the unsigned transaction with the chain ID it is signed for,
+// and exp is the type of the transaction, which is signed by the key
+// of EIP-155 example, and which goes through the RLP list of the body
+type signTxTestIn struct {
+	tx      TxData
+	chainID *uint256.Int
+}
+
+var accessList = AccessList{
+	{Address: eip155To, StorageKeys: []Hash{BytesToHash([]byte{1}), BytesToHash([]byte{2})}},
+	{Address: eip155Sender},
+}
+
+var signTxTests = []genericTest{
+	{
+		s:   "unprotected legacy transaction",
+		in:  signTxTestIn{&LegacyTx{Nonce: 1, GasPrice: u256(1), Gas: 21000, To: &eip155To, Value: u256(1)}, nil},
+		exp: LegacyTxType,
+	},
+	{
+		s:   "legacy contract creation",
+		in:  signTxTestIn{&LegacyTx{GasPrice: u256(1), Gas: 100000, Value: u256(0), Data: hexToBytes("6001")}, u256(1337)},
+		exp: LegacyTxType,
+	},
+	{
+		s: "access list transaction",
+		in: signTxTestIn{&AccessListTx{
+			ChainID: u256(1), Nonce: 2, GasPrice: u256(1), Gas: 30000, To: &eip155To,
+			Value: u256(0), Data: hexToBytes("00"), AccessList: accessList,
+		}, u256(1)},
+		exp: AccessListTxType,
+	},
+	{
+		s: "dynamic fee contract creation",
+		in: signTxTestIn{&DynamicFeeTx{
+			ChainID: u256(5), Nonce: 3, GasTipCap: u256(2), GasFeeCap: u256(10), Gas: 60000,
+			Value: u256(7), Data: hexToBytes("6001"),
+		}, u256(5)},
+		exp: DynamicFeeTxType,
+	},
+	{
+		s: "blob transaction",
+		in: signTxTestIn{&BlobTx{
+			ChainID: u256(1), Nonce: 4, GasTipCap: u256(2), GasFeeCap: u256(10), Gas: 21000, To: eip155To,
+			Value: u256(0), AccessList: accessList, BlobFeeCap: u256(3),
+			BlobHashes: []Hash{BytesToHash(append([]byte{1}, make([]byte, 31)...))},
+		}, u256(1)},
+		exp: BlobTxType,
+	},
+}
+
+func Test_Transaction_SignTx(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range signTxTests {
+		testIn := test.in.(signTxTestIn)
+		signed, err := SignTx(NewTx(testIn.tx), testIn.chainID, eip155Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body []*Transaction
+		encBody, encErr := rlp.Encode([]*Transaction{signed})
+		decErr := rlp.Decode(encBody, &body)
+		var sender Address
+		var senderErr error = errors.New("missing transaction")
+		if len(body) == 1 {
+			sender, senderErr = body[0].Sender()
+		}
+
+		test.exp = []interface{}{test.exp, signed.Hash(), eip155Sender, error(nil), error(nil), error(nil), testIn.tx.to() == nil}
+		test.act = []interface{}{signed.Type(), body[0].Hash(), sender, senderErr, encErr, decErr, body[0].To() == nil}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in modifies the signed example transaction of EIP-155,
+// and exp is the error of decoding it or recovering its sender
+var invalidTxTests = []genericTest{
+	{s: "empty transaction", in: func(enc []byte) []byte { return nil }, exp: ErrInvalidTx},
+	{s: "unknown type", in: func(enc []byte) []byte { return append([]byte{0x05}, enc...) }, exp: ErrTxTypeNotSupported},
+	{s: "legacy transaction with type", in: func(enc []byte) []byte { return append([]byte{DynamicFeeTxType}, enc...) }, exp: ErrInvalidTx},
+	{s: "trailing bytes", in: func(enc []byte) []byte { return append(enc, 0) }, exp: ErrInvalidTx},
+	{
+		s: "invalid v",
+		in: func(enc []byte) []byte {
+			enc = append([]byte{}, enc...)
+			enc[len(enc)-67] = 29
+			return enc
+		},
+		exp: ErrInvalidTxSignature,
+	},
+	{
+		s: "high s",
+		in: func(enc []byte) []byte {
+			tx := new(Transaction)
+			tx.UnmarshalBinary(enc)
+			v, r, s := tx.RawSignatureValues()
+			n, _ := uint256.FromBig(secp256k1.N)
+			enc, _ = NewTx(tx.inner.withSignature(v, r, new(uint256.Int).Sub(n, s))).MarshalBinary()
+			return enc
+		},
+		exp: ErrInvalidTxSignature,
+	},
+	{
+		s: "unsigned",
+		in: func(enc []byte) []byte {
+			enc, _ = NewTx(eip155Tx).MarshalBinary()
+			return enc
+		},
+		exp: ErrInvalidTxSignature,
+	},
+}
+
+func Test_Transaction_Invalid(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range invalidTxTests {
+		tx := new(Transaction)
+		err := tx.UnmarshalBinary(test.in.(func([]byte) []byte)(hexToBytes(eip155SignedTx)))
+		if err == nil {
+			_, err = tx.Sender()
+		}
+		test.act = err
+		if errors.Is(err, test.exp.(error)) {
+			test.act = test.exp
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+
+	_, err := SignTx(NewTx(&DynamicFeeTx{ChainID: u256(1)}), u256(2), eip155Key)
+	test := genericTest{s: "signing for another chain", exp: true, act: errors.Is(err, ErrInvalidChainID)}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if anyTestFailed || failed {
+		t.FailNow()
+	}
+}
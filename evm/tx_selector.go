@@ -0,0 +1,108 @@
+package space_evm
+
+import (
+	"bytes"
+	"container/heap"
+
+	"github.com/holiman/uint256"
+)
+
+// txHead is the next transaction of an account with its effective
+// tip, and with its hash which breaks the ties of the tips
+type txHead struct {
+	tx     *Transaction
+	hash   Hash
+	sender Address
+	tip    *uint256.Int
+}
+
+// txHeads is the max heap of the next transactions of the accounts
+// by their effective tips, where the ties are broken by their hashes
+type txHeads []*txHead
+
+func (h txHeads) Len() int { return len(h) }
+
+func (h txHeads) Less(i, j int) bool {
+	if cmp := h[i].tip.Cmp(h[j].tip); cmp != 0 {
+		return cmp > 0
+	}
+	return bytes.Compare(h[i].hash[:], h[j].hash[:]) < 0
+}
+
+func (h txHeads) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *txHeads) Push(x interface{}) { *h = append(*h, x.(*txHead)) }
+
+func (h *txHeads) Pop() interface{} {
+	old := *h
+	head := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return head
+}
+
+// TxSelector yields the transactions of the accounts for a block in the
+// order of their effective tips at the base fee of the block, while the
+// transactions of each account are yielded in their nonce order. Account
+// is skipped from its first transaction whose fee cap is below the base
+// fee, since its later transactions can not be executed before it.
+type TxSelector struct {
+	txs     map[Address][]*Transaction
+	heads   txHeads
+	baseFee *uint256.Int
+}
+
+// Returns the selector of the transactions, which are the
+// transactions of the accounts sorted by their nonces
+func NewTxSelector(txs map[Address][]*Transaction, baseFee *uint256.Int) *TxSelector {
+	s := &TxSelector{txs: make(map[Address][]*Transaction, len(txs)), baseFee: baseFee}
+	for addr, accountTxs := range txs {
+		s.txs[addr] = accountTxs
+		s.pushNext(addr)
+	}
+	return s
+}
+
+// Pushes the next transaction of the account into the heads
+func (s *TxSelector) pushNext(addr Address) {
+	txs := s.txs[addr]
+	if len(txs) == 0 {
+		delete(s.txs, addr)
+		return
+	}
+	tip, err := txs[0].EffectiveGasTip(s.baseFee)
+	if err != nil {
+		delete(s.txs, addr)
+		return
+	}
+	s.txs[addr] = txs[1:]
+	heap.Push(&s.heads, &txHead{tx: txs[0], hash: txs[0].Hash(), sender: addr, tip: tip})
+}
+
+// Returns the transaction with the highest tip, or nil if there is none
+func (s *TxSelector) Peek() *Transaction {
+	if len(s.heads) == 0 {
+		return nil
+	}
+	return s.heads[0].tx
+}
+
+// Replaces the transaction with the highest tip
+// with the next transaction of its sender
+func (s *TxSelector) Shift() {
+	if len(s.heads) == 0 {
+		return
+	}
+	head := heap.Pop(&s.heads).(*txHead)
+	s.pushNext(head.sender)
+}
+
+// Drops the transaction with the highest tip with the rest of the
+// transactions of its sender, which is used when the transaction
+// can not be included in the block
+func (s *TxSelector) Pop() {
+	if len(s.heads) == 0 {
+		return
+	}
+	head := heap.Pop(&s.heads).(*txHead)
+	delete(s.txs, head.sender)
+}
@@ -0,0 +1,55 @@
+package space_evm
+
+import (
+	"fmt"
+	"testing"
+)
+
+var testSelectorTxs = map[Address][]*Transaction{
+	eip155Sender: {genPoolTx(eip155Key, 0, 1, 100), genPoolTx(eip155Key, 1, 50, 55)},
+	testSender2:  {genPoolTx(testKey2, 0, 5, 100), genPoolTx(testKey2, 1, 3, 100)},
+	// below the base fee
+	testSender3: {genPoolTx(testKey3, 0, 100, 5), genPoolTx(testKey3, 1, 100, 500)},
+}
+
+// in is the number of the transactions which are shifted before
+// the account of the head is popped, and exp is the selected
+// transactions, where the base fee is 10
+var txSelectorTests = []genericTest{
+	{
+		s:  "ordered by tip and nonce",
+		in: -1,
+		exp: []*Transaction{
+			testSelectorTxs[testSender2][0], testSelectorTxs[testSender2][1],
+			testSelectorTxs[eip155Sender][0], testSelectorTxs[eip155Sender][1],
+		},
+	},
+	{
+		s:   "account of the head is popped",
+		in:  0,
+		exp: []*Transaction{testSelectorTxs[testSender2][0], testSelectorTxs[eip155Sender][0], testSelectorTxs[eip155Sender][1]},
+	},
+}
+
+func Test_TxSelector_Order(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range txSelectorTests {
+		selector := NewTxSelector(testSelectorTxs, u256(10))
+		selected := []*Transaction{}
+		for tx := selector.Peek(); tx != nil; tx = selector.Peek() {
+			selected = append(selected, tx)
+			if len(selected) == test.in.(int)+1 {
+				selector.Pop()
+			} else {
+				selector.Shift()
+			}
+		}
+		test.act = selected
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,332 @@
+package space_evm
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/holiman/uint256"
+)
+
+// TxPoolConfig holds the limits of the transaction pool
+type TxPoolConfig struct {
+	// Minimum percentage by which a transaction must raise both the
+	// fee cap and the tip of the transaction with the same nonce
+	// of the same sender to replace it
+	PriceBump uint64
+	// Maximum number of transactions in the pool
+	Capacity int
+}
+
+var DefaultTxPoolConfig = TxPoolConfig{PriceBump: 10, Capacity: 4096}
+
+// TxPool holds the signed transactions which are waiting to be included
+// in a block. Transactions are validated against the state of the head
+// block when they are added. Transactions of an account which follow
+// the nonce of the account without a gap are pending, and they can be
+// executed in the next block, while the rest of them are queued until
+// the gap is filled. When the pool is full, the cheapest transaction
+// is evicted for a better paying one.
+type TxPool struct {
+	config  TxPoolConfig
+	fork    EVMFork
+	chainID *uint256.Int
+
+	lock    sync.Mutex
+	state   *StateDB
+	head    *Header
+	baseFee *uint256.Int
+	all     map[Hash]*Transaction
+	senders map[Hash]Address
+	// transactions of the accounts by their nonces
+	accounts map[Address]map[uint64]*Transaction
+}
+
+// Returns an empty pool of the transactions of the chain, which
+// validates the transactions against the fork of the chain and the
+// state of the head block
+func NewTxPool(config TxPoolConfig, fork EVMFork, chainID *uint256.Int, state *StateDB, head *Header) *TxPool {
+	if config.Capacity <= 0 {
+		config.Capacity = DefaultTxPoolConfig.Capacity
+	}
+	pool := &TxPool{
+		config:   config,
+		fork:     fork,
+		chainID:  chainID,
+		all:      make(map[Hash]*Transaction),
+		senders:  make(map[Hash]Address),
+		accounts: make(map[Address]map[uint64]*Transaction),
+	}
+	pool.Reset(state, head)
+	return pool
+}
+
+// Sets the new head block of the chain with its state, and drops the
+// transactions which are included in the chain or which can not be
+// executed on top of the new state anymore. Transactions are checked
+// against the new state with their senders recovered when they were
+// added.
+func (p *TxPool) Reset(state *StateDB, head *Header) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.state, p.head, p.baseFee = state.Copy(), head, CalcBaseFee(head)
+	for hash, tx := range p.all {
+		if err := p.validateState(tx, p.senders[hash]); err != nil {
+			p.remove(hash)
+		}
+	}
+}
+
+// Returns the base fee of the block which follows the head
+func (p *TxPool) BaseFee() *uint256.Int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return new(uint256.Int).Set(p.baseFee)
+}
+
+// Validates the transaction and adds it into the pool. Transaction
+// with the nonce of a transaction of the same sender in the pool
+// replaces it if it raises the fee cap and the tip by the price bump.
+func (p *TxPool) Add(tx *Transaction) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	hash := tx.Hash()
+	if _, ok := p.all[hash]; ok {
+		return fmt.Errorf("%w: %v", ErrAlreadyKnown, hash.Hex())
+	}
+	sender, err := p.validateTx(tx)
+	if err != nil {
+		return err
+	}
+
+	if old := p.accounts[sender][tx.Nonce()]; old != nil {
+		if err := p.checkReplacement(old, tx); err != nil {
+			return err
+		}
+		p.remove(old.Hash())
+	} else if len(p.all) >= p.config.Capacity {
+		if err := p.evict(tx); err != nil {
+			return err
+		}
+	}
+
+	if p.accounts[sender] == nil {
+		p.accounts[sender] = make(map[uint64]*Transaction)
+	}
+	p.accounts[sender][tx.Nonce()] = tx
+	p.all[hash], p.senders[hash] = tx, sender
+	return nil
+}
+
+// Checks whether the transaction can be executed on top of the state
+// of the head block once the transactions with the lower nonces of
+// its sender are executed, and returns its sender
+func (p *TxPool) validateTx(tx *Transaction) (Address, error) {
+	if tx.GasTipCap() == nil || tx.GasFeeCap() == nil || tx.Value() == nil {
+		return Address{}, fmt.Errorf("%w: missing gas price or value", ErrInvalidTx)
+	}
+	if tx.Type() == BlobTxType && p.fork < Saturn {
+		return Address{}, fmt.Errorf("%w: blob transactions before %v", ErrTxTypeNotSupported, Saturn)
+	}
+	if tx.Type() == BlobTxType && (tx.BlobGasFeeCap() == nil || len(tx.BlobHashes()) == 0) {
+		return Address{}, ErrMissingBlobHashes
+	}
+	if tx.GasTipCap().Gt(tx.GasFeeCap()) {
+		return Address{}, fmt.Errorf("%w: tip %v, fee cap %v", ErrTipAboveFeeCap, tx.GasTipCap(), tx.GasFeeCap())
+	}
+	if tx.Protected() && (p.chainID == nil || !tx.ChainID().Eq(p.chainID)) {
+		return Address{}, fmt.Errorf("%w: transaction has %v, chain has %v", ErrInvalidChainID, tx.ChainID(), p.chainID)
+	}
+	if tx.To() == nil && len(tx.Data()) > maxInitCodeSize {
+		return Address{}, fmt.Errorf("%w: code size %d, limit %d", ErrMaxInitCodeSizeExceeded, len(tx.Data()), maxInitCodeSize)
+	}
+	intrinsicGas, err := IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil)
+	if err != nil {
+		return Address{}, err
+	}
+	if tx.Gas() < intrinsicGas {
+		return Address{}, fmt.Errorf("%w: have %d, want %d", ErrIntrinsicGas, tx.Gas(), intrinsicGas)
+	}
+	sender, err := tx.Sender()
+	if err != nil {
+		return Address{}, err
+	}
+	return sender, p.validateState(tx, sender)
+}
+
+// Checks whether the transaction of the sender can be executed on top
+// of the state of the head block, which is checked again for all of
+// the transactions in the pool whenever the head changes
+func (p *TxPool) validateState(tx *Transaction, sender Address) error {
+	if nonce := p.state.GetNonce(sender); tx.Nonce() < nonce {
+		return fmt.Errorf("%w: address %v, tx: %d state: %d", ErrNonceTooLow, sender.Hex(), tx.Nonce(), nonce)
+	}
+	if len(p.state.GetCode(sender)) > 0 {
+		return fmt.Errorf("%w: address %v", ErrSenderNoEOA, sender.Hex())
+	}
+	if tx.Gas() > p.head.GasLimit {
+		return fmt.Errorf("%w: tx gas %d, limit %d", ErrGasLimitReached, tx.Gas(), p.head.GasLimit)
+	}
+
+	// sender must afford the gas with the highest price, and the value
+	cost, overflow1 := new(uint256.Int).MulOverflow(uint256.NewInt(tx.Gas()), tx.GasFeeCap())
+	_, overflow2 := cost.AddOverflow(cost, tx.Value())
+	overflow := overflow1 || overflow2
+	if tx.Type() == BlobTxType {
+		blobGas := uint256.NewInt(uint64(len(tx.BlobHashes())) * blobGasPerBlob)
+		blobCost, overflow3 := new(uint256.Int).MulOverflow(blobGas, tx.BlobGasFeeCap())
+		_, overflow4 := cost.AddOverflow(cost, blobCost)
+		overflow = overflow || overflow3 || overflow4
+	}
+	if balance := p.state.GetBalance(sender); overflow || balance.Lt(cost) {
+		return fmt.Errorf("%w: address %v have %v want %v", ErrInsufficientFunds, sender.Hex(), balance, cost)
+	}
+	return nil
+}
+
+// Checks whether the transaction raises both the fee cap and the tip
+// of the old transaction by at least the price bump
+func (p *TxPool) checkReplacement(old, tx *Transaction) error {
+	bump := uint256.NewInt(100 + p.config.PriceBump)
+	minFeeCap := new(uint256.Int).Mul(old.GasFeeCap(), bump)
+	minFeeCap.Div(minFeeCap, uint256.NewInt(100))
+	minTip := new(uint256.Int).Mul(old.GasTipCap(), bump)
+	minTip.Div(minTip, uint256.NewInt(100))
+
+	if !tx.GasFeeCap().Gt(old.GasFeeCap()) || !tx.GasTipCap().Gt(old.GasTipCap()) ||
+		tx.GasFeeCap().Lt(minFeeCap) || tx.GasTipCap().Lt(minTip) {
+		return fmt.Errorf("%w: fee cap %v, tip %v, want at least %v, %v",
+			ErrReplaceUnderpriced, tx.GasFeeCap(), tx.GasTipCap(), minFeeCap, minTip)
+	}
+	return nil
+}
+
+// Makes room for the transaction by evicting the cheapest of the last
+// transactions of the accounts, which are the ones with the highest
+// nonces, so that no gap is left behind in the nonces of the accounts.
+// Transaction is rejected if it does not pay more than the evicted one.
+func (p *TxPool) evict(tx *Transaction) error {
+	var cheapest *Transaction
+	for _, txs := range p.accounts {
+		var last *Transaction
+		for _, accountTx := range txs {
+			if last == nil || accountTx.Nonce() > last.Nonce() {
+				last = accountTx
+			}
+		}
+		if cheapest == nil || compareTips(last, cheapest, p.baseFee) < 0 {
+			cheapest = last
+		}
+	}
+	if cheapest == nil || compareTips(tx, cheapest, p.baseFee) <= 0 {
+		return fmt.Errorf("%w: capacity %d", ErrTxPoolOverflow, p.config.Capacity)
+	}
+	p.remove(cheapest.Hash())
+	return nil
+}
+
+// Compares the effective tips of the transactions at the base fee,
+// where the transactions below the base fee pay the lowest tip
+func compareTips(a, b *Transaction, baseFee *uint256.Int) int {
+	tipA, errA := a.EffectiveGasTip(baseFee)
+	tipB, errB := b.EffectiveGasTip(baseFee)
+	switch {
+	case errA != nil && errB != nil:
+		return 0
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	return tipA.Cmp(tipB)
+}
+
+func (p *TxPool) remove(hash Hash) {
+	tx, sender := p.all[hash], p.senders[hash]
+	delete(p.all, hash)
+	delete(p.senders, hash)
+	delete(p.accounts[sender], tx.Nonce())
+	if len(p.accounts[sender]) == 0 {
+		delete(p.accounts, sender)
+	}
+}
+
+// Returns the transaction with the hash, or nil if it is not in the pool
+func (p *TxPool) Get(hash Hash) *Transaction {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.all[hash]
+}
+
+// Returns the number of the transactions in the pool
+func (p *TxPool) Len() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.all)
+}
+
+// Returns the next nonce of the account, which follows
+// its pending transactions in the pool
+func (p *TxPool) Nonce(addr Address) uint64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	nonce := p.state.GetNonce(addr)
+	for p.accounts[addr][nonce] != nil {
+		nonce++
+	}
+	return nonce
+}
+
+// Returns the pending transactions of the accounts in their nonce
+// order, which can be executed on top of the state of the head
+func (p *TxPool) Pending() map[Address][]*Transaction {
+	pending, _ := p.Content()
+	return pending
+}
+
+// Returns the pending transactions, and the queued transactions
+// of the accounts, which wait for the gaps in their nonces
+func (p *TxPool) Content() (map[Address][]*Transaction, map[Address][]*Transaction) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	pending := make(map[Address][]*Transaction)
+	queued := make(map[Address][]*Transaction)
+	for addr, txs := range p.accounts {
+		nonce := p.state.GetNonce(addr)
+		for ; txs[nonce] != nil; nonce++ {
+			pending[addr] = append(pending[addr], txs[nonce])
+		}
+		for txNonce, tx := range txs {
+			if txNonce > nonce {
+				queued[addr] = append(queued[addr], tx)
+			}
+		}
+		sortByNonce(queued[addr])
+	}
+	return pending, queued
+}
+
+func sortByNonce(txs []*Transaction) {
+	sort.Slice(txs, func(i, j int) bool {
+		return txs[i].Nonce() < txs[j].Nonce()
+	})
+}
+
+// Returns the number of the pending and the queued transactions
+func (p *TxPool) Stats() (int, int) {
+	pending, queued := p.Content()
+	pendingCount, queuedCount := 0, 0
+	for _, txs := range pending {
+		pendingCount += len(txs)
+	}
+	for _, txs := range queued {
+		queuedCount += len(txs)
+	}
+	return pendingCount, queuedCount
+}
+
+// Returns the selector of the pending transactions
+// for the block which follows the head
+func (p *TxPool) Selector() *TxSelector {
+	return NewTxSelector(p.Pending(), p.BaseFee())
+}
@@ -0,0 +1,274 @@
+package space_evm
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"space/crypto/secp256k1"
+
+	"github.com/holiman/uint256"
+)
+
+var (
+	testKey2    = hexToBytes("0000000000000000000000000000000000000000000000000000000000000002")
+	testKey3    = hexToBytes("0000000000000000000000000000000000000000000000000000000000000003")
+	testSender2 = keyToAddress(testKey2)
+	testSender3 = keyToAddress(testKey3)
+	// head block whose child has the base fee 10
+	testPoolHead = &Header{GasLimit: 30000000, GasUsed: 15000000, BaseFee: u256(10)}
+)
+
+func keyToAddress(key []byte) Address {
+	pubkey, _ := secp256k1.PubkeyFromSeckey(key)
+	return PubkeyToAddress(pubkey)
+}
+
+// Returns the signed transfer of the key with the nonce and the fees
+func genPoolTx(key []byte, nonce uint64, tip, feeCap uint64) *Transaction {
+	tx, _ := SignTx(NewTx(&DynamicFeeTx{
+		ChainID: u256(DefaultChainID), Nonce: nonce, GasTipCap: u256(tip), GasFeeCap: u256(feeCap),
+		Gas: 21000, To: testTo(testRecipient), Value: u256(0),
+	}), u256(DefaultChainID), key)
+	return tx
+}
+
+// Returns the pool of the Saturn fork whose state has the senders of
+// the EIP-155 example and the second test key with 1 ether, where the
+// nonce of the second test key is 1
+func genTxPool(config TxPoolConfig) *TxPool {
+	return genForkTxPool(config, Saturn)
+}
+
+func genForkTxPool(config TxPoolConfig, fork EVMFork) *TxPool {
+	state := NewStateDB()
+	state.SetBalance(eip155Sender, u256(1000000000000000000))
+	state.SetBalance(testSender2, u256(1000000000000000000))
+	state.SetNonce(testSender2, 1)
+	return NewTxPool(config, fork, u256(DefaultChainID), state, testPoolHead)
+}
+
+// in is the transactions which are added into the pool in their order,
+// and exp is the error of adding the last one, and the number of the
+// pending and queued transactions in the pool
+var txPoolAddTests = []genericTest{
+	{
+		s:   "pending transaction",
+		in:  []*Transaction{genPoolTx(eip155Key, 0, 1, 10)},
+		exp: []interface{}{nil, 1, 0},
+	},
+	{
+		s:   "future nonce is queued",
+		in:  []*Transaction{genPoolTx(eip155Key, 1, 1, 10)},
+		exp: []interface{}{nil, 0, 1},
+	},
+	{
+		s:   "gap is filled",
+		in:  []*Transaction{genPoolTx(eip155Key, 1, 1, 10), genPoolTx(eip155Key, 2, 1, 10), genPoolTx(eip155Key, 0, 1, 10)},
+		exp: []interface{}{nil, 3, 0},
+	},
+	{
+		s:   "fee cap below the base fee waits in the pool",
+		in:  []*Transaction{genPoolTx(eip155Key, 0, 1, 1)},
+		exp: []interface{}{nil, 1, 0},
+	},
+	{
+		s:   "already known",
+		in:  []*Transaction{genPoolTx(eip155Key, 0, 1, 10), genPoolTx(eip155Key, 0, 1, 10)},
+		exp: []interface{}{ErrAlreadyKnown, 1, 0},
+	},
+	{
+		s:   "replacement with the price bump",
+		in:  []*Transaction{genPoolTx(eip155Key, 0, 10, 100), genPoolTx(eip155Key, 0, 11, 110)},
+		exp: []interface{}{nil, 1, 0},
+	},
+	{
+		s:   "replacement without the fee cap bump",
+		in:  []*Transaction{genPoolTx(eip155Key, 0, 10, 100), genPoolTx(eip155Key, 0, 11, 109)},
+		exp: []interface{}{ErrReplaceUnderpriced, 1, 0},
+	},
+	{
+		s:   "replacement without the tip bump",
+		in:  []*Transaction{genPoolTx(eip155Key, 0, 10, 100), genPoolTx(eip155Key, 0, 10, 200)},
+		exp: []interface{}{ErrReplaceUnderpriced, 1, 0},
+	},
+	{
+		s:   "nonce too low",
+		in:  []*Transaction{genPoolTx(testKey2, 0, 1, 10)},
+		exp: []interface{}{ErrNonceTooLow, 0, 0},
+	},
+	{
+		s:   "sender without funds",
+		in:  []*Transaction{genPoolTx(testKey3, 0, 1, 10)},
+		exp: []interface{}{ErrInsufficientFunds, 0, 0},
+	},
+	{
+		s:   "tip above fee cap",
+		in:  []*Transaction{genPoolTx(eip155Key, 0, 11, 10)},
+		exp: []interface{}{ErrTipAboveFeeCap, 0, 0},
+	},
+	{
+		s: "intrinsic gas too low",
+		in: func() []*Transaction {
+			tx, _ := SignTx(NewTx(&LegacyTx{GasPrice: u256(10), Gas: 20000, To: testTo(testRecipient), Value: u256(0)}), nil, eip155Key)
+			return []*Transaction{tx}
+		}(),
+		exp: []interface{}{ErrIntrinsicGas, 0, 0},
+	},
+	{
+		s: "gas above block gas limit",
+		in: func() []*Transaction {
+			tx, _ := SignTx(NewTx(&LegacyTx{GasPrice: u256(10), Gas: 30000001, To: testTo(testRecipient), Value: u256(0)}), nil, eip155Key)
+			return []*Transaction{tx}
+		}(),
+		exp: []interface{}{ErrGasLimitReached, 0, 0},
+	},
+	{
+		s: "another chain",
+		in: func() []*Transaction {
+			tx, _ := SignTx(NewTx(&LegacyTx{GasPrice: u256(10), Gas: 21000, To: testTo(testRecipient), Value: u256(0)}), u256(1), eip155Key)
+			return []*Transaction{tx}
+		}(),
+		exp: []interface{}{ErrInvalidChainID, 0, 0},
+	},
+}
+
+func Test_TxPool_Add(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range txPoolAddTests {
+		pool := genTxPool(DefaultTxPoolConfig)
+		var err error
+		for _, tx := range test.in.([]*Transaction) {
+			err = pool.Add(tx)
+		}
+		if expErr, ok := test.exp.([]interface{})[0].(error); ok && errors.Is(err, expErr) {
+			err = expErr
+		}
+		pending, queued := pool.Stats()
+		test.act = []interface{}{err, pending, queued}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the fork of the pool, and exp is the error of adding a blob
+// transaction, and the number of the pending transactions
+var txPoolBlobTxTests = []genericTest{
+	{s: "blob transaction before Saturn", in: Jupiter, exp: []interface{}{ErrTxTypeNotSupported, 0}},
+	{s: "blob transaction on Saturn", in: Saturn, exp: []interface{}{nil, 1}},
+}
+
+func Test_TxPool_BlobTx(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range txPoolBlobTxTests {
+		pool := genForkTxPool(DefaultTxPoolConfig, test.in.(EVMFork))
+		tx, _ := SignTx(NewTx(&BlobTx{
+			ChainID: u256(DefaultChainID), GasTipCap: u256(1), GasFeeCap: u256(10), Gas: 21000, To: testRecipient,
+			Value: u256(0), BlobFeeCap: u256(1), BlobHashes: []Hash{BytesToHash(append([]byte{1}, make([]byte, 31)...))},
+		}), u256(DefaultChainID), eip155Key)
+		err := pool.Add(tx)
+		if expErr, ok := test.exp.([]interface{})[0].(error); ok && errors.Is(err, expErr) {
+			err = expErr
+		}
+		pending, _ := pool.Stats()
+		test.act = []interface{}{err, pending}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+func Test_TxPool_Evict(t *testing.T) {
+	pool := genTxPool(TxPoolConfig{PriceBump: 10, Capacity: 2})
+	cheap, expensive := genPoolTx(eip155Key, 0, 2, 100), genPoolTx(testKey2, 1, 5, 100)
+	pool.Add(cheap)
+	pool.Add(expensive)
+	errOverflow := pool.Add(genPoolTx(eip155Key, 1, 1, 100))
+	// cheapest of the last transactions is evicted
+	errEvict := pool.Add(genPoolTx(testKey2, 2, 3, 100))
+
+	test := genericTest{
+		s:   "cheapest transaction is evicted for a better paying one",
+		exp: []interface{}{true, nil, (*Transaction)(nil), expensive, 2},
+		act: []interface{}{errors.Is(errOverflow, ErrTxPoolOverflow), errEvict, pool.Get(cheap.Hash()), pool.Get(expensive.Hash()), pool.Len()},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+func Test_TxPool_Reset(t *testing.T) {
+	pool := genTxPool(DefaultTxPoolConfig)
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		pool.Add(genPoolTx(eip155Key, nonce, 1, 10))
+	}
+	pool.Add(genPoolTx(eip155Key, 4, 1, 10))
+	pool.Add(genPoolTx(testKey2, 1, 1, 10))
+	nonceBefore := pool.Nonce(eip155Sender)
+
+	// first two transactions of the sender are included in the
+	// new head, and the second test key has no funds anymore
+	state := NewStateDB()
+	state.SetBalance(eip155Sender, u256(1000000000000000000))
+	state.SetNonce(eip155Sender, 2)
+	state.SetNonce(testSender2, 1)
+	head := &Header{Number: 1, GasLimit: 30000000, GasUsed: 30000000, BaseFee: u256(10)}
+	pool.Reset(state, head)
+	pending, queued := pool.Content()
+
+	test := genericTest{
+		s: "included and unexecutable transactions are dropped",
+		exp: []interface{}{
+			uint64(3), uint64(3), []*Transaction{genPoolTx(eip155Key, 2, 1, 10)}, []*Transaction{genPoolTx(eip155Key, 4, 1, 10)},
+			0, u256(11),
+		},
+		act: []interface{}{
+			nonceBefore, pool.Nonce(eip155Sender), pending[eip155Sender], queued[eip155Sender],
+			len(pending[testSender2]) + len(queued[testSender2]), pool.BaseFee(),
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+// in is the base fee, and exp is the effective tip of the
+// transaction whose tip is 5 and fee cap is 20
+var effectiveGasTipTests = []genericTest{
+	{s: "without base fee", in: (*uint256.Int)(nil), exp: u256(5)},
+	{s: "tip below fee cap", in: u256(10), exp: u256(5)},
+	{s: "tip capped with fee cap", in: u256(17), exp: u256(3)},
+	{s: "fee cap below base fee", in: u256(21), exp: ErrFeeCapTooLow},
+}
+
+func Test_TxPool_EffectiveGasTip(t *testing.T) {
+	anyTestFailed := false
+	tx := genPoolTx(eip155Key, 0, 5, 20)
+	for _, test := range effectiveGasTipTests {
+		tip, err := tx.EffectiveGasTip(test.in.(*uint256.Int))
+		test.act = tip
+		if err != nil {
+			test.act = err
+			if errors.Is(err, ErrFeeCapTooLow) {
+				test.act = ErrFeeCapTooLow
+			}
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
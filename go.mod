@@ -5,6 +5,7 @@ go 1.19
 require github.com/holiman/uint256 v1.2.1 // direct
 
 require (
+	github.com/kilic/bls12-381 v0.1.0 // direct
 	golang.org/x/crypto v0.3.0 // direct
 	golang.org/x/sys v0.2.0 // indirect
 )
@@ -5,8 +5,15 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"net/http"
+	"os"
 	space_evm "space/evm"
+	"space/rpc"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/holiman/uint256"
 )
 
 func parseFlags(bytecode string, gas string) ([]byte, uint64, error) {
@@ -26,14 +33,220 @@ func parseFlags(bytecode string, gas string) ([]byte, uint64, error) {
 	return code, uint64(gasLimit), nil
 }
 
+// Sets the world state of the EVM to the state of the genesis file or
+// the state dump, if any. State root and hash of the genesis block are
+// displayed if display is set.
+func loadState(evm *space_evm.EVM, genesis string, dump string, display bool) error {
+	if genesis != "" && dump != "" {
+		return errors.New("flags --genesis and --state are exclusive")
+	}
+	if genesis != "" {
+		g, err := space_evm.LoadGenesis(genesis)
+		if err != nil {
+			return err
+		}
+		state, header := g.ToBlock()
+		if display {
+			fmt.Println("--------------------------------------------------")
+			fmt.Printf("%-22s%v\n", "Genesis State Root:", hex.EncodeToString(header.Root[:]))
+			fmt.Printf("%-22s%v\n", "Genesis Hash:", hex.EncodeToString(header.Hash().Bytes()))
+		}
+		evm.SetStateDB(state)
+	}
+	if dump != "" {
+		state, err := space_evm.LoadStateDump(dump)
+		if err != nil {
+			return err
+		}
+		evm.SetStateDB(state)
+	}
+	return nil
+}
+
+// Runs the bytecode if it is given, and writes the world
+// state after the execution as JSON to the output or stdout
+func runDump(args []string) error {
+	var (
+		bytecode string
+		gas      string
+		genesis  string
+		state    string
+		out      string
+	)
+	flags := flag.NewFlagSet("dump", flag.ExitOnError)
+	flags.StringVar(&bytecode, "bytecode", "", "bytecode to be executed before the dump")
+	flags.StringVar(&gas, "gas", "1000000000", "gas limit for the execution")
+	flags.StringVar(&genesis, "genesis", "", "genesis.json whose alloc is the initial world state")
+	flags.StringVar(&state, "state", "", "state dump which is the initial world state")
+	flags.StringVar(&out, "out", "", "file to write the dump into, instead of stdout")
+	flags.Parse(args)
+
+	evm := space_evm.NewEVM(space_evm.Moon)
+	if err := loadState(evm, genesis, state, false); err != nil {
+		return err
+	}
+	if bytecode != "" {
+		code, gasLimit, err := parseFlags(bytecode, gas)
+		if err != nil {
+			return err
+		}
+		// state is dumped even if the execution fails
+		if result := evm.Run(code, gasLimit); result.EvmError != nil {
+			fmt.Fprintln(os.Stderr, "EVM Error:", result.EvmError)
+		}
+	}
+
+	buff, err := evm.StateDB().DumpJSON()
+	if err != nil {
+		return err
+	}
+	buff = append(buff, '\n')
+	if out == "" {
+		_, err = os.Stdout.Write(buff)
+		return err
+	}
+	return os.WriteFile(out, buff, 0644)
+}
+
+// Estimates the gas of the call of the transaction fields
+// against the world state of the genesis or the state dump
+func runEstimate(args []string) error {
+	var (
+		from     string
+		to       string
+		data     string
+		value    string
+		gasLimit uint64
+		genesis  string
+		state    string
+	)
+	flags := flag.NewFlagSet("estimate", flag.ExitOnError)
+	flags.StringVar(&from, "from", "", "0x prefixed address of the sender, the zero address by default")
+	flags.StringVar(&to, "to", "", "0x prefixed address of the recipient, a contract is created without it")
+	flags.StringVar(&data, "data", "", "calldata, or the init code for the contract creations")
+	flags.StringVar(&value, "value", "0", "value of the call in wei, it is a decimal or a 0x prefixed hex")
+	flags.Uint64Var(&gasLimit, "gaslimit", space_evm.DefaultDevConfig.GasLimit, "gas limit of the block, which caps the estimation")
+	flags.StringVar(&genesis, "genesis", "", "genesis.json whose alloc is the world state")
+	flags.StringVar(&state, "state", "", "state dump which is the world state")
+	flags.Parse(args)
+
+	evm := space_evm.NewEVM(space_evm.Moon)
+	if err := loadState(evm, genesis, state, false); err != nil {
+		return err
+	}
+	evm.SetBlockContext(space_evm.BlockContext{GasLimit: gasLimit})
+	var sender space_evm.Address
+	if from != "" {
+		if err := sender.UnmarshalText([]byte(from)); err != nil {
+			return err
+		}
+	}
+	tx := &space_evm.LegacyTx{GasPrice: new(uint256.Int), Value: new(uint256.Int)}
+	if to != "" {
+		tx.To = new(space_evm.Address)
+		if err := tx.To.UnmarshalText([]byte(to)); err != nil {
+			return err
+		}
+	}
+	if err := (*space_evm.HexOrDecimal256)(tx.Value).UnmarshalText([]byte(value)); err != nil {
+		return err
+	}
+	code, err := hex.DecodeString(data)
+	if err != nil {
+		return err
+	}
+	tx.Data = code
+
+	gas, err := evm.EstimateGas(space_evm.NewTx(tx), sender)
+	if err != nil {
+		return err
+	}
+	fmt.Println("--------------------------------------------------")
+	fmt.Printf("%-22s%v\n", "Estimated Gas:", gas)
+	return nil
+}
+
+// Starts a dev chain, displays its dev accounts with their
+// keys, and serves the chain with JSON-RPC over HTTP
+func runNode(args []string) error {
+	var (
+		addr     string
+		period   time.Duration
+		accounts int
+		chainID  uint64
+		dataDir  string
+		cors     string
+	)
+	flags := flag.NewFlagSet("node", flag.ExitOnError)
+	flags.StringVar(&addr, "http", "127.0.0.1:8545", "address of the JSON-RPC server")
+	flags.DurationVar(&period, "period", 0, "interval of the blocks, a block is sealed for each transaction if it is zero")
+	flags.IntVar(&accounts, "accounts", space_evm.DefaultDevConfig.Accounts, "number of the dev accounts")
+	flags.Uint64Var(&chainID, "chainid", space_evm.DefaultChainID, "chain ID of the dev chain")
+	flags.StringVar(&dataDir, "datadir", "", "directory which the chain is persisted into, it is kept in memory if it is empty")
+	flags.StringVar(&cors, "cors", "", "comma separated origins of the browser pages which can call the server, * allows any origin")
+	flags.Parse(args)
+
+	config := space_evm.DefaultDevConfig
+	config.Period, config.Accounts, config.ChainID = period, accounts, uint256.NewInt(chainID)
+	config.DataDir = dataDir
+	config.OnSealError = func(err error) {
+		fmt.Fprintln(os.Stderr, "failed to seal block:", err)
+	}
+	dev, err := space_evm.NewDevChain(config)
+	if err != nil {
+		return err
+	}
+	fmt.Println("--------------------------------------------------")
+	for i, account := range dev.Accounts() {
+		fmt.Printf("(%d) %v\n    %v\n", i, account.Address.Hex(), hex.EncodeToString(account.Key))
+	}
+	fmt.Println("--------------------------------------------------")
+	fmt.Printf("%-22s%v\n", "Chain ID:", chainID)
+	fmt.Printf("%-22s%v\n", "Head Block:", dev.Chain().CurrentHeader().Number)
+	fmt.Printf("%-22s%v\n", "Listening on:", "http://"+addr)
+
+	dev.Start()
+	defer dev.Close()
+	serverConfig := rpc.ServerConfig{}
+	for _, origin := range strings.Split(cors, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			serverConfig.CorsOrigins = append(serverConfig.CorsOrigins, origin)
+		}
+	}
+	return http.ListenAndServe(addr, rpc.NewServer(dev, serverConfig))
+}
+
 func main() {
-	// bytecode required, gas optional
+	if len(os.Args) > 1 && os.Args[1] == "dump" {
+		if err := runDump(os.Args[2:]); err != nil {
+			fmt.Println(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "estimate" {
+		if err := runEstimate(os.Args[2:]); err != nil {
+			fmt.Println(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "node" {
+		if err := runNode(os.Args[2:]); err != nil {
+			fmt.Println(err)
+		}
+		return
+	}
+
+	// bytecode required, gas, genesis and state optional
 	var (
 		bytecode string
 		gas      string
+		genesis  string
+		state    string
 	)
 	flag.StringVar(&bytecode, "bytecode", "", "bytecode to be executed")
 	flag.StringVar(&gas, "gas", "1000000000", "gas limit for the execution")
+	flag.StringVar(&genesis, "genesis", "", "genesis.json whose alloc is the initial world state")
+	flag.StringVar(&state, "state", "", "state dump which is the initial world state")
 	flag.Parse()
 
 	code, gasLimit, err := parseFlags(bytecode, gas)
@@ -43,5 +256,9 @@ func main() {
 	}
 
 	evm := space_evm.NewEVM(space_evm.Moon)
+	if err := loadState(evm, genesis, state, true); err != nil {
+		fmt.Println(err)
+		return
+	}
 	evm.RunCode(code, gasLimit)
 }
@@ -0,0 +1,246 @@
+package rlp
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/holiman/uint256"
+)
+
+// Decoder is implemented by the types which decode themselves
+type Decoder interface {
+	// Decodes the whole encoding of a single value
+	DecodeRLP(buff []byte) error
+}
+
+// Decodes the encoding of a single value into the value pointed by val,
+// which can be any of the types supported by Encode. Encoding must be
+// canonical, and it must not have any bytes after the value. Integers
+// must not have leading zeroes, and they must fit into their types.
+// Empty interfaces are decoded into []byte and []interface{} values.
+func Decode(buff []byte, val interface{}) error {
+	v := reflect.ValueOf(val)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("%w %T", ErrDecodeIntoNonPointer, val)
+	}
+	rest, err := decodeValue(buff, v.Elem())
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return ErrMoreThanOneValue
+	}
+	return nil
+}
+
+// Decodes the first value of the buffer into v,
+// and returns the rest of the buffer after it
+func decodeValue(buff []byte, v reflect.Value) ([]byte, error) {
+	typ := v.Type()
+	switch {
+	case typ == rawValueType:
+		_, _, rest, err := Split(buff)
+		if err != nil {
+			return nil, err
+		}
+		v.SetBytes(append([]byte{}, buff[:len(buff)-len(rest)]...))
+		return rest, nil
+	case reflect.PtrTo(typ).Implements(decoderType):
+		_, _, rest, err := Split(buff)
+		if err != nil {
+			return nil, err
+		}
+		return rest, v.Addr().Interface().(Decoder).DecodeRLP(buff[:len(buff)-len(rest)])
+	case typ == uint256Type:
+		content, rest, err := splitInt(buff, 32, typ)
+		if err != nil {
+			return nil, err
+		}
+		v.Set(reflect.ValueOf(*new(uint256.Int).SetBytes(content)))
+		return rest, nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(typ.Elem())
+		rest, err := decodeValue(buff, elem.Elem())
+		if err != nil {
+			return nil, err
+		}
+		v.Set(elem)
+		return rest, nil
+	case reflect.Interface:
+		if typ.NumMethod() != 0 {
+			return nil, fmt.Errorf("%w %v", ErrUnsupportedType, typ)
+		}
+		val, rest, err := decodeInterface(buff)
+		if err != nil {
+			return nil, err
+		}
+		v.Set(reflect.ValueOf(val))
+		return rest, nil
+	case reflect.Bool:
+		content, rest, err := SplitString(buff)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case len(content) == 0:
+			v.SetBool(false)
+		case len(content) == 1 && content[0] == 1:
+			v.SetBool(true)
+		default:
+			return nil, fmt.Errorf("%w %x", ErrInvalidBool, content)
+		}
+		return rest, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		content, rest, err := splitInt(buff, int(typ.Size()), typ)
+		if err != nil {
+			return nil, err
+		}
+		i := uint64(0)
+		for _, b := range content {
+			i = i<<8 | uint64(b)
+		}
+		v.SetUint(i)
+		return rest, nil
+	case reflect.String:
+		content, rest, err := SplitString(buff)
+		if err != nil {
+			return nil, err
+		}
+		v.SetString(string(content))
+		return rest, nil
+	case reflect.Slice, reflect.Array:
+		if isByteString(typ) {
+			return decodeByteString(buff, v)
+		}
+		return decodeList(buff, v)
+	case reflect.Struct:
+		return decodeStruct(buff, v)
+	default:
+		return nil, fmt.Errorf("%w %v", ErrUnsupportedType, typ)
+	}
+}
+
+// Splits the string of an integer off the buffer, which
+// must not have leading zeroes, and must fit in size bytes
+func splitInt(buff []byte, size int, typ reflect.Type) ([]byte, []byte, error) {
+	content, rest, err := SplitString(buff)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(content) > size {
+		return nil, nil, fmt.Errorf("%w %v", ErrIntRange, typ)
+	}
+	if len(content) > 0 && content[0] == 0 {
+		return nil, nil, ErrCanonInt
+	}
+	return content, rest, nil
+}
+
+func decodeInterface(buff []byte) (interface{}, []byte, error) {
+	kind, content, rest, err := Split(buff)
+	if err != nil {
+		return nil, nil, err
+	}
+	if kind != List {
+		return append([]byte{}, content...), rest, nil
+	}
+	vals := []interface{}{}
+	for len(content) > 0 {
+		var val interface{}
+		val, content, err = decodeInterface(content)
+		if err != nil {
+			return nil, nil, err
+		}
+		vals = append(vals, val)
+	}
+	return vals, rest, nil
+}
+
+func decodeByteString(buff []byte, v reflect.Value) ([]byte, error) {
+	content, rest, err := SplitString(buff)
+	if err != nil {
+		return nil, err
+	}
+	if v.Kind() == reflect.Slice {
+		v.SetBytes(append([]byte{}, content...))
+		return rest, nil
+	}
+	if len(content) != v.Len() {
+		return nil, fmt.Errorf("%w: %d bytes for %v", ErrByteArraySize, len(content), v.Type())
+	}
+	reflect.Copy(v, reflect.ValueOf(content))
+	return rest, nil
+}
+
+// Decodes the list into a slice, or into an array
+// which must have exactly as many elements as the list
+func decodeList(buff []byte, v reflect.Value) ([]byte, error) {
+	content, rest, err := SplitList(buff)
+	if err != nil {
+		return nil, err
+	}
+	typ := v.Type()
+	if typ.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(typ, 0, 0)
+		for len(content) > 0 {
+			elem := reflect.New(typ.Elem()).Elem()
+			if content, err = decodeValue(content, elem); err != nil {
+				return nil, err
+			}
+			slice = reflect.Append(slice, elem)
+		}
+		v.Set(slice)
+		return rest, nil
+	}
+	for i := 0; i < v.Len(); i++ {
+		if len(content) == 0 {
+			return nil, fmt.Errorf("%w for %v", ErrTooFewElements, typ)
+		}
+		if content, err = decodeValue(content, v.Index(i)); err != nil {
+			return nil, err
+		}
+	}
+	if len(content) > 0 {
+		return nil, fmt.Errorf("%w for %v", ErrTooManyElements, typ)
+	}
+	return rest, nil
+}
+
+// Decodes the list into the fields of the struct. List can
+// only end early if the rest of the fields are optional.
+func decodeStruct(buff []byte, v reflect.Value) ([]byte, error) {
+	typ := v.Type()
+	fields, err := structFields(typ)
+	if err != nil {
+		return nil, err
+	}
+	content, rest, err := SplitList(buff)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		fieldVal := v.Field(f.index)
+		if len(content) == 0 {
+			if !f.optional {
+				return nil, fmt.Errorf("%w for %v", ErrTooFewElements, typ)
+			}
+			fieldVal.Set(reflect.Zero(fieldVal.Type()))
+			continue
+		}
+		if f.nilOK && content[0] == encodeNil(fieldVal.Type().Elem())[0] {
+			fieldVal.Set(reflect.Zero(fieldVal.Type()))
+			content = content[1:]
+			continue
+		}
+		if content, err = decodeValue(content, fieldVal); err != nil {
+			return nil, err
+		}
+	}
+	if len(content) > 0 {
+		return nil, fmt.Errorf("%w for %v", ErrTooManyElements, typ)
+	}
+	return rest, nil
+}
@@ -0,0 +1,205 @@
+package rlp
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/holiman/uint256"
+)
+
+// Encoder is implemented by the types which encode themselves
+type Encoder interface {
+	// Returns the encoding of a single value
+	EncodeRLP() ([]byte, error)
+}
+
+// RawValue is an already encoded value. It is written as is while
+// encoding, and it holds the whole encoding of a value while decoding.
+type RawValue []byte
+
+var (
+	encoderType  = reflect.TypeOf((*Encoder)(nil)).Elem()
+	decoderType  = reflect.TypeOf((*Decoder)(nil)).Elem()
+	rawValueType = reflect.TypeOf(RawValue{})
+	uint256Type  = reflect.TypeOf(uint256.Int{})
+)
+
+// Returns the encoding of the value. Following types are supported:
+//   - Encoder implementations, and RawValue
+//   - unsigned integers, uint256.Int and bool, which are encoded as integers
+//   - strings, byte slices and byte arrays, which are encoded as strings
+//   - other slices and arrays, which are encoded as lists
+//   - structs, which are encoded as the list of their exported fields
+//   - pointers to, and interfaces holding any of the above
+//
+// Struct fields can be skipped with the `rlp:"-"` tag. Trailing fields
+// with the `rlp:"optional"` tag are omitted if they are zero, and they
+// are left zero while decoding if the list ends before them. Pointer
+// fields with the `rlp:"nil"` tag are decoded as nil from the empty value.
+//
+// Nil pointers are encoded as the empty value of their element type,
+// which is the empty list for structs and lists, and the empty string
+// for the others.
+func Encode(val interface{}) ([]byte, error) {
+	if val == nil {
+		return EmptyList, nil
+	}
+	// value is copied to be addressable, so that the
+	// fields whose pointers are Encoders can be encoded
+	v := reflect.New(reflect.TypeOf(val)).Elem()
+	v.Set(reflect.ValueOf(val))
+	return encodeValue(v)
+}
+
+func encodeValue(v reflect.Value) ([]byte, error) {
+	typ := v.Type()
+	switch {
+	case typ == rawValueType:
+		return append([]byte{}, v.Bytes()...), nil
+	case typ.Implements(encoderType):
+		if typ.Kind() == reflect.Ptr && v.IsNil() {
+			return encodeNil(typ.Elem()), nil
+		}
+		return v.Interface().(Encoder).EncodeRLP()
+	case v.CanAddr() && reflect.PtrTo(typ).Implements(encoderType):
+		return v.Addr().Interface().(Encoder).EncodeRLP()
+	case typ == uint256Type:
+		u := v.Interface().(uint256.Int)
+		return EncodeBytes(u.Bytes()), nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return encodeNil(typ.Elem()), nil
+		}
+		return encodeValue(v.Elem())
+	case reflect.Interface:
+		if v.IsNil() {
+			return EmptyList, nil
+		}
+		return encodeValue(v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			return []byte{0x01}, nil
+		}
+		return EmptyString, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return EncodeUint64(v.Uint()), nil
+	case reflect.String:
+		return EncodeBytes([]byte(v.String())), nil
+	case reflect.Slice, reflect.Array:
+		if isByteString(typ) {
+			return EncodeBytes(byteStringOf(v)), nil
+		}
+		items := make([][]byte, v.Len())
+		for i := range items {
+			item, err := encodeValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return EncodeList(items...), nil
+	case reflect.Struct:
+		fields, err := structFields(typ)
+		if err != nil {
+			return nil, err
+		}
+		// zero optional fields at the end are omitted
+		for len(fields) > 0 {
+			last := fields[len(fields)-1]
+			if !last.optional || !v.Field(last.index).IsZero() {
+				break
+			}
+			fields = fields[:len(fields)-1]
+		}
+		items := make([][]byte, len(fields))
+		for i, f := range fields {
+			item, err := encodeValue(v.Field(f.index))
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return EncodeList(items...), nil
+	default:
+		return nil, fmt.Errorf("%w %v", ErrUnsupportedType, typ)
+	}
+}
+
+// Returns the empty value of the type, which
+// is either the empty list or the empty string
+func encodeNil(typ reflect.Type) []byte {
+	if typ == uint256Type {
+		return EmptyString
+	}
+	switch typ.Kind() {
+	case reflect.Struct, reflect.Interface:
+		return EmptyList
+	case reflect.Slice, reflect.Array:
+		if isByteString(typ) {
+			return EmptyString
+		}
+		return EmptyList
+	default:
+		return EmptyString
+	}
+}
+
+// Byte slices and arrays are encoded as strings,
+// unless their elements encode themselves
+func isByteString(typ reflect.Type) bool {
+	elem := typ.Elem()
+	return elem.Kind() == reflect.Uint8 && !reflect.PtrTo(elem).Implements(encoderType)
+}
+
+func byteStringOf(v reflect.Value) []byte {
+	if v.Kind() == reflect.Slice {
+		return v.Bytes()
+	}
+	// unaddressable arrays can not be sliced, hence they are copied
+	buff := make([]byte, v.Len())
+	reflect.Copy(reflect.ValueOf(buff), v)
+	return buff
+}
+
+type field struct {
+	index    int
+	optional bool
+	nilOK    bool
+}
+
+// Returns the exported fields of the struct which are not skipped.
+// Optional fields can only be followed by other optional fields.
+func structFields(typ reflect.Type) ([]field, error) {
+	var fields []field
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		switch tag := f.Tag.Get("rlp"); tag {
+		case "-":
+			continue
+		case "optional":
+			fields = append(fields, field{index: i, optional: true})
+		case "nil":
+			if f.Type.Kind() != reflect.Ptr {
+				return nil, fmt.Errorf("%w: field %v.%s must be a pointer", ErrInvalidStructTag, typ, f.Name)
+			}
+			if len(fields) > 0 && fields[len(fields)-1].optional {
+				return nil, fmt.Errorf("%w: field %v.%s must be optional", ErrInvalidStructTag, typ, f.Name)
+			}
+			fields = append(fields, field{index: i, nilOK: true})
+		case "":
+			if len(fields) > 0 && fields[len(fields)-1].optional {
+				return nil, fmt.Errorf("%w: field %v.%s must be optional", ErrInvalidStructTag, typ, f.Name)
+			}
+			fields = append(fields, field{index: i})
+		default:
+			return nil, fmt.Errorf("%w %q on field %v.%s", ErrInvalidStructTag, tag, typ, f.Name)
+		}
+	}
+	return fields, nil
+}
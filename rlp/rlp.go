@@ -0,0 +1,195 @@
+// Package rlp implements the Recursive Length Prefix encoding, which is
+// used to serialize the nested arrays of bytes such as trie nodes,
+// accounts and transactions. Values can be encoded either by hand with
+// the functions of this file, or by reflection with Encode and Decode.
+// Only the canonical encoding of a value is accepted.
+package rlp
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+)
+
+var (
+	ErrUnexpectedEOF        = errors.New("rlp: value size exceeds available input length")
+	ErrCanonSize            = errors.New("rlp: non-canonical size information")
+	ErrCanonSingleByte      = errors.New("rlp: non-canonical encoding of single byte")
+	ErrExpectedString       = errors.New("rlp: expected string or byte")
+	ErrExpectedList         = errors.New("rlp: expected list")
+	ErrCanonInt             = errors.New("rlp: non-canonical integer (leading zero bytes)")
+	ErrIntRange             = errors.New("rlp: integer too large for")
+	ErrInvalidBool          = errors.New("rlp: invalid boolean value")
+	ErrByteArraySize        = errors.New("rlp: invalid byte array size")
+	ErrTooFewElements       = errors.New("rlp: too few elements")
+	ErrTooManyElements      = errors.New("rlp: too many elements")
+	ErrMoreThanOneValue     = errors.New("rlp: input contains more than one value")
+	ErrUnsupportedType      = errors.New("rlp: unsupported type")
+	ErrInvalidStructTag     = errors.New("rlp: invalid struct tag")
+	ErrDecodeIntoNonPointer = errors.New("rlp: decode into non-pointer or nil pointer")
+)
+
+// Kind is the type of an encoded value
+type Kind int
+
+const (
+	Byte Kind = iota
+	String
+	List
+)
+
+// Encoding of the empty string, and the empty list
+var (
+	EmptyString = []byte{0x80}
+	EmptyList   = []byte{0xc0}
+)
+
+// Returns the encoding of the byte string. A single byte
+// below 0x80 is its own encoding, and any other string is
+// prefixed with its length.
+func EncodeBytes(buff []byte) []byte {
+	if len(buff) == 1 && buff[0] < 0x80 {
+		return []byte{buff[0]}
+	}
+	return append(encodeHeader(0x80, uint64(len(buff))), buff...)
+}
+
+// Returns the encoding of the integer, which is its
+// big-endian byte string without leading zeroes
+func EncodeUint64(i uint64) []byte {
+	if i == 0 {
+		return EmptyString
+	}
+	var buff [8]byte
+	binary.BigEndian.PutUint64(buff[:], i)
+	return EncodeBytes(buff[bits.LeadingZeros64(i)/8:])
+}
+
+// Returns the encoding of the list of already encoded items
+func EncodeList(items ...[]byte) []byte {
+	size := 0
+	for _, item := range items {
+		size += len(item)
+	}
+	buff := encodeHeader(0xc0, uint64(size))
+	for _, item := range items {
+		buff = append(buff, item...)
+	}
+	return buff
+}
+
+// Short values have their size in the prefix byte, and longer
+// values have the big-endian bytes of their size after it
+func encodeHeader(offset byte, size uint64) []byte {
+	if size < 56 {
+		return []byte{offset + byte(size)}
+	}
+	var buff [8]byte
+	binary.BigEndian.PutUint64(buff[:], size)
+	sizeBytes := buff[bits.LeadingZeros64(size)/8:]
+	return append([]byte{offset + 55 + byte(len(sizeBytes))}, sizeBytes...)
+}
+
+// Splits the first value off the buffer, and returns its kind, its
+// content without the prefix, and the rest of the buffer after it
+func Split(buff []byte) (kind Kind, content []byte, rest []byte, err error) {
+	kind, offset, size, err := readKind(buff)
+	if err != nil {
+		return 0, nil, buff, err
+	}
+	return kind, buff[offset : offset+size], buff[offset+size:], nil
+}
+
+// Splits the first value off the buffer, which must be a string
+func SplitString(buff []byte) (content []byte, rest []byte, err error) {
+	kind, content, rest, err := Split(buff)
+	if err != nil {
+		return nil, buff, err
+	}
+	if kind == List {
+		return nil, buff, ErrExpectedString
+	}
+	return content, rest, nil
+}
+
+// Splits the first value off the buffer, which must be a list
+func SplitList(buff []byte) (content []byte, rest []byte, err error) {
+	kind, content, rest, err := Split(buff)
+	if err != nil {
+		return nil, buff, err
+	}
+	if kind != List {
+		return nil, buff, ErrExpectedList
+	}
+	return content, rest, nil
+}
+
+// Returns the number of values encoded in the buffer
+func CountValues(buff []byte) (int, error) {
+	count := 0
+	for ; len(buff) > 0; count++ {
+		_, offset, size, err := readKind(buff)
+		if err != nil {
+			return 0, err
+		}
+		buff = buff[offset+size:]
+	}
+	return count, nil
+}
+
+// Reads the prefix of the first value in the buffer, and returns
+// its kind, the size of its prefix, and the size of its content
+func readKind(buff []byte) (Kind, uint64, uint64, error) {
+	if len(buff) == 0 {
+		return 0, 0, 0, ErrUnexpectedEOF
+	}
+	var (
+		kind         Kind
+		offset, size uint64
+		err          error
+	)
+	prefix := buff[0]
+	switch {
+	case prefix < 0x80:
+		kind, offset, size = Byte, 0, 1
+	case prefix < 0xb8:
+		kind, offset, size = String, 1, uint64(prefix-0x80)
+		// single byte below 0x80 must be encoded as itself
+		if size == 1 && len(buff) > 1 && buff[1] < 0x80 {
+			return 0, 0, 0, ErrCanonSingleByte
+		}
+	case prefix < 0xc0:
+		kind, offset = String, uint64(prefix-0xb7)+1
+		size, err = readSize(buff[1:], prefix-0xb7)
+	case prefix < 0xf8:
+		kind, offset, size = List, 1, uint64(prefix-0xc0)
+	default:
+		kind, offset = List, uint64(prefix-0xf7)+1
+		size, err = readSize(buff[1:], prefix-0xf7)
+	}
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if size > uint64(len(buff))-offset {
+		return 0, 0, 0, ErrUnexpectedEOF
+	}
+	return kind, offset, size, nil
+}
+
+// Reads the big-endian size of a long value, which must not have
+// leading zeroes, and must not fit into the prefix byte
+func readSize(buff []byte, sizeLen byte) (uint64, error) {
+	if int(sizeLen) > len(buff) {
+		return 0, ErrUnexpectedEOF
+	}
+	if buff[0] == 0 {
+		return 0, ErrCanonSize
+	}
+	var sizeBuff [8]byte
+	copy(sizeBuff[8-sizeLen:], buff[:sizeLen])
+	size := binary.BigEndian.Uint64(sizeBuff[:])
+	if size < 56 {
+		return 0, ErrCanonSize
+	}
+	return size, nil
+}
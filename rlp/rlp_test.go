@@ -0,0 +1,277 @@
+package rlp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/holiman/uint256"
+)
+
+type simpleStruct struct {
+	A uint64
+	B string
+}
+
+type optionalStruct struct {
+	A uint64
+	B uint64       `rlp:"optional"`
+	C *uint256.Int `rlp:"optional"`
+}
+
+type skipStruct struct {
+	A       uint8
+	B       []byte `rlp:"-"`
+	C       []byte
+	private uint64
+}
+
+type nilStruct struct {
+	A *[2]byte `rlp:"nil"`
+	B uint64
+}
+
+type invalidTagStruct struct {
+	A uint64 `rlp:"optional"`
+	B uint64
+}
+
+// customEncoder is always encoded as a 2 bytes string
+type customEncoder struct {
+	val uint16
+}
+
+func (c *customEncoder) EncodeRLP() ([]byte, error) {
+	return EncodeBytes([]byte{byte(c.val >> 8), byte(c.val)}), nil
+}
+
+func (c *customEncoder) DecodeRLP(buff []byte) error {
+	content, _, err := SplitString(buff)
+	if err != nil {
+		return err
+	}
+	if len(content) != 2 {
+		return errors.New("custom encoder must be 2 bytes")
+	}
+	c.val = uint16(content[0])<<8 | uint16(content[1])
+	return nil
+}
+
+const loremIpsum = "Lorem ipsum dolor sit amet, consectetur adipisicing elit"
+
+func u256Hex(h string) *uint256.Int {
+	v, _ := uint256.FromHex(h)
+	return v
+}
+
+// in is the value to encode, and exp is its encoding in hex or the error
+var encodeTests = []genericTest{
+	{s: "zero", in: uint64(0), exp: "80"},
+	{s: "single byte integer", in: uint8(15), exp: "0f"},
+	{s: "two bytes integer", in: uint16(1024), exp: "820400"},
+	{s: "max uint64", in: ^uint64(0), exp: "88ffffffffffffffff"},
+	{s: "true", in: true, exp: "01"},
+	{s: "false", in: false, exp: "80"},
+	{s: "empty string", in: "", exp: "80"},
+	{s: "short string", in: "dog", exp: "83646f67"},
+	{s: "long string", in: loremIpsum, exp: "b838" + fmt.Sprintf("%x", loremIpsum)},
+	{s: "single byte below 0x80", in: []byte{0x7f}, exp: "7f"},
+	{s: "single byte 0x80", in: []byte{0x80}, exp: "8180"},
+	{s: "byte array", in: [3]byte{1, 2, 3}, exp: "83010203"},
+	{s: "list of strings", in: []string{"cat", "dog"}, exp: "c88363617483646f67"},
+	{s: "empty list", in: []uint64{}, exp: "c0"},
+	{
+		s:   "set theoretical representation of three",
+		in:  []interface{}{[]interface{}{}, []interface{}{[]interface{}{}}, []interface{}{[]interface{}{}, []interface{}{[]interface{}{}}}},
+		exp: "c7c0c1c0c3c0c1c0",
+	},
+	{s: "uint256 zero", in: uint256.Int{}, exp: "80"},
+	{s: "uint256", in: u256Hex("0x100102030405060708090a0b0c0d0e0f"), exp: "90100102030405060708090a0b0c0d0e0f"},
+	{s: "max uint256", in: u256Hex("0x" + strings.Repeat("ff", 32)), exp: "a0" + strings.Repeat("ff", 32)},
+	{s: "nil uint256", in: (*uint256.Int)(nil), exp: "80"},
+	{s: "struct", in: simpleStruct{A: 1, B: "a"}, exp: "c20161"},
+	{s: "nil struct pointer", in: (*simpleStruct)(nil), exp: "c0"},
+	{s: "nil byte slice pointer", in: (*[]byte)(nil), exp: "80"},
+	{s: "nil", in: nil, exp: "c0"},
+	{s: "omitted optional fields", in: optionalStruct{A: 1}, exp: "c101"},
+	{s: "zero optional field before non-zero", in: optionalStruct{A: 1, C: uint256.NewInt(2)}, exp: "c3018002"},
+	{s: "nil field", in: nilStruct{B: 1}, exp: "c28001"},
+	{s: "skipped and unexported fields", in: skipStruct{A: 1, B: []byte{2}, C: []byte{3}, private: 4}, exp: "c20103"},
+	{s: "raw values", in: []RawValue{hexToBytes("c0"), hexToBytes("01")}, exp: "c2c001"},
+	{s: "encoder", in: &customEncoder{val: 1}, exp: "820001"},
+	{s: "encoder by value in struct", in: struct{ C customEncoder }{customEncoder{val: 2}}, exp: "c3820002"},
+	{s: "signed integer", in: int(1), exp: ErrUnsupportedType},
+	{s: "non-optional field after optional", in: invalidTagStruct{}, exp: ErrInvalidStructTag},
+	{s: "nil tag on non-pointer", in: struct {
+		A uint64 `rlp:"nil"`
+	}{}, exp: ErrInvalidStructTag},
+}
+
+func Test_RLP_Encode(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range encodeTests {
+		enc, err := Encode(test.in)
+		if expErr, ok := test.exp.(error); ok {
+			test.act = err
+			if errors.Is(err, expErr) {
+				test.act = expErr
+			}
+		} else {
+			test.act = fmt.Sprintf("%x", enc)
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// decodeTestIn is the encoding in hex, and the pointer to decode into
+type decodeTestIn struct {
+	enc string
+	ptr interface{}
+}
+
+// exp is the decoded value or the error
+var decodeTests = []genericTest{
+	{s: "zero", in: decodeTestIn{"80", new(uint64)}, exp: uint64(0)},
+	{s: "single byte integer", in: decodeTestIn{"0f", new(uint8)}, exp: uint8(15)},
+	{s: "integer", in: decodeTestIn{"820400", new(uint64)}, exp: uint64(1024)},
+	{s: "true", in: decodeTestIn{"01", new(bool)}, exp: true},
+	{s: "false", in: decodeTestIn{"80", new(bool)}, exp: false},
+	{s: "string", in: decodeTestIn{"83646f67", new(string)}, exp: "dog"},
+	{s: "long string", in: decodeTestIn{"b838" + fmt.Sprintf("%x", loremIpsum), new(string)}, exp: loremIpsum},
+	{s: "empty byte slice", in: decodeTestIn{"80", new([]byte)}, exp: []byte{}},
+	{s: "byte array", in: decodeTestIn{"83010203", new([3]byte)}, exp: [3]byte{1, 2, 3}},
+	{s: "list of strings", in: decodeTestIn{"c88363617483646f67", new([]string)}, exp: []string{"cat", "dog"}},
+	{s: "empty list", in: decodeTestIn{"c0", new([]uint64)}, exp: []uint64{}},
+	{s: "array", in: decodeTestIn{"c20102", new([2]uint64)}, exp: [2]uint64{1, 2}},
+	{s: "uint256", in: decodeTestIn{"820400", new(*uint256.Int)}, exp: uint256.NewInt(1024)},
+	{s: "max uint256", in: decodeTestIn{"a0" + strings.Repeat("ff", 32), new(uint256.Int)}, exp: *u256Hex("0x" + strings.Repeat("ff", 32))},
+	{s: "struct", in: decodeTestIn{"c20161", new(simpleStruct)}, exp: simpleStruct{A: 1, B: "a"}},
+	{s: "struct pointer", in: decodeTestIn{"c20161", new(*simpleStruct)}, exp: &simpleStruct{A: 1, B: "a"}},
+	{s: "omitted optional fields", in: decodeTestIn{"c101", new(optionalStruct)}, exp: optionalStruct{A: 1}},
+	{s: "optional fields", in: decodeTestIn{"c3018002", new(optionalStruct)}, exp: optionalStruct{A: 1, C: uint256.NewInt(2)}},
+	{s: "skipped fields", in: decodeTestIn{"c20103", new(skipStruct)}, exp: skipStruct{A: 1, C: []byte{3}}},
+	{s: "nil field", in: decodeTestIn{"c28001", new(nilStruct)}, exp: nilStruct{B: 1}},
+	{s: "non-nil nil field", in: decodeTestIn{"c482010203", new(nilStruct)}, exp: nilStruct{A: &[2]byte{1, 2}, B: 3}},
+	{
+		s:   "interface",
+		in:  decodeTestIn{"c7c0c1c0c3c0c1c0", new(interface{})},
+		exp: []interface{}{[]interface{}{}, []interface{}{[]interface{}{}}, []interface{}{[]interface{}{}, []interface{}{[]interface{}{}}}},
+	},
+	{s: "interface string", in: decodeTestIn{"83646f67", new(interface{})}, exp: []byte("dog")},
+	{s: "raw values", in: decodeTestIn{"c2c001", new([]RawValue)}, exp: []RawValue{hexToBytes("c0"), hexToBytes("01")}},
+	{s: "decoder", in: decodeTestIn{"820001", new(customEncoder)}, exp: customEncoder{val: 1}},
+	{s: "decoder error", in: decodeTestIn{"8101", new(customEncoder)}, exp: ErrCanonSingleByte},
+
+	{s: "integer with leading zero", in: decodeTestIn{"820004", new(uint64)}, exp: ErrCanonInt},
+	{s: "zero byte integer", in: decodeTestIn{"00", new(uint64)}, exp: ErrCanonInt},
+	{s: "uint256 with leading zero", in: decodeTestIn{"820004", new(uint256.Int)}, exp: ErrCanonInt},
+	{s: "single byte with string prefix", in: decodeTestIn{"8105", new(uint64)}, exp: ErrCanonSingleByte},
+	{s: "uint8 overflow", in: decodeTestIn{"820100", new(uint8)}, exp: ErrIntRange},
+	{s: "uint64 overflow", in: decodeTestIn{"89010000000000000000", new(uint64)}, exp: ErrIntRange},
+	{s: "uint256 overflow", in: decodeTestIn{"a101" + strings.Repeat("00", 32), new(uint256.Int)}, exp: ErrIntRange},
+	{s: "long string size with leading zero", in: decodeTestIn{"b90038" + strings.Repeat("61", 56), new(string)}, exp: ErrCanonSize},
+	{s: "short string with long prefix", in: decodeTestIn{"b80161", new(string)}, exp: ErrCanonSize},
+	{s: "short list with long prefix", in: decodeTestIn{"f80101", new([]uint64)}, exp: ErrCanonSize},
+	{s: "string longer than input", in: decodeTestIn{"83646f", new(string)}, exp: ErrUnexpectedEOF},
+	{s: "list longer than input", in: decodeTestIn{"c30102", new([]uint64)}, exp: ErrUnexpectedEOF},
+	{s: "empty input", in: decodeTestIn{"", new(uint64)}, exp: ErrUnexpectedEOF},
+	{s: "invalid bool", in: decodeTestIn{"02", new(bool)}, exp: ErrInvalidBool},
+	{s: "byte array size", in: decodeTestIn{"820102", new([3]byte)}, exp: ErrByteArraySize},
+	{s: "array with too few elements", in: decodeTestIn{"c101", new([2]uint64)}, exp: ErrTooFewElements},
+	{s: "array with too many elements", in: decodeTestIn{"c3010203", new([2]uint64)}, exp: ErrTooManyElements},
+	{s: "struct with too few elements", in: decodeTestIn{"c101", new(simpleStruct)}, exp: ErrTooFewElements},
+	{s: "struct with too many elements", in: decodeTestIn{"c3016162", new(simpleStruct)}, exp: ErrTooManyElements},
+	{s: "list into integer", in: decodeTestIn{"c0", new(uint64)}, exp: ErrExpectedString},
+	{s: "string into list", in: decodeTestIn{"80", new([]uint64)}, exp: ErrExpectedList},
+	{s: "trailing bytes", in: decodeTestIn{"0102", new(uint64)}, exp: ErrMoreThanOneValue},
+	{s: "signed integer", in: decodeTestIn{"01", new(int)}, exp: ErrUnsupportedType},
+	{s: "non-pointer", in: decodeTestIn{"01", uint64(0)}, exp: ErrDecodeIntoNonPointer},
+	{s: "nil pointer", in: decodeTestIn{"01", (*uint64)(nil)}, exp: ErrDecodeIntoNonPointer},
+}
+
+func Test_RLP_Decode(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range decodeTests {
+		in := test.in.(decodeTestIn)
+		err := Decode(hexToBytes(in.enc), in.ptr)
+		if expErr, ok := test.exp.(error); ok {
+			test.act = err
+			if errors.Is(err, expErr) {
+				test.act = expErr
+			}
+		} else if err != nil {
+			test.act = err
+		} else {
+			test.act = reflect.ValueOf(in.ptr).Elem().Interface()
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// fuzzStruct covers most of the supported types
+type fuzzStruct struct {
+	A uint64
+	B []byte
+	C []string
+	D *uint256.Int
+	E bool
+	F [2]byte
+	G []interface{}
+	H []simpleStruct
+}
+
+// Any input which is decoded successfully must be canonical,
+// hence it must be encoded back to the same bytes
+func FuzzDecode(f *testing.F) {
+	for _, test := range decodeTests {
+		f.Add(hexToBytes(test.in.(decodeTestIn).enc))
+	}
+	f.Add(hexToBytes("d401820102c483636174820400018201028ac9c0c88363617483646f67c0"))
+	f.Fuzz(func(t *testing.T, buff []byte) {
+		var val fuzzStruct
+		if err := Decode(buff, &val); err != nil {
+			return
+		}
+		enc, err := Encode(&val)
+		if err != nil {
+			t.Fatalf("encode decoded value %#v: %v", val, err)
+		}
+		if !bytes.Equal(enc, buff) {
+			t.Fatalf("non-canonical input is accepted\n\tinput:   %x\n\tencoded: %x", buff, enc)
+		}
+	})
+}
+
+// Any value can be decoded into an empty interface, which
+// must also be encoded back to the same bytes
+func FuzzDecodeInterface(f *testing.F) {
+	for _, test := range decodeTests {
+		f.Add(hexToBytes(test.in.(decodeTestIn).enc))
+	}
+	f.Fuzz(func(t *testing.T, buff []byte) {
+		var val interface{}
+		if err := Decode(buff, &val); err != nil {
+			return
+		}
+		enc, err := Encode(val)
+		if err != nil {
+			t.Fatalf("encode decoded value %#v: %v", val, err)
+		}
+		if !bytes.Equal(enc, buff) {
+			t.Fatalf("non-canonical input is accepted\n\tinput:   %x\n\tencoded: %x", buff, enc)
+		}
+	})
+}
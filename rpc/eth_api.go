@@ -0,0 +1,351 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	space_evm "space/evm"
+
+	"github.com/holiman/uint256"
+)
+
+// Backend is the chain which the server serves, such as the dev chain
+type Backend interface {
+	ChainID() *uint256.Int
+	Fork() space_evm.EVMFork
+	Chain() *space_evm.Chain
+	TxPool() *space_evm.TxPool
+	// Returns a copy of the state of the head block with the head header
+	HeadState() (*space_evm.StateDB, *space_evm.Header)
+	SendTransaction(tx *space_evm.Transaction) error
+}
+
+// ethAPI implements the methods of the eth namespace. Only the state
+// of the head block is kept, hence the methods which read the state
+// can not be served for the earlier blocks.
+type ethAPI struct {
+	backend Backend
+}
+
+func (api *ethAPI) methods() map[string]method {
+	return map[string]method{
+		"eth_chainId":               api.chainID,
+		"net_version":               api.netVersion,
+		"eth_blockNumber":           api.blockNumber,
+		"eth_gasPrice":              api.gasPrice,
+		"eth_getBalance":            api.getBalance,
+		"eth_getCode":               api.getCode,
+		"eth_getStorageAt":          api.getStorageAt,
+		"eth_getTransactionCount":   api.getTransactionCount,
+		"eth_call":                  api.call,
+		"eth_estimateGas":           api.estimateGas,
+		"eth_sendRawTransaction":    api.sendRawTransaction,
+		"eth_getTransactionByHash":  api.getTransactionByHash,
+		"eth_getTransactionReceipt": api.getTransactionReceipt,
+		"eth_getBlockByNumber":      api.getBlockByNumber,
+		"eth_getBlockByHash":        api.getBlockByHash,
+	}
+}
+
+// Returns the state of the block with the number and its header,
+// which is only available for the head block
+func (api *ethAPI) stateAt(number BlockNumber) (*space_evm.StateDB, *space_evm.Header, error) {
+	state, header := api.backend.HeadState()
+	if number >= 0 && uint64(number) != header.Number {
+		if uint64(number) > header.Number {
+			return nil, nil, fmt.Errorf("%w: number %d", space_evm.ErrUnknownBlock, number)
+		}
+		return nil, nil, fmt.Errorf("state of block %d is not available, only the state of the head block %d is kept", number, header.Number)
+	}
+	return state, header, nil
+}
+
+func (api *ethAPI) chainID(params []json.RawMessage) (interface{}, error) {
+	if err := parseParams(params, 0); err != nil {
+		return nil, err
+	}
+	return api.backend.ChainID(), nil
+}
+
+// Returns the chain ID as a decimal string, which is the network ID
+func (api *ethAPI) netVersion(params []json.RawMessage) (interface{}, error) {
+	if err := parseParams(params, 0); err != nil {
+		return nil, err
+	}
+	return api.backend.ChainID().ToBig().String(), nil
+}
+
+func (api *ethAPI) blockNumber(params []json.RawMessage) (interface{}, error) {
+	if err := parseParams(params, 0); err != nil {
+		return nil, err
+	}
+	return space_evm.HexUint64(api.backend.Chain().CurrentHeader().Number), nil
+}
+
+// Returns the base fee of the next block, which is enough for the
+// transactions to be included since the dev chain does not require
+// a tip
+func (api *ethAPI) gasPrice(params []json.RawMessage) (interface{}, error) {
+	if err := parseParams(params, 0); err != nil {
+		return nil, err
+	}
+	return space_evm.CalcBaseFee(api.backend.Chain().CurrentHeader()), nil
+}
+
+func (api *ethAPI) getBalance(params []json.RawMessage) (interface{}, error) {
+	var addr space_evm.Address
+	number := LatestBlockNumber
+	if err := parseParams(params, 1, &addr, &number); err != nil {
+		return nil, err
+	}
+	state, _, err := api.stateAt(number)
+	if err != nil {
+		return nil, err
+	}
+	return state.GetBalance(addr), nil
+}
+
+func (api *ethAPI) getCode(params []json.RawMessage) (interface{}, error) {
+	var addr space_evm.Address
+	number := LatestBlockNumber
+	if err := parseParams(params, 1, &addr, &number); err != nil {
+		return nil, err
+	}
+	state, _, err := api.stateAt(number)
+	if err != nil {
+		return nil, err
+	}
+	return space_evm.HexBytes(state.GetCode(addr)), nil
+}
+
+func (api *ethAPI) getStorageAt(params []json.RawMessage) (interface{}, error) {
+	var (
+		addr space_evm.Address
+		slot StorageKey
+	)
+	number := LatestBlockNumber
+	if err := parseParams(params, 2, &addr, &slot, &number); err != nil {
+		return nil, err
+	}
+	state, _, err := api.stateAt(number)
+	if err != nil {
+		return nil, err
+	}
+	return state.GetState(addr, space_evm.Hash(slot)), nil
+}
+
+// Returns the nonce of the account, which is the
+// next nonce of its transactions in the pool for
+// the pending block
+func (api *ethAPI) getTransactionCount(params []json.RawMessage) (interface{}, error) {
+	var addr space_evm.Address
+	number := LatestBlockNumber
+	if err := parseParams(params, 1, &addr, &number); err != nil {
+		return nil, err
+	}
+	if number == PendingBlockNumber {
+		return space_evm.HexUint64(api.backend.TxPool().Nonce(addr)), nil
+	}
+	state, _, err := api.stateAt(number)
+	if err != nil {
+		return nil, err
+	}
+	return space_evm.HexUint64(state.GetNonce(addr)), nil
+}
+
+// Executes the call on a copy of the state of the block, in the
+// context of the block, and returns the return data of the call.
+// Accounts of the state and the fields of the block can be overridden
+// for the call, which only changes the copy of the state. Failed calls
+// are returned as errors with the return data.
+func (api *ethAPI) call(params []json.RawMessage) (interface{}, error) {
+	var (
+		args           CallArgs
+		stateOverride  space_evm.StateOverride
+		blockOverrides space_evm.BlockOverrides
+	)
+	number := LatestBlockNumber
+	if err := parseParams(params, 1, &args, &number, &stateOverride, &blockOverrides); err != nil {
+		return nil, err
+	}
+	evm, blockCtx, err := api.newEVM(number, stateOverride, &blockOverrides)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := args.toTransaction(blockCtx.GasLimit)
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := evm.ApplyCall(tx, args.from())
+	if err != nil {
+		return nil, err
+	}
+	if receipt.Status == space_evm.ReceiptStatusFailed {
+		return nil, executionError(space_evm.NewExecutionError(receipt.Err, receipt.ReturnData))
+	}
+	return space_evm.HexBytes(receipt.ReturnData), nil
+}
+
+// Returns the lowest gas limit with which the call succeeds, which
+// is searched up to the gas of the call or the gas limit of the block.
+// Overrides are applied as in eth_call. Calls which fail at every gas
+// limit are returned as errors with the return data.
+func (api *ethAPI) estimateGas(params []json.RawMessage) (interface{}, error) {
+	var (
+		args           CallArgs
+		stateOverride  space_evm.StateOverride
+		blockOverrides space_evm.BlockOverrides
+	)
+	number := LatestBlockNumber
+	if err := parseParams(params, 1, &args, &number, &stateOverride, &blockOverrides); err != nil {
+		return nil, err
+	}
+	evm, _, err := api.newEVM(number, stateOverride, &blockOverrides)
+	if err != nil {
+		return nil, err
+	}
+	// gas of the transaction is zero unless it is given,
+	// hence the estimation is capped with the block
+	tx, err := args.toTransaction(0)
+	if err != nil {
+		return nil, err
+	}
+	gas, err := evm.EstimateGas(tx, args.from())
+	var execErr *space_evm.ExecutionError
+	if errors.As(err, &execErr) {
+		return nil, executionError(execErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return space_evm.HexUint64(gas), nil
+}
+
+// Returns the EVM of the chain which runs on a copy of the state of
+// the block, with the overrides of the accounts and the block, and
+// the context of the block which the EVM runs in
+func (api *ethAPI) newEVM(number BlockNumber, stateOverride space_evm.StateOverride, blockOverrides *space_evm.BlockOverrides) (*space_evm.EVM, space_evm.BlockContext, error) {
+	state, header, err := api.stateAt(number)
+	if err != nil {
+		return nil, space_evm.BlockContext{}, err
+	}
+	if err := stateOverride.Apply(state); err != nil {
+		return nil, space_evm.BlockContext{}, invalidParams("%v", err)
+	}
+	blockCtx := space_evm.NewBlockContext(header)
+	if err := blockOverrides.Apply(&blockCtx); err != nil {
+		return nil, space_evm.BlockContext{}, invalidParams("%v", err)
+	}
+
+	evm := space_evm.NewEVM(api.backend.Fork())
+	evm.ChainID = api.backend.ChainID()
+	evm.SetStateDB(state)
+	evm.SetBlockContext(blockCtx)
+	return evm, blockCtx, nil
+}
+
+// Returns the error of the failed execution, whose data is the return data
+func executionError(err *space_evm.ExecutionError) *Error {
+	return &Error{Code: CodeServerError, Message: err.Error(), Data: space_evm.HexBytes(err.Data)}
+}
+
+// Adds the signed transaction into the pool, and returns its hash
+func (api *ethAPI) sendRawTransaction(params []json.RawMessage) (interface{}, error) {
+	var raw space_evm.HexBytes
+	if err := parseParams(params, 1, &raw); err != nil {
+		return nil, err
+	}
+	tx := new(space_evm.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, invalidParams("invalid transaction: %v", err)
+	}
+	if err := api.backend.SendTransaction(tx); err != nil {
+		return nil, err
+	}
+	return tx.Hash(), nil
+}
+
+// Returns the transaction with the hash from the chain or the pool,
+// or null if the transaction is unknown
+func (api *ethAPI) getTransactionByHash(params []json.RawMessage) (interface{}, error) {
+	var hash space_evm.Hash
+	if err := parseParams(params, 1, &hash); err != nil {
+		return nil, err
+	}
+	tx, block, index, err := api.backend.Chain().GetTransaction(hash)
+	if errors.Is(err, space_evm.ErrUnknownTransaction) {
+		if tx := api.backend.TxPool().Get(hash); tx != nil {
+			return newRPCTransaction(tx, nil, 0), nil
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newRPCTransaction(tx, block, index), nil
+}
+
+// Returns the receipt of the transaction with the
+// hash, or null if the transaction is not in the chain
+func (api *ethAPI) getTransactionReceipt(params []json.RawMessage) (interface{}, error) {
+	var hash space_evm.Hash
+	if err := parseParams(params, 1, &hash); err != nil {
+		return nil, err
+	}
+	tx, _, _, err := api.backend.Chain().GetTransaction(hash)
+	if errors.Is(err, space_evm.ErrUnknownTransaction) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := api.backend.Chain().GetReceipt(hash)
+	if err != nil {
+		return nil, err
+	}
+	return newRPCReceipt(receipt, tx), nil
+}
+
+// Returns the block with the number, or null if the block is not in
+// the chain. Transactions of the block are their hashes, unless the
+// second param is true. Pending block is the head block.
+func (api *ethAPI) getBlockByNumber(params []json.RawMessage) (interface{}, error) {
+	var (
+		number BlockNumber
+		fullTx bool
+	)
+	if err := parseParams(params, 1, &number, &fullTx); err != nil {
+		return nil, err
+	}
+	chain := api.backend.Chain()
+	if number < 0 {
+		number = BlockNumber(chain.CurrentHeader().Number)
+	}
+	block, err := chain.GetBlockByNumber(uint64(number))
+	return blockResult(block, err, fullTx)
+}
+
+func (api *ethAPI) getBlockByHash(params []json.RawMessage) (interface{}, error) {
+	var (
+		hash   space_evm.Hash
+		fullTx bool
+	)
+	if err := parseParams(params, 1, &hash, &fullTx); err != nil {
+		return nil, err
+	}
+	block, err := api.backend.Chain().GetBlockByHash(hash)
+	return blockResult(block, err, fullTx)
+}
+
+// Returns the JSON form of the block which is read
+// from the chain, or null if the block is unknown
+func blockResult(block *space_evm.Block, err error, fullTx bool) (interface{}, error) {
+	if errors.Is(err, space_evm.ErrUnknownBlock) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newRPCBlock(block, fullTx)
+}
@@ -0,0 +1,282 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	space_evm "space/evm"
+
+	"github.com/holiman/uint256"
+)
+
+var (
+	// code which returns 42 as a word
+	testRuntimeCode = "602a60005260206000f3"
+	// address without code, whose code is overridden for the calls
+	testOverridden = space_evm.BytesToAddress([]byte{0xe0})
+	// code which returns the value of the storage slot 1, the timestamp
+	// and the number of the block as words
+	testSlotReaderCode = "600154600052" + "42602052" + "43604052" + "60606000f3"
+	// code which deploys the runtime code
+	testInitCode = "7f" + strings.Repeat("00", 22) + testRuntimeCode + "600052" + "600a6016f3"
+	// code which reverts with Error("revert reason"), and its revert data
+	testReverterCode = "7f08c379a0" + strings.Repeat("00", 28) + "600052" + "6020600452" + "600d602452" +
+		"7f72657665727420726561736f6e" + strings.Repeat("00", 19) + "604452" + "60646000fd"
+	testRevertData = "0x08c379a0" + fmt.Sprintf("%064x%064x", 0x20, 0x0d) + "72657665727420726561736f6e" + strings.Repeat("00", 19)
+)
+
+// Sends the transaction which deploys the runtime code from the first
+// dev account, and returns the transaction and the deployed address
+func deployTestContract(t *testing.T, dev *space_evm.DevChain, url string) (*space_evm.Transaction, space_evm.Address) {
+	account := dev.Accounts()[0]
+	tx, err := space_evm.SignTx(space_evm.NewTx(&space_evm.DynamicFeeTx{
+		ChainID: dev.ChainID(), Nonce: 0, GasTipCap: uint256.NewInt(1), GasFeeCap: uint256.NewInt(2000000000),
+		Gas: 100000, Value: uint256.NewInt(0), Data: hexToBytes(testInitCode),
+	}), dev.ChainID(), account.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, _ := tx.MarshalBinary()
+	if _, rpcErr := rpcCall(t, url, "eth_sendRawTransaction", space_evm.HexBytes(enc)); rpcErr != nil {
+		t.Fatal(rpcErr)
+	}
+	return tx, space_evm.CreateAddress(account.Address, 0)
+}
+
+func Test_EthAPI_State(t *testing.T) {
+	dev, url := genTestServer(t)
+	tx, contract := deployTestContract(t, dev, url)
+	sender := dev.Accounts()[0].Address
+	state, _ := dev.HeadState()
+
+	// in is the method with its params, and exp is the result or the error code
+	tests := []genericTest{
+		{s: "block number", in: []interface{}{"eth_blockNumber"}, exp: `"0x1"`},
+		{s: "balance", in: []interface{}{"eth_getBalance", sender, "latest"}, exp: fmt.Sprintf("%q", state.GetBalance(sender).Hex())},
+		{s: "balance of the block number", in: []interface{}{"eth_getBalance", sender, "0x1"}, exp: fmt.Sprintf("%q", state.GetBalance(sender).Hex())},
+		{s: "balance of an earlier block", in: []interface{}{"eth_getBalance", sender, "0x0"}, exp: CodeServerError},
+		{s: "balance of an unknown block", in: []interface{}{"eth_getBalance", sender, "0x2"}, exp: CodeServerError},
+		{s: "code", in: []interface{}{"eth_getCode", contract, "latest"}, exp: `"0x` + testRuntimeCode + `"`},
+		{s: "code of an account", in: []interface{}{"eth_getCode", sender}, exp: `"0x"`},
+		{s: "storage", in: []interface{}{"eth_getStorageAt", contract, "0x0", "latest"}, exp: `"0x` + strings.Repeat("00", 32) + `"`},
+		{s: "invalid storage key", in: []interface{}{"eth_getStorageAt", contract, "0x" + strings.Repeat("00", 33)}, exp: CodeInvalidParams},
+		{s: "nonce", in: []interface{}{"eth_getTransactionCount", sender, "latest"}, exp: `"0x1"`},
+		{s: "pending nonce", in: []interface{}{"eth_getTransactionCount", sender, "pending"}, exp: `"0x1"`},
+		{s: "nonce of a new account", in: []interface{}{"eth_getTransactionCount", contract}, exp: `"0x1"`},
+		{s: "call", in: []interface{}{"eth_call", map[string]interface{}{"to": contract}, "latest"}, exp: `"0x` + strings.Repeat("00", 31) + `2a"`},
+		{s: "call with a sender", in: []interface{}{"eth_call", map[string]interface{}{"from": sender, "to": contract, "input": "0x01"}}, exp: `"0x` + strings.Repeat("00", 31) + `2a"`},
+		{s: "call of an account", in: []interface{}{"eth_call", map[string]interface{}{"to": sender}}, exp: `"0x"`},
+		{s: "failed call", in: []interface{}{"eth_call", map[string]interface{}{"data": "0xfe"}}, exp: CodeServerError},
+		{s: "call above the block gas limit", in: []interface{}{"eth_call", map[string]interface{}{"to": contract, "gas": "0x1c9c381"}}, exp: CodeServerError},
+		{
+			s:   "call with both fee fields",
+			in:  []interface{}{"eth_call", map[string]interface{}{"to": contract, "gasPrice": "0x1", "maxFeePerGas": "0x1"}},
+			exp: CodeInvalidParams,
+		},
+		{
+			s:   "call with a code override",
+			in:  []interface{}{"eth_call", map[string]interface{}{"to": testOverridden}, "latest", map[string]interface{}{testOverridden.Hex(): map[string]interface{}{"code": "0x" + testRuntimeCode}}},
+			exp: `"0x` + strings.Repeat("00", 31) + `2a"`,
+		},
+		{s: "code is not overridden for the chain", in: []interface{}{"eth_getCode", testOverridden}, exp: `"0x"`},
+		{
+			s: "call with a block override",
+			in: []interface{}{
+				"eth_call", map[string]interface{}{"to": testOverridden}, "latest",
+				map[string]interface{}{testOverridden.Hex(): map[string]interface{}{"code": "0x4860005260206000f3"}},
+				map[string]interface{}{"baseFeePerGas": "0x7", "time": "0x1"},
+			},
+			exp: `"0x` + strings.Repeat("00", 31) + `07"`,
+		},
+		{
+			s: "call with the block number and time overrides",
+			in: []interface{}{
+				"eth_call", map[string]interface{}{"to": testOverridden}, "latest",
+				map[string]interface{}{testOverridden.Hex(): map[string]interface{}{"code": "0x" + testSlotReaderCode}},
+				map[string]interface{}{"number": "0x9", "time": "0x2a"},
+			},
+			exp: `"0x` + strings.Repeat("00", 32) + strings.Repeat("00", 31) + `2a` + strings.Repeat("00", 31) + `09"`,
+		},
+		{
+			s: "call with a state override",
+			in: []interface{}{
+				"eth_call", map[string]interface{}{"to": testOverridden}, "latest",
+				map[string]interface{}{testOverridden.Hex(): map[string]interface{}{
+					"code": "0x" + testSlotReaderCode, "state": map[string]interface{}{"0x" + strings.Repeat("00", 31) + "01": "0x" + strings.Repeat("00", 31) + "07"},
+				}},
+				map[string]interface{}{"number": "0x0", "time": "0x0"},
+			},
+			exp: `"0x` + strings.Repeat("00", 31) + `07` + strings.Repeat("00", 64) + `"`,
+		},
+		{
+			s: "call with a stateDiff override",
+			in: []interface{}{
+				"eth_call", map[string]interface{}{"to": testOverridden}, "latest",
+				map[string]interface{}{testOverridden.Hex(): map[string]interface{}{
+					"code": "0x" + testSlotReaderCode, "stateDiff": map[string]interface{}{"0x" + strings.Repeat("00", 31) + "01": "0x" + strings.Repeat("00", 31) + "08"},
+				}},
+				map[string]interface{}{"number": "0x0", "time": "0x0"},
+			},
+			exp: `"0x` + strings.Repeat("00", 31) + `08` + strings.Repeat("00", 64) + `"`,
+		},
+		{
+			s: "call with a difficulty override",
+			in: []interface{}{
+				"eth_call", map[string]interface{}{"to": contract}, "latest", map[string]interface{}{}, map[string]interface{}{"difficulty": "0x1"},
+			},
+			exp: CodeInvalidParams,
+		},
+		{
+			s: "call with a balance override",
+			in: []interface{}{
+				"eth_call", map[string]interface{}{"from": testOverridden, "to": contract, "maxFeePerGas": "0x3b9aca00"}, "latest",
+				map[string]interface{}{testOverridden.Hex(): map[string]interface{}{"balance": "0xde0b6b3a7640000"}},
+			},
+			exp: `"0x` + strings.Repeat("00", 31) + `2a"`,
+		},
+		{s: "call without the balance", in: []interface{}{"eth_call", map[string]interface{}{"from": testOverridden, "to": contract, "maxFeePerGas": "0x3b9aca00"}}, exp: CodeServerError},
+		{
+			s: "call with both state and stateDiff",
+			in: []interface{}{
+				"eth_call", map[string]interface{}{"to": contract}, "latest",
+				map[string]interface{}{contract.Hex(): map[string]interface{}{"state": map[string]interface{}{}, "stateDiff": map[string]interface{}{}}},
+			},
+			exp: CodeInvalidParams,
+		},
+		{
+			s: "estimate with a code override",
+			in: []interface{}{
+				"eth_estimateGas", map[string]interface{}{"to": testOverridden}, "latest",
+				map[string]interface{}{testOverridden.Hex(): map[string]interface{}{"code": "0x" + testRuntimeCode}},
+			},
+			exp: `"0x521a"`,
+		},
+		{s: "estimate of a transfer", in: []interface{}{"eth_estimateGas", map[string]interface{}{"from": sender, "to": contract, "value": "0x1"}}, exp: `"0x521a"`},
+		{s: "estimate of a call", in: []interface{}{"eth_estimateGas", map[string]interface{}{"to": contract, "input": "0x01"}, "latest"}, exp: `"0x522a"`},
+		{s: "estimate of a failed call", in: []interface{}{"eth_estimateGas", map[string]interface{}{"data": "0xfe"}}, exp: CodeServerError},
+		{s: "estimate above the gas", in: []interface{}{"eth_estimateGas", map[string]interface{}{"to": contract, "gas": "0x5219"}}, exp: CodeServerError},
+		{s: "transaction", in: []interface{}{"eth_getTransactionByHash", tx.Hash()}, exp: tx.Hash()},
+		{s: "unknown transaction", in: []interface{}{"eth_getTransactionByHash", space_evm.Hash{}}, exp: `null`},
+	}
+
+	anyTestFailed := false
+	for _, test := range tests {
+		in := test.in.([]interface{})
+		result, rpcErr := rpcCall(t, url, in[0].(string), in[1:]...)
+		switch test.exp.(type) {
+		case int:
+			if rpcErr != nil {
+				test.act = rpcErr.Code
+			}
+		case space_evm.Hash:
+			var tx RPCTransaction
+			json.Unmarshal(result, &tx)
+			test.act = tx.Hash
+		default:
+			test.act = string(result)
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+func Test_EthAPI_Revert(t *testing.T) {
+	_, url := genTestServer(t)
+	// init code of the creation reverts
+	args := map[string]interface{}{"data": "0x" + testReverterCode}
+	_, callErr := rpcCall(t, url, "eth_call", args, "latest")
+	_, estimateErr := rpcCall(t, url, "eth_estimateGas", args, "latest")
+	if callErr == nil || estimateErr == nil {
+		t.Fatalf("reverted call succeeded: %v, %v", callErr, estimateErr)
+	}
+
+	test := genericTest{
+		s: "reverted call and estimation return the reason and the data",
+		exp: []interface{}{
+			CodeServerError, "execution reverted: revert reason", testRevertData,
+			CodeServerError, "execution reverted: revert reason", testRevertData,
+		},
+		act: []interface{}{
+			callErr.Code, callErr.Message, callErr.Data,
+			estimateErr.Code, estimateErr.Message, estimateErr.Data,
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+func Test_EthAPI_Receipt(t *testing.T) {
+	dev, url := genTestServer(t)
+	tx, contract := deployTestContract(t, dev, url)
+
+	var receipt RPCReceipt
+	result, rpcErr := rpcCall(t, url, "eth_getTransactionReceipt", tx.Hash())
+	err := json.Unmarshal(result, &receipt)
+	block, _ := dev.Chain().GetBlockByNumber(1)
+
+	test := genericTest{
+		s: "receipt of a contract creation",
+		exp: []interface{}{
+			(*Error)(nil), nil, tx.Hash(), block.Hash(), space_evm.HexUint64(1), space_evm.HexUint64(0),
+			dev.Accounts()[0].Address, (*space_evm.Address)(nil), &contract, space_evm.HexUint64(1),
+			space_evm.HexUint64(2), block.Header.GasUsed, 0,
+		},
+		act: []interface{}{
+			rpcErr, err, receipt.TransactionHash, receipt.BlockHash, receipt.BlockNumber, receipt.TransactionIndex,
+			receipt.From, receipt.To, receipt.ContractAddress, receipt.Status,
+			receipt.Type, uint64(receipt.GasUsed), len(receipt.Logs),
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+func Test_EthAPI_GetBlockByNumber(t *testing.T) {
+	dev, url := genTestServer(t)
+	tx, _ := deployTestContract(t, dev, url)
+
+	var (
+		hashes RPCBlock
+		full   struct {
+			Hash         space_evm.Hash    `json:"hash"`
+			Transactions []*RPCTransaction `json:"transactions"`
+		}
+	)
+	latest, _ := rpcCall(t, url, "eth_getBlockByNumber", "latest", false)
+	json.Unmarshal(latest, &hashes)
+	result, _ := rpcCall(t, url, "eth_getBlockByNumber", "0x1", true)
+	json.Unmarshal(result, &full)
+	unknown, _ := rpcCall(t, url, "eth_getBlockByNumber", "0x2", false)
+	byHash, _ := rpcCall(t, url, "eth_getBlockByHash", hashes.Hash, false)
+	block, _ := dev.Chain().GetBlockByNumber(1)
+	price := space_evm.EffectiveGasPrice(tx, block.Header.BaseFee)
+
+	test := genericTest{
+		s: "block with the hashes and the full transactions",
+		exp: []interface{}{
+			block.Hash(), space_evm.HexUint64(1), block.Header.BaseFee, []interface{}{tx.Hash().Hex()},
+			block.Hash(), tx.Hash(), dev.Accounts()[0].Address, price, "null", latest,
+		},
+		act: []interface{}{
+			hashes.Hash, hashes.Number, hashes.BaseFeePerGas, hashes.Transactions,
+			full.Hash, full.Transactions[0].Hash, full.Transactions[0].From, full.Transactions[0].GasPrice, string(unknown), byHash,
+		},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
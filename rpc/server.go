@@ -0,0 +1,250 @@
+// Package rpc serves the chain over HTTP with JSON-RPC 2.0, with
+// the methods of the eth namespace which the wallets, scripts and
+// libraries of Ethereum use to talk to a node
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Codes of the errors of JSON-RPC 2.0, and the code of the
+// errors of the executed methods which is used by the nodes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeServerError    = -32000
+)
+
+// Maximum size of the body of a request
+const maxRequestSize = 5 * 1024 * 1024
+
+// Error is the error object of a response
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func invalidParams(format string, args ...interface{}) *Error {
+	return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf(format, args...)}
+}
+
+type request struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// Requests without an id are notifications, which are not responded
+func (r *request) isNotification() bool {
+	return len(r.ID) == 0
+}
+
+// Result is left out only if there is an error, hence
+// the methods with no result respond with a null result
+type response struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// method is the handler of an RPC method, whose params
+// are the elements of the params array of the request
+type method func(params []json.RawMessage) (interface{}, error)
+
+type ServerConfig struct {
+	// Origins of the browser pages which are allowed to send requests
+	// to the server, where "*" allows any origin. Browsers are not
+	// allowed to read the responses if there are no origins.
+	CorsOrigins []string
+}
+
+// Server is the HTTP handler which serves the methods of
+// the eth namespace over the chain of the backend. Requests
+// can be batched, and notifications are executed without a
+// response. Errors of the methods are returned with the
+// server error code unless they have a code of their own.
+type Server struct {
+	config  ServerConfig
+	backend Backend
+	methods map[string]method
+}
+
+func NewServer(backend Backend, config ServerConfig) *Server {
+	s := &Server{config: config, backend: backend, methods: map[string]method{}}
+	api := &ethAPI{backend: backend}
+	for name, m := range api.methods() {
+		s.methods[name] = m
+	}
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if origin := s.allowedOrigin(r.Header.Get("Origin")); origin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	}
+	// allowed origin depends on the origin of the request
+	w.Header().Add("Vary", "Origin")
+	switch r.Method {
+	case http.MethodOptions:
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	var out interface{}
+	if body = bytes.TrimSpace(body); len(body) > 0 && body[0] == '[' {
+		out, err = s.handleBatch(body)
+	} else {
+		out, err = s.handleSingle(body)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		out = &response{Version: "2.0", Error: &Error{Code: CodeParseError, Message: err.Error()}}
+	}
+	if out == nil {
+		// only notifications are sent
+		return
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+// Returns the value of the allowed origin header for the origin of
+// the request, which is empty if the origin is not allowed
+func (s *Server) allowedOrigin(origin string) string {
+	if origin == "" {
+		return ""
+	}
+	for _, allowed := range s.config.CorsOrigins {
+		if allowed == "*" {
+			return "*"
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// Returns the response of the request in the body, which is
+// nil if the request is a notification
+func (s *Server) handleSingle(body []byte) (interface{}, error) {
+	var msg json.RawMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, err
+	}
+	if resp := s.handleMessage(msg); resp != nil {
+		return resp, nil
+	}
+	return nil, nil
+}
+
+// Returns the responses of the requests in the body, which
+// is nil if all the requests are notifications
+func (s *Server) handleBatch(body []byte) (interface{}, error) {
+	var msgs []json.RawMessage
+	if err := json.Unmarshal(body, &msgs); err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		return []*response{{Version: "2.0", Error: &Error{Code: CodeInvalidRequest, Message: "empty batch"}}}, nil
+	}
+	var responses []*response
+	for _, msg := range msgs {
+		if resp := s.handleMessage(msg); resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+	if len(responses) == 0 {
+		return nil, nil
+	}
+	return responses, nil
+}
+
+// Executes the request of the message, and returns
+// its response, which is nil for the notifications
+func (s *Server) handleMessage(msg json.RawMessage) *response {
+	var req request
+	if err := json.Unmarshal(msg, &req); err != nil || req.Version != "2.0" || req.Method == "" {
+		return &response{Version: "2.0", Error: &Error{Code: CodeInvalidRequest, Message: "invalid request"}}
+	}
+	result, err := s.call(&req)
+	if req.isNotification() {
+		return nil
+	}
+	resp := &response{Version: "2.0", ID: req.ID}
+	if err == nil {
+		resp.Result, err = json.Marshal(result)
+	}
+	if err != nil {
+		rpcErr, ok := err.(*Error)
+		if !ok {
+			rpcErr = &Error{Code: CodeServerError, Message: err.Error()}
+		}
+		resp.Result, resp.Error = nil, rpcErr
+	}
+	return resp
+}
+
+func (s *Server) call(req *request) (result interface{}, err error) {
+	m, ok := s.methods[req.Method]
+	if !ok {
+		return nil, &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("the method %s does not exist/is not available", req.Method)}
+	}
+	var params []json.RawMessage
+	if len(req.Params) > 0 && string(req.Params) != "null" {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams("non-array params")
+		}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, &Error{Code: CodeInternalError, Message: fmt.Sprintf("method handler crashed: %v", r)}
+		}
+	}()
+	return m(params)
+}
+
+// Decodes the params into the args in their order. First required
+// args must be given, and the rest of the args are left as they are
+// if they are not given or they are null.
+func parseParams(params []json.RawMessage, required int, args ...interface{}) error {
+	if len(params) > len(args) {
+		return invalidParams("too many arguments, want at most %d", len(args))
+	}
+	for i, arg := range args {
+		if i >= len(params) || string(params[i]) == "null" {
+			if i < required {
+				return invalidParams("missing value for required argument %d", i)
+			}
+			continue
+		}
+		if err := json.Unmarshal(params[i], arg); err != nil {
+			return invalidParams("invalid argument %d: %v", i, err)
+		}
+	}
+	return nil
+}
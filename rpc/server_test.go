@@ -0,0 +1,118 @@
+package rpc
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	space_evm "space/evm"
+)
+
+// in is the body of the request, and exp is the body of the response
+var serverTests = []genericTest{
+	{
+		s:   "single request",
+		in:  `{"jsonrpc":"2.0","id":1,"method":"eth_chainId"}`,
+		exp: `{"jsonrpc":"2.0","id":1,"result":"0x539"}`,
+	},
+	{
+		s:   "null result",
+		in:  `{"jsonrpc":"2.0","id":"a","method":"eth_getTransactionReceipt","params":["0x0000000000000000000000000000000000000000000000000000000000000001"]}`,
+		exp: `{"jsonrpc":"2.0","id":"a","result":null}`,
+	},
+	{
+		s:   "unknown method",
+		in:  `{"jsonrpc":"2.0","id":2,"method":"eth_foo"}`,
+		exp: `{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"the method eth_foo does not exist/is not available"}}`,
+	},
+	{
+		s:   "missing params",
+		in:  `{"jsonrpc":"2.0","id":3,"method":"eth_getBalance","params":[]}`,
+		exp: `{"jsonrpc":"2.0","id":3,"error":{"code":-32602,"message":"missing value for required argument 0"}}`,
+	},
+	{
+		s:   "too many params",
+		in:  `{"jsonrpc":"2.0","id":4,"method":"eth_blockNumber","params":[1]}`,
+		exp: `{"jsonrpc":"2.0","id":4,"error":{"code":-32602,"message":"too many arguments, want at most 0"}}`,
+	},
+	{
+		s:   "invalid request",
+		in:  `{"id":5,"method":"eth_chainId"}`,
+		exp: `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"invalid request"}}`,
+	},
+	{
+		s:   "parse error",
+		in:  `{"jsonrpc"`,
+		exp: `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"unexpected end of JSON input"}}`,
+	},
+	{
+		s:   "notification",
+		in:  `{"jsonrpc":"2.0","method":"eth_chainId"}`,
+		exp: ``,
+	},
+	{
+		s:   "batch with a notification",
+		in:  `[{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"},{"jsonrpc":"2.0","method":"eth_chainId"},{"jsonrpc":"2.0","id":2,"method":"eth_chainId"}]`,
+		exp: `[{"jsonrpc":"2.0","id":1,"result":"0x0"},{"jsonrpc":"2.0","id":2,"result":"0x539"}]`,
+	},
+	{
+		s:   "empty batch",
+		in:  `[]`,
+		exp: `[{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"empty batch"}}]`,
+	},
+}
+
+func Test_Server_ServeHTTP(t *testing.T) {
+	_, url := genTestServer(t)
+	anyTestFailed := false
+	for _, test := range serverTests {
+		test.act = rpcPost(t, url, test.in.(string))
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the [allowed origins, origin of the request], and exp is the
+// allowed origin and the allowed methods of the preflight response
+var corsTests = []genericTest{
+	{s: "no origins allowed", in: []interface{}{[]string(nil), "http://localhost:3000"}, exp: []string{"", ""}},
+	{s: "allowed origin", in: []interface{}{[]string{"http://localhost:3000"}, "http://localhost:3000"}, exp: []string{"http://localhost:3000", "POST, OPTIONS"}},
+	{s: "other origin", in: []interface{}{[]string{"http://localhost:3000"}, "http://example.com"}, exp: []string{"", ""}},
+	{s: "any origin", in: []interface{}{[]string{"http://localhost:3000", "*"}, "http://example.com"}, exp: []string{"*", "POST, OPTIONS"}},
+	{s: "request without origin", in: []interface{}{[]string{"*"}, ""}, exp: []string{"", ""}},
+}
+
+func Test_Server_Cors(t *testing.T) {
+	dev, err := space_evm.NewDevChain(space_evm.DefaultDevConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	anyTestFailed := false
+	for _, test := range corsTests {
+		testIn := test.in.([]interface{})
+		server := httptest.NewServer(NewServer(dev, ServerConfig{CorsOrigins: testIn[0].([]string)}))
+		req, _ := http.NewRequest(http.MethodOptions, server.URL, nil)
+		if origin := testIn[1].(string); origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		server.Close()
+
+		test.act = []string{resp.Header.Get("Access-Control-Allow-Origin"), resp.Header.Get("Access-Control-Allow-Methods")}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,83 @@
+// This file contains helper functions and data for testing
+package rpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	space_evm "space/evm"
+)
+
+// genericTest is the struct used to hold the test data
+// in an organized format. It also helps generate messages
+// based on the expected and actual result comparison.
+type genericTest struct {
+	s          string
+	in         interface{}
+	exp        interface{}
+	act        interface{}
+	shouldFail bool
+}
+
+func (t genericTest) Check() (string, bool) {
+	if reflect.DeepEqual(t.exp, t.act) {
+		return fmt.Sprintf("\t✔ %s\n", t.s), false
+	} else {
+		return fmt.Sprintf("\033[31m\t✖ %s\n\t\texp: %#v\n\t\tgot: %#v\n\033[39m", t.s, t.exp, t.act), true
+	}
+}
+
+func hexToBytes(str string) []byte {
+	buff, _ := hex.DecodeString(str)
+	return buff
+}
+
+// Returns the dev chain which seals the blocks instantly,
+// and the URL of the server which serves the chain
+func genTestServer(t *testing.T) (*space_evm.DevChain, string) {
+	dev, err := space_evm.NewDevChain(space_evm.DefaultDevConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(NewServer(dev, ServerConfig{}))
+	t.Cleanup(server.Close)
+	return dev, server.URL
+}
+
+// Posts the body to the server, and returns the body of the response
+func rpcPost(t *testing.T, url string, body string) string {
+	resp, err := http.Post(url, "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	out, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(bytes.TrimSpace(out))
+}
+
+// Calls the method with the params, and returns
+// either the result or the error of the response
+func rpcCall(t *testing.T, url string, method string, params ...interface{}) (json.RawMessage, *Error) {
+	if params == nil {
+		params = []interface{}{}
+	}
+	req, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp response
+	if err := json.Unmarshal([]byte(rpcPost(t, url, string(req))), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp.Result, resp.Error
+}
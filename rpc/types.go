@@ -0,0 +1,389 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	space_evm "space/evm"
+	"space/rlp"
+
+	"github.com/holiman/uint256"
+)
+
+// BlockNumber is the block param of the methods, which is either a
+// hex number, or one of the tags. Safe and finalized blocks are the
+// head block, since the blocks of the dev chain are final at once.
+// Pending block is the head block with the transactions of the pool.
+type BlockNumber int64
+
+const (
+	PendingBlockNumber  BlockNumber = -2
+	LatestBlockNumber   BlockNumber = -1
+	EarliestBlockNumber BlockNumber = 0
+)
+
+func (n *BlockNumber) UnmarshalJSON(input []byte) error {
+	var str string
+	if err := json.Unmarshal(input, &str); err != nil {
+		return err
+	}
+	switch str {
+	case "latest", "safe", "finalized":
+		*n = LatestBlockNumber
+		return nil
+	case "pending":
+		*n = PendingBlockNumber
+		return nil
+	case "earliest":
+		*n = EarliestBlockNumber
+		return nil
+	}
+	var number space_evm.HexUint64
+	if err := number.UnmarshalText([]byte(str)); err != nil {
+		return err
+	}
+	if int64(number) < 0 {
+		return fmt.Errorf("block number %v is too large", str)
+	}
+	*n = BlockNumber(number)
+	return nil
+}
+
+// StorageKey is the slot param of eth_getStorageAt, which is a hex
+// string of up to 32 bytes, with or without the leading zeroes
+type StorageKey space_evm.Hash
+
+func (k *StorageKey) UnmarshalText(input []byte) error {
+	str := string(input)
+	if !strings.HasPrefix(str, "0x") {
+		return fmt.Errorf("%w %q", space_evm.ErrInvalidHexString, str)
+	}
+	if str = str[2:]; len(str)%2 == 1 {
+		str = "0" + str
+	}
+	buff, err := hex.DecodeString(str)
+	if err != nil || len(buff) > space_evm.HashLength {
+		return fmt.Errorf("%w %q", space_evm.ErrInvalidHexString, input)
+	}
+	*k = StorageKey(space_evm.BytesToHash(buff))
+	return nil
+}
+
+// CallArgs are the fields of the transaction of eth_call. Calls with a
+// gas price are legacy transactions, or access list transactions if they
+// have an access list, and the rest of the calls are dynamic fee
+// transactions. Gas is the gas limit of the block if it is not given.
+type CallArgs struct {
+	From                 *space_evm.Address   `json:"from"`
+	To                   *space_evm.Address   `json:"to"`
+	Gas                  *space_evm.HexUint64 `json:"gas"`
+	GasPrice             *uint256.Int         `json:"gasPrice"`
+	MaxFeePerGas         *uint256.Int         `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *uint256.Int         `json:"maxPriorityFeePerGas"`
+	Value                *uint256.Int         `json:"value"`
+	Nonce                *space_evm.HexUint64 `json:"nonce"`
+	// input is preferred over data, which is its legacy name
+	Data       *space_evm.HexBytes   `json:"data"`
+	Input      *space_evm.HexBytes   `json:"input"`
+	AccessList *space_evm.AccessList `json:"accessList"`
+}
+
+// Returns the sender of the call, which is the zero address if it is not set
+func (args *CallArgs) from() space_evm.Address {
+	if args.From == nil {
+		return space_evm.Address{}
+	}
+	return *args.From
+}
+
+func (args *CallArgs) data() []byte {
+	if args.Input != nil {
+		return *args.Input
+	}
+	if args.Data != nil {
+		return *args.Data
+	}
+	return nil
+}
+
+// Returns the unsigned transaction of the call
+func (args *CallArgs) toTransaction(gasLimit uint64) (*space_evm.Transaction, error) {
+	if args.GasPrice != nil && (args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil) {
+		return nil, invalidParams("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
+	}
+	gas, nonce, value := gasLimit, uint64(0), new(uint256.Int)
+	if args.Gas != nil {
+		gas = uint64(*args.Gas)
+	}
+	if args.Nonce != nil {
+		nonce = uint64(*args.Nonce)
+	}
+	if args.Value != nil {
+		value = args.Value
+	}
+	var accessList space_evm.AccessList
+	if args.AccessList != nil {
+		accessList = *args.AccessList
+	}
+
+	if args.GasPrice != nil {
+		if accessList == nil {
+			return space_evm.NewTx(&space_evm.LegacyTx{
+				Nonce: nonce, GasPrice: args.GasPrice, Gas: gas, To: args.To, Value: value, Data: args.data(),
+			}), nil
+		}
+		return space_evm.NewTx(&space_evm.AccessListTx{
+			Nonce: nonce, GasPrice: args.GasPrice, Gas: gas, To: args.To, Value: value, Data: args.data(), AccessList: accessList,
+		}), nil
+	}
+	feeCap, tip := new(uint256.Int), new(uint256.Int)
+	if args.MaxFeePerGas != nil {
+		feeCap = args.MaxFeePerGas
+	}
+	if args.MaxPriorityFeePerGas != nil {
+		tip = args.MaxPriorityFeePerGas
+	}
+	return space_evm.NewTx(&space_evm.DynamicFeeTx{
+		Nonce: nonce, GasTipCap: tip, GasFeeCap: feeCap, Gas: gas, To: args.To, Value: value, Data: args.data(), AccessList: accessList,
+	}), nil
+}
+
+// RPCTransaction is the JSON form of a transaction, with
+// the block which includes it if it is in the chain
+type RPCTransaction struct {
+	BlockHash            *space_evm.Hash       `json:"blockHash"`
+	BlockNumber          *space_evm.HexUint64  `json:"blockNumber"`
+	TransactionIndex     *space_evm.HexUint64  `json:"transactionIndex"`
+	Hash                 space_evm.Hash        `json:"hash"`
+	Type                 space_evm.HexUint64   `json:"type"`
+	ChainID              *uint256.Int          `json:"chainId,omitempty"`
+	From                 space_evm.Address     `json:"from"`
+	Nonce                space_evm.HexUint64   `json:"nonce"`
+	To                   *space_evm.Address    `json:"to"`
+	Value                *uint256.Int          `json:"value"`
+	Gas                  space_evm.HexUint64   `json:"gas"`
+	GasPrice             *uint256.Int          `json:"gasPrice"`
+	MaxFeePerGas         *uint256.Int          `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *uint256.Int          `json:"maxPriorityFeePerGas,omitempty"`
+	MaxFeePerBlobGas     *uint256.Int          `json:"maxFeePerBlobGas,omitempty"`
+	Input                space_evm.HexBytes    `json:"input"`
+	AccessList           *space_evm.AccessList `json:"accessList,omitempty"`
+	BlobVersionedHashes  []space_evm.Hash      `json:"blobVersionedHashes,omitempty"`
+	V                    *uint256.Int          `json:"v"`
+	R                    *uint256.Int          `json:"r"`
+	S                    *uint256.Int          `json:"s"`
+	YParity              *space_evm.HexUint64  `json:"yParity,omitempty"`
+}
+
+// Returns the JSON form of the transaction, which is included in the
+// block at the index if the block is given. Gas price of the dynamic
+// fee transactions is the effective gas price in their block, and
+// their fee cap if they are not included yet.
+func newRPCTransaction(tx *space_evm.Transaction, block *space_evm.Block, index uint64) *RPCTransaction {
+	// transactions are validated when they are added
+	// to the chain or the pool, hence they have a sender
+	from, _ := tx.Sender()
+	v, r, s := tx.RawSignatureValues()
+	result := &RPCTransaction{
+		Hash:     tx.Hash(),
+		Type:     space_evm.HexUint64(tx.Type()),
+		From:     from,
+		Nonce:    space_evm.HexUint64(tx.Nonce()),
+		To:       tx.To(),
+		Value:    tx.Value(),
+		Gas:      space_evm.HexUint64(tx.Gas()),
+		GasPrice: tx.GasPrice(),
+		Input:    tx.Data(),
+		V:        v,
+		R:        r,
+		S:        s,
+	}
+	if block != nil {
+		hash, number, index := block.Hash(), space_evm.HexUint64(block.Number()), space_evm.HexUint64(index)
+		result.BlockHash, result.BlockNumber, result.TransactionIndex = &hash, &number, &index
+	}
+	if tx.Type() == space_evm.LegacyTxType {
+		if tx.Protected() {
+			result.ChainID = tx.ChainID()
+		}
+		return result
+	}
+	accessList, yParity := tx.AccessList(), space_evm.HexUint64(v.Uint64())
+	result.ChainID, result.AccessList, result.YParity = tx.ChainID(), &accessList, &yParity
+	if tx.Type() == space_evm.AccessListTxType {
+		return result
+	}
+	result.MaxFeePerGas, result.MaxPriorityFeePerGas = tx.GasFeeCap(), tx.GasTipCap()
+	if block != nil && block.Header.BaseFee != nil {
+		result.GasPrice = space_evm.EffectiveGasPrice(tx, block.Header.BaseFee)
+	}
+	if tx.Type() == space_evm.BlobTxType {
+		result.MaxFeePerBlobGas, result.BlobVersionedHashes = tx.BlobGasFeeCap(), tx.BlobHashes()
+	}
+	return result
+}
+
+// RPCLog is the JSON form of a log of a receipt
+type RPCLog struct {
+	Address          space_evm.Address   `json:"address"`
+	Topics           []space_evm.Hash    `json:"topics"`
+	Data             space_evm.HexBytes  `json:"data"`
+	BlockNumber      space_evm.HexUint64 `json:"blockNumber"`
+	BlockHash        space_evm.Hash      `json:"blockHash"`
+	TransactionHash  space_evm.Hash      `json:"transactionHash"`
+	TransactionIndex space_evm.HexUint64 `json:"transactionIndex"`
+	LogIndex         space_evm.HexUint64 `json:"logIndex"`
+	Removed          bool                `json:"removed"`
+}
+
+func newRPCLog(log *space_evm.Log) *RPCLog {
+	topics := log.Topics
+	if topics == nil {
+		topics = []space_evm.Hash{}
+	}
+	return &RPCLog{
+		Address:          log.Address,
+		Topics:           topics,
+		Data:             log.Data,
+		BlockNumber:      space_evm.HexUint64(log.BlockNumber),
+		BlockHash:        log.BlockHash,
+		TransactionHash:  log.TxHash,
+		TransactionIndex: space_evm.HexUint64(log.TxIndex),
+		LogIndex:         space_evm.HexUint64(log.Index),
+	}
+}
+
+// RPCReceipt is the JSON form of the receipt of a transaction
+type RPCReceipt struct {
+	TransactionHash   space_evm.Hash       `json:"transactionHash"`
+	TransactionIndex  space_evm.HexUint64  `json:"transactionIndex"`
+	BlockHash         space_evm.Hash       `json:"blockHash"`
+	BlockNumber       space_evm.HexUint64  `json:"blockNumber"`
+	From              space_evm.Address    `json:"from"`
+	To                *space_evm.Address   `json:"to"`
+	CumulativeGasUsed space_evm.HexUint64  `json:"cumulativeGasUsed"`
+	GasUsed           space_evm.HexUint64  `json:"gasUsed"`
+	EffectiveGasPrice *uint256.Int         `json:"effectiveGasPrice"`
+	BlobGasUsed       *space_evm.HexUint64 `json:"blobGasUsed,omitempty"`
+	BlobGasPrice      *uint256.Int         `json:"blobGasPrice,omitempty"`
+	ContractAddress   *space_evm.Address   `json:"contractAddress"`
+	Logs              []*RPCLog            `json:"logs"`
+	LogsBloom         space_evm.HexBytes   `json:"logsBloom"`
+	Type              space_evm.HexUint64  `json:"type"`
+	Status            space_evm.HexUint64  `json:"status"`
+}
+
+func newRPCReceipt(receipt *space_evm.Receipt, tx *space_evm.Transaction) *RPCReceipt {
+	from, _ := tx.Sender()
+	result := &RPCReceipt{
+		TransactionHash:   receipt.TxHash,
+		TransactionIndex:  space_evm.HexUint64(receipt.TransactionIndex),
+		BlockHash:         receipt.BlockHash,
+		BlockNumber:       space_evm.HexUint64(receipt.BlockNumber),
+		From:              from,
+		To:                tx.To(),
+		CumulativeGasUsed: space_evm.HexUint64(receipt.CumulativeGasUsed),
+		GasUsed:           space_evm.HexUint64(receipt.GasUsed),
+		EffectiveGasPrice: receipt.EffectiveGasPrice,
+		ContractAddress:   receipt.ContractAddress,
+		Logs:              make([]*RPCLog, len(receipt.Logs)),
+		LogsBloom:         receipt.Bloom[:],
+		Type:              space_evm.HexUint64(receipt.Type),
+		Status:            space_evm.HexUint64(receipt.Status),
+	}
+	for i, log := range receipt.Logs {
+		result.Logs[i] = newRPCLog(log)
+	}
+	if receipt.BlobGasUsed > 0 {
+		blobGasUsed := space_evm.HexUint64(receipt.BlobGasUsed)
+		result.BlobGasUsed, result.BlobGasPrice = &blobGasUsed, receipt.BlobGasPrice
+	}
+	return result
+}
+
+// RPCBlock is the JSON form of a block, whose transactions are
+// either their hashes or their JSON forms. Blocks have no uncles,
+// and the fields of the later forks are left out if they are not
+// set in the header.
+type RPCBlock struct {
+	Number           space_evm.HexUint64  `json:"number"`
+	Hash             space_evm.Hash       `json:"hash"`
+	ParentHash       space_evm.Hash       `json:"parentHash"`
+	Nonce            space_evm.HexBytes   `json:"nonce"`
+	MixHash          space_evm.Hash       `json:"mixHash"`
+	Sha3Uncles       space_evm.Hash       `json:"sha3Uncles"`
+	LogsBloom        space_evm.HexBytes   `json:"logsBloom"`
+	StateRoot        space_evm.Hash       `json:"stateRoot"`
+	TransactionsRoot space_evm.Hash       `json:"transactionsRoot"`
+	ReceiptsRoot     space_evm.Hash       `json:"receiptsRoot"`
+	Miner            space_evm.Address    `json:"miner"`
+	Difficulty       *uint256.Int         `json:"difficulty"`
+	ExtraData        space_evm.HexBytes   `json:"extraData"`
+	Size             space_evm.HexUint64  `json:"size"`
+	GasLimit         space_evm.HexUint64  `json:"gasLimit"`
+	GasUsed          space_evm.HexUint64  `json:"gasUsed"`
+	Timestamp        space_evm.HexUint64  `json:"timestamp"`
+	BaseFeePerGas    *uint256.Int         `json:"baseFeePerGas,omitempty"`
+	WithdrawalsRoot  *space_evm.Hash      `json:"withdrawalsRoot,omitempty"`
+	BlobGasUsed      *space_evm.HexUint64 `json:"blobGasUsed,omitempty"`
+	ExcessBlobGas    *space_evm.HexUint64 `json:"excessBlobGas,omitempty"`
+	ParentBeaconRoot *space_evm.Hash      `json:"parentBeaconBlockRoot,omitempty"`
+	Transactions     []interface{}        `json:"transactions"`
+	Uncles           []space_evm.Hash     `json:"uncles"`
+}
+
+func newRPCBlock(block *space_evm.Block, fullTx bool) (*RPCBlock, error) {
+	enc, err := rlp.Encode(block)
+	if err != nil {
+		return nil, err
+	}
+	header := block.Header
+	result := &RPCBlock{
+		Number:           space_evm.HexUint64(header.Number),
+		Hash:             block.Hash(),
+		ParentHash:       header.ParentHash,
+		Nonce:            header.Nonce[:],
+		MixHash:          header.MixDigest,
+		Sha3Uncles:       header.UncleHash,
+		LogsBloom:        header.Bloom[:],
+		StateRoot:        header.Root,
+		TransactionsRoot: header.TxHash,
+		ReceiptsRoot:     header.ReceiptHash,
+		Miner:            header.Coinbase,
+		Difficulty:       header.Difficulty,
+		ExtraData:        header.Extra,
+		Size:             space_evm.HexUint64(len(enc)),
+		GasLimit:         space_evm.HexUint64(header.GasLimit),
+		GasUsed:          space_evm.HexUint64(header.GasUsed),
+		Timestamp:        space_evm.HexUint64(header.Time),
+		BaseFeePerGas:    header.BaseFee,
+		WithdrawalsRoot:  header.WithdrawalsHash,
+		ParentBeaconRoot: header.ParentBeaconRoot,
+		Transactions:     make([]interface{}, len(block.Transactions)),
+		Uncles:           []space_evm.Hash{},
+	}
+	if result.Difficulty == nil {
+		result.Difficulty = new(uint256.Int)
+	}
+	if result.ExtraData == nil {
+		result.ExtraData = space_evm.HexBytes{}
+	}
+	if header.BlobGasUsed != nil {
+		blobGasUsed := space_evm.HexUint64(*header.BlobGasUsed)
+		result.BlobGasUsed = &blobGasUsed
+	}
+	if header.ExcessBlobGas != nil {
+		excessBlobGas := space_evm.HexUint64(*header.ExcessBlobGas)
+		result.ExcessBlobGas = &excessBlobGas
+	}
+	for i, tx := range block.Transactions {
+		if fullTx {
+			result.Transactions[i] = newRPCTransaction(tx, block, uint64(i))
+		} else {
+			result.Transactions[i] = tx.Hash()
+		}
+	}
+	return result, nil
+}
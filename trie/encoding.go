@@ -0,0 +1,79 @@
+package trie
+
+// Keys are handled in 3 different encodings. Keybytes is the plain key.
+// Hex encoding has a nibble per byte, and it ends with the terminator
+// nibble 16 if the key points to a value. Compact encoding is the hex
+// encoding packed into bytes, which is used in the encoded nodes. Its
+// first nibble holds the flags, where the lowest bit is set for odd
+// length keys, and the second lowest bit is set for terminated keys.
+// Even length keys have a zero nibble after the flags as padding.
+
+const terminator = 16
+
+func keybytesToHex(key []byte) []byte {
+	hex := make([]byte, len(key)*2+1)
+	for i, b := range key {
+		hex[i*2] = b / 16
+		hex[i*2+1] = b % 16
+	}
+	hex[len(hex)-1] = terminator
+	return hex
+}
+
+// Returns the plain key of a terminated hex key, whose
+// nibbles are paired into bytes after the terminator is removed
+func hexToKeybytes(hex []byte) []byte {
+	if hasTerm(hex) {
+		hex = hex[:len(hex)-1]
+	}
+	key := make([]byte, len(hex)/2)
+	for i := range key {
+		key[i] = hex[i*2]<<4 | hex[i*2+1]
+	}
+	return key
+}
+
+func hexToCompact(hex []byte) []byte {
+	flags := byte(0)
+	if hasTerm(hex) {
+		flags = 2
+		hex = hex[:len(hex)-1]
+	}
+	compact := make([]byte, len(hex)/2+1)
+	compact[0] = flags << 4
+	if len(hex)%2 == 1 {
+		compact[0] |= 1<<4 | hex[0]
+		hex = hex[1:]
+	}
+	for i := 0; i < len(hex); i += 2 {
+		compact[i/2+1] = hex[i]<<4 | hex[i+1]
+	}
+	return compact
+}
+
+func compactToHex(compact []byte) []byte {
+	if len(compact) == 0 {
+		return compact
+	}
+	hex := keybytesToHex(compact)
+	// remove the terminator if the key is not terminated
+	if hex[0] < 2 {
+		hex = hex[:len(hex)-1]
+	}
+	// remove the flags, and the padding nibble of even length keys
+	return hex[2-hex[0]&1:]
+}
+
+func hasTerm(hex []byte) bool {
+	return len(hex) > 0 && hex[len(hex)-1] == terminator
+}
+
+func prefixLen(a, b []byte) int {
+	i := 0
+	for ; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			break
+		}
+	}
+	return i
+}
@@ -0,0 +1,129 @@
+package trie
+
+import (
+	"space/database"
+	"space/rlp"
+
+	"golang.org/x/crypto/sha3"
+)
+
+const hashLen = 32
+
+// hasher computes the hashes of the nodes, and stores the
+// encoded nodes by their hashes into the writer if it is set
+type hasher struct {
+	writer database.KeyValueWriter
+}
+
+func keccak256(buff []byte) []byte {
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write(buff)
+	return hasher.Sum(nil)
+}
+
+// Returns the hash of the root node, which is always hashed regardless
+// of its encoding size, with the root where the hashes of the nodes are
+// cached. Nodes which are already hashed are not encoded again, unless
+// they are not in the store yet while the nodes are written.
+func (h *hasher) hashRoot(n node) ([]byte, node, error) {
+	if hash, ok := n.(hashNode); ok {
+		return hash, n, nil
+	}
+	if flags := flagsOf(n); flags.hash != nil && (h.writer == nil || flags.stored) {
+		return flags.hash, n, nil
+	}
+	enc, cached, err := h.encode(n)
+	if err != nil {
+		return nil, nil, err
+	}
+	hash, err := h.store(enc)
+	if err != nil {
+		return nil, nil, err
+	}
+	// hash of a root shorter than a hash is not cached, since the
+	// node would be embedded into its parent if it is not the root
+	if len(enc) >= hashLen {
+		setFlags(cached, nodeFlag{hash: hash, stored: h.writer != nil})
+	}
+	return hash, cached, nil
+}
+
+// Returns the encoding of the node, where its children are replaced
+// with their references, and the node with the cached children
+func (h *hasher) encode(n node) ([]byte, node, error) {
+	switch n := n.(type) {
+	case nil:
+		return rlp.EmptyString, n, nil
+	case *shortNode:
+		val, cachedVal, err := h.ref(n.Val)
+		if err != nil {
+			return nil, nil, err
+		}
+		cached := &shortNode{Key: n.Key, Val: cachedVal}
+		return rlp.EncodeList(rlp.EncodeBytes(hexToCompact(n.Key)), val), cached, nil
+	case *fullNode:
+		cached := &fullNode{}
+		children := make([][]byte, 17)
+		for i, child := range n.Children {
+			ref, cachedChild, err := h.ref(child)
+			if err != nil {
+				return nil, nil, err
+			}
+			children[i], cached.Children[i] = ref, cachedChild
+		}
+		return rlp.EncodeList(children...), cached, nil
+	case hashNode:
+		return rlp.EncodeBytes(n), n, nil
+	case valueNode:
+		return rlp.EncodeBytes(n), n, nil
+	default:
+		panic("trie: unknown node type")
+	}
+}
+
+// Returns the reference to the node from its parent, with the node where
+// the hashes are cached. Nodes whose encoding is shorter than a hash are
+// embedded into their parent, and the others are referenced with the
+// hash of their encoding.
+func (h *hasher) ref(n node) ([]byte, node, error) {
+	switch n.(type) {
+	case nil, hashNode, valueNode:
+		return h.encode(n)
+	}
+	if flags := flagsOf(n); flags.hash != nil && (h.writer == nil || flags.stored) {
+		return rlp.EncodeBytes(flags.hash), n, nil
+	}
+	enc, cached, err := h.encode(n)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(enc) < hashLen {
+		return enc, cached, nil
+	}
+	hash, err := h.store(enc)
+	if err != nil {
+		return nil, nil, err
+	}
+	setFlags(cached, nodeFlag{hash: hash, stored: h.writer != nil})
+	return rlp.EncodeBytes(hash), cached, nil
+}
+
+// Sets the flags of the node, which is a new node of the hasher
+func setFlags(n node, flags nodeFlag) {
+	switch n := n.(type) {
+	case *fullNode:
+		n.flags = flags
+	case *shortNode:
+		n.flags = flags
+	}
+}
+
+func (h *hasher) store(enc []byte) ([]byte, error) {
+	hash := keccak256(enc)
+	if h.writer != nil {
+		if err := h.writer.Put(hash, enc); err != nil {
+			return nil, err
+		}
+	}
+	return hash, nil
+}
@@ -0,0 +1,158 @@
+package trie
+
+import (
+	"errors"
+	"fmt"
+
+	"space/rlp"
+)
+
+var ErrInvalidNode = errors.New("invalid trie node")
+
+// Trie is made of 4 kinds of nodes. Full nodes branch into 16 children
+// by the next nibble of the key, and they can also hold a value at the
+// 17th slot. Short nodes hold the common part of the keys, and they
+// point to either a value or a full node. Hash nodes are references to
+// the nodes in the store, which are not loaded yet. Value nodes are
+// the values of the keys.
+//
+// Nodes are never modified once they are in a trie, and the changed
+// nodes are replaced by new nodes instead, hence the tries can share
+// their nodes.
+type (
+	node interface{}
+
+	fullNode struct {
+		Children [17]node
+		flags    nodeFlag
+	}
+	shortNode struct {
+		Key   []byte
+		Val   node
+		flags nodeFlag
+	}
+	hashNode  []byte
+	valueNode []byte
+)
+
+// nodeFlag caches the hash of a node, which is nil until the node is
+// hashed, and whether the node is in the store of its trie. Nodes
+// which are created by the changes of the trie have empty flags.
+type nodeFlag struct {
+	hash   hashNode
+	stored bool
+}
+
+func (n *fullNode) copy() *fullNode {
+	cpy := *n
+	return &cpy
+}
+
+// Returns the flags of the full and short nodes,
+// which are empty for the other nodes
+func flagsOf(n node) nodeFlag {
+	switch n := n.(type) {
+	case *fullNode:
+		return n.flags
+	case *shortNode:
+		return n.flags
+	}
+	return nodeFlag{}
+}
+
+// Decodes the node stored with the given hash
+func decodeNode(hash, buff []byte) (node, error) {
+	elems, _, err := rlp.SplitList(buff)
+	if err != nil {
+		return nil, fmt.Errorf("%w %x: %v", ErrInvalidNode, hash, err)
+	}
+	count, err := rlp.CountValues(elems)
+	if err != nil {
+		return nil, fmt.Errorf("%w %x: %v", ErrInvalidNode, hash, err)
+	}
+
+	// nodes read by their hashes are in the store, while
+	// the embedded nodes are stored within their parents
+	flags := nodeFlag{hash: hash, stored: hash != nil}
+	switch count {
+	case 2:
+		n, err := decodeShort(elems)
+		if err != nil {
+			return nil, fmt.Errorf("%w %x: %v", ErrInvalidNode, hash, err)
+		}
+		n.flags = flags
+		return n, nil
+	case 17:
+		n, err := decodeFull(elems)
+		if err != nil {
+			return nil, fmt.Errorf("%w %x: %v", ErrInvalidNode, hash, err)
+		}
+		n.flags = flags
+		return n, nil
+	default:
+		return nil, fmt.Errorf("%w %x: %d list elements", ErrInvalidNode, hash, count)
+	}
+}
+
+func decodeShort(elems []byte) (*shortNode, error) {
+	compactKey, rest, err := rlp.SplitString(elems)
+	if err != nil {
+		return nil, err
+	}
+	key := compactToHex(compactKey)
+	if hasTerm(key) {
+		val, _, err := rlp.SplitString(rest)
+		if err != nil {
+			return nil, err
+		}
+		return &shortNode{Key: key, Val: valueNode(append([]byte{}, val...))}, nil
+	}
+	val, _, err := decodeRef(rest)
+	if err != nil {
+		return nil, err
+	}
+	return &shortNode{Key: key, Val: val}, nil
+}
+
+func decodeFull(elems []byte) (*fullNode, error) {
+	n := &fullNode{}
+	for i := 0; i < 16; i++ {
+		child, rest, err := decodeRef(elems)
+		if err != nil {
+			return nil, err
+		}
+		n.Children[i], elems = child, rest
+	}
+	val, _, err := rlp.SplitString(elems)
+	if err != nil {
+		return nil, err
+	}
+	if len(val) > 0 {
+		n.Children[16] = valueNode(append([]byte{}, val...))
+	}
+	return n, nil
+}
+
+// Decodes the reference to a child node, which is either the
+// hash of the child, or the child itself if it is small enough
+func decodeRef(buff []byte) (node, []byte, error) {
+	kind, val, rest, err := rlp.Split(buff)
+	if err != nil {
+		return nil, buff, err
+	}
+	switch {
+	case kind == rlp.List:
+		// embedded nodes are shorter than a hash
+		if size := len(buff) - len(rest); size >= hashLen {
+			return nil, buff, fmt.Errorf("oversized embedded node (size %d)", size)
+		}
+		n, err := decodeNode(nil, buff[:len(buff)-len(rest)])
+		return n, rest, err
+	case kind == rlp.String && len(val) == 0:
+		return nil, rest, nil
+	case kind == rlp.String && len(val) == hashLen:
+		return hashNode(append([]byte{}, val...)), rest, nil
+	default:
+		return nil, nil, fmt.Errorf("invalid reference string size %d", len(val))
+	}
+}
@@ -0,0 +1,115 @@
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidProof = errors.New("invalid trie proof")
+
+// Returns the Merkle proof of the key, which is the list of the encoded
+// nodes on the path from the root to the key. Nodes which are embedded
+// into their parents are not listed separately. If the key is not in
+// the trie, the proof shows where the path to the key ends.
+func (t *Trie) Prove(key []byte) ([][]byte, error) {
+	key = keybytesToHex(key)
+	var nodes []node
+	for n := t.root; n != nil && len(key) > 0; {
+		switch cur := n.(type) {
+		case *shortNode:
+			nodes = append(nodes, cur)
+			if len(key) < len(cur.Key) || !bytes.Equal(cur.Key, key[:len(cur.Key)]) {
+				n = nil
+			} else {
+				n, key = cur.Val, key[len(cur.Key):]
+			}
+		case *fullNode:
+			nodes = append(nodes, cur)
+			n, key = cur.Children[key[0]], key[1:]
+		case hashNode:
+			resolved, err := t.resolveHash(cur)
+			if err != nil {
+				return nil, err
+			}
+			n = resolved
+		case valueNode:
+			n = nil
+		default:
+			panic(fmt.Sprintf("trie: invalid node %T", n))
+		}
+	}
+
+	h := &hasher{}
+	proof := make([][]byte, 0, len(nodes))
+	for i, n := range nodes {
+		// hashing can only fail while writing into a store
+		enc, _, _ := h.encode(n)
+		if i == 0 || len(enc) >= hashLen {
+			proof = append(proof, enc)
+		}
+	}
+	return proof, nil
+}
+
+// Verifies the Merkle proof of the key against the root, and returns
+// the value of the key. Value is nil if the proof shows that the key
+// is not in the trie. Proof must be in the format returned by Prove,
+// although the order of its nodes does not matter.
+func VerifyProof(root [32]byte, key []byte, proof [][]byte) ([]byte, error) {
+	if root == EmptyRootHash {
+		return nil, nil
+	}
+	nodes := make(map[string][]byte, len(proof))
+	for _, enc := range proof {
+		nodes[string(keccak256(enc))] = enc
+	}
+
+	key = keybytesToHex(key)
+	hash := root[:]
+	for {
+		enc, ok := nodes[string(hash)]
+		if !ok {
+			return nil, fmt.Errorf("%w: missing node %x", ErrInvalidProof, hash)
+		}
+		n, err := decodeNode(hash, enc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidProof, err)
+		}
+		var child node
+		key, child = walkNode(n, key)
+		switch child := child.(type) {
+		case nil:
+			return nil, nil
+		case hashNode:
+			hash = child
+		case valueNode:
+			return child, nil
+		}
+	}
+}
+
+// Follows the key through the node and its embedded children, and
+// returns the rest of the key with the node where the path ends,
+// which is either nil, a value or a reference to another node
+func walkNode(n node, key []byte) ([]byte, node) {
+	for {
+		switch cur := n.(type) {
+		case *shortNode:
+			if len(key) < len(cur.Key) || !bytes.Equal(cur.Key, key[:len(cur.Key)]) {
+				return nil, nil
+			}
+			n, key = cur.Val, key[len(cur.Key):]
+		case *fullNode:
+			n, key = cur.Children[key[0]], key[1:]
+		case hashNode:
+			return key, cur
+		case valueNode:
+			return nil, cur
+		case nil:
+			return nil, nil
+		default:
+			panic(fmt.Sprintf("trie: invalid node %T", n))
+		}
+	}
+}
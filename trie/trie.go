@@ -0,0 +1,348 @@
+// Package trie implements the Merkle Patricia Trie, which commits to a
+// set of key/value pairs with a single root hash. Nodes are encoded with
+// RLP, and they are loaded from a key/value store by their hashes on
+// demand, and written back to a store when the trie is committed.
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"space/database"
+)
+
+var ErrMissingNode = errors.New("missing trie node")
+
+// EmptyRootHash is the root hash of an empty trie,
+// which is the keccak256 of the encoded empty string
+var EmptyRootHash = [32]byte{
+	0x56, 0xe8, 0x1f, 0x17, 0x1b, 0xcc, 0x55, 0xa6, 0xff, 0x83, 0x45, 0xe6, 0x92, 0xc0, 0xf8, 0x6e,
+	0x5b, 0x48, 0xe0, 0x1b, 0x99, 0x6c, 0xad, 0xc0, 0x01, 0x62, 0x2f, 0xb5, 0xe3, 0x63, 0xb4, 0x21,
+}
+
+// Trie is a Merkle Patricia Trie. It is not safe for concurrent use.
+type Trie struct {
+	root   node
+	reader database.KeyValueReader
+}
+
+// Creates a trie with the given root, whose nodes are read from the
+// reader. Zero or empty root hash creates an empty trie, and the
+// reader can be nil if the trie is not loaded from a store.
+func New(root [32]byte, reader database.KeyValueReader) (*Trie, error) {
+	t := &Trie{reader: reader}
+	if root != EmptyRootHash && root != ([32]byte{}) {
+		rootNode, err := t.resolveHash(root[:])
+		if err != nil {
+			return nil, err
+		}
+		t.root = rootNode
+	}
+	return t, nil
+}
+
+// Returns the value of the key, and nil if the key is not in the trie
+func (t *Trie) Get(key []byte) ([]byte, error) {
+	value, newRoot, resolved, err := t.get(t.root, keybytesToHex(key))
+	if err != nil {
+		return nil, err
+	}
+	if resolved {
+		t.root = newRoot
+	}
+	return value, nil
+}
+
+// Returns the value of the key under the node, and the node itself
+// with the hash nodes on the path replaced by the resolved nodes
+func (t *Trie) get(n node, key []byte) ([]byte, node, bool, error) {
+	switch n := n.(type) {
+	case nil:
+		return nil, nil, false, nil
+	case valueNode:
+		return n, n, false, nil
+	case *shortNode:
+		if len(key) < len(n.Key) || !bytes.Equal(n.Key, key[:len(n.Key)]) {
+			return nil, n, false, nil
+		}
+		value, newVal, resolved, err := t.get(n.Val, key[len(n.Key):])
+		if err == nil && resolved {
+			// resolved node has the same hash as the hash node
+			n = &shortNode{Key: n.Key, Val: newVal, flags: n.flags}
+		}
+		return value, n, resolved, err
+	case *fullNode:
+		value, newChild, resolved, err := t.get(n.Children[key[0]], key[1:])
+		if err == nil && resolved {
+			n = n.copy()
+			n.Children[key[0]] = newChild
+		}
+		return value, n, resolved, err
+	case hashNode:
+		child, err := t.resolveHash(n)
+		if err != nil {
+			return nil, n, false, err
+		}
+		value, newNode, _, err := t.get(child, key)
+		return value, newNode, true, err
+	default:
+		panic(fmt.Sprintf("trie: invalid node %T", n))
+	}
+}
+
+// Sets the value of the key. Empty value deletes the key.
+func (t *Trie) Update(key []byte, value []byte) error {
+	if len(value) == 0 {
+		return t.Delete(key)
+	}
+	_, n, err := t.insert(t.root, keybytesToHex(key), valueNode(append([]byte{}, value...)))
+	if err != nil {
+		return err
+	}
+	t.root = n
+	return nil
+}
+
+// Inserts the value under the node, and returns the new node
+// with whether anything is changed
+func (t *Trie) insert(n node, key []byte, value node) (bool, node, error) {
+	if len(key) == 0 {
+		if v, ok := n.(valueNode); ok {
+			return !bytes.Equal(v, value.(valueNode)), value, nil
+		}
+		return true, value, nil
+	}
+	switch n := n.(type) {
+	case nil:
+		return true, &shortNode{Key: key, Val: value}, nil
+	case *shortNode:
+		matchLen := prefixLen(key, n.Key)
+		// whole key of the node matches, hence insert into its value
+		if matchLen == len(n.Key) {
+			dirty, newVal, err := t.insert(n.Val, key[matchLen:], value)
+			if !dirty || err != nil {
+				return false, n, err
+			}
+			return true, &shortNode{Key: n.Key, Val: newVal}, nil
+		}
+		// otherwise branch out where the keys differ
+		branch := &fullNode{}
+		var err error
+		_, branch.Children[n.Key[matchLen]], err = t.insert(nil, n.Key[matchLen+1:], n.Val)
+		if err != nil {
+			return false, nil, err
+		}
+		_, branch.Children[key[matchLen]], err = t.insert(nil, key[matchLen+1:], value)
+		if err != nil {
+			return false, nil, err
+		}
+		if matchLen == 0 {
+			return true, branch, nil
+		}
+		return true, &shortNode{Key: key[:matchLen], Val: branch}, nil
+	case *fullNode:
+		dirty, newChild, err := t.insert(n.Children[key[0]], key[1:], value)
+		if !dirty || err != nil {
+			return false, n, err
+		}
+		n = n.copy()
+		n.Children[key[0]], n.flags = newChild, nodeFlag{}
+		return true, n, nil
+	case hashNode:
+		child, err := t.resolveHash(n)
+		if err != nil {
+			return false, nil, err
+		}
+		dirty, newNode, err := t.insert(child, key, value)
+		if !dirty || err != nil {
+			return false, child, err
+		}
+		return true, newNode, nil
+	default:
+		panic(fmt.Sprintf("trie: invalid node %T", n))
+	}
+}
+
+// Deletes the key from the trie, if it exists
+func (t *Trie) Delete(key []byte) error {
+	_, n, err := t.delete(t.root, keybytesToHex(key))
+	if err != nil {
+		return err
+	}
+	t.root = n
+	return nil
+}
+
+// Deletes the key under the node, and returns the new node with whether
+// anything is changed. Nodes are collapsed to keep the trie minimal.
+func (t *Trie) delete(n node, key []byte) (bool, node, error) {
+	switch n := n.(type) {
+	case nil:
+		return false, nil, nil
+	case valueNode:
+		return true, nil, nil
+	case *shortNode:
+		matchLen := prefixLen(key, n.Key)
+		if matchLen < len(n.Key) {
+			return false, n, nil
+		}
+		if matchLen == len(key) {
+			return true, nil, nil
+		}
+		dirty, child, err := t.delete(n.Val, key[len(n.Key):])
+		if !dirty || err != nil {
+			return false, n, err
+		}
+		// merge the keys if the child is also a short node
+		if child, ok := child.(*shortNode); ok {
+			return true, &shortNode{Key: concat(n.Key, child.Key), Val: child.Val}, nil
+		}
+		return true, &shortNode{Key: n.Key, Val: child}, nil
+	case *fullNode:
+		dirty, newChild, err := t.delete(n.Children[key[0]], key[1:])
+		if !dirty || err != nil {
+			return false, n, err
+		}
+		n = n.copy()
+		n.Children[key[0]], n.flags = newChild, nodeFlag{}
+		if newChild != nil {
+			return true, n, nil
+		}
+
+		// full node with a single child is replaced by a short node
+		pos := -1
+		for i, child := range n.Children {
+			if child != nil {
+				if pos != -1 {
+					return true, n, nil
+				}
+				pos = i
+			}
+		}
+		if pos != 16 {
+			child, err := t.resolve(n.Children[pos])
+			if err != nil {
+				return false, nil, err
+			}
+			if child, ok := child.(*shortNode); ok {
+				return true, &shortNode{Key: concat([]byte{byte(pos)}, child.Key), Val: child.Val}, nil
+			}
+		}
+		return true, &shortNode{Key: []byte{byte(pos)}, Val: n.Children[pos]}, nil
+	case hashNode:
+		child, err := t.resolveHash(n)
+		if err != nil {
+			return false, nil, err
+		}
+		dirty, newNode, err := t.delete(child, key)
+		if !dirty || err != nil {
+			return false, child, err
+		}
+		return true, newNode, nil
+	default:
+		panic(fmt.Sprintf("trie: invalid node %T", n))
+	}
+}
+
+// Calls fn with every key and value of the trie in the ascending order
+// of the keys. Nodes are read from the reader as they are reached, and
+// the iteration stops at the first error, which is returned.
+func (t *Trie) Iterate(fn func(key, value []byte) error) error {
+	return t.iterate(t.root, nil, fn)
+}
+
+func (t *Trie) iterate(n node, prefix []byte, fn func(key, value []byte) error) error {
+	switch n := n.(type) {
+	case nil:
+		return nil
+	case valueNode:
+		return fn(hexToKeybytes(prefix), n)
+	case *shortNode:
+		return t.iterate(n.Val, concat(prefix, n.Key), fn)
+	case *fullNode:
+		// value of the full node has the shortest key
+		if err := t.iterate(n.Children[16], concat(prefix, []byte{terminator}), fn); err != nil {
+			return err
+		}
+		for i, child := range n.Children[:16] {
+			if err := t.iterate(child, concat(prefix, []byte{byte(i)}), fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	case hashNode:
+		child, err := t.resolveHash(n)
+		if err != nil {
+			return err
+		}
+		return t.iterate(child, prefix, fn)
+	default:
+		panic(fmt.Sprintf("trie: invalid node %T", n))
+	}
+}
+
+func concat(a, b []byte) []byte {
+	res := make([]byte, 0, len(a)+len(b))
+	return append(append(res, a...), b...)
+}
+
+func (t *Trie) resolve(n node) (node, error) {
+	if hash, ok := n.(hashNode); ok {
+		return t.resolveHash(hash)
+	}
+	return n, nil
+}
+
+func (t *Trie) resolveHash(hash hashNode) (node, error) {
+	if t.reader == nil {
+		return nil, fmt.Errorf("%w %x", ErrMissingNode, []byte(hash))
+	}
+	enc, err := t.reader.Get(hash)
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, fmt.Errorf("%w %x", ErrMissingNode, []byte(hash))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeNode(hash, enc)
+}
+
+// Returns the root hash of the trie. Hashes of the nodes are cached,
+// hence only the nodes changed since the last hashing are hashed.
+func (t *Trie) Hash() [32]byte {
+	// hashing can only fail while writing into a store
+	hash, cached, _ := (&hasher{}).hashRoot(t.root)
+	t.root = cached
+	return toHash(hash)
+}
+
+// Writes the nodes of the trie which are not in the store yet into the
+// writer by their hashes, and returns the root hash. Trie can be loaded
+// back from the store afterwards with the root hash. Nodes read from
+// the store or written by the earlier commits are not written again,
+// hence the trie must always be committed into the same store.
+func (t *Trie) Commit(writer database.KeyValueWriter) ([32]byte, error) {
+	if t.root == nil {
+		return EmptyRootHash, nil
+	}
+	hash, cached, err := (&hasher{writer: writer}).hashRoot(t.root)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	t.root = cached
+	return toHash(hash), nil
+}
+
+// Returns a copy of the trie, which shares the nodes with the trie. Nodes
+// are not modified by the changes of either trie, hence the changes of
+// the copy do not affect the trie, and the other way around.
+func (t *Trie) Copy() *Trie {
+	return &Trie{root: t.root, reader: t.reader}
+}
+
+func toHash(buff []byte) [32]byte {
+	var hash [32]byte
+	copy(hash[:], buff)
+	return hash
+}
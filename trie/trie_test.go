@@ -0,0 +1,453 @@
+package trie
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"space/database"
+)
+
+// Strings with 0x prefix in the ethereum trie test vectors are hex encoded
+func vectorBytes(s string) []byte {
+	if strings.HasPrefix(s, "0x") {
+		return hexToBytes(s[2:])
+	}
+	return []byte(s)
+}
+
+func vectorRoot(s string) [32]byte {
+	return toHash(hexToBytes(s[2:]))
+}
+
+func newTestTrie(kvs ...string) *Trie {
+	t, _ := New([32]byte{}, nil)
+	for i := 0; i < len(kvs); i += 2 {
+		t.Update([]byte(kvs[i]), []byte(kvs[i+1]))
+	}
+	return t
+}
+
+// Test vectors are the trie tests of ethereum/tests. Keys
+// are inserted in the given order, and null values delete the keys.
+func Test_Trie_Vectors(t *testing.T) {
+	buff, err := os.ReadFile("testdata/trietest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors map[string]struct {
+		In   [][2]*string `json:"in"`
+		Root string       `json:"root"`
+	}
+	if err := json.Unmarshal(buff, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	anyTestFailed := false
+	for name, vector := range vectors {
+		trie := newTestTrie()
+		for _, kv := range vector.In {
+			if kv[1] == nil {
+				trie.Delete(vectorBytes(*kv[0]))
+			} else {
+				trie.Update(vectorBytes(*kv[0]), vectorBytes(*kv[1]))
+			}
+		}
+		test := genericTest{s: name, exp: vectorRoot(vector.Root), act: trie.Hash()}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// Root must not depend on the insertion order, hence the keys
+// are inserted both in ascending and descending order
+func Test_Trie_AnyOrderVectors(t *testing.T) {
+	buff, err := os.ReadFile("testdata/trieanyorder.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors map[string]struct {
+		In   map[string]string `json:"in"`
+		Root string            `json:"root"`
+	}
+	if err := json.Unmarshal(buff, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	anyTestFailed := false
+	for name, vector := range vectors {
+		keys := make([]string, 0, len(vector.In))
+		for k := range vector.In {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		ascending, descending := newTestTrie(), newTestTrie()
+		for i := range keys {
+			k := keys[i]
+			ascending.Update(vectorBytes(k), vectorBytes(vector.In[k]))
+			k = keys[len(keys)-1-i]
+			descending.Update(vectorBytes(k), vectorBytes(vector.In[k]))
+		}
+		test := genericTest{
+			s:   name,
+			exp: []interface{}{vectorRoot(vector.Root), vectorRoot(vector.Root)},
+			act: []interface{}{ascending.Hash(), descending.Hash()},
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the key to get from the dogs trie, and exp is the value
+var trieGetTests = []genericTest{
+	{s: "existing key", in: "dog", exp: []byte("puppy")},
+	{s: "key in the middle of a short node", in: "dogg", exp: []byte(nil)},
+	{s: "key prefix of existing keys", in: "do", exp: []byte(nil)},
+	{s: "key longer than existing keys", in: "dogglesworths", exp: []byte(nil)},
+	{s: "longest existing key", in: "dogglesworth", exp: []byte("cat")},
+}
+
+func Test_Trie_Get(t *testing.T) {
+	anyTestFailed := false
+	trie := newTestTrie("doe", "reindeer", "dog", "puppy", "dogglesworth", "cat")
+	for _, test := range trieGetTests {
+		test.act, _ = trie.Get([]byte(test.in.(string)))
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the keys to delete from the dogs trie, and exp is the
+// root which is equal to the root of the trie of the remaining keys
+var trieDeleteTests = []genericTest{
+	{s: "delete nothing", in: []string{}, exp: newTestTrie("doe", "reindeer", "dog", "puppy", "dogglesworth", "cat").Hash()},
+	{s: "delete missing key", in: []string{"dogg"}, exp: newTestTrie("doe", "reindeer", "dog", "puppy", "dogglesworth", "cat").Hash()},
+	{s: "delete leaf", in: []string{"dogglesworth"}, exp: newTestTrie("doe", "reindeer", "dog", "puppy").Hash()},
+	{s: "delete branch value", in: []string{"dog"}, exp: newTestTrie("doe", "reindeer", "dogglesworth", "cat").Hash()},
+	{s: "delete sibling collapses branch", in: []string{"doe"}, exp: newTestTrie("dog", "puppy", "dogglesworth", "cat").Hash()},
+	{s: "delete all keys", in: []string{"doe", "dog", "dogglesworth"}, exp: EmptyRootHash},
+}
+
+func Test_Trie_Delete(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range trieDeleteTests {
+		trie := newTestTrie("doe", "reindeer", "dog", "puppy", "dogglesworth", "cat")
+		for _, key := range test.in.([]string) {
+			trie.Delete([]byte(key))
+		}
+		test.act = trie.Hash()
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+func Test_Trie_Commit(t *testing.T) {
+	db := database.NewMemoryDB()
+	trie := newTestTrie("doe", "reindeer", "dog", "puppy", "dogglesworth", "cat")
+	hash := trie.Hash()
+	root, err := trie.Commit(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := New(root, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dog, _ := loaded.Get([]byte("dog"))
+	loaded.Update([]byte("horse"), []byte("stallion"))
+	loaded.Delete([]byte("doe"))
+	trie.Update([]byte("horse"), []byte("stallion"))
+	trie.Delete([]byte("doe"))
+	_, missingErr := New(toHash(hexToBytes("ff")), db)
+
+	test := genericTest{
+		s:   "commit and load",
+		exp: []interface{}{hash, []byte("puppy"), trie.Hash(), true},
+		act: []interface{}{root, dog, loaded.Hash(), errors.Is(missingErr, ErrMissingNode)},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+// mapWriter is the store which records the nodes written by the commits
+type mapWriter map[string][]byte
+
+func (w mapWriter) Has(key []byte) (bool, error) {
+	_, ok := w[string(key)]
+	return ok, nil
+}
+
+func (w mapWriter) Put(key []byte, value []byte) error {
+	w[string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+func (w mapWriter) Delete(key []byte) error {
+	delete(w, string(key))
+	return nil
+}
+
+func (w mapWriter) Get(key []byte) ([]byte, error) {
+	if value, ok := w[string(key)]; ok {
+		return value, nil
+	}
+	return nil, database.ErrNotFound
+}
+
+// in changes the committed trie, and the nodes written by the next commit
+// must be the nodes of the changed trie which are not in the store yet
+var commitChangesTests = []genericTest{
+	{s: "no changes", in: func(t *Trie) {}},
+	{s: "same value", in: func(t *Trie) { t.Update([]byte("dog"), []byte("puppy")) }},
+	{s: "changed value", in: func(t *Trie) { t.Update([]byte("dog"), []byte("hound")) }},
+	{s: "new key", in: func(t *Trie) { t.Update([]byte("horse"), []byte("stallion")) }},
+	{s: "deleted key", in: func(t *Trie) { t.Delete([]byte("dogglesworth")) }},
+	{s: "deleted long key", in: func(t *Trie) { t.Delete([]byte("bear")) }},
+	{s: "changes after hashing", in: func(t *Trie) {
+		t.Update([]byte("dog"), []byte("hound"))
+		t.Hash()
+		t.Update([]byte("doe"), []byte("hind"))
+	}},
+}
+
+func Test_Trie_CommitChanges(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range commitChangesTests {
+		for _, loaded := range []bool{false, true} {
+			// values of the long keys are long enough for their
+			// nodes to be referenced by their hashes
+			db := mapWriter{}
+			trie := newTestTrie("doe", "reindeer", "dog", "puppy", "dogglesworth", "cat", "do", "verb",
+				"ant", strings.Repeat("a", 40), "bee", strings.Repeat("b", 40), "bear", strings.Repeat("c", 40))
+			root, _ := trie.Commit(db)
+			if loaded {
+				trie, _ = New(root, db)
+			}
+			test.in.(func(*Trie))(trie)
+
+			// nodes of the changed trie which are not in the store, where
+			// the nodes are taken from a new trie with the same keys
+			fresh, all := newTestTrie(), mapWriter{}
+			contents := mapWriter{}
+			trie.Iterate(func(key, value []byte) error {
+				contents[string(key)] = value
+				return fresh.Update(key, value)
+			})
+			fresh.Commit(all)
+			missing := mapWriter{}
+			for key, value := range all {
+				if _, ok := db[key]; !ok {
+					missing[key] = value
+				}
+			}
+			written := mapWriter{}
+			root, _ = trie.Commit(written)
+			for key, value := range written {
+				db[key] = value
+			}
+			reloaded, err := New(root, db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reloadedContents := mapWriter{}
+			reloaded.Iterate(func(key, value []byte) error {
+				reloadedContents[string(key)] = value
+				return nil
+			})
+
+			name := test.s
+			if loaded {
+				name += ", loaded from the store"
+			}
+			check := genericTest{s: name, exp: []interface{}{missing, contents}, act: []interface{}{written, reloadedContents}}
+			msg, failed := check.Check()
+			anyTestFailed = anyTestFailed || failed
+			fmt.Print(msg)
+		}
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+func Test_Trie_Iterate(t *testing.T) {
+	db := database.NewMemoryDB()
+	root, _ := newTestTrie("doe", "reindeer", "dog", "puppy", "dogglesworth", "cat", "do", "verb").Commit(db)
+	// nodes are read from the database while iterating
+	loaded, err := New(root, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kvs []string
+	err = loaded.Iterate(func(key, value []byte) error {
+		kvs = append(kvs, string(key), string(value))
+		return nil
+	})
+	errStop := errors.New("stop")
+	stopErr := loaded.Iterate(func(key, value []byte) error { return errStop })
+
+	test := genericTest{
+		s:   "keys in ascending order",
+		exp: []interface{}{nil, []string{"do", "verb", "doe", "reindeer", "dog", "puppy", "dogglesworth", "cat"}, errStop},
+		act: []interface{}{err, kvs, stopErr},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}
+
+// in is the hex key and exp is its compact encoding
+var compactTests = []genericTest{
+	{s: "empty key", in: []byte{}, exp: hexToBytes("00")},
+	{s: "empty terminated key", in: []byte{16}, exp: hexToBytes("20")},
+	{s: "odd key", in: []byte{1, 2, 3, 4, 5}, exp: hexToBytes("112345")},
+	{s: "even key", in: []byte{0, 1, 2, 3, 4, 5}, exp: hexToBytes("00012345")},
+	{s: "odd terminated key", in: []byte{15, 1, 12, 11, 8, 16}, exp: hexToBytes("3f1cb8")},
+	{s: "even terminated key", in: []byte{0, 15, 1, 12, 11, 8, 16}, exp: hexToBytes("200f1cb8")},
+}
+
+func Test_Trie_Compact(t *testing.T) {
+	anyTestFailed := false
+	for _, test := range compactTests {
+		compact := hexToCompact(test.in.([]byte))
+		test.act = compact
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+
+		test.s += " decoded back"
+		test.exp, test.act = test.in, compactToHex(compact)
+		msg, failed = test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the key to prove in the dogs trie,
+// and exp is its value and the size of its proof
+var proofTests = []genericTest{
+	{s: "existing key", in: "dog", exp: []interface{}{[]byte("puppy"), 3}},
+	{s: "key with value in branch", in: "doe", exp: []interface{}{[]byte("reindeer"), 2}},
+	{s: "longest existing key", in: "dogglesworth", exp: []interface{}{[]byte("cat"), 3}},
+	{s: "missing key in branch", in: "dogg", exp: []interface{}{[]byte(nil), 3}},
+	{s: "missing key diverging from root", in: "horse", exp: []interface{}{[]byte(nil), 1}},
+}
+
+func Test_Trie_Proof(t *testing.T) {
+	anyTestFailed := false
+	db := database.NewMemoryDB()
+	root, _ := newTestTrie("doe", "reindeer", "dog", "puppy", "dogglesworth", "cat").Commit(db)
+	// proofs are generated from a trie loaded from the store
+	trie, _ := New(root, db)
+	for _, test := range proofTests {
+		key := []byte(test.in.(string))
+		proof, err := trie.Prove(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		value, err := VerifyProof(root, key, proof)
+		if err != nil {
+			test.act = err
+		} else {
+			test.act = []interface{}{value, len(proof)}
+		}
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+// in is the proof of dog modified by the function
+var invalidProofTests = []genericTest{
+	{s: "missing node", in: func(proof [][]byte) [][]byte { return proof[:len(proof)-1] }},
+	{s: "modified node", in: func(proof [][]byte) [][]byte { proof[1][3] ^= 1; return proof }},
+	{s: "empty proof", in: func(proof [][]byte) [][]byte { return nil }},
+	{s: "node which is not a trie node", in: func(proof [][]byte) [][]byte { return [][]byte{hexToBytes("c3010203")} }},
+}
+
+func Test_Trie_InvalidProof(t *testing.T) {
+	anyTestFailed := false
+	trie := newTestTrie("doe", "reindeer", "dog", "puppy", "dogglesworth", "cat")
+	for _, test := range invalidProofTests {
+		proof, _ := trie.Prove([]byte("dog"))
+		_, err := VerifyProof(trie.Hash(), []byte("dog"), test.in.(func([][]byte) [][]byte)(proof))
+		test.exp, test.act = true, errors.Is(err, ErrInvalidProof)
+		msg, failed := test.Check()
+		anyTestFailed = anyTestFailed || failed
+		fmt.Print(msg)
+	}
+	if anyTestFailed {
+		t.FailNow()
+	}
+}
+
+func Test_Trie_CommitFileDB(t *testing.T) {
+	dir := t.TempDir()
+	db, err := database.OpenFileDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trie := newTestTrie("doe", "reindeer", "dog", "puppy", "dogglesworth", "cat")
+	batch := db.NewBatch()
+	root, err := trie.Commit(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch.Write()
+	db.Close()
+
+	// trie is loaded after reopening the database
+	db, err = database.OpenFileDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	loaded, err := New(root, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dog, _ := loaded.Get([]byte("dogglesworth"))
+
+	test := genericTest{
+		s:   "commit into file database and reopen",
+		exp: []interface{}{trie.Hash(), []byte("cat")},
+		act: []interface{}{loaded.Hash(), dog},
+	}
+	msg, failed := test.Check()
+	fmt.Print(msg)
+	if failed {
+		t.FailNow()
+	}
+}